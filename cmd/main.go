@@ -1,12 +1,15 @@
 package main
 
 import (
+	"context"
 	"errors"
 	"net/http"
 	"os"
 	"os/signal"
 	"rms/database"
+	"rms/events"
 	"rms/handler"
+	"rms/jobs"
 	"rms/server"
 	"syscall"
 	"time"
@@ -40,6 +43,15 @@ func main() {
 	logrus.Infof("migration successful!!")
 	handler.RegisterAdmin()
 
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	jobs.Start(jobsCtx)
+	// with several instances behind a load balancer events have to go through Postgres to reach every stream
+	if os.Getenv("EVENTS_BACKEND") == "postgres" {
+		if err := events.StartPostgres(jobsCtx); err != nil {
+			logrus.Fatalf("Failed to listen for events with error: %+v", err)
+		}
+	}
+
 	go func() {
 		if err := srv.Run(":8080"); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			logrus.Errorf("Failed to run server with error: %+v", err)
@@ -50,6 +62,7 @@ func main() {
 	<-done
 
 	logrus.Info("shutting down server")
+	stopJobs()
 	if err := database.ShutdownDatabase(); err != nil {
 		logrus.WithError(err).Error("failed to close database connection")
 	}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
@@ -12,13 +13,14 @@ import (
 	_ "github.com/golang-migrate/migrate/v4/source/file"
 	"github.com/jmoiron/sqlx"
 
-	// load pq as database driver
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
 	"github.com/sirupsen/logrus"
 )
 
 var (
 	RMS *sqlx.DB
+	// connStr is kept for connections that can't come from the pool, like LISTEN
+	connStr string
 )
 
 type SSLMode string
@@ -30,7 +32,7 @@ const (
 
 // ConnectAndMigrate function connects with a given database and returns error if there is any error
 func ConnectAndMigrate(host, port, databaseName, user, password string, sslMode SSLMode) error {
-	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s", host, port, user, password, databaseName, sslMode)
+	connStr = fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s", host, port, user, password, databaseName, sslMode)
 	DB, err := sqlx.Open("postgres", connStr)
 
 	if err != nil {
@@ -45,6 +47,11 @@ func ConnectAndMigrate(host, port, databaseName, user, password string, sslMode
 	return migrateUp(DB)
 }
 
+// NewListener opens a dedicated connection for Postgres LISTEN/NOTIFY
+func NewListener(minReconnect, maxReconnect time.Duration, eventCallback pq.EventCallbackType) *pq.Listener {
+	return pq.NewListener(connStr, minReconnect, maxReconnect, eventCallback)
+}
+
 func ShutdownDatabase() error {
 	return RMS.Close()
 }
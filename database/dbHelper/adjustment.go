@@ -0,0 +1,50 @@
+package dbHelper
+
+import (
+	"rms/database"
+	"rms/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// CreateOrderAdjustments writes the ledger lines of one adjustment under a shared adjustment id
+func CreateOrderAdjustments(db sqlx.Ext, lines []models.OrderAdjustment) error {
+	if len(lines) == 0 {
+		return nil
+	}
+	// language=SQL
+	SQL := `WITH adjustment AS (SELECT gen_random_uuid() AS id)
+			INSERT INTO order_adjustments(adjustment_id, order_id, kind, order_item_id, quantity, amount, reason, created_by)
+			SELECT adjustment.id, line.order_id::UUID, line.kind::order_adjustment_kind, line.order_item_id::UUID, line.quantity::INT,
+				line.amount::BIGINT, line.reason, line.created_by::UUID
+			FROM adjustment, (VALUES %s) AS line(order_id, kind, order_item_id, quantity, amount, reason, created_by)`
+	arguments := make([]interface{}, 0, len(lines)*7)
+	for _, line := range lines {
+		arguments = append(arguments, line.OrderID, line.Kind, line.OrderItemID, line.Quantity, line.Amount, line.Reason, line.CreatedBy)
+	}
+	_, err := db.Exec(database.SetupBindVars(SQL, "(?, ?, ?, ?, ?, ?, ?)", len(lines)), arguments...)
+	return err
+}
+
+func GetOrderAdjustments(orderID string) ([]models.OrderAdjustment, error) {
+	// language=SQL
+	SQL := `SELECT
+				oa.id,
+				oa.adjustment_id,
+				oa.order_id,
+				oa.kind,
+				oa.order_item_id,
+				oa.quantity,
+				oa.amount,
+				oa.reason,
+				oa.created_by,
+				oa.created_at
+			FROM order_adjustments oa
+			WHERE oa.order_id = $1
+			ORDER BY oa.created_at, oa.kind`
+	adjustments := make([]models.OrderAdjustment, 0)
+	if err := database.RMS.Select(&adjustments, SQL, orderID); err != nil {
+		return nil, err
+	}
+	return adjustments, nil
+}
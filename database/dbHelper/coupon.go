@@ -0,0 +1,223 @@
+package dbHelper
+
+import (
+	"database/sql"
+	"errors"
+	"rms/database"
+	"rms/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func IsCouponCodeExists(code string) (bool, error) {
+	// language=SQL
+	SQL := `SELECT count(*) > 0 FROM coupons WHERE archived_at IS NULL AND UPPER(TRIM(code)) = UPPER(TRIM($1))`
+	var exists bool
+	err := database.RMS.Get(&exists, SQL, code)
+	return exists, err
+}
+
+func CreateCoupon(restaurantID *string, createdBy string, body *models.AddCouponBody) (string, error) {
+	startsAt := time.Now()
+	if body.StartsAt != nil {
+		startsAt = *body.StartsAt
+	}
+	arguments := []interface{}{
+		body.Code,
+		restaurantID,
+		body.DiscountType,
+		body.Value,
+		body.MinOrderValue,
+		body.MaxDiscount,
+		startsAt,
+		body.EndsAt,
+		body.UsageLimit,
+		body.PerUserLimit,
+		body.FirstOrderOnly,
+		createdBy,
+	}
+	// language=SQL
+	SQL := `INSERT INTO coupons(code, restaurant_id, discount_type, value, min_order_value, max_discount, starts_at, ends_at, usage_limit, per_user_limit, first_order_only, created_by)
+			VALUES (UPPER(TRIM($1)), $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id`
+	var couponID string
+	if err := database.RMS.QueryRowx(SQL, arguments...).Scan(&couponID); err != nil {
+		return "", err
+	}
+	return couponID, nil
+}
+
+// GetCouponsCount counts the active coupons of a restaurant, or the platform coupons when restaurantID is nil
+func GetCouponsCount(restaurantID *string) (int64, error) {
+	// language=SQL
+	SQL := `SELECT COUNT(c.id) FROM coupons c WHERE c.archived_at IS NULL AND c.restaurant_id IS NOT DISTINCT FROM $1`
+	var count int64
+	err := database.RMS.Get(&count, SQL, restaurantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return count, nil
+}
+
+// GetCoupons lists the active coupons of a restaurant, or the platform coupons when restaurantID is nil
+func GetCoupons(restaurantID *string, Filters models.Filters) ([]models.Coupon, error) {
+	// language=SQL
+	SQL := `SELECT
+				c.id,
+				c.code,
+				c.restaurant_id,
+				c.discount_type,
+				c.value,
+				c.min_order_value,
+				c.max_discount,
+				c.starts_at,
+				c.ends_at,
+				c.usage_limit,
+				c.per_user_limit,
+				c.used_count,
+				c.first_order_only,
+				c.created_by,
+				c.created_at
+			FROM coupons c
+			WHERE c.archived_at IS NULL AND c.restaurant_id IS NOT DISTINCT FROM $1
+			ORDER BY c.created_at DESC
+			LIMIT $2
+			OFFSET $3`
+	coupons := make([]models.Coupon, 0)
+	err := database.RMS.Select(&coupons, SQL, restaurantID, Filters.PageSize, Filters.PageSize*Filters.PageNumber)
+	if err != nil {
+		return nil, err
+	}
+	return coupons, nil
+}
+
+func ArchiveCoupon(couponID string, restaurantID *string) error {
+	// language=SQL
+	SQL := `UPDATE coupons
+		SET archived_at = $1
+		WHERE id = $2 AND restaurant_id IS NOT DISTINCT FROM $3`
+	_, err := database.RMS.Exec(SQL, time.Now(), couponID, restaurantID)
+	return err
+}
+
+// GetCouponByCode returns the active coupon with the code, lock keeps the row locked for the rest of the transaction
+func GetCouponByCode(db sqlx.Ext, code string, lock bool) (*models.Coupon, error) {
+	// language=SQL
+	SQL := `SELECT
+				c.id,
+				c.code,
+				c.restaurant_id,
+				c.discount_type,
+				c.value,
+				c.min_order_value,
+				c.max_discount,
+				c.starts_at,
+				c.ends_at,
+				c.usage_limit,
+				c.per_user_limit,
+				c.used_count,
+				c.first_order_only,
+				c.created_by,
+				c.created_at
+			FROM coupons c
+			WHERE c.archived_at IS NULL AND UPPER(TRIM(c.code)) = UPPER(TRIM($1))`
+	if lock {
+		SQL += ` FOR UPDATE`
+	}
+	var coupon models.Coupon
+	err := sqlx.Get(db, &coupon, SQL, code)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+// GetCouponByID returns the coupon even if it was archived since, orders keep the coupon they were placed with
+func GetCouponByID(db sqlx.Ext, couponID string) (*models.Coupon, error) {
+	// language=SQL
+	SQL := `SELECT
+				c.id,
+				c.code,
+				c.restaurant_id,
+				c.discount_type,
+				c.value,
+				c.min_order_value,
+				c.max_discount,
+				c.starts_at,
+				c.ends_at,
+				c.usage_limit,
+				c.per_user_limit,
+				c.used_count,
+				c.first_order_only,
+				c.created_by,
+				c.created_at
+			FROM coupons c
+			WHERE c.id = $1`
+	var coupon models.Coupon
+	err := sqlx.Get(db, &coupon, SQL, couponID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+func GetUserCouponRedemptionsCount(db sqlx.Ext, couponID, userID string) (int64, error) {
+	// language=SQL
+	SQL := `SELECT COUNT(cr.id)
+			FROM coupon_redemptions cr
+			JOIN orders o on cr.order_id = o.id
+			WHERE cr.coupon_id = $1 AND cr.user_id = $2 AND o.status <> 'cancelled'`
+	var count int64
+	err := sqlx.Get(db, &count, SQL, couponID, userID)
+	return count, err
+}
+
+// GetUserPlacedOrdersCount counts the orders of a user that were not cancelled
+func GetUserPlacedOrdersCount(db sqlx.Ext, userID string) (int64, error) {
+	// language=SQL
+	SQL := `SELECT COUNT(o.id) FROM orders o WHERE o.user_id = $1 AND o.status <> 'cancelled'`
+	var count int64
+	err := sqlx.Get(db, &count, SQL, userID)
+	return count, err
+}
+
+// IncrementCouponUsage takes one use of the coupon, returns false when the total usage limit is already reached
+func IncrementCouponUsage(db sqlx.Ext, couponID string) (bool, error) {
+	// language=SQL
+	SQL := `UPDATE coupons
+		SET used_count = used_count + 1
+		WHERE id = $1 AND (usage_limit IS NULL OR used_count < usage_limit)`
+	result, err := db.Exec(SQL, couponID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// ReleaseCouponUsage gives back the use taken by an order that got cancelled
+func ReleaseCouponUsage(db sqlx.Ext, couponID string) error {
+	// language=SQL
+	SQL := `UPDATE coupons SET used_count = GREATEST(used_count - 1, 0) WHERE id = $1`
+	_, err := db.Exec(SQL, couponID)
+	return err
+}
+
+func CreateCouponRedemption(db sqlx.Ext, couponID, userID, orderID string, discount int64) error {
+	// language=SQL
+	SQL := `INSERT INTO coupon_redemptions(coupon_id, user_id, order_id, discount) VALUES ($1, $2, $3, $4)`
+	_, err := db.Exec(SQL, couponID, userID, orderID, discount)
+	return err
+}
@@ -0,0 +1,138 @@
+package dbHelper
+
+import (
+	"rms/database"
+	"rms/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+func GetCuisines() ([]models.Cuisine, error) {
+	// language=SQL
+	SQL := `SELECT slug, name, created_at FROM cuisines ORDER BY name`
+	cuisines := make([]models.Cuisine, 0)
+	err := database.RMS.Select(&cuisines, SQL)
+	return cuisines, err
+}
+
+// CreateCuisine adds a cuisine to the taxonomy, it tells whether the slug was free
+func CreateCuisine(db sqlx.Ext, slug, name string) (bool, error) {
+	// language=SQL
+	SQL := `INSERT INTO cuisines(slug, name) VALUES ($1, $2) ON CONFLICT (slug) DO NOTHING`
+	result, err := db.Exec(SQL, slug, name)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// CountCuisines tells how many of the slugs are in the taxonomy
+func CountCuisines(db sqlx.Ext, slugs []string) (int64, error) {
+	// language=SQL
+	SQL := `SELECT COUNT(*) FROM cuisines WHERE slug = ANY($1)`
+	var count int64
+	err := sqlx.Get(db, &count, SQL, pq.Array(slugs))
+	return count, err
+}
+
+// UpdateRestaurantAttributes saves the price range, cost for two and features of a restaurant, it tells whether the
+// restaurant is open
+func UpdateRestaurantAttributes(db sqlx.Ext, restaurantID string, priceRange, costForTwo *int64, features []string) (bool, error) {
+	// language=SQL
+	SQL := `UPDATE restaurants SET price_range = $1, cost_for_two = $2, features = $3 WHERE id = $4 AND archived_at IS NULL`
+	result, err := db.Exec(SQL, priceRange, costForTwo, pq.Array(features), restaurantID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// ReplaceRestaurantCuisines swaps the cuisines of a restaurant for the given ones
+func ReplaceRestaurantCuisines(db sqlx.Ext, restaurantID string, cuisines []string) error {
+	// language=SQL
+	SQL := `DELETE FROM restaurant_cuisines WHERE restaurant_id = $1`
+	if _, err := db.Exec(SQL, restaurantID); err != nil {
+		return err
+	}
+	if len(cuisines) == 0 {
+		return nil
+	}
+	// language=SQL
+	SQL = `INSERT INTO restaurant_cuisines(restaurant_id, cuisine) SELECT $1, UNNEST($2::TEXT[]) ON CONFLICT DO NOTHING`
+	_, err := db.Exec(SQL, restaurantID, pq.Array(cuisines))
+	return err
+}
+
+type restaurantFacet struct {
+	Kind string `db:"kind"`
+	models.Facet
+}
+
+// GetRestaurantFacets counts the restaurants GetRestaurants lists by cuisine, feature and price range. Cuisines and
+// price ranges are counted without their own filter since picking another one widens the listing, features narrow
+// it so they are counted with every filter
+func GetRestaurantFacets(Filters models.Filters) (*models.RestaurantFacets, error) {
+	arguments := []interface{}{
+		Filters.CreatedBy,
+		Filters.Name,
+		Filters.Email,
+		Filters.MinRating,
+		pq.Array(Filters.Cuisines),
+		pq.Array(Filters.Features),
+		pq.Array(Filters.PriceRanges),
+		Filters.MaxCostForTwo,
+	}
+	// language=SQL
+	SQL := `WITH matched AS (
+				SELECT
+					r.id,
+					r.price_range,
+					r.features,
+					(CARDINALITY(COALESCE($5::TEXT[], '{}')) = 0 OR EXISTS (SELECT 1 FROM restaurant_cuisines rc WHERE rc.restaurant_id = r.id AND rc.cuisine = ANY($5))) AS cuisine_match,
+					r.features @> COALESCE($6::TEXT[], '{}') AS feature_match,
+					(CARDINALITY(COALESCE($7::INT[], '{}')) = 0 OR r.price_range = ANY($7)) AS price_match
+				FROM restaurants r
+				WHERE r.archived_at IS NULL AND r.created_by::text ILIKE '%' || $1 || '%'  AND
+					r.name ILIKE '%' || $2 || '%' AND  r.email ILIKE '%' || $3 || '%' AND r.avg_rating >= $4 AND
+					($8::BIGINT IS NULL OR r.cost_for_two <= $8)
+			)
+			SELECT 'cuisine' AS kind, c.slug AS value, c.name, COUNT(m.id) AS count
+			FROM cuisines c
+				JOIN restaurant_cuisines rc ON rc.cuisine = c.slug
+				JOIN matched m ON m.id = rc.restaurant_id AND m.feature_match AND m.price_match
+			GROUP BY c.slug, c.name
+			UNION ALL
+			SELECT 'feature', f.feature, f.feature, COUNT(m.id)
+			FROM matched m, UNNEST(m.features) AS f(feature)
+			WHERE m.cuisine_match AND m.feature_match AND m.price_match
+			GROUP BY f.feature
+			UNION ALL
+			SELECT 'price_range', m.price_range::TEXT, m.price_range::TEXT, COUNT(m.id)
+			FROM matched m
+			WHERE m.price_range IS NOT NULL AND m.cuisine_match AND m.feature_match
+			GROUP BY m.price_range
+			ORDER BY kind, count DESC, value`
+	rows := make([]restaurantFacet, 0)
+	if err := database.RMS.Select(&rows, SQL, arguments...); err != nil {
+		return nil, err
+	}
+	facets := &models.RestaurantFacets{
+		Cuisines:    make([]models.Facet, 0),
+		Features:    make([]models.Facet, 0),
+		PriceRanges: make([]models.Facet, 0),
+	}
+	for _, row := range rows {
+		switch row.Kind {
+		case "cuisine":
+			facets.Cuisines = append(facets.Cuisines, row.Facet)
+		case "feature":
+			facets.Features = append(facets.Features, row.Facet)
+		case "price_range":
+			facets.PriceRanges = append(facets.PriceRanges, row.Facet)
+		}
+	}
+	return facets, nil
+}
@@ -0,0 +1,259 @@
+package dbHelper
+
+import (
+	"database/sql"
+	"errors"
+	"rms/database"
+	"rms/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+func CreateRestaurantArea(restaurantID, name, createdBy string) error {
+	// language=SQL
+	SQL := `INSERT INTO restaurant_areas(restaurant_id, name, created_by) VALUES ($1, $2, $3)`
+	_, err := database.RMS.Exec(SQL, restaurantID, name, createdBy)
+	return err
+}
+
+func IsRestaurantAreaExists(restaurantID, areaID string) (bool, error) {
+	// language=SQL
+	SQL := `SELECT COUNT(*) > 0 FROM restaurant_areas WHERE archived_at IS NULL AND restaurant_id = $1 AND id = $2`
+	var exists bool
+	err := database.RMS.Get(&exists, SQL, restaurantID, areaID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return exists, nil
+}
+
+func GetRestaurantAreas(restaurantID string) ([]models.RestaurantArea, error) {
+	// language=SQL
+	SQL := `SELECT
+				ra.id,
+				ra.restaurant_id,
+				ra.name,
+				ra.created_at
+			FROM restaurant_areas ra
+			WHERE ra.archived_at IS NULL AND ra.restaurant_id = $1
+			ORDER BY ra.name`
+	areas := make([]models.RestaurantArea, 0)
+	err := database.RMS.Select(&areas, SQL, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	return areas, nil
+}
+
+// ArchiveRestaurantArea removes the area, its tables stay without an area
+func ArchiveRestaurantArea(db sqlx.Ext, restaurantID, areaID string) error {
+	// language=SQL
+	SQL := `UPDATE restaurant_areas SET archived_at = NOW() WHERE id = $1 AND restaurant_id = $2 AND archived_at IS NULL`
+	if _, err := db.Exec(SQL, areaID, restaurantID); err != nil {
+		return err
+	}
+	// language=SQL
+	SQL = `UPDATE restaurant_tables SET area_id = NULL WHERE area_id = $1 AND restaurant_id = $2`
+	_, err := db.Exec(SQL, areaID, restaurantID)
+	return err
+}
+
+func CreateRestaurantTable(restaurantID string, areaID *string, name string, seats int64, nonce, createdBy string) (string, error) {
+	// language=SQL
+	SQL := `INSERT INTO restaurant_tables(restaurant_id, area_id, name, seats, qr_nonce, created_by) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	var tableID string
+	if err := database.RMS.QueryRowx(SQL, restaurantID, areaID, name, seats, nonce, createdBy).Scan(&tableID); err != nil {
+		return "", err
+	}
+	return tableID, nil
+}
+
+func GetRestaurantTables(db sqlx.Ext, restaurantID string) ([]models.RestaurantTable, error) {
+	// language=SQL
+	SQL := `SELECT
+				rt.id,
+				rt.restaurant_id,
+				rt.area_id,
+				rt.name,
+				rt.seats,
+				rt.qr_nonce,
+				rt.created_at
+			FROM restaurant_tables rt
+			WHERE rt.archived_at IS NULL AND rt.restaurant_id = $1
+			ORDER BY rt.name`
+	tables := make([]models.RestaurantTable, 0)
+	err := sqlx.Select(db, &tables, SQL, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+// GetRestaurantTableByID returns an active table of an open restaurant
+func GetRestaurantTableByID(tableID string) (*models.RestaurantTable, error) {
+	// language=SQL
+	SQL := `SELECT
+				rt.id,
+				rt.restaurant_id,
+				rt.area_id,
+				rt.name,
+				rt.seats,
+				rt.qr_nonce,
+				rt.created_at
+			FROM restaurant_tables rt
+			JOIN restaurants r ON r.id = rt.restaurant_id
+			WHERE rt.archived_at IS NULL AND r.archived_at IS NULL AND rt.id = $1`
+	var table models.RestaurantTable
+	err := database.RMS.Get(&table, SQL, tableID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &table, nil
+}
+
+// UpdateRestaurantTableNonce rotates the QR nonce of the table, the codes printed before stop working
+func UpdateRestaurantTableNonce(restaurantID, tableID, nonce string) (bool, error) {
+	// language=SQL
+	SQL := `UPDATE restaurant_tables SET qr_nonce = $1 WHERE id = $2 AND restaurant_id = $3 AND archived_at IS NULL`
+	result, err := database.RMS.Exec(SQL, nonce, tableID, restaurantID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func ArchiveRestaurantTable(restaurantID, tableID string) error {
+	// language=SQL
+	SQL := `UPDATE restaurant_tables SET archived_at = NOW() WHERE id = $1 AND restaurant_id = $2 AND archived_at IS NULL`
+	_, err := database.RMS.Exec(SQL, tableID, restaurantID)
+	return err
+}
+
+// OpenDineInSession returns the open session of the table, opening one with the token when there is none
+func OpenDineInSession(db sqlx.Ext, restaurantID, tableID, token string) (*models.DineInSession, error) {
+	// language=SQL
+	SQL := `INSERT INTO dine_in_sessions(restaurant_id, table_id, token) VALUES ($1, $2, $3)
+			ON CONFLICT (table_id) WHERE closed_at IS NULL DO NOTHING`
+	if _, err := db.Exec(SQL, restaurantID, tableID, token); err != nil {
+		return nil, err
+	}
+	// language=SQL
+	SQL = dineInSessionSelect + ` WHERE s.table_id = $1 AND s.closed_at IS NULL`
+	var session models.DineInSession
+	if err := sqlx.Get(db, &session, SQL, tableID); err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// language=SQL
+const dineInSessionSelect = `SELECT
+				s.id,
+				s.restaurant_id,
+				s.table_id,
+				rt.name AS table_name,
+				s.token,
+				s.opened_at,
+				s.closed_at
+			FROM dine_in_sessions s
+			JOIN restaurant_tables rt ON rt.id = s.table_id`
+
+// GetOpenDineInSessionByToken finds the open session of a guest token, lock holds it open until the transaction ends
+func GetOpenDineInSessionByToken(db sqlx.Ext, token string, lock bool) (*models.DineInSession, error) {
+	SQL := dineInSessionSelect + ` WHERE s.token = $1 AND s.closed_at IS NULL`
+	if lock {
+		SQL += ` FOR UPDATE OF s`
+	}
+	var session models.DineInSession
+	err := sqlx.Get(db, &session, SQL, token)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func GetOpenDineInSessions(restaurantID string) ([]models.DineInSession, error) {
+	SQL := dineInSessionSelect + ` WHERE s.restaurant_id = $1 AND s.closed_at IS NULL ORDER BY s.opened_at`
+	sessions := make([]models.DineInSession, 0)
+	err := database.RMS.Select(&sessions, SQL, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func GetDineInSessionByID(db sqlx.Ext, restaurantID, sessionID string, lock bool) (*models.DineInSession, error) {
+	SQL := dineInSessionSelect + ` WHERE s.restaurant_id = $1 AND s.id = $2`
+	if lock {
+		SQL += ` FOR UPDATE OF s`
+	}
+	var session models.DineInSession
+	err := sqlx.Get(db, &session, SQL, restaurantID, sessionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &session, nil
+}
+
+func CloseDineInSession(db sqlx.Ext, sessionID, closedBy string) (time.Time, error) {
+	// language=SQL
+	SQL := `UPDATE dine_in_sessions SET closed_at = NOW(), closed_by = $2 WHERE id = $1 AND closed_at IS NULL RETURNING closed_at`
+	var closedAt time.Time
+	err := db.QueryRowx(SQL, sessionID, closedBy).Scan(&closedAt)
+	return closedAt, err
+}
+
+// GetDineInSessionOrders returns the orders of the sessions with their items
+func GetDineInSessionOrders(db sqlx.Ext, sessionIDs []string) ([]models.Order, error) {
+	// language=SQL
+	SQL := `SELECT
+				o.id,
+				COALESCE(o.user_id::TEXT, '') AS user_id,
+				o.restaurant_id,
+				COALESCE(o.address_id::TEXT, '') AS address_id,
+				o.status,
+				o.order_type,
+				o.dine_in_session_id,
+				o.rider_id,
+				o.estimated_delivery_at,
+				o.sub_total,
+				o.discount,
+				o.coupon_id,
+				o.coupon_discount,
+				o.points_redeemed,
+				o.packaging_charge,
+				o.delivery_fee,
+				o.tax,
+				o.tax_included,
+				o.rounding,
+				o.price_breakdown,
+				o.total,
+				o.scheduled_for,
+				o.release_at,
+				o.delivered_at,
+				o.created_at,
+				o.updated_at
+			FROM orders o
+			WHERE o.dine_in_session_id = ANY($1)
+			ORDER BY o.created_at`
+	orders := make([]models.Order, 0)
+	if err := sqlx.Select(db, &orders, SQL, pq.Array(sessionIDs)); err != nil {
+		return nil, err
+	}
+	return attachOrderItems(orders)
+}
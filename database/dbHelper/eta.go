@@ -0,0 +1,83 @@
+package dbHelper
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"rms/database"
+	"rms/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// language=SQL
+const avgPrepSecondsSQL = `COALESCE((
+					SELECT AVG(EXTRACT(EPOCH FROM kt.bumped_at - kt.created_at))
+					FROM kitchen_tickets kt
+					WHERE kt.restaurant_id = %s AND kt.bumped_at IS NOT NULL AND kt.created_at >= NOW() - INTERVAL '7 days'
+				), 0)`
+
+// GetKitchenLoads returns the average prep time of the last week and the current queue of the restaurants
+func GetKitchenLoads(restaurantIDs []string) (map[string]models.KitchenLoad, error) {
+	// language=SQL
+	SQL := `SELECT
+				r.id AS restaurant_id,
+				` + fmt.Sprintf(avgPrepSecondsSQL, "r.id") + ` AS avg_prep_seconds,
+				(SELECT COUNT(o.id) FROM orders o WHERE o.restaurant_id = r.id AND o.status IN ('placed', 'accepted', 'preparing')) AS queue_length
+			FROM restaurants r
+			WHERE r.id::TEXT = ANY($1)`
+	loads := make([]models.KitchenLoad, 0)
+	if err := database.RMS.Select(&loads, SQL, pq.Array(restaurantIDs)); err != nil {
+		return nil, err
+	}
+	byRestaurant := make(map[string]models.KitchenLoad, len(loads))
+	for _, load := range loads {
+		byRestaurant[load.RestaurantID] = load
+	}
+	return byRestaurant, nil
+}
+
+// GetOrderETAInput collects the positions and kitchen state the ETA of the order depends on
+func GetOrderETAInput(db sqlx.Ext, orderID string) (*models.OrderETAInput, error) {
+	// language=SQL
+	SQL := `SELECT
+				o.status,
+				o.order_type,
+				o.scheduled_for,
+				r.lat AS restaurant_lat,
+				r.lng AS restaurant_lng,
+				ua.lat AS address_lat,
+				ua.lng AS address_lng,
+				rs.lat AS rider_lat,
+				rs.lng AS rider_lng,
+				kt.created_at AS ticket_created_at,
+				` + fmt.Sprintf(avgPrepSecondsSQL, "o.restaurant_id") + ` AS avg_prep_seconds,
+				(
+					SELECT COUNT(q.id) FROM orders q
+					WHERE q.restaurant_id = o.restaurant_id AND q.status IN ('placed', 'accepted', 'preparing') AND q.created_at < o.created_at
+				) AS queue_ahead
+			FROM orders o
+			JOIN restaurants r ON r.id = o.restaurant_id
+			LEFT JOIN user_address ua ON ua.id = o.address_id
+			LEFT JOIN rider_status rs ON rs.user_id = o.rider_id
+			LEFT JOIN kitchen_tickets kt ON kt.order_id = o.id
+			WHERE o.id = $1`
+	var input models.OrderETAInput
+	err := sqlx.Get(db, &input, SQL, orderID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &input, nil
+}
+
+func UpdateOrderETA(db sqlx.Ext, orderID string, estimatedAt *time.Time) error {
+	// language=SQL
+	SQL := `UPDATE orders SET estimated_delivery_at = $1 WHERE id = $2`
+	_, err := db.Exec(SQL, estimatedAt, orderID)
+	return err
+}
@@ -0,0 +1,159 @@
+package dbHelper
+
+import (
+	"rms/database"
+	"rms/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// AddFavouriteRestaurant favourites a restaurant for the user, favouriting it again changes nothing
+func AddFavouriteRestaurant(db sqlx.Ext, userID, restaurantID string) error {
+	// language=SQL
+	SQL := `INSERT INTO favourite_restaurants(user_id, restaurant_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	_, err := db.Exec(SQL, userID, restaurantID)
+	return err
+}
+
+// RemoveFavouriteRestaurant tells whether the restaurant was a favourite of the user
+func RemoveFavouriteRestaurant(db sqlx.Ext, userID, restaurantID string) (bool, error) {
+	// language=SQL
+	SQL := `DELETE FROM favourite_restaurants WHERE user_id = $1 AND restaurant_id = $2`
+	result, err := db.Exec(SQL, userID, restaurantID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// AddFavouriteDish favourites a dish for the user, favouriting it again changes nothing
+func AddFavouriteDish(db sqlx.Ext, userID, dishID string) error {
+	// language=SQL
+	SQL := `INSERT INTO favourite_dishes(user_id, dish_id) VALUES ($1, $2) ON CONFLICT DO NOTHING`
+	_, err := db.Exec(SQL, userID, dishID)
+	return err
+}
+
+// RemoveFavouriteDish tells whether the dish was a favourite of the user
+func RemoveFavouriteDish(db sqlx.Ext, userID, dishID string) (bool, error) {
+	// language=SQL
+	SQL := `DELETE FROM favourite_dishes WHERE user_id = $1 AND dish_id = $2`
+	result, err := db.Exec(SQL, userID, dishID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// GetFavouriteRestaurants lists the open restaurants the user favourited, the latest first
+func GetFavouriteRestaurants(userID string) ([]models.Restaurant, error) {
+	// language=SQL
+	SQL := `SELECT
+       			r.id,
+       			r.name,
+       			r.email,
+       			r.created_at,
+       			r.created_by,
+				r.address,
+				r.state,
+				r.city,
+				r.pin_code,
+				r.lat,
+				r.lng,
+				r.avg_rating,
+				r.rating_count,
+				r.currency,
+				r.logo,
+				r.banner,
+				ARRAY(SELECT rc.cuisine FROM restaurant_cuisines rc WHERE rc.restaurant_id = r.id ORDER BY rc.cuisine) AS cuisines,
+				r.price_range,
+				r.cost_for_two,
+				r.features
+			FROM favourite_restaurants f
+				JOIN restaurants r ON r.id = f.restaurant_id
+			WHERE f.user_id = $1 AND r.archived_at IS NULL
+			ORDER BY f.created_at DESC`
+	restaurants := make([]models.Restaurant, 0)
+	if err := database.RMS.Select(&restaurants, SQL, userID); err != nil {
+		return nil, err
+	}
+	for i := range restaurants {
+		restaurants[i].Favourite = true
+	}
+	return restaurants, nil
+}
+
+// GetFavouriteDishes lists the dishes the user favourited that are still on open restaurants, the latest first
+func GetFavouriteDishes(userID string) ([]models.Dishes, error) {
+	// language=SQL
+	SQL := `SELECT
+       			d.id,
+				d.restaurants_id AS restaurant_id,
+       			d.name,
+       			d.description,
+				d.quantity,
+				d.price,
+				d.discount,
+				d.station,
+				d.tax_category,
+				d.packaging_charge,
+				r.currency,
+				d.available OR COALESCE(d.unavailable_until <= NOW(), FALSE) AS available,
+				CASE WHEN NOT d.available AND d.unavailable_until > NOW() THEN d.unavailable_until END AS unavailable_until,
+				d.quantity <= 0 AS sold_out,
+				d.photo,
+				d.tags,
+				d.allergens,
+				d.spice_level,
+				d.nutrition,
+				dish_served_at(d.id, NOW()) AS served,
+				d.avg_rating,
+				d.rating_count,
+       			d.created_at,
+       			d.created_by
+			FROM favourite_dishes f
+				JOIN dishes d ON d.id = f.dish_id
+				JOIN restaurants r ON r.id = d.restaurants_id
+			WHERE f.user_id = $1 AND d.archived_at IS NULL AND r.archived_at IS NULL
+			ORDER BY f.created_at DESC`
+	dishes := make([]models.Dishes, 0)
+	if err := database.RMS.Select(&dishes, SQL, userID); err != nil {
+		return nil, err
+	}
+	for i := range dishes {
+		dishes[i].Favourite = true
+	}
+	return dishes, nil
+}
+
+// GetFavouriteRestaurantIDs tells which of the restaurants the user favourited
+func GetFavouriteRestaurantIDs(userID string, restaurantIDs []string) (map[string]bool, error) {
+	// language=SQL
+	SQL := `SELECT restaurant_id FROM favourite_restaurants WHERE user_id = $1 AND restaurant_id = ANY($2::UUID[])`
+	return favouriteIDs(SQL, userID, restaurantIDs)
+}
+
+// GetFavouriteDishIDs tells which of the dishes the user favourited
+func GetFavouriteDishIDs(userID string, dishIDs []string) (map[string]bool, error) {
+	// language=SQL
+	SQL := `SELECT dish_id FROM favourite_dishes WHERE user_id = $1 AND dish_id = ANY($2::UUID[])`
+	return favouriteIDs(SQL, userID, dishIDs)
+}
+
+func favouriteIDs(SQL, userID string, ids []string) (map[string]bool, error) {
+	favourites := make(map[string]bool)
+	if len(ids) == 0 {
+		return favourites, nil
+	}
+	found := make([]string, 0)
+	if err := database.RMS.Select(&found, SQL, userID, pq.Array(ids)); err != nil {
+		return nil, err
+	}
+	for _, id := range found {
+		favourites[id] = true
+	}
+	return favourites, nil
+}
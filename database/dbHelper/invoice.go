@@ -0,0 +1,127 @@
+package dbHelper
+
+import (
+	"database/sql"
+	"errors"
+	"rms/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// NextInvoiceSequence takes the next invoice number of the restaurant and fiscal year. The counter row stays locked
+// until the transaction ends, so the number is only used up when the invoice is committed with it
+func NextInvoiceSequence(db sqlx.Ext, restaurantID string, fiscalYear int) (int64, error) {
+	// language=SQL
+	SQL := `INSERT INTO invoice_sequences(restaurant_id, fiscal_year, last_number) VALUES ($1, $2, 1)
+			ON CONFLICT (restaurant_id, fiscal_year) DO UPDATE SET last_number = invoice_sequences.last_number + 1
+			RETURNING last_number`
+	var sequence int64
+	err := sqlx.Get(db, &sequence, SQL, restaurantID, fiscalYear)
+	return sequence, err
+}
+
+func CreateInvoice(db sqlx.Ext, invoice *models.Invoice) (string, error) {
+	// language=SQL
+	SQL := `INSERT INTO invoices(order_id, restaurant_id, fiscal_year, sequence, number, details, issued_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7)
+			RETURNING id`
+	var invoiceID string
+	err := sqlx.Get(db, &invoiceID, SQL, invoice.OrderID, invoice.RestaurantID, invoice.FiscalYear, invoice.Sequence,
+		invoice.Number, invoice.Details, invoice.IssuedAt)
+	return invoiceID, err
+}
+
+func GetInvoiceByOrderID(db sqlx.Ext, orderID string) (*models.Invoice, error) {
+	// language=SQL
+	SQL := `SELECT
+				i.id,
+				i.order_id,
+				i.restaurant_id,
+				i.fiscal_year,
+				i.sequence,
+				i.number,
+				i.details,
+				i.issued_at
+			FROM invoices i
+			WHERE i.order_id = $1`
+	var invoice models.Invoice
+	err := sqlx.Get(db, &invoice, SQL, orderID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+// GetInvoiceRestaurant loads the restaurant for an invoice, a restaurant closed since the order still bills it
+func GetInvoiceRestaurant(db sqlx.Ext, restaurantID string) (*models.Restaurant, error) {
+	// language=SQL
+	SQL := `SELECT
+				r.id,
+				r.name,
+				r.email,
+				r.created_at,
+				r.created_by,
+				r.address,
+				r.state,
+				r.city,
+				r.pin_code,
+				r.lat,
+				r.lng,
+				r.avg_rating,
+				r.rating_count,
+				r.currency
+			FROM restaurants r
+			WHERE r.id = $1`
+	var restaurant models.Restaurant
+	err := sqlx.Get(db, &restaurant, SQL, restaurantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &restaurant, nil
+}
+
+// GetInvoiceCustomer loads who an order is billed to with the address it went to, even if the address was removed since
+func GetInvoiceCustomer(db sqlx.Ext, userID, addressID string) (*models.InvoiceCustomer, error) {
+	// language=SQL
+	SQL := `SELECT u.name, u.email FROM users u WHERE u.id = $1`
+	var customer models.InvoiceCustomer
+	err := sqlx.Get(db, &customer, SQL, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if addressID == "" {
+		return &customer, nil
+	}
+	// language=SQL
+	SQL = `SELECT
+				ua.id,
+				ua.address,
+				ua.state,
+				ua.city,
+				COALESCE(ua.pin_code, '') AS pin_code,
+				ua.lat,
+				ua.lng,
+				ua.created_at,
+				ua.user_id
+			FROM user_address ua
+			WHERE ua.id = $1 AND ua.user_id = $2`
+	var address models.UserAddress
+	err = sqlx.Get(db, &address, SQL, addressID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return &customer, nil
+		}
+		return nil, err
+	}
+	customer.Address = &address
+	return &customer, nil
+}
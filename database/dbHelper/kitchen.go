@@ -0,0 +1,220 @@
+package dbHelper
+
+import (
+	"rms/database"
+	"rms/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// CreateKitchenTicket puts an accepted order on the kitchen screens, every item is routed to its dish's station
+func CreateKitchenTicket(db sqlx.Ext, orderID, restaurantID string) (string, error) {
+	// language=SQL
+	SQL := `INSERT INTO kitchen_tickets(order_id, restaurant_id) VALUES ($1, $2) RETURNING id`
+	var ticketID string
+	if err := db.QueryRowx(SQL, orderID, restaurantID).Scan(&ticketID); err != nil {
+		return "", err
+	}
+	// language=SQL
+	SQL = `INSERT INTO kitchen_ticket_items(ticket_id, dish_id, name, quantity, station)
+			SELECT $1, oi.dish_id, oi.name, oi.quantity, d.station
+			FROM order_items oi
+			JOIN dishes d ON d.id = oi.dish_id
+			WHERE oi.order_id = $2`
+	if _, err := db.Exec(SQL, ticketID, orderID); err != nil {
+		return "", err
+	}
+	return ticketID, nil
+}
+
+// ReduceKitchenTicketItem takes quantity of a dish off the ticket of the order, an item with nothing left leaves the
+// screen and the ticket closes once its remaining items are all bumped. It returns the ticket or an empty string when
+// the order has none
+func ReduceKitchenTicketItem(db sqlx.Ext, orderID, dishID string, quantity int64) (string, error) {
+	// language=SQL
+	SQL := `DELETE FROM kitchen_ticket_items kti
+			USING kitchen_tickets kt
+			WHERE kt.id = kti.ticket_id AND kt.order_id = $1 AND kti.dish_id = $2 AND kti.quantity <= $3
+			RETURNING kti.ticket_id`
+	ticketIDs := make([]string, 0)
+	if err := sqlx.Select(db, &ticketIDs, SQL, orderID, dishID, quantity); err != nil {
+		return "", err
+	}
+	if len(ticketIDs) == 0 {
+		// language=SQL
+		SQL = `UPDATE kitchen_ticket_items kti
+				SET quantity = kti.quantity - $3
+				FROM kitchen_tickets kt
+				WHERE kt.id = kti.ticket_id AND kt.order_id = $1 AND kti.dish_id = $2
+				RETURNING kti.ticket_id`
+		if err := sqlx.Select(db, &ticketIDs, SQL, orderID, dishID, quantity); err != nil || len(ticketIDs) == 0 {
+			return "", err
+		}
+		return ticketIDs[0], nil
+	}
+	// language=SQL
+	SQL = `UPDATE kitchen_tickets
+			SET bumped_at = NOW()
+			WHERE id = $1 AND bumped_at IS NULL
+			AND NOT EXISTS (SELECT 1 FROM kitchen_ticket_items WHERE ticket_id = $1 AND bumped_at IS NULL)`
+	_, err := db.Exec(SQL, ticketIDs[0])
+	return ticketIDs[0], err
+}
+
+// GetKitchenTickets lists the open tickets, or the latest bumped ones so they can be recalled
+func GetKitchenTickets(restaurantID string, bumped bool, limit int64) ([]models.KitchenTicket, error) {
+	// language=SQL
+	SQL := `SELECT
+				kt.id,
+				kt.order_id,
+				kt.restaurant_id,
+				kt.bumped_at,
+				kt.created_at
+			FROM kitchen_tickets kt
+			JOIN orders o ON o.id = kt.order_id
+			WHERE kt.restaurant_id = $1 AND o.status <> 'cancelled' AND (kt.bumped_at IS NOT NULL) = $2
+			ORDER BY CASE WHEN $2 THEN kt.bumped_at END DESC, kt.created_at
+			LIMIT $3`
+	tickets := make([]models.KitchenTicket, 0)
+	if err := database.RMS.Select(&tickets, SQL, restaurantID, bumped, limit); err != nil {
+		return nil, err
+	}
+	return tickets, attachKitchenTicketItems(tickets)
+}
+
+func attachKitchenTicketItems(tickets []models.KitchenTicket) error {
+	if len(tickets) == 0 {
+		return nil
+	}
+	ticketIDs := make([]string, 0, len(tickets))
+	for _, ticket := range tickets {
+		ticketIDs = append(ticketIDs, ticket.ID)
+	}
+	// language=SQL
+	SQL := `SELECT
+				kti.id,
+				kti.ticket_id,
+				kti.dish_id,
+				kti.name,
+				kti.quantity,
+				kti.station,
+				kti.started_at,
+				kti.bumped_at,
+				kti.recall_count,
+				EXTRACT(EPOCH FROM COALESCE(kti.bumped_at, NOW()) - kti.started_at)::BIGINT AS elapsed_seconds
+			FROM kitchen_ticket_items kti
+			WHERE kti.ticket_id = ANY($1)
+			ORDER BY kti.station, kti.created_at`
+	items := make([]models.KitchenTicketItem, 0)
+	if err := database.RMS.Select(&items, SQL, pq.Array(ticketIDs)); err != nil {
+		return err
+	}
+	itemMap := make(map[string][]models.KitchenTicketItem)
+	for _, item := range items {
+		itemMap[item.TicketID] = append(itemMap[item.TicketID], item)
+	}
+	for index := range tickets {
+		tickets[index].Items = itemMap[tickets[index].ID]
+	}
+	return nil
+}
+
+// BumpKitchenItem marks an item of the restaurant prepared and closes its ticket once every item is, it returns the
+// ticket of the item or an empty string when no open item matched
+func BumpKitchenItem(db sqlx.Ext, restaurantID, itemID string) (string, error) {
+	// language=SQL
+	SQL := `UPDATE kitchen_ticket_items kti
+			SET bumped_at = NOW()
+			FROM kitchen_tickets kt
+			WHERE kt.id = kti.ticket_id AND kt.restaurant_id = $1 AND kti.id = $2 AND kti.bumped_at IS NULL
+			RETURNING kti.ticket_id`
+	ticketIDs := make([]string, 0)
+	if err := sqlx.Select(db, &ticketIDs, SQL, restaurantID, itemID); err != nil || len(ticketIDs) == 0 {
+		return "", err
+	}
+	// language=SQL
+	SQL = `UPDATE kitchen_tickets
+			SET bumped_at = NOW()
+			WHERE id = $1 AND bumped_at IS NULL
+			AND NOT EXISTS (SELECT 1 FROM kitchen_ticket_items WHERE ticket_id = $1 AND bumped_at IS NULL)`
+	_, err := db.Exec(SQL, ticketIDs[0])
+	return ticketIDs[0], err
+}
+
+// RecallKitchenItem puts a bumped item back on the screen along with its ticket
+func RecallKitchenItem(db sqlx.Ext, restaurantID, itemID string) (string, error) {
+	// language=SQL
+	SQL := `UPDATE kitchen_ticket_items kti
+			SET bumped_at = NULL, recall_count = kti.recall_count + 1
+			FROM kitchen_tickets kt
+			WHERE kt.id = kti.ticket_id AND kt.restaurant_id = $1 AND kti.id = $2 AND kti.bumped_at IS NOT NULL
+			RETURNING kti.ticket_id`
+	ticketIDs := make([]string, 0)
+	if err := sqlx.Select(db, &ticketIDs, SQL, restaurantID, itemID); err != nil || len(ticketIDs) == 0 {
+		return "", err
+	}
+	// language=SQL
+	SQL = `UPDATE kitchen_tickets SET bumped_at = NULL WHERE id = $1`
+	_, err := db.Exec(SQL, ticketIDs[0])
+	return ticketIDs[0], err
+}
+
+// BumpKitchenTicket marks the ticket and all its open items prepared
+func BumpKitchenTicket(db sqlx.Ext, restaurantID, ticketID string) (bool, error) {
+	// language=SQL
+	SQL := `UPDATE kitchen_tickets SET bumped_at = NOW() WHERE id = $1 AND restaurant_id = $2 AND bumped_at IS NULL`
+	result, err := db.Exec(SQL, ticketID, restaurantID)
+	if err != nil {
+		return false, err
+	}
+	if rows, rowsErr := result.RowsAffected(); rowsErr != nil || rows == 0 {
+		return false, rowsErr
+	}
+	// language=SQL
+	SQL = `UPDATE kitchen_ticket_items SET bumped_at = NOW() WHERE ticket_id = $1 AND bumped_at IS NULL`
+	_, err = db.Exec(SQL, ticketID)
+	return err == nil, err
+}
+
+// RecallKitchenTicket puts a bumped ticket and all its items back on the screen
+func RecallKitchenTicket(db sqlx.Ext, restaurantID, ticketID string) (bool, error) {
+	// language=SQL
+	SQL := `UPDATE kitchen_tickets SET bumped_at = NULL WHERE id = $1 AND restaurant_id = $2 AND bumped_at IS NOT NULL`
+	result, err := db.Exec(SQL, ticketID, restaurantID)
+	if err != nil {
+		return false, err
+	}
+	if rows, rowsErr := result.RowsAffected(); rowsErr != nil || rows == 0 {
+		return false, rowsErr
+	}
+	// language=SQL
+	SQL = `UPDATE kitchen_ticket_items SET bumped_at = NULL, recall_count = recall_count + 1 WHERE ticket_id = $1 AND bumped_at IS NOT NULL`
+	_, err = db.Exec(SQL, ticketID)
+	return err == nil, err
+}
+
+// GetDishPrepTimes aggregates the prep timers of the restaurant's dishes, recalled items are left out since their
+// timer also ran while they were off the screen
+func GetDishPrepTimes(restaurantID string, since time.Time) ([]models.DishPrepTime, error) {
+	// language=SQL
+	SQL := `SELECT
+				kti.dish_id,
+				d.name,
+				d.station,
+				COUNT(kti.id) AS prepared_count,
+				AVG(EXTRACT(EPOCH FROM kti.bumped_at - kti.started_at)) AS avg_prep_seconds,
+				PERCENTILE_CONT(0.5) WITHIN GROUP (ORDER BY EXTRACT(EPOCH FROM kti.bumped_at - kti.started_at)) AS median_prep_seconds
+			FROM kitchen_ticket_items kti
+			JOIN kitchen_tickets kt ON kt.id = kti.ticket_id
+			JOIN dishes d ON d.id = kti.dish_id
+			WHERE kt.restaurant_id = $1 AND kti.bumped_at IS NOT NULL AND kti.recall_count = 0 AND kti.started_at >= $2
+			GROUP BY kti.dish_id, d.name, d.station
+			ORDER BY d.name`
+	prepTimes := make([]models.DishPrepTime, 0)
+	if err := database.RMS.Select(&prepTimes, SQL, restaurantID, since); err != nil {
+		return nil, err
+	}
+	return prepTimes, nil
+}
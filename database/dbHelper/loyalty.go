@@ -0,0 +1,168 @@
+package dbHelper
+
+import (
+	"database/sql"
+	"errors"
+	"rms/database"
+	"rms/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// LockUserLoyalty serialises ledger postings of one user until the transaction ends
+func LockUserLoyalty(db sqlx.Ext, userID string) error {
+	// language=SQL
+	SQL := `SELECT id FROM users WHERE id = $1 FOR UPDATE`
+	var id string
+	return sqlx.Get(db, &id, SQL, userID)
+}
+
+// GetLoyaltyBalance derives the balance of a user from the customer side of the ledger
+func GetLoyaltyBalance(db sqlx.Ext, userID string) (int64, error) {
+	// language=SQL
+	SQL := `SELECT COALESCE(SUM(points), 0) FROM loyalty_ledger WHERE user_id = $1 AND account = 'customer'`
+	var balance int64
+	err := sqlx.Get(db, &balance, SQL, userID)
+	return balance, err
+}
+
+// PostLoyaltyTransaction writes both legs of a posting, points move from the platform to the customer when positive
+func PostLoyaltyTransaction(db sqlx.Ext, userID string, kind models.LoyaltyEntryKind, points int64, orderID, createdBy *string, reason string, expiresAt *time.Time) error {
+	// language=SQL
+	SQL := `WITH txn AS (SELECT gen_random_uuid() AS id)
+			INSERT INTO loyalty_ledger(txn_id, account, user_id, kind, points, order_id, created_by, reason, expires_at)
+			SELECT txn.id, entry.account, $1, $2, entry.points, $4, $5, $6, $7
+			FROM txn, (VALUES ('customer'::loyalty_account, $3::BIGINT), ('platform'::loyalty_account, -$3::BIGINT)) AS entry(account, points)`
+	_, err := db.Exec(SQL, userID, kind, points, orderID, createdBy, reason, expiresAt)
+	return err
+}
+
+func GetLoyaltyHistoryCount(userID string) (int64, error) {
+	// language=SQL
+	SQL := `SELECT COUNT(id) FROM loyalty_ledger WHERE user_id = $1 AND account = 'customer'`
+	var count int64
+	err := database.RMS.Get(&count, SQL, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return count, nil
+}
+
+func GetLoyaltyHistory(userID string, Filters models.Filters) ([]models.LoyaltyEntry, error) {
+	// language=SQL
+	SQL := `SELECT
+				ll.id,
+				ll.txn_id,
+				ll.kind,
+				ll.points,
+				ll.order_id,
+				ll.reason,
+				ll.expires_at,
+				ll.created_at
+			FROM loyalty_ledger ll
+			WHERE ll.user_id = $1 AND ll.account = 'customer'
+			ORDER BY ll.created_at DESC
+			LIMIT $2
+			OFFSET $3`
+	entries := make([]models.LoyaltyEntry, 0)
+	err := database.RMS.Select(&entries, SQL, userID, Filters.PageSize, Filters.PageSize*Filters.PageNumber)
+	if err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetUsersWithExpiredPoints lists the users that may have points to expire, an expired earn that was not written off
+// in full yet. Whether anything of it is left unspent is decided by GetExpiredPoints
+func GetUsersWithExpiredPoints() ([]string, error) {
+	// language=SQL
+	SQL := `SELECT user_id
+			FROM loyalty_ledger
+			WHERE account = 'customer'
+			GROUP BY user_id
+			HAVING COALESCE(SUM(points) FILTER (WHERE kind = 'earn' AND expires_at <= NOW()), 0) > COALESCE(-SUM(points) FILTER (WHERE kind = 'expire'), 0)`
+	userIDs := make([]string, 0)
+	err := database.RMS.Select(&userIDs, SQL)
+	if err != nil {
+		return nil, err
+	}
+	return userIDs, nil
+}
+
+type loyaltyLot struct {
+	points    int64
+	expiresAt *time.Time
+}
+
+func (lot *loyaltyLot) expiredAt(t time.Time) bool {
+	return lot.expiresAt != nil && !lot.expiresAt.After(t)
+}
+
+// consumeLots takes points from the oldest lots first, only from the lots that match
+func consumeLots(lots []*loyaltyLot, points int64, match func(lot *loyaltyLot) bool) int64 {
+	for _, lot := range lots {
+		if points == 0 {
+			break
+		}
+		if lot.points == 0 || !match(lot) {
+			continue
+		}
+		taken := lot.points
+		if taken > points {
+			taken = points
+		}
+		lot.points -= taken
+		points -= taken
+	}
+	return points
+}
+
+// GetExpiredPoints returns how many points of the user are due to expire, call it with the user's loyalty locked.
+// Every credit of the ledger is a lot and debits spend the oldest lots first. Write offs only ever cover expired
+// earns, the rest of the debits spend the lots still valid at the time and fall back to expired ones not written
+// off yet. What is left of the expired lots is due
+func GetExpiredPoints(db sqlx.Ext, userID string) (int64, error) {
+	// language=SQL
+	SQL := `SELECT kind, points, expires_at, created_at
+			FROM loyalty_ledger
+			WHERE user_id = $1 AND account = 'customer'
+			ORDER BY created_at, points DESC, id`
+	entries := make([]models.LoyaltyEntry, 0)
+	if err := sqlx.Select(db, &entries, SQL, userID); err != nil {
+		return 0, err
+	}
+
+	lots := make([]*loyaltyLot, 0, len(entries))
+	for i := range entries {
+		entry := entries[i]
+		if entry.Points > 0 {
+			lot := &loyaltyLot{points: entry.Points}
+			if entry.Kind == models.LoyaltyEarn {
+				lot.expiresAt = entry.ExpiresAt
+			}
+			lots = append(lots, lot)
+			continue
+		}
+		at := entry.CreatedAt
+		expired := func(lot *loyaltyLot) bool { return lot.expiredAt(at) }
+		if entry.Kind == models.LoyaltyExpire {
+			consumeLots(lots, -entry.Points, expired)
+			continue
+		}
+		left := consumeLots(lots, -entry.Points, func(lot *loyaltyLot) bool { return !lot.expiredAt(at) })
+		consumeLots(lots, left, expired)
+	}
+
+	now := time.Now()
+	var due int64
+	for _, lot := range lots {
+		if lot.expiredAt(now) {
+			due += lot.points
+		}
+	}
+	return due, nil
+}
@@ -0,0 +1,52 @@
+package dbHelper
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"rms/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SwapRestaurantImage puts the image in the slot of the restaurant, nil clears it. It returns the image it replaced
+// and whether the restaurant exists
+func SwapRestaurantImage(db sqlx.Ext, restaurantID string, slot models.RestaurantImage, image *models.Image) (*models.Image, bool, error) {
+	if !slot.IsValid() {
+		return nil, false, fmt.Errorf("invalid restaurant image: %s", slot)
+	}
+	// language=SQL
+	SQL := fmt.Sprintf(`WITH previous AS (
+				SELECT id, %[1]s FROM restaurants WHERE id = $2 AND archived_at IS NULL FOR UPDATE
+			)
+			UPDATE restaurants r SET %[1]s = $1 FROM previous WHERE r.id = previous.id
+			RETURNING previous.%[1]s`, slot)
+	var replaced *models.Image
+	err := db.QueryRowx(SQL, image, restaurantID).Scan(&replaced)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return replaced, true, nil
+}
+
+// SwapDishPhoto puts the photo on the dish, nil clears it. It returns the photo it replaced and whether the dish exists
+func SwapDishPhoto(db sqlx.Ext, dishID, restaurantID string, photo *models.Image) (*models.Image, bool, error) {
+	// language=SQL
+	SQL := `WITH previous AS (
+				SELECT id, photo FROM dishes WHERE id = $2 AND restaurants_id = $3 AND archived_at IS NULL FOR UPDATE
+			)
+			UPDATE dishes d SET photo = $1 FROM previous WHERE d.id = previous.id
+			RETURNING previous.photo`
+	var replaced *models.Image
+	err := db.QueryRowx(SQL, photo, dishID, restaurantID).Scan(&replaced)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+	return replaced, true, nil
+}
@@ -0,0 +1,159 @@
+package dbHelper
+
+import (
+	"rms/database"
+	"rms/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+func CreateMenu(db sqlx.Ext, restaurantID, name string) (string, error) {
+	// language=SQL
+	SQL := `INSERT INTO menus(restaurant_id, name) VALUES ($1, $2) RETURNING id`
+	var menuID string
+	if err := db.QueryRowx(SQL, restaurantID, name).Scan(&menuID); err != nil {
+		return "", err
+	}
+	return menuID, nil
+}
+
+// UpdateMenu renames the menu, it tells whether the restaurant has the menu
+func UpdateMenu(db sqlx.Ext, restaurantID, menuID, name string) (bool, error) {
+	// language=SQL
+	SQL := `UPDATE menus SET name = $1 WHERE id = $2 AND restaurant_id = $3 AND archived_at IS NULL`
+	result, err := db.Exec(SQL, name, menuID, restaurantID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// ArchiveMenu removes the menu, its dishes are served during the windows of their other menus or, on none left,
+// whenever the restaurant is
+func ArchiveMenu(db sqlx.Ext, restaurantID, menuID string) (bool, error) {
+	// language=SQL
+	SQL := `UPDATE menus SET archived_at = NOW() WHERE id = $1 AND restaurant_id = $2 AND archived_at IS NULL`
+	result, err := db.Exec(SQL, menuID, restaurantID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// ReplaceMenuHours swaps the serving windows of a menu for the given ones
+func ReplaceMenuHours(db sqlx.Ext, menuID string, hours []models.MenuHours) error {
+	// language=SQL
+	SQL := `DELETE FROM menu_hours WHERE menu_id = $1`
+	if _, err := db.Exec(SQL, menuID); err != nil {
+		return err
+	}
+	if len(hours) == 0 {
+		return nil
+	}
+	// language=SQL
+	SQL = `INSERT INTO menu_hours(menu_id, day_of_week, starts_at, ends_at) VALUES %s`
+	arguments := make([]interface{}, 0, len(hours)*4)
+	for _, window := range hours {
+		arguments = append(arguments, menuID, window.DayOfWeek, window.StartsAt, window.EndsAt)
+	}
+	_, err := db.Exec(database.SetupBindVars(SQL, "(?, ?, ?, ?)", len(hours)), arguments...)
+	return err
+}
+
+// ReplaceMenuDishes swaps the dishes of a menu for the given ones
+func ReplaceMenuDishes(db sqlx.Ext, menuID string, dishIDs []string) error {
+	// language=SQL
+	SQL := `DELETE FROM menu_dishes WHERE menu_id = $1`
+	if _, err := db.Exec(SQL, menuID); err != nil {
+		return err
+	}
+	// language=SQL
+	SQL = `INSERT INTO menu_dishes(menu_id, dish_id) SELECT $1, UNNEST($2::UUID[]) ON CONFLICT DO NOTHING`
+	_, err := db.Exec(SQL, menuID, pq.Array(dishIDs))
+	return err
+}
+
+// CountRestaurantDishes counts how many of the dishes are live dishes of the restaurant
+func CountRestaurantDishes(db sqlx.Ext, restaurantID string, dishIDs []string) (int64, error) {
+	// language=SQL
+	SQL := `SELECT COUNT(DISTINCT d.id) FROM dishes d WHERE d.archived_at IS NULL AND d.restaurants_id = $1 AND d.id = ANY($2::UUID[])`
+	var count int64
+	err := sqlx.Get(db, &count, SQL, restaurantID, pq.Array(dishIDs))
+	return count, err
+}
+
+// GetMenus returns the live menus of a restaurant with their windows and dishes
+func GetMenus(restaurantID string) ([]models.Menu, error) {
+	// language=SQL
+	SQL := `SELECT
+				m.id,
+				m.restaurant_id,
+				m.name,
+				m.created_at
+			FROM menus m
+			WHERE m.archived_at IS NULL AND m.restaurant_id = $1
+			ORDER BY m.name`
+	menus := make([]models.Menu, 0)
+	if err := database.RMS.Select(&menus, SQL, restaurantID); err != nil {
+		return nil, err
+	}
+	if len(menus) == 0 {
+		return menus, nil
+	}
+	menuIDs := make([]string, 0, len(menus))
+	for index := range menus {
+		menus[index].Hours = make([]models.MenuHours, 0)
+		menus[index].DishIDs = make([]string, 0)
+		menuIDs = append(menuIDs, menus[index].ID)
+	}
+
+	// language=SQL
+	SQL = `SELECT
+				mh.menu_id,
+				mh.day_of_week,
+				TO_CHAR(mh.starts_at, 'HH24:MI') AS starts_at,
+				TO_CHAR(mh.ends_at, 'HH24:MI') AS ends_at
+			FROM menu_hours mh
+			WHERE mh.menu_id = ANY($1::UUID[])
+			ORDER BY mh.day_of_week, mh.starts_at`
+	hours := make([]struct {
+		MenuID string `db:"menu_id"`
+		models.MenuHours
+	}, 0)
+	if err := database.RMS.Select(&hours, SQL, pq.Array(menuIDs)); err != nil {
+		return nil, err
+	}
+
+	// language=SQL
+	SQL = `SELECT
+				md.menu_id,
+				md.dish_id
+			FROM menu_dishes md
+			JOIN dishes d ON d.id = md.dish_id
+			WHERE md.menu_id = ANY($1::UUID[]) AND d.archived_at IS NULL
+			ORDER BY d.name`
+	dishes := make([]struct {
+		MenuID string `db:"menu_id"`
+		DishID string `db:"dish_id"`
+	}, 0)
+	if err := database.RMS.Select(&dishes, SQL, pq.Array(menuIDs)); err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*models.Menu, len(menus))
+	for index := range menus {
+		byID[menus[index].ID] = &menus[index]
+	}
+	for _, window := range hours {
+		menu := byID[window.MenuID]
+		menu.Hours = append(menu.Hours, window.MenuHours)
+	}
+	for _, dish := range dishes {
+		menu := byID[dish.MenuID]
+		menu.DishIDs = append(menu.DishIDs, dish.DishID)
+	}
+	return menus, nil
+}
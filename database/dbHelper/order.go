@@ -0,0 +1,371 @@
+package dbHelper
+
+import (
+	"database/sql"
+	"errors"
+	"rms/database"
+	"rms/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// GetDishForUpdate locks the dish row until the transaction ends so concurrent orders can't oversell it, served tells
+// whether its menus serve it at servedAt
+func GetDishForUpdate(db sqlx.Ext, restaurantID, dishID string, servedAt time.Time) (*models.Dishes, error) {
+	// language=SQL
+	SQL := `SELECT
+       			d.id,
+       			d.name,
+       			d.description,
+				d.quantity,
+				d.price,
+				d.discount,
+				d.station,
+				d.tax_category,
+				d.packaging_charge,
+				(SELECT r.currency FROM restaurants r WHERE r.id = d.restaurants_id) AS currency,
+				d.available OR COALESCE(d.unavailable_until <= NOW(), FALSE) AS available,
+				CASE WHEN NOT d.available AND d.unavailable_until > NOW() THEN d.unavailable_until END AS unavailable_until,
+				d.quantity <= 0 AS sold_out,
+				d.photo,
+				d.tags,
+				d.allergens,
+				d.spice_level,
+				d.nutrition,
+				dish_served_at(d.id, $3) AS served,
+       			d.created_at,
+       			d.created_by
+			FROM dishes d
+			WHERE d.archived_at IS NULL AND d.restaurants_id = $1 AND d.id = $2
+			FOR UPDATE`
+	var dish models.Dishes
+	err := sqlx.Get(db, &dish, SQL, restaurantID, dishID, servedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &dish, nil
+}
+
+// UpdateDishQuantity adds delta to the dish stock, a negative delta takes stock out. It tells whether the dish went
+// sold out or came back in stock
+func UpdateDishQuantity(db sqlx.Ext, dishID string, delta int64) (bool, error) {
+	// language=SQL
+	SQL := `UPDATE dishes SET quantity = quantity + $1 WHERE id = $2 RETURNING (quantity <= 0) <> (quantity - $1 <= 0)`
+	var flipped bool
+	err := sqlx.Get(db, &flipped, SQL, delta, dishID)
+	return flipped, err
+}
+
+func CreateOrder(db sqlx.Ext, order *models.Order) (string, error) {
+	arguments := []interface{}{
+		order.UserID,
+		order.RestaurantID,
+		order.AddressID,
+		order.Status,
+		order.SubTotal,
+		order.Discount,
+		order.CouponID,
+		order.CouponDiscount,
+		order.PointsRedeemed,
+		order.Total,
+		order.ScheduledFor,
+		order.ReleaseAt,
+		order.OrderType,
+		order.DineInSessionID,
+		order.PackagingCharge,
+		order.DeliveryFee,
+		order.Tax,
+		order.TaxIncluded,
+		order.Rounding,
+		order.Breakdown,
+	}
+	// guests ordering at a table have no account and no address
+	// language=SQL
+	SQL := `INSERT INTO orders(user_id, restaurant_id, address_id, status, sub_total, discount, coupon_id, coupon_discount, points_redeemed, total, scheduled_for, release_at, order_type, dine_in_session_id,
+				packaging_charge, delivery_fee, tax, tax_included, rounding, price_breakdown)
+			VALUES (NULLIF($1, '')::UUID, $2, NULLIF($3, '')::UUID, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20) RETURNING id`
+	var orderID string
+	if err := db.QueryRowx(SQL, arguments...).Scan(&orderID); err != nil {
+		return "", err
+	}
+	return orderID, nil
+}
+
+func CreateOrderItems(db sqlx.Ext, orderID string, items []models.OrderItem) error {
+	// language=SQL
+	SQL := `INSERT INTO order_items(order_id, dish_id, name, quantity, price, discount, total) VALUES %s`
+	arguments := make([]interface{}, 0, len(items)*7)
+	for _, item := range items {
+		arguments = append(arguments, orderID, item.DishID, item.Name, item.Quantity, item.Price, item.Discount, item.Total)
+	}
+	_, err := db.Exec(database.SetupBindVars(SQL, "(?, ?, ?, ?, ?, ?, ?)", len(items)), arguments...)
+	return err
+}
+
+func GetOrderByID(db sqlx.Ext, orderID string) (*models.Order, error) {
+	// language=SQL
+	SQL := `SELECT
+				o.id,
+				COALESCE(o.user_id::TEXT, '') AS user_id,
+				o.restaurant_id,
+				COALESCE(o.address_id::TEXT, '') AS address_id,
+				o.status,
+				o.order_type,
+				o.dine_in_session_id,
+				o.rider_id,
+				o.estimated_delivery_at,
+				o.sub_total,
+				o.discount,
+				o.coupon_id,
+				o.coupon_discount,
+				o.points_redeemed,
+				o.packaging_charge,
+				o.delivery_fee,
+				o.tax,
+				o.tax_included,
+				o.rounding,
+				o.price_breakdown,
+				o.total,
+				o.scheduled_for,
+				o.release_at,
+				o.delivered_at,
+				o.created_at,
+				o.updated_at
+			FROM orders o
+			WHERE o.id = $1`
+	var order models.Order
+	err := sqlx.Get(db, &order, SQL, orderID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	items, itemsErr := GetOrderItemsByOrderIDs(db, []string{orderID})
+	if itemsErr != nil {
+		return nil, itemsErr
+	}
+	order.Items = items
+	return &order, nil
+}
+
+func GetOrderItemsByOrderIDs(db sqlx.Ext, orderIDs []string) ([]models.OrderItem, error) {
+	// language=SQL
+	SQL := `SELECT
+				oi.id,
+				oi.order_id,
+				oi.dish_id,
+				oi.name,
+				oi.quantity,
+				oi.price,
+				oi.discount,
+				oi.total
+			FROM order_items oi
+			WHERE oi.order_id::text = any($1) AND oi.quantity > 0
+			ORDER BY oi.created_at`
+	items := make([]models.OrderItem, 0)
+	err := sqlx.Select(db, &items, SQL, pq.StringArray(orderIDs))
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func GetOrdersCountByUserID(userID string) (int64, error) {
+	// language=SQL
+	SQL := `SELECT COUNT(o.id) FROM orders o WHERE o.user_id = $1`
+	var count int64
+	err := database.RMS.Get(&count, SQL, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return count, nil
+}
+
+func GetOrdersByUserID(userID string, Filters models.Filters) ([]models.Order, error) {
+	// language=SQL
+	SQL := `SELECT
+				o.id,
+				COALESCE(o.user_id::TEXT, '') AS user_id,
+				o.restaurant_id,
+				COALESCE(o.address_id::TEXT, '') AS address_id,
+				o.status,
+				o.order_type,
+				o.dine_in_session_id,
+				o.rider_id,
+				o.estimated_delivery_at,
+				o.sub_total,
+				o.discount,
+				o.coupon_id,
+				o.coupon_discount,
+				o.points_redeemed,
+				o.packaging_charge,
+				o.delivery_fee,
+				o.tax,
+				o.tax_included,
+				o.rounding,
+				o.price_breakdown,
+				o.total,
+				o.scheduled_for,
+				o.release_at,
+				o.delivered_at,
+				o.created_at,
+				o.updated_at
+			FROM orders o
+			WHERE o.user_id = $1
+			ORDER BY o.created_at DESC
+			LIMIT $2
+			OFFSET $3`
+	orders := make([]models.Order, 0)
+	err := database.RMS.Select(&orders, SQL, userID, Filters.PageSize, Filters.PageSize*Filters.PageNumber)
+	if err != nil {
+		return nil, err
+	}
+	return attachOrderItems(orders)
+}
+
+func GetRestaurantOrdersCount(restaurantID, status string) (int64, error) {
+	// language=SQL
+	SQL := `SELECT COUNT(o.id) FROM orders o WHERE o.restaurant_id = $1 AND o.status::text ILIKE '%' || $2 || '%'`
+	var count int64
+	err := database.RMS.Get(&count, SQL, restaurantID, status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return count, nil
+}
+
+func GetRestaurantOrders(restaurantID, status string, Filters models.Filters) ([]models.Order, error) {
+	arguments := []interface{}{
+		restaurantID,
+		status,
+		Filters.PageSize,
+		Filters.PageSize * Filters.PageNumber,
+	}
+	// language=SQL
+	SQL := `SELECT
+				o.id,
+				COALESCE(o.user_id::TEXT, '') AS user_id,
+				o.restaurant_id,
+				COALESCE(o.address_id::TEXT, '') AS address_id,
+				o.status,
+				o.order_type,
+				o.dine_in_session_id,
+				o.rider_id,
+				o.estimated_delivery_at,
+				o.sub_total,
+				o.discount,
+				o.coupon_id,
+				o.coupon_discount,
+				o.points_redeemed,
+				o.packaging_charge,
+				o.delivery_fee,
+				o.tax,
+				o.tax_included,
+				o.rounding,
+				o.price_breakdown,
+				o.total,
+				o.scheduled_for,
+				o.release_at,
+				o.delivered_at,
+				o.created_at,
+				o.updated_at
+			FROM orders o
+			WHERE o.restaurant_id = $1 AND o.status::text ILIKE '%' || $2 || '%'
+			ORDER BY o.created_at DESC
+			LIMIT $3
+			OFFSET $4`
+	orders := make([]models.Order, 0)
+	err := database.RMS.Select(&orders, SQL, arguments...)
+	if err != nil {
+		return nil, err
+	}
+	return attachOrderItems(orders)
+}
+
+// UpdateOrderStatus moves the order only if it is still in the expected status, returns false when someone else moved it first
+func UpdateOrderStatus(db sqlx.Ext, orderID string, from, to models.OrderStatus) (bool, error) {
+	// language=SQL
+	SQL := `UPDATE orders
+		SET status = $1,
+			updated_at = NOW(),
+			delivered_at = CASE WHEN $1 = 'delivered' THEN NOW() ELSE delivered_at END
+		WHERE id = $2 AND status = $3`
+	result, err := db.Exec(SQL, to, orderID, from)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+// LockOrder holds the order row until the transaction ends, adjustments load the order again after taking it
+func LockOrder(db sqlx.Ext, orderID string) error {
+	// language=SQL
+	SQL := `SELECT id FROM orders WHERE id = $1 FOR UPDATE`
+	var id string
+	return sqlx.Get(db, &id, SQL, orderID)
+}
+
+// UpdateOrderItemQuantity reprices an order line after part of it was taken off
+func UpdateOrderItemQuantity(db sqlx.Ext, itemID string, quantity, total int64) error {
+	// language=SQL
+	SQL := `UPDATE order_items SET quantity = $1, total = $2 WHERE id = $3`
+	_, err := db.Exec(SQL, quantity, total, itemID)
+	return err
+}
+
+// UpdateOrderTotals stores the recomputed amounts of an adjusted order
+func UpdateOrderTotals(db sqlx.Ext, order *models.Order) error {
+	// language=SQL
+	SQL := `UPDATE orders
+		SET sub_total = $1,
+			discount = $2,
+			coupon_discount = $3,
+			points_redeemed = $4,
+			total = $5,
+			packaging_charge = $6,
+			delivery_fee = $7,
+			tax = $8,
+			tax_included = $9,
+			rounding = $10,
+			price_breakdown = $11,
+			updated_at = NOW()
+		WHERE id = $12`
+	_, err := db.Exec(SQL, order.SubTotal, order.Discount, order.CouponDiscount, order.PointsRedeemed, order.Total, order.PackagingCharge,
+		order.DeliveryFee, order.Tax, order.TaxIncluded, order.Rounding, order.Breakdown, order.ID)
+	return err
+}
+
+func attachOrderItems(orders []models.Order) ([]models.Order, error) {
+	orderIDs := make([]string, 0, len(orders))
+	for _, order := range orders {
+		orderIDs = append(orderIDs, order.ID)
+	}
+	items, err := GetOrderItemsByOrderIDs(database.RMS, orderIDs)
+	if err != nil {
+		return nil, err
+	}
+	itemMap := make(map[string][]models.OrderItem)
+	for _, item := range items {
+		itemMap[item.OrderID] = append(itemMap[item.OrderID], item)
+	}
+	for index := range orders {
+		orders[index].Items = itemMap[orders[index].ID]
+	}
+	return orders, nil
+}
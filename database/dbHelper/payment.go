@@ -0,0 +1,185 @@
+package dbHelper
+
+import (
+	"database/sql"
+	"errors"
+	"rms/database"
+	"rms/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// language=SQL
+const paymentSelect = `SELECT
+				p.id,
+				p.order_id,
+				p.user_id,
+				p.provider,
+				p.method,
+				p.provider_ref,
+				p.amount,
+				p.refunded_amount,
+				p.pending_refund,
+				p.status,
+				p.failure_reason,
+				p.created_at,
+				p.updated_at
+			FROM payments p`
+
+func CreatePayment(db sqlx.Ext, payment *models.Payment, idempotencyKey string) (string, error) {
+	// language=SQL
+	SQL := `INSERT INTO payments(order_id, user_id, provider, method, amount, idempotency_key) VALUES ($1, $2, $3, $4, $5, NULLIF($6, '')) RETURNING id`
+	var paymentID string
+	err := db.QueryRowx(SQL, payment.OrderID, payment.UserID, payment.Provider, payment.Method, payment.Amount, idempotencyKey).Scan(&paymentID)
+	if err != nil {
+		return "", err
+	}
+	return paymentID, nil
+}
+
+func getPayment(db sqlx.Ext, SQL string, arguments ...interface{}) (*models.Payment, error) {
+	var payment models.Payment
+	err := sqlx.Get(db, &payment, SQL, arguments...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &payment, nil
+}
+
+// GetPaymentByID loads a payment, lock holds it until the transaction ends so webhooks and settlements don't interleave
+func GetPaymentByID(db sqlx.Ext, paymentID string, lock bool) (*models.Payment, error) {
+	SQL := paymentSelect + ` WHERE p.id = $1`
+	if lock {
+		SQL += ` FOR UPDATE`
+	}
+	return getPayment(db, SQL, paymentID)
+}
+
+func GetPaymentByOrderID(db sqlx.Ext, orderID string, lock bool) (*models.Payment, error) {
+	SQL := paymentSelect + ` WHERE p.order_id = $1`
+	if lock {
+		SQL += ` FOR UPDATE`
+	}
+	return getPayment(db, SQL, orderID)
+}
+
+// LockIdempotencyKey holds concurrent checkouts with the same key until the transaction ends, so a retry finds the
+// payment of the first one instead of failing on the unique index
+func LockIdempotencyKey(db sqlx.Ext, userID, idempotencyKey string) error {
+	// language=SQL
+	SQL := `SELECT pg_advisory_xact_lock(hashtext($1 || ':' || $2))`
+	_, err := db.Exec(SQL, userID, idempotencyKey)
+	return err
+}
+
+func GetPaymentByIdempotencyKey(db sqlx.Ext, userID, idempotencyKey string) (*models.Payment, error) {
+	return getPayment(db, paymentSelect+` WHERE p.user_id = $1 AND p.idempotency_key = $2`, userID, idempotencyKey)
+}
+
+// SetPaymentProviderRef records the intent the provider created for the payment
+func SetPaymentProviderRef(db sqlx.Ext, paymentID, providerRef string) error {
+	// language=SQL
+	SQL := `UPDATE payments SET provider_ref = $1, updated_at = NOW() WHERE id = $2 AND provider_ref IS NULL`
+	_, err := db.Exec(SQL, providerRef, paymentID)
+	return err
+}
+
+// UpdatePaymentStatus moves the payment to the status if it is still in one of from, false means it moved on already
+func UpdatePaymentStatus(db sqlx.Ext, paymentID string, from []models.PaymentStatus, to models.PaymentStatus, reason string) (bool, error) {
+	statuses := make([]string, 0, len(from))
+	for _, status := range from {
+		statuses = append(statuses, string(status))
+	}
+	// language=SQL
+	SQL := `UPDATE payments SET status = $1, failure_reason = $2, updated_at = NOW() WHERE id = $3 AND status::TEXT = ANY($4)`
+	result, err := db.Exec(SQL, to, reason, paymentID, pq.Array(statuses))
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+func CreatePaymentAttempt(db sqlx.Ext, paymentID string, action models.PaymentAction, attemptErr error) error {
+	var message *string
+	if attemptErr != nil {
+		text := attemptErr.Error()
+		message = &text
+	}
+	// language=SQL
+	SQL := `INSERT INTO payment_attempts(payment_id, action, succeeded, error) VALUES ($1, $2, $3, $4)`
+	_, err := db.Exec(SQL, paymentID, action, attemptErr == nil, message)
+	return err
+}
+
+// AddPendingRefund records money to give back on a payment that stays in place, the settlement job hands it to the
+// provider once the change that owes it committed
+func AddPendingRefund(db sqlx.Ext, paymentID string, amount int64) error {
+	// language=SQL
+	SQL := `UPDATE payments SET pending_refund = pending_refund + $1, updated_at = NOW() WHERE id = $2`
+	_, err := db.Exec(SQL, amount, paymentID)
+	return err
+}
+
+// SettlePendingRefund moves money the provider gave back from pending to refunded
+func SettlePendingRefund(db sqlx.Ext, paymentID string, amount int64) error {
+	// language=SQL
+	SQL := `UPDATE payments
+			SET pending_refund = pending_refund - $1, refunded_amount = refunded_amount + $1, settlement_failures = 0, updated_at = NOW()
+			WHERE id = $2`
+	_, err := db.Exec(SQL, amount, paymentID)
+	return err
+}
+
+// DeferPaymentSettlement counts a failed provider call and leaves the payment alone until next
+func DeferPaymentSettlement(db sqlx.Ext, paymentID string, next time.Time) error {
+	// language=SQL
+	SQL := `UPDATE payments SET settlement_failures = settlement_failures + 1, next_settlement_at = $1 WHERE id = $2`
+	_, err := db.Exec(SQL, next, paymentID)
+	return err
+}
+
+// GetDuePaymentSettlements lists the held payments of delivered orders to capture, the payments of cancelled
+// orders to give back and the payments with a pending partial refund. A payment that failed waits out its backoff,
+// the longest due come first
+func GetDuePaymentSettlements(limit int) ([]models.PaymentSettlement, error) {
+	// language=SQL
+	SQL := `SELECT
+				p.id,
+				p.order_id,
+				p.user_id,
+				p.provider,
+				p.method,
+				p.provider_ref,
+				p.amount,
+				p.refunded_amount,
+				p.pending_refund,
+				p.status,
+				p.failure_reason,
+				p.created_at,
+				p.updated_at,
+				p.settlement_failures,
+				CASE
+					WHEN p.status = 'authorized' AND o.status = 'delivered' THEN 'capture'
+					WHEN o.status = 'cancelled' THEN 'refund'
+					ELSE 'partial_refund'
+				END AS action
+			FROM payments p
+			JOIN orders o ON o.id = p.order_id
+			WHERE p.next_settlement_at <= NOW()
+				AND ((p.status = 'authorized' AND o.status = 'delivered')
+					OR (p.status IN ('authorized', 'captured') AND o.status = 'cancelled')
+					OR (p.status IN ('authorized', 'captured') AND p.pending_refund > 0))
+			ORDER BY p.next_settlement_at
+			LIMIT $1`
+	settlements := make([]models.PaymentSettlement, 0)
+	if err := database.RMS.Select(&settlements, SQL, limit); err != nil {
+		return nil, err
+	}
+	return settlements, nil
+}
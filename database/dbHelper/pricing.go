@@ -0,0 +1,83 @@
+package dbHelper
+
+import (
+	"database/sql"
+	"errors"
+	"rms/database"
+	"rms/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// GetRestaurantPricing returns the charges of a restaurant with its active tax rates
+func GetRestaurantPricing(db sqlx.Ext, restaurantID string) (*models.RestaurantPricing, error) {
+	// language=SQL
+	SQL := `SELECT
+				r.id,
+				r.delivery_fee,
+				r.free_delivery_above,
+				r.rounding_unit,
+				r.currency
+			FROM restaurants r
+			WHERE r.archived_at IS NULL AND r.id = $1`
+	var pricing models.RestaurantPricing
+	err := sqlx.Get(db, &pricing, SQL, restaurantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	// language=SQL
+	SQL = `SELECT
+				rtr.id,
+				rtr.restaurant_id,
+				rtr.category,
+				rtr.name,
+				rtr.rate_bps,
+				rtr.inclusive,
+				rtr.created_at
+			FROM restaurant_tax_rates rtr
+			WHERE rtr.archived_at IS NULL AND rtr.restaurant_id = $1
+			ORDER BY rtr.category, rtr.created_at`
+	pricing.TaxRates = make([]models.TaxRate, 0)
+	if err := sqlx.Select(db, &pricing.TaxRates, SQL, restaurantID); err != nil {
+		return nil, err
+	}
+	return &pricing, nil
+}
+
+func UpdateRestaurantPricing(restaurantID string, body *models.UpdateRestaurantPricingBody) error {
+	// language=SQL
+	SQL := `UPDATE restaurants SET delivery_fee = $1, free_delivery_above = $2, rounding_unit = $3 WHERE id = $4`
+	_, err := database.RMS.Exec(SQL, body.DeliveryFee, body.FreeDeliveryAbove, body.RoundingUnit, restaurantID)
+	return err
+}
+
+func IsTaxRateExists(restaurantID, category, name string) (bool, error) {
+	// language=SQL
+	SQL := `SELECT COUNT(id) > 0 FROM restaurant_tax_rates WHERE archived_at IS NULL AND restaurant_id = $1 AND category = $2 AND LOWER(name) = LOWER($3)`
+	var exists bool
+	err := database.RMS.Get(&exists, SQL, restaurantID, category, name)
+	return exists, err
+}
+
+func CreateTaxRate(restaurantID, createdBy string, body *models.AddTaxRateBody) (string, error) {
+	// language=SQL
+	SQL := `INSERT INTO restaurant_tax_rates(restaurant_id, category, name, rate_bps, inclusive, created_by) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id`
+	var taxRateID string
+	err := database.RMS.QueryRowx(SQL, restaurantID, body.Category, body.Name, body.RateBps, body.Inclusive, createdBy).Scan(&taxRateID)
+	return taxRateID, err
+}
+
+func ArchiveTaxRate(restaurantID, taxRateID string) (bool, error) {
+	// language=SQL
+	SQL := `UPDATE restaurant_tax_rates SET archived_at = $1 WHERE id = $2 AND restaurant_id = $3 AND archived_at IS NULL`
+	result, err := database.RMS.Exec(SQL, time.Now(), taxRateID, restaurantID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
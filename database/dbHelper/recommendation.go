@@ -0,0 +1,340 @@
+package dbHelper
+
+import (
+	"database/sql"
+	"errors"
+	"rms/database"
+	"rms/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// ClaimRecommendationRefresh starts a recommendation run when the last one started over maxAge ago, it tells whether
+// this caller got the run so instances don't work the same customers out twice
+func ClaimRecommendationRefresh(db sqlx.Ext, maxAge time.Duration) (bool, error) {
+	// language=SQL
+	SQL := `UPDATE recommendation_refresh SET refreshed_at = NOW() WHERE refreshed_at <= NOW() - $1::FLOAT8 * INTERVAL '1 second'`
+	result, err := db.Exec(SQL, maxAge.Seconds())
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// RebuildDishPairs counts the delivered orders placed after since that had each pair of dishes, pairs seen in fewer
+// than minOrders orders are left out
+func RebuildDishPairs(db sqlx.Ext, since time.Time, minOrders int64) error {
+	// language=SQL
+	SQL := `DELETE FROM dish_pairs`
+	if _, err := db.Exec(SQL); err != nil {
+		return err
+	}
+	// language=SQL
+	SQL = `INSERT INTO dish_pairs(dish_id, paired_dish_id, orders)
+			SELECT a.dish_id, b.dish_id, COUNT(*)
+			FROM orders o
+				JOIN order_items a ON a.order_id = o.id
+				JOIN order_items b ON b.order_id = o.id AND b.dish_id <> a.dish_id
+			WHERE o.status = 'delivered' AND o.created_at >= $1
+			GROUP BY a.dish_id, b.dish_id
+			HAVING COUNT(*) >= $2`
+	_, err := db.Exec(SQL, since, minOrders)
+	return err
+}
+
+// GetCustomerIDsAfter pages through the customers in id order
+func GetCustomerIDsAfter(db sqlx.Ext, after string, limit int64) ([]string, error) {
+	// language=SQL
+	SQL := `SELECT DISTINCT ur.user_id::TEXT AS user_id
+			FROM user_roles ur
+			WHERE ur.role_name = 'user' AND ur.archived_at IS NULL AND ur.user_id::TEXT > $1
+			ORDER BY user_id
+			LIMIT $2`
+	userIDs := make([]string, 0)
+	err := sqlx.Select(db, &userIDs, SQL, after, limit)
+	return userIDs, err
+}
+
+// recommendationSignals works out, for the customers in $1, their default address, the restaurants within $3 km of
+// it and how much they like each restaurant and cuisine. Orders count once, favourites three times and reviews by
+// how far the rating is from 3, so a poor review can outweigh a few orders
+const recommendationSignals = `WITH customers AS (
+				SELECT UNNEST($1::UUID[]) AS user_id
+			),
+			-- the default address is the one the customer last ordered to, or added last when they have not ordered
+			home AS (
+				SELECT DISTINCT ON (a.user_id) a.user_id, a.lat, a.lng
+				FROM user_address a
+					JOIN customers c ON c.user_id = a.user_id
+				WHERE a.archived_at IS NULL
+				ORDER BY a.user_id, (SELECT MAX(o.created_at) FROM orders o WHERE o.address_id = a.id) DESC NULLS LAST, a.created_at DESC
+			),
+			nearby AS (
+				SELECT h.user_id, r.id AS restaurant_id
+				FROM home h
+					JOIN restaurants r ON r.archived_at IS NULL AND distance_km(h.lat, h.lng, r.lat, r.lng) <= $3
+			),
+			-- customers without an address can be sent anywhere
+			reachable AS (
+				SELECT user_id, restaurant_id FROM nearby
+				UNION ALL
+				SELECT c.user_id, r.id
+				FROM customers c
+					JOIN restaurants r ON r.archived_at IS NULL
+				WHERE NOT EXISTS (SELECT 1 FROM home h WHERE h.user_id = c.user_id)
+			),
+			restaurant_likes AS (
+				SELECT user_id, restaurant_id, SUM(weight) AS weight
+				FROM (
+					SELECT o.user_id, o.restaurant_id, 1.0 AS weight
+					FROM orders o
+						JOIN customers c ON c.user_id = o.user_id
+					WHERE o.status = 'delivered'
+					UNION ALL
+					SELECT f.user_id, f.restaurant_id, 3.0
+					FROM favourite_restaurants f
+						JOIN customers c ON c.user_id = f.user_id
+					UNION ALL
+					SELECT rv.user_id, rv.restaurant_id, (rv.rating - 3) * 2.0
+					FROM reviews rv
+						JOIN customers c ON c.user_id = rv.user_id
+					WHERE rv.hidden_at IS NULL
+				) likes
+				GROUP BY user_id, restaurant_id
+			),
+			-- affinity is the share of a customer's liking that went to restaurants of each cuisine
+			affinity AS (
+				SELECT l.user_id, rc.cuisine, (SUM(l.weight) / SUM(SUM(l.weight)) OVER (PARTITION BY l.user_id))::FLOAT8 AS share
+				FROM restaurant_likes l
+					JOIN restaurant_cuisines rc ON rc.restaurant_id = l.restaurant_id
+				WHERE l.weight > 0
+				GROUP BY l.user_id, rc.cuisine
+			)`
+
+// RefreshDishRecommendations works out again the top limit dishes of each of the customers. Candidates come from the
+// pairs of the dishes they like, the best sellers since since near their default address and the dishes of
+// reachable restaurants of the cuisines they like. Dishes they rated poorly are never recommended
+func RefreshDishRecommendations(db sqlx.Ext, userIDs []string, since time.Time, radiusKm float64, limit int64) error {
+	// language=SQL
+	SQL := `DELETE FROM dish_recommendations WHERE user_id = ANY($1::UUID[])`
+	if _, err := db.Exec(SQL, pq.Array(userIDs)); err != nil {
+		return err
+	}
+	// language=SQL
+	SQL = recommendationSignals + `,
+			sales AS (
+				SELECT o.restaurant_id, oi.dish_id, SUM(oi.quantity)::FLOAT8 AS sold
+				FROM orders o
+					JOIN order_items oi ON oi.order_id = o.id
+				WHERE o.status = 'delivered' AND o.created_at >= $2
+				GROUP BY o.restaurant_id, oi.dish_id
+			),
+			dish_likes AS (
+				SELECT user_id, dish_id, SUM(weight) AS weight
+				FROM (
+					SELECT o.user_id, oi.dish_id, oi.quantity::FLOAT8 AS weight
+					FROM orders o
+						JOIN customers c ON c.user_id = o.user_id
+						JOIN order_items oi ON oi.order_id = o.id
+					WHERE o.status = 'delivered'
+					UNION ALL
+					SELECT f.user_id, f.dish_id, 3.0
+					FROM favourite_dishes f
+						JOIN customers c ON c.user_id = f.user_id
+					UNION ALL
+					SELECT rv.user_id, dr.dish_id, (dr.rating - 3) * 2.0
+					FROM dish_reviews dr
+						JOIN reviews rv ON rv.id = dr.review_id
+						JOIN customers c ON c.user_id = rv.user_id
+					WHERE rv.hidden_at IS NULL
+				) likes
+				GROUP BY user_id, dish_id
+			),
+			candidates AS (
+				SELECT l.user_id, p.paired_dish_id AS dish_id, 'bought_together' AS reason, LN(1 + SUM(l.weight * p.orders))::FLOAT8 AS score
+				FROM dish_likes l
+					JOIN dish_pairs p ON p.dish_id = l.dish_id
+				WHERE l.weight > 0
+				GROUP BY l.user_id, p.paired_dish_id
+				UNION ALL
+				SELECT n.user_id, s.dish_id, 'popular_nearby', 0.5 * LN(1 + s.sold)
+				FROM nearby n
+					JOIN sales s ON s.restaurant_id = n.restaurant_id
+				UNION ALL
+				SELECT a.user_id, d.id, 'cuisine_affinity', 0.8 * SUM(a.share) * LN(2 + COALESCE(MAX(s.sold), 0))
+				FROM affinity a
+					JOIN restaurant_cuisines rc ON rc.cuisine = a.cuisine
+					JOIN reachable re ON re.user_id = a.user_id AND re.restaurant_id = rc.restaurant_id
+					JOIN dishes d ON d.restaurants_id = rc.restaurant_id AND d.archived_at IS NULL
+					LEFT JOIN sales s ON s.dish_id = d.id
+				GROUP BY a.user_id, d.id
+			),
+			scored AS (
+				SELECT c.user_id, c.dish_id, SUM(c.score) + 0.1 * d.avg_rating::FLOAT8 AS score,
+					ARRAY_AGG(DISTINCT c.reason ORDER BY c.reason) AS reasons
+				FROM candidates c
+					JOIN dishes d ON d.id = c.dish_id AND d.archived_at IS NULL
+					JOIN restaurants r ON r.id = d.restaurants_id AND r.archived_at IS NULL
+				WHERE NOT EXISTS (SELECT 1 FROM dish_likes l WHERE l.user_id = c.user_id AND l.dish_id = c.dish_id AND l.weight < 0)
+				GROUP BY c.user_id, c.dish_id, d.avg_rating
+			),
+			picks AS (
+				SELECT user_id, dish_id, score, reasons, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY score DESC, dish_id) AS pick
+				FROM scored
+			)
+			INSERT INTO dish_recommendations(user_id, dish_id, score, reasons)
+			SELECT user_id, dish_id, score, reasons FROM picks WHERE pick <= $4`
+	_, err := db.Exec(SQL, pq.Array(userIDs), since, radiusKm, limit)
+	return err
+}
+
+// RefreshRestaurantRecommendations works out again the top limit restaurants of each of the customers from the
+// orders placed since since near their default address, the cuisines they like and the restaurants of their bought
+// together dishes, so it runs after RefreshDishRecommendations. Restaurants they reviewed poorly are never recommended
+func RefreshRestaurantRecommendations(db sqlx.Ext, userIDs []string, since time.Time, radiusKm float64, limit int64) error {
+	// language=SQL
+	SQL := `DELETE FROM restaurant_recommendations WHERE user_id = ANY($1::UUID[])`
+	if _, err := db.Exec(SQL, pq.Array(userIDs)); err != nil {
+		return err
+	}
+	// language=SQL
+	SQL = recommendationSignals + `,
+			sales AS (
+				SELECT o.restaurant_id, COUNT(*)::FLOAT8 AS orders
+				FROM orders o
+				WHERE o.status = 'delivered' AND o.created_at >= $2
+				GROUP BY o.restaurant_id
+			),
+			candidates AS (
+				SELECT n.user_id, n.restaurant_id, 'popular_nearby' AS reason, (0.5 * LN(1 + s.orders))::FLOAT8 AS score
+				FROM nearby n
+					JOIN sales s ON s.restaurant_id = n.restaurant_id
+				UNION ALL
+				SELECT a.user_id, rc.restaurant_id, 'cuisine_affinity', 0.8 * SUM(a.share) * LN(2 + COALESCE(MAX(s.orders), 0))
+				FROM affinity a
+					JOIN restaurant_cuisines rc ON rc.cuisine = a.cuisine
+					JOIN reachable re ON re.user_id = a.user_id AND re.restaurant_id = rc.restaurant_id
+					LEFT JOIN sales s ON s.restaurant_id = rc.restaurant_id
+				GROUP BY a.user_id, rc.restaurant_id
+				UNION ALL
+				SELECT dr.user_id, d.restaurants_id, 'bought_together', MAX(dr.score)
+				FROM dish_recommendations dr
+					JOIN customers c ON c.user_id = dr.user_id
+					JOIN dishes d ON d.id = dr.dish_id
+				WHERE 'bought_together' = ANY(dr.reasons)
+				GROUP BY dr.user_id, d.restaurants_id
+			),
+			scored AS (
+				SELECT c.user_id, c.restaurant_id, SUM(c.score) + 0.2 * r.avg_rating::FLOAT8 AS score,
+					ARRAY_AGG(DISTINCT c.reason ORDER BY c.reason) AS reasons
+				FROM candidates c
+					JOIN restaurants r ON r.id = c.restaurant_id AND r.archived_at IS NULL
+				WHERE NOT EXISTS (SELECT 1 FROM restaurant_likes l WHERE l.user_id = c.user_id AND l.restaurant_id = c.restaurant_id AND l.weight < 0)
+				GROUP BY c.user_id, c.restaurant_id, r.avg_rating
+			),
+			picks AS (
+				SELECT user_id, restaurant_id, score, reasons, ROW_NUMBER() OVER (PARTITION BY user_id ORDER BY score DESC, restaurant_id) AS pick
+				FROM scored
+			)
+			INSERT INTO restaurant_recommendations(user_id, restaurant_id, score, reasons)
+			SELECT user_id, restaurant_id, score, reasons FROM picks WHERE pick <= $4`
+	_, err := db.Exec(SQL, pq.Array(userIDs), since, radiusKm, limit)
+	return err
+}
+
+func GetDishRecommendations(userID string, limit int64) ([]models.RecommendedDish, error) {
+	// language=SQL
+	SQL := `SELECT
+       			d.id,
+				d.restaurants_id AS restaurant_id,
+       			d.name,
+       			d.description,
+				d.quantity,
+				d.price,
+				d.discount,
+				d.station,
+				d.tax_category,
+				d.packaging_charge,
+				r.currency,
+				d.available OR COALESCE(d.unavailable_until <= NOW(), FALSE) AS available,
+				CASE WHEN NOT d.available AND d.unavailable_until > NOW() THEN d.unavailable_until END AS unavailable_until,
+				d.quantity <= 0 AS sold_out,
+				d.photo,
+				d.tags,
+				d.allergens,
+				d.spice_level,
+				d.nutrition,
+				dish_served_at(d.id, NOW()) AS served,
+				d.avg_rating,
+				d.rating_count,
+       			d.created_at,
+       			d.created_by,
+				dr.score,
+				dr.reasons
+			FROM dish_recommendations dr
+				JOIN dishes d ON d.id = dr.dish_id
+				JOIN restaurants r ON r.id = d.restaurants_id
+			WHERE dr.user_id = $1 AND d.archived_at IS NULL AND r.archived_at IS NULL
+			ORDER BY dr.score DESC
+			LIMIT $2`
+	dishes := make([]models.RecommendedDish, 0)
+	err := database.RMS.Select(&dishes, SQL, userID, limit)
+	return dishes, err
+}
+
+func GetRestaurantRecommendations(userID string, limit int64) ([]models.RecommendedRestaurant, error) {
+	// language=SQL
+	SQL := `SELECT
+       			r.id,
+       			r.name,
+       			r.email,
+       			r.created_at,
+       			r.created_by,
+				r.address,
+				r.state,
+				r.city,
+				r.pin_code,
+				r.lat,
+				r.lng,
+				r.avg_rating,
+				r.rating_count,
+				r.currency,
+				r.logo,
+				r.banner,
+				ARRAY(SELECT rc.cuisine FROM restaurant_cuisines rc WHERE rc.restaurant_id = r.id ORDER BY rc.cuisine) AS cuisines,
+				r.price_range,
+				r.cost_for_two,
+				r.features,
+				rr.score,
+				rr.reasons
+			FROM restaurant_recommendations rr
+				JOIN restaurants r ON r.id = rr.restaurant_id
+			WHERE rr.user_id = $1 AND r.archived_at IS NULL
+			ORDER BY rr.score DESC
+			LIMIT $2`
+	restaurants := make([]models.RecommendedRestaurant, 0)
+	err := database.RMS.Select(&restaurants, SQL, userID, limit)
+	return restaurants, err
+}
+
+// GetRecommendationsComputedAt is when the picks of the customer were last worked out, nil when they have none
+func GetRecommendationsComputedAt(userID string) (*time.Time, error) {
+	// language=SQL
+	SQL := `SELECT MAX(computed_at) FROM (
+				SELECT computed_at FROM dish_recommendations WHERE user_id = $1
+				UNION ALL
+				SELECT computed_at FROM restaurant_recommendations WHERE user_id = $1
+			) computed`
+	var computedAt *time.Time
+	err := database.RMS.Get(&computedAt, SQL, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return computedAt, nil
+}
@@ -0,0 +1,223 @@
+package dbHelper
+
+import (
+	"rms/database"
+	"rms/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// LockReportRefresh locks the refresh state until the transaction ends and returns how far the summaries are
+// refreshed along with the time the transaction started
+func LockReportRefresh(db sqlx.Ext) (time.Time, time.Time, error) {
+	// language=SQL
+	SQL := `SELECT refreshed_until, NOW() AS now FROM report_refresh FOR UPDATE`
+	var refresh struct {
+		RefreshedUntil time.Time `db:"refreshed_until"`
+		Now            time.Time `db:"now"`
+	}
+	err := sqlx.Get(db, &refresh, SQL)
+	return refresh.RefreshedUntil, refresh.Now, err
+}
+
+func SetReportRefreshedUntil(db sqlx.Ext, refreshedUntil time.Time) error {
+	// language=SQL
+	SQL := `UPDATE report_refresh SET refreshed_until = $1`
+	_, err := db.Exec(SQL, refreshedUntil)
+	return err
+}
+
+func GetReportRefreshedUntil() (time.Time, error) {
+	// language=SQL
+	SQL := `SELECT refreshed_until FROM report_refresh`
+	var refreshedUntil time.Time
+	err := database.RMS.Get(&refreshedUntil, SQL)
+	return refreshedUntil, err
+}
+
+// GetStaleReportBuckets lists the slots holding orders changed after since
+func GetStaleReportBuckets(db sqlx.Ext, since time.Time) ([]models.ReportBucket, error) {
+	// language=SQL
+	SQL := `SELECT DISTINCT restaurant_id, report_bucket(created_at) AS bucket_start
+			FROM orders
+			WHERE updated_at > $1`
+	buckets := make([]models.ReportBucket, 0)
+	err := sqlx.Select(db, &buckets, SQL, since)
+	return buckets, err
+}
+
+// RefreshReportBuckets works the summary rows of the slots out again from their orders
+func RefreshReportBuckets(db sqlx.Ext, buckets []models.ReportBucket) error {
+	restaurantIDs := make([]string, 0, len(buckets))
+	starts := make([]string, 0, len(buckets))
+	for _, bucket := range buckets {
+		restaurantIDs = append(restaurantIDs, bucket.RestaurantID)
+		starts = append(starts, bucket.BucketStart.Format(time.RFC3339))
+	}
+	// language=SQL
+	stale := `WITH stale AS (
+				SELECT * FROM UNNEST($1::UUID[], $2::TIMESTAMP WITH TIME ZONE[]) AS stale(restaurant_id, bucket_start)
+			)`
+	statements := []string{
+		// language=SQL
+		stale + ` DELETE FROM report_order_buckets rb USING stale
+			WHERE rb.restaurant_id = stale.restaurant_id AND rb.bucket_start = stale.bucket_start`,
+		// language=SQL
+		stale + ` INSERT INTO report_order_buckets(restaurant_id, bucket_start, orders, delivered, cancelled, revenue)
+			SELECT
+				o.restaurant_id,
+				report_bucket(o.created_at),
+				COUNT(*),
+				COUNT(*) FILTER (WHERE o.status = 'delivered'),
+				COUNT(*) FILTER (WHERE o.status = 'cancelled'),
+				COALESCE(SUM(o.total) FILTER (WHERE o.status = 'delivered'), 0)
+			FROM orders o
+			JOIN stale ON stale.restaurant_id = o.restaurant_id AND stale.bucket_start = report_bucket(o.created_at)
+			WHERE o.status <> 'scheduled'
+			GROUP BY o.restaurant_id, report_bucket(o.created_at)`,
+		// language=SQL
+		stale + ` DELETE FROM report_dish_buckets db USING stale
+			WHERE db.restaurant_id = stale.restaurant_id AND db.bucket_start = stale.bucket_start`,
+		// language=SQL
+		stale + ` INSERT INTO report_dish_buckets(restaurant_id, bucket_start, dish_id, name, quantity, revenue)
+			SELECT
+				o.restaurant_id,
+				report_bucket(o.created_at),
+				oi.dish_id,
+				MAX(oi.name),
+				SUM(oi.quantity),
+				SUM(oi.total)
+			FROM orders o
+			JOIN stale ON stale.restaurant_id = o.restaurant_id AND stale.bucket_start = report_bucket(o.created_at)
+			JOIN order_items oi ON oi.order_id = o.id
+			WHERE o.status = 'delivered' AND oi.quantity > 0
+			GROUP BY o.restaurant_id, report_bucket(o.created_at), oi.dish_id`,
+		// language=SQL
+		stale + ` DELETE FROM report_customer_buckets cb USING stale
+			WHERE cb.restaurant_id = stale.restaurant_id AND cb.bucket_start = stale.bucket_start`,
+		// language=SQL
+		stale + ` INSERT INTO report_customer_buckets(restaurant_id, bucket_start, user_id, orders)
+			SELECT
+				o.restaurant_id,
+				report_bucket(o.created_at),
+				o.user_id,
+				COUNT(*)
+			FROM orders o
+			JOIN stale ON stale.restaurant_id = o.restaurant_id AND stale.bucket_start = report_bucket(o.created_at)
+			WHERE o.user_id IS NOT NULL AND o.status NOT IN ('scheduled', 'cancelled')
+			GROUP BY o.restaurant_id, report_bucket(o.created_at), o.user_id`,
+	}
+	for _, statement := range statements {
+		if _, err := db.Exec(statement, pq.Array(restaurantIDs), pq.Array(starts)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetRevenueReport sums the slots of the range per local day, week or month and currency, a nil restaurant covers
+// the whole platform
+func GetRevenueReport(restaurantID *string, from, to time.Time, granularity models.ReportGranularity, timeZone string) ([]models.RevenuePoint, error) {
+	// language=SQL
+	SQL := `SELECT
+				TO_CHAR(DATE_TRUNC($4::TEXT, rb.bucket_start AT TIME ZONE $5::TEXT), 'YYYY-MM-DD') AS period,
+				r.currency,
+				SUM(rb.orders) AS orders,
+				SUM(rb.delivered) AS delivered,
+				SUM(rb.revenue) AS revenue
+			FROM report_order_buckets rb
+			JOIN restaurants r ON r.id = rb.restaurant_id
+			WHERE ($1::UUID IS NULL OR rb.restaurant_id = $1) AND rb.bucket_start >= $2 AND rb.bucket_start < $3
+			GROUP BY 1, 2
+			ORDER BY 1, 2`
+	points := make([]models.RevenuePoint, 0)
+	err := database.RMS.Select(&points, SQL, restaurantID, from, to, granularity, timeZone)
+	return points, err
+}
+
+func GetTopDishesReport(restaurantID *string, from, to time.Time, limit int64) ([]models.TopDish, error) {
+	// language=SQL
+	SQL := `SELECT
+				db.dish_id,
+				(ARRAY_AGG(db.name ORDER BY db.bucket_start DESC))[1] AS name,
+				db.restaurant_id,
+				r.currency,
+				SUM(db.quantity) AS quantity,
+				SUM(db.revenue) AS revenue
+			FROM report_dish_buckets db
+			JOIN restaurants r ON r.id = db.restaurant_id
+			WHERE ($1::UUID IS NULL OR db.restaurant_id = $1) AND db.bucket_start >= $2 AND db.bucket_start < $3
+			GROUP BY db.dish_id, db.restaurant_id, r.currency
+			ORDER BY quantity DESC, revenue DESC, db.dish_id
+			LIMIT $4`
+	dishes := make([]models.TopDish, 0)
+	err := database.RMS.Select(&dishes, SQL, restaurantID, from, to, limit)
+	return dishes, err
+}
+
+func GetOrderSummaryReport(restaurantID *string, from, to time.Time) (models.OrderSummary, error) {
+	// language=SQL
+	SQL := `SELECT
+				COALESCE(SUM(rb.orders), 0) AS orders,
+				COALESCE(SUM(rb.delivered), 0) AS delivered,
+				COALESCE(SUM(rb.cancelled), 0) AS cancelled
+			FROM report_order_buckets rb
+			WHERE ($1::UUID IS NULL OR rb.restaurant_id = $1) AND rb.bucket_start >= $2 AND rb.bucket_start < $3`
+	var summary models.OrderSummary
+	if err := database.RMS.Get(&summary, SQL, restaurantID, from, to); err != nil {
+		return summary, err
+	}
+	// language=SQL
+	SQL = `SELECT
+				r.currency,
+				SUM(rb.delivered) AS delivered,
+				SUM(rb.revenue) AS revenue
+			FROM report_order_buckets rb
+			JOIN restaurants r ON r.id = rb.restaurant_id
+			WHERE ($1::UUID IS NULL OR rb.restaurant_id = $1) AND rb.bucket_start >= $2 AND rb.bucket_start < $3
+			GROUP BY r.currency
+			HAVING SUM(rb.delivered) > 0
+			ORDER BY r.currency`
+	summary.Revenue = make([]models.CurrencyRevenue, 0)
+	err := database.RMS.Select(&summary.Revenue, SQL, restaurantID, from, to)
+	return summary, err
+}
+
+func GetPeakHoursReport(restaurantID *string, from, to time.Time, timeZone string) ([]models.PeakHour, error) {
+	// language=SQL
+	SQL := `SELECT
+				EXTRACT(HOUR FROM rb.bucket_start AT TIME ZONE $4::TEXT)::INT AS hour,
+				SUM(rb.orders) AS orders
+			FROM report_order_buckets rb
+			WHERE ($1::UUID IS NULL OR rb.restaurant_id = $1) AND rb.bucket_start >= $2 AND rb.bucket_start < $3
+			GROUP BY 1
+			ORDER BY 1`
+	hours := make([]models.PeakHour, 0)
+	err := database.RMS.Select(&hours, SQL, restaurantID, from, to, timeZone)
+	return hours, err
+}
+
+// GetCustomerReport counts the customers of the range, returning ones ordered before it from the same restaurant or,
+// platform wide, from any
+func GetCustomerReport(restaurantID *string, from, to time.Time) (models.CustomerReport, error) {
+	// language=SQL
+	SQL := `WITH active AS (
+				SELECT DISTINCT cb.user_id
+				FROM report_customer_buckets cb
+				WHERE ($1::UUID IS NULL OR cb.restaurant_id = $1) AND cb.bucket_start >= $2 AND cb.bucket_start < $3
+			), earlier AS (
+				SELECT DISTINCT cb.user_id
+				FROM report_customer_buckets cb
+				JOIN active ON active.user_id = cb.user_id
+				WHERE ($1::UUID IS NULL OR cb.restaurant_id = $1) AND cb.bucket_start < $2
+			)
+			SELECT
+				(SELECT COUNT(*) FROM active) AS customers,
+				(SELECT COUNT(*) FROM active) - (SELECT COUNT(*) FROM earlier) AS new,
+				(SELECT COUNT(*) FROM earlier) AS returning`
+	var report models.CustomerReport
+	err := database.RMS.Get(&report, SQL, restaurantID, from, to)
+	return report, err
+}
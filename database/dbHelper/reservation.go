@@ -0,0 +1,166 @@
+package dbHelper
+
+import (
+	"database/sql"
+	"errors"
+	"rms/database"
+	"rms/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// language=SQL
+const reservationSelect = `SELECT
+				rv.id,
+				rv.restaurant_id,
+				rv.user_id,
+				u.name AS user_name,
+				rv.table_id,
+				rt.name AS table_name,
+				rv.party_size,
+				rv.starts_at,
+				rv.ends_at,
+				rv.status,
+				rv.note,
+				rv.created_at,
+				rv.updated_at
+			FROM reservations rv
+			JOIN users u ON u.id = rv.user_id
+			JOIN restaurant_tables rt ON rt.id = rv.table_id`
+
+func CreateReservation(db sqlx.Ext, reservation *models.Reservation) (string, error) {
+	// language=SQL
+	SQL := `INSERT INTO reservations(restaurant_id, user_id, table_id, party_size, starts_at, ends_at, note) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	var reservationID string
+	err := db.QueryRowx(SQL, reservation.RestaurantID, reservation.UserID, reservation.TableID, reservation.PartySize,
+		reservation.StartsAt, reservation.EndsAt, reservation.Note).Scan(&reservationID)
+	if err != nil {
+		return "", err
+	}
+	return reservationID, nil
+}
+
+func GetReservationByID(db sqlx.Ext, reservationID string) (*models.Reservation, error) {
+	SQL := reservationSelect + ` WHERE rv.id = $1`
+	var reservation models.Reservation
+	err := sqlx.Get(db, &reservation, SQL, reservationID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// UpdateReservation moves a booked reservation to another time, party or table
+func UpdateReservation(db sqlx.Ext, reservation *models.Reservation) (bool, error) {
+	// language=SQL
+	SQL := `UPDATE reservations
+			SET table_id = $1, party_size = $2, starts_at = $3, ends_at = $4, note = $5, updated_at = NOW()
+			WHERE id = $6 AND status = 'booked'`
+	result, err := db.Exec(SQL, reservation.TableID, reservation.PartySize, reservation.StartsAt, reservation.EndsAt, reservation.Note, reservation.ID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// UpdateReservationStatus moves the reservation from one status to another, false means it is no longer in from
+func UpdateReservationStatus(db sqlx.Ext, reservationID string, from, to models.ReservationStatus) (bool, error) {
+	// language=SQL
+	SQL := `UPDATE reservations SET status = $1, updated_at = NOW() WHERE id = $2 AND status = $3`
+	result, err := db.Exec(SQL, to, reservationID, from)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// GetBookedReservations returns the reservations of a restaurant that still hold a table between from and to
+func GetBookedReservations(db sqlx.Ext, restaurantID string, from, to time.Time) ([]models.Reservation, error) {
+	SQL := reservationSelect + ` WHERE rv.restaurant_id = $1 AND rv.status = 'booked' AND rv.starts_at < $3 AND rv.ends_at > $2`
+	reservations := make([]models.Reservation, 0)
+	err := sqlx.Select(db, &reservations, SQL, restaurantID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+// GetRestaurantReservations returns every reservation of a restaurant starting between from and to
+func GetRestaurantReservations(restaurantID string, from, to time.Time) ([]models.Reservation, error) {
+	SQL := reservationSelect + ` WHERE rv.restaurant_id = $1 AND rv.starts_at >= $2 AND rv.starts_at < $3 ORDER BY rv.starts_at, rt.name`
+	reservations := make([]models.Reservation, 0)
+	err := database.RMS.Select(&reservations, SQL, restaurantID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+func GetReservationsCountByUserID(userID string) (int64, error) {
+	// language=SQL
+	SQL := `SELECT COUNT(id) FROM reservations WHERE user_id = $1`
+	var count int64
+	err := database.RMS.Get(&count, SQL, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return count, nil
+}
+
+func GetReservationsByUserID(userID string, Filters models.Filters) ([]models.Reservation, error) {
+	SQL := reservationSelect + ` WHERE rv.user_id = $1
+			ORDER BY rv.starts_at DESC
+			LIMIT $2
+			OFFSET $3`
+	reservations := make([]models.Reservation, 0)
+	err := database.RMS.Select(&reservations, SQL, userID, Filters.PageSize, Filters.PageSize*Filters.PageNumber)
+	if err != nil {
+		return nil, err
+	}
+	return reservations, nil
+}
+
+func CreateReservationBlock(restaurantID string, block *models.AddReservationBlockBody, createdBy string) error {
+	// language=SQL
+	SQL := `INSERT INTO reservation_blocks(restaurant_id, table_id, starts_at, ends_at, reason, created_by) VALUES ($1, $2, $3, $4, $5, $6)`
+	_, err := database.RMS.Exec(SQL, restaurantID, block.TableID, block.StartsAt, block.EndsAt, block.Reason, createdBy)
+	return err
+}
+
+// GetReservationBlocks returns the blocks of a restaurant overlapping the time between from and to
+func GetReservationBlocks(db sqlx.Ext, restaurantID string, from, to time.Time) ([]models.ReservationBlock, error) {
+	// language=SQL
+	SQL := `SELECT
+				rb.id,
+				rb.restaurant_id,
+				rb.table_id,
+				rb.starts_at,
+				rb.ends_at,
+				rb.reason,
+				rb.created_at
+			FROM reservation_blocks rb
+			WHERE rb.archived_at IS NULL AND rb.restaurant_id = $1 AND rb.starts_at < $3 AND rb.ends_at > $2
+			ORDER BY rb.starts_at`
+	blocks := make([]models.ReservationBlock, 0)
+	err := sqlx.Select(db, &blocks, SQL, restaurantID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return blocks, nil
+}
+
+func ArchiveReservationBlock(restaurantID, blockID string) error {
+	// language=SQL
+	SQL := `UPDATE reservation_blocks SET archived_at = NOW() WHERE id = $1 AND restaurant_id = $2 AND archived_at IS NULL`
+	_, err := database.RMS.Exec(SQL, blockID, restaurantID)
+	return err
+}
@@ -6,9 +6,12 @@ import (
 	"rms/database"
 	"rms/models"
 	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 )
 
-func CreateRestaurant(name, email, createdBy, address, state, city, pinCode string, lat, lng float64) (string, error) {
+func CreateRestaurant(name, email, createdBy, address, state, city, pinCode, currency string, lat, lng float64) (string, error) {
 	arguments := []interface{}{
 		name,
 		email,
@@ -19,9 +22,10 @@ func CreateRestaurant(name, email, createdBy, address, state, city, pinCode stri
 		pinCode,
 		lat,
 		lng,
+		currency,
 	}
 	// language=SQL
-	SQL := `INSERT INTO restaurants(name, email, created_by, address, state, city, pin_code, lat, lng) VALUES ($1, TRIM(LOWER($2)), $3, $4, $5, $6, $7, $8, $9) RETURNING id`
+	SQL := `INSERT INTO restaurants(name, email, created_by, address, state, city, pin_code, lat, lng, currency) VALUES ($1, TRIM(LOWER($2)), $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`
 	var restaurantID string
 	if err := database.RMS.QueryRowx(SQL, arguments...).Scan(&restaurantID); err != nil {
 		return "", err
@@ -29,20 +33,51 @@ func CreateRestaurant(name, email, createdBy, address, state, city, pinCode stri
 	return restaurantID, nil
 }
 
-func CreateDish(restaurantID, createdBy, name, description string, quantity, price, discount int64) (string, error) {
+// GetRestaurantCurrency returns the currency of an open restaurant, it is empty when there is no such restaurant
+func GetRestaurantCurrency(restaurantID string) (string, error) {
+	// language=SQL
+	SQL := `SELECT currency FROM restaurants WHERE archived_at IS NULL AND id = $1`
+	var currency string
+	err := database.RMS.Get(&currency, SQL, restaurantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+	return currency, nil
+}
+
+func CreateDish(db sqlx.Ext, restaurantID, createdBy string, body *models.AddDishesBody) (string, error) {
+	var taxCategory string
+	if body.TaxCategory != nil {
+		taxCategory = *body.TaxCategory
+	}
+	var packagingCharge int64
+	if body.PackagingCharge != nil {
+		packagingCharge = body.PackagingCharge.Amount
+	}
 	arguments := []interface{}{
 		restaurantID,
-		quantity,
-		price,
-		discount,
+		body.Quantity,
+		body.Price.Amount,
+		body.Discount,
 		createdBy,
-		name,
-		description,
+		body.Name,
+		body.Description,
+		body.Station,
+		taxCategory,
+		packagingCharge,
+		pq.Array(body.Tags),
+		pq.Array(body.Allergens),
+		body.SpiceLevel,
+		body.Nutrition,
 	}
 	// language=SQL
-	SQL := `INSERT INTO dishes(restaurants_id, quantity, price, discount, created_by, name, description) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id`
+	SQL := `INSERT INTO dishes(restaurants_id, quantity, price, discount, created_by, name, description, station, tax_category, packaging_charge, tags, allergens, spice_level, nutrition)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id`
 	var dishID string
-	if err := database.RMS.QueryRowx(SQL, arguments...).Scan(&dishID); err != nil {
+	if err := db.QueryRowx(SQL, arguments...).Scan(&dishID); err != nil {
 		return "", err
 	}
 	return dishID, nil
@@ -118,34 +153,50 @@ func UpdateRestaurant(restaurantID, name, email, Address, State, City, PinCode s
 	return err
 }
 
-func CloseMyRestaurant(restaurantID, createdBy string) error {
+func CloseMyRestaurant(db sqlx.Ext, restaurantID, createdBy string) (bool, error) {
 	//todo := dont return id **DONE**
 	// language=SQL
 	SQL := `UPDATE restaurants 
 		SET archived_at = $1
-		WHERE id = $2 AND created_by = $3`
-	_, err := database.RMS.Exec(SQL, time.Now(), restaurantID, createdBy)
-	return err
+		WHERE id = $2 AND created_by = $3 AND archived_at IS NULL`
+	result, err := db.Exec(SQL, time.Now(), restaurantID, createdBy)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
 }
 
-func CloseRestaurant(restaurantID string) error {
+func CloseRestaurant(db sqlx.Ext, restaurantID string) (bool, error) {
 	// language=SQL
 	SQL := `UPDATE restaurants 
 		SET archived_at = $1
-		WHERE id = $2`
-	_, err := database.RMS.Exec(SQL, time.Now(), restaurantID)
-	return err
+		WHERE id = $2 AND archived_at IS NULL`
+	result, err := db.Exec(SQL, time.Now(), restaurantID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
 }
 
-func UpdateDish(dishID, restaurantId, name, description string, quantity, price, discount int64) error {
+// UpdateDish saves the body over the dish, callers fill in what the body left out from the current dish
+func UpdateDish(db sqlx.Ext, dishID, restaurantId string, body *models.AddDishesBody) error {
 	arguments := []interface{}{
-		name,
-		description,
-		quantity,
-		price,
-		discount,
+		body.Name,
+		body.Description,
+		body.Quantity,
+		body.Price.Amount,
+		body.Discount,
 		dishID,
 		restaurantId,
+		body.Station,
+		body.TaxCategory,
+		body.PackagingCharge.Amount,
+		pq.Array(body.Tags),
+		pq.Array(body.Allergens),
+		body.SpiceLevel,
+		body.Nutrition,
 	}
 	// language=SQL
 	SQL := `UPDATE dishes
@@ -153,28 +204,60 @@ func UpdateDish(dishID, restaurantId, name, description string, quantity, price,
 			description = $2,
 			quantity = $3, 
 			price = $4,
-			discount = $5
+			discount = $5,
+			station = $8,
+			tax_category = $9,
+			packaging_charge = $10,
+			tags = $11,
+			allergens = $12,
+			spice_level = $13,
+			nutrition = $14
 		WHERE id = $6 AND restaurants_id = $7`
-	_, err := database.RMS.Exec(SQL, arguments...)
+	_, err := db.Exec(SQL, arguments...)
 	return err
 }
 
-func RemoveDishByUserID(dishID, restaurantID, createdBy string) error {
+// SetDishAvailability switches the dish on or off, backAt is dropped when it is switched on
+func SetDishAvailability(db sqlx.Ext, dishID, restaurantID string, available bool, backAt *time.Time) (bool, error) {
+	// language=SQL
+	SQL := `UPDATE dishes
+		SET available = $1,
+			unavailable_until = CASE WHEN $1 THEN NULL ELSE $2::TIMESTAMP WITH TIME ZONE END
+		WHERE id = $3 AND restaurants_id = $4 AND archived_at IS NULL`
+	result, err := db.Exec(SQL, available, backAt, dishID, restaurantID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// RemoveDishByUserID tells whether a dish of the user was on the menu to remove
+func RemoveDishByUserID(db sqlx.Ext, dishID, restaurantID, createdBy string) (bool, error) {
 	// language=SQL
 	SQL := `UPDATE dishes 
 		SET archived_at = $1
-		WHERE id = $2 AND restaurants_id = $3 AND created_by = $4`
-	_, err := database.RMS.Exec(SQL, time.Now(), dishID, restaurantID, createdBy)
-	return err
+		WHERE id = $2 AND restaurants_id = $3 AND created_by = $4 AND archived_at IS NULL`
+	result, err := db.Exec(SQL, time.Now(), dishID, restaurantID, createdBy)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
 }
 
-func RemoveDish(dishID, restaurantID string) error {
+// RemoveDish tells whether the dish was on the menu to remove
+func RemoveDish(db sqlx.Ext, dishID, restaurantID string) (bool, error) {
 	// language=SQL
 	SQL := `UPDATE dishes 
 		SET archived_at = $1
-		WHERE id = $2 AND restaurants_id = $3`
-	_, err := database.RMS.Exec(SQL, time.Now(), dishID, restaurantID)
-	return err
+		WHERE id = $2 AND restaurants_id = $3 AND archived_at IS NULL`
+	result, err := db.Exec(SQL, time.Now(), dishID, restaurantID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
 }
 
 func GetDishByID(dishID string) (*models.Dishes, error) {
@@ -186,6 +269,21 @@ func GetDishByID(dishID string) (*models.Dishes, error) {
 				d.quantity,
 				d.price,
 				d.discount,
+				d.station,
+				d.tax_category,
+				d.packaging_charge,
+				(SELECT r.currency FROM restaurants r WHERE r.id = d.restaurants_id) AS currency,
+				d.available OR COALESCE(d.unavailable_until <= NOW(), FALSE) AS available,
+				CASE WHEN NOT d.available AND d.unavailable_until > NOW() THEN d.unavailable_until END AS unavailable_until,
+				d.quantity <= 0 AS sold_out,
+				d.photo,
+				d.tags,
+				d.allergens,
+				d.spice_level,
+				d.nutrition,
+				dish_served_at(d.id, NOW()) AS served,
+				d.avg_rating,
+				d.rating_count,
        			d.created_at,
        			d.created_by
 			FROM dishes d
@@ -207,9 +305,14 @@ func GetRestaurantsCountByUserID(createdBy string, Filters models.Filters) (int6
        			COUNT(r.id)
 			FROM restaurants r
 			WHERE r.archived_at IS NULL AND r.created_by = $1 AND
-				r.name ILIKE '%' || $2 || '%' AND  r.email ILIKE '%' || $3 || '%'`
+				r.name ILIKE '%' || $2 || '%' AND  r.email ILIKE '%' || $3 || '%' AND r.avg_rating >= $4 AND
+				(CARDINALITY(COALESCE($5::TEXT[], '{}')) = 0 OR EXISTS (SELECT 1 FROM restaurant_cuisines rc WHERE rc.restaurant_id = r.id AND rc.cuisine = ANY($5))) AND
+				r.features @> COALESCE($6::TEXT[], '{}') AND
+				(CARDINALITY(COALESCE($7::INT[], '{}')) = 0 OR r.price_range = ANY($7)) AND
+				($8::BIGINT IS NULL OR r.cost_for_two <= $8)`
 	var count int64
-	err := database.RMS.Get(&count, SQL, createdBy, Filters.Name, Filters.Email)
+	err := database.RMS.Get(&count, SQL, createdBy, Filters.Name, Filters.Email, Filters.MinRating,
+		pq.Array(Filters.Cuisines), pq.Array(Filters.Features), pq.Array(Filters.PriceRanges), Filters.MaxCostForTwo)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return 0, nil
@@ -227,6 +330,11 @@ func GetRestaurantsByUserID(createdBy string, Filters models.Filters) ([]models.
 		Filters.SortBy,
 		Filters.PageSize,
 		Filters.PageSize * Filters.PageNumber,
+		Filters.MinRating,
+		pq.Array(Filters.Cuisines),
+		pq.Array(Filters.Features),
+		pq.Array(Filters.PriceRanges),
+		Filters.MaxCostForTwo,
 	}
 	// language=SQL
 	SQL := `SELECT 
@@ -240,11 +348,25 @@ func GetRestaurantsByUserID(createdBy string, Filters models.Filters) ([]models.
 				r.city,
 				r.pin_code,
 				r.lat,
-				r.lng
+				r.lng,
+				r.avg_rating,
+				r.rating_count,
+				r.currency,
+				r.logo,
+				r.banner,
+				ARRAY(SELECT rc.cuisine FROM restaurant_cuisines rc WHERE rc.restaurant_id = r.id ORDER BY rc.cuisine) AS cuisines,
+				r.price_range,
+				r.cost_for_two,
+				r.features
 			FROM restaurants r
 			WHERE r.archived_at IS NULL AND r.created_by = $1 AND
-				r.name ILIKE '%' || $2 || '%' AND  r.email ILIKE '%' || $3 || '%'
-			ORDER BY $4
+				r.name ILIKE '%' || $2 || '%' AND  r.email ILIKE '%' || $3 || '%' AND r.avg_rating >= $7 AND
+				(CARDINALITY(COALESCE($8::TEXT[], '{}')) = 0 OR EXISTS (SELECT 1 FROM restaurant_cuisines rc WHERE rc.restaurant_id = r.id AND rc.cuisine = ANY($8))) AND
+				r.features @> COALESCE($9::TEXT[], '{}') AND
+				(CARDINALITY(COALESCE($10::INT[], '{}')) = 0 OR r.price_range = ANY($10)) AND
+				($11::BIGINT IS NULL OR r.cost_for_two <= $11)
+			ORDER BY CASE WHEN $4::text = 'avg_rating' THEN r.avg_rating END DESC,
+				CASE $4::text WHEN 'name' THEN r.name WHEN 'email' THEN r.email WHEN 'created_by' THEN r.created_by::text ELSE r.id::text END
 			LIMIT $5
 			OFFSET $6`
 	restaurant := make([]models.Restaurant, 0)
@@ -268,7 +390,16 @@ func GetRestaurantByID(restaurantId string) (*models.Restaurant, error) {
 				r.city,
 				r.pin_code,
 				r.lat,
-				r.lng
+				r.lng,
+				r.avg_rating,
+				r.rating_count,
+				r.currency,
+				r.logo,
+				r.banner,
+				ARRAY(SELECT rc.cuisine FROM restaurant_cuisines rc WHERE rc.restaurant_id = r.id ORDER BY rc.cuisine) AS cuisines,
+				r.price_range,
+				r.cost_for_two,
+				r.features
 			FROM restaurants r
 			WHERE r.archived_at IS NULL AND r.id = $1`
 	var restaurant models.Restaurant
@@ -295,7 +426,16 @@ func GetRestaurantByIDAndUserID(restaurantId, createdBy string) (*models.Restaur
 				r.city,
 				r.pin_code,
 				r.lat,
-				r.lng
+				r.lng,
+				r.avg_rating,
+				r.rating_count,
+				r.currency,
+				r.logo,
+				r.banner,
+				ARRAY(SELECT rc.cuisine FROM restaurant_cuisines rc WHERE rc.restaurant_id = r.id ORDER BY rc.cuisine) AS cuisines,
+				r.price_range,
+				r.cost_for_two,
+				r.features
 			FROM restaurants r
 			WHERE r.archived_at IS NULL AND r.restaurants_id = $1 AND r.created_by = $2`
 	var restaurant models.Restaurant
@@ -315,17 +455,24 @@ func GetRestaurantDishesCount(restaurantID string, Filters models.DishFilters) (
 		Filters.CreatedBy,
 		Filters.Name,
 		Filters.MinQuantity,
-		Filters.MinPrice,
-		Filters.MaxPrice,
+		Filters.MinPrice.Amount,
+		Filters.MaxPrice.Amount,
 		Filters.MinDiscount,
 		Filters.MaxDiscount,
+		Filters.ServedAt,
+		pq.Array(Filters.IncludeTags),
+		pq.Array(Filters.ExcludeTags),
+		Filters.MaxSpiceLevel,
 	}
 	// language=SQL
 	SQL := `SELECT 
        			COUNT(d.id)
 			FROM dishes d
 			WHERE d.archived_at IS NULL AND d.restaurants_id = $1 AND d.created_by::text ILIKE '%' || $2 || '%' AND
-			d.name ILIKE '%' || $3 || '%' AND  d.quantity > $4 AND d.price BETWEEN $5 AND $6 AND d.discount BETWEEN $7 AND $8`
+			d.name ILIKE '%' || $3 || '%' AND  ($4::BIGINT IS NULL OR d.quantity > $4) AND d.price BETWEEN $5 AND $6 AND d.discount BETWEEN $7 AND $8 AND
+			($9::TIMESTAMP WITH TIME ZONE IS NULL OR dish_served_at(d.id, $9)) AND
+			(d.tags || d.allergens) @> COALESCE($10::TEXT[], '{}') AND NOT (d.tags && $11::TEXT[] OR d.allergens && $11::TEXT[]) IS TRUE AND
+			($12::INT IS NULL OR d.spice_level <= $12)`
 	var count int64
 	err := database.RMS.Get(&count, SQL, arguments...)
 	if err != nil {
@@ -343,13 +490,17 @@ func GetRestaurantDishes(restaurantID string, Filters models.DishFilters) ([]mod
 		Filters.CreatedBy,
 		Filters.Name,
 		Filters.MinQuantity,
-		Filters.MinPrice,
-		Filters.MaxPrice,
+		Filters.MinPrice.Amount,
+		Filters.MaxPrice.Amount,
 		Filters.MinDiscount,
 		Filters.MaxDiscount,
 		Filters.SortBy,
 		Filters.PageSize,
 		Filters.PageSize * Filters.PageNumber,
+		Filters.ServedAt,
+		pq.Array(Filters.IncludeTags),
+		pq.Array(Filters.ExcludeTags),
+		Filters.MaxSpiceLevel,
 	}
 	// language=SQL
 	SQL := `SELECT 
@@ -359,11 +510,29 @@ func GetRestaurantDishes(restaurantID string, Filters models.DishFilters) ([]mod
 				d.quantity,
 				d.price,
 				d.discount,
+				d.station,
+				d.tax_category,
+				d.packaging_charge,
+				(SELECT r.currency FROM restaurants r WHERE r.id = d.restaurants_id) AS currency,
+				d.available OR COALESCE(d.unavailable_until <= NOW(), FALSE) AS available,
+				CASE WHEN NOT d.available AND d.unavailable_until > NOW() THEN d.unavailable_until END AS unavailable_until,
+				d.quantity <= 0 AS sold_out,
+				d.photo,
+				d.tags,
+				d.allergens,
+				d.spice_level,
+				d.nutrition,
+				dish_served_at(d.id, COALESCE($12::TIMESTAMP WITH TIME ZONE, NOW())) AS served,
+				d.avg_rating,
+				d.rating_count,
        			d.created_at,
        			d.created_by
 			FROM dishes d
 			WHERE d.archived_at IS NULL AND d.restaurants_id = $1 AND d.created_by::text ILIKE '%' || $2 || '%' AND
-			d.name ILIKE '%' || $3 || '%' AND  d.quantity > $4 AND d.price BETWEEN $5 AND $6 AND d.discount BETWEEN $7 AND $8
+			d.name ILIKE '%' || $3 || '%' AND  ($4::BIGINT IS NULL OR d.quantity > $4) AND d.price BETWEEN $5 AND $6 AND d.discount BETWEEN $7 AND $8 AND
+			($12::TIMESTAMP WITH TIME ZONE IS NULL OR dish_served_at(d.id, $12)) AND
+			(d.tags || d.allergens) @> COALESCE($13::TEXT[], '{}') AND NOT (d.tags && $14::TEXT[] OR d.allergens && $14::TEXT[]) IS TRUE AND
+			($15::INT IS NULL OR d.spice_level <= $15)
 			ORDER BY $9
 			LIMIT $10
 			OFFSET $11`
@@ -375,7 +544,7 @@ func GetRestaurantDishes(restaurantID string, Filters models.DishFilters) ([]mod
 	return dishes, nil
 }
 
-func GetRestaurantDishById(restaurantID, dishID string) (*models.Dishes, error) {
+func GetRestaurantDishById(restaurantID, dishID string, servedAt time.Time) (*models.Dishes, error) {
 	// language=SQL
 	SQL := `SELECT 
        			d.id,
@@ -384,12 +553,27 @@ func GetRestaurantDishById(restaurantID, dishID string) (*models.Dishes, error)
 				d.quantity,
 				d.price,
 				d.discount,
+				d.station,
+				d.tax_category,
+				d.packaging_charge,
+				(SELECT r.currency FROM restaurants r WHERE r.id = d.restaurants_id) AS currency,
+				d.available OR COALESCE(d.unavailable_until <= NOW(), FALSE) AS available,
+				CASE WHEN NOT d.available AND d.unavailable_until > NOW() THEN d.unavailable_until END AS unavailable_until,
+				d.quantity <= 0 AS sold_out,
+				d.photo,
+				d.tags,
+				d.allergens,
+				d.spice_level,
+				d.nutrition,
+				dish_served_at(d.id, $3) AS served,
+				d.avg_rating,
+				d.rating_count,
        			d.created_at,
        			d.created_by
 			FROM dishes d
 			WHERE d.archived_at IS NULL AND d.restaurants_id = $1 AND d.id = $2`
 	var dishes models.Dishes
-	err := database.RMS.Select(&dishes, SQL, restaurantID, dishID)
+	err := database.RMS.Get(&dishes, SQL, restaurantID, dishID, servedAt)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil
@@ -405,17 +589,24 @@ func GetRestaurantDishesCountByUserId(restaurantID, createdBy string, Filters mo
 		createdBy,
 		Filters.Name,
 		Filters.MinQuantity,
-		Filters.MinPrice,
-		Filters.MaxPrice,
+		Filters.MinPrice.Amount,
+		Filters.MaxPrice.Amount,
 		Filters.MinDiscount,
 		Filters.MaxDiscount,
+		Filters.ServedAt,
+		pq.Array(Filters.IncludeTags),
+		pq.Array(Filters.ExcludeTags),
+		Filters.MaxSpiceLevel,
 	}
 	// language=SQL
 	SQL := `SELECT 
        			COUNT(d.id)
 			FROM dishes d
 			WHERE d.archived_at IS NULL AND d.restaurants_id = $1 AND d.created_by = $2 AND 
-			d.name ILIKE '%' || $3 || '%' AND  d.quantity > $4 AND d.price BETWEEN $5 AND $6 AND d.discount BETWEEN $7 AND $8`
+			d.name ILIKE '%' || $3 || '%' AND  ($4::BIGINT IS NULL OR d.quantity > $4) AND d.price BETWEEN $5 AND $6 AND d.discount BETWEEN $7 AND $8 AND
+			($9::TIMESTAMP WITH TIME ZONE IS NULL OR dish_served_at(d.id, $9)) AND
+			(d.tags || d.allergens) @> COALESCE($10::TEXT[], '{}') AND NOT (d.tags && $11::TEXT[] OR d.allergens && $11::TEXT[]) IS TRUE AND
+			($12::INT IS NULL OR d.spice_level <= $12)`
 	var count int64
 	err := database.RMS.Get(&count, SQL, arguments...)
 	if err != nil {
@@ -433,13 +624,17 @@ func GetRestaurantDishesByUserID(restaurantID, createdBy string, Filters models.
 		createdBy,
 		Filters.Name,
 		Filters.MinQuantity,
-		Filters.MinPrice,
-		Filters.MaxPrice,
+		Filters.MinPrice.Amount,
+		Filters.MaxPrice.Amount,
 		Filters.MinDiscount,
 		Filters.MaxDiscount,
 		Filters.SortBy,
 		Filters.PageSize,
 		Filters.PageSize * Filters.PageNumber,
+		Filters.ServedAt,
+		pq.Array(Filters.IncludeTags),
+		pq.Array(Filters.ExcludeTags),
+		Filters.MaxSpiceLevel,
 	}
 	// language=SQL
 	SQL := `SELECT 
@@ -449,11 +644,29 @@ func GetRestaurantDishesByUserID(restaurantID, createdBy string, Filters models.
 				d.quantity,
 				d.price,
 				d.discount,
+				d.station,
+				d.tax_category,
+				d.packaging_charge,
+				(SELECT r.currency FROM restaurants r WHERE r.id = d.restaurants_id) AS currency,
+				d.available OR COALESCE(d.unavailable_until <= NOW(), FALSE) AS available,
+				CASE WHEN NOT d.available AND d.unavailable_until > NOW() THEN d.unavailable_until END AS unavailable_until,
+				d.quantity <= 0 AS sold_out,
+				d.photo,
+				d.tags,
+				d.allergens,
+				d.spice_level,
+				d.nutrition,
+				dish_served_at(d.id, COALESCE($12::TIMESTAMP WITH TIME ZONE, NOW())) AS served,
+				d.avg_rating,
+				d.rating_count,
        			d.created_at,
        			d.created_by
 			FROM dishes d
 			WHERE d.archived_at IS NULL AND d.restaurants_id = $1 AND d.created_by = $2 AND 
-			d.name ILIKE '%' || $3 || '%' AND  d.quantity > $4 AND d.price BETWEEN $5 AND $6 AND d.discount BETWEEN $7 AND $8
+			d.name ILIKE '%' || $3 || '%' AND  ($4::BIGINT IS NULL OR d.quantity > $4) AND d.price BETWEEN $5 AND $6 AND d.discount BETWEEN $7 AND $8 AND
+			($12::TIMESTAMP WITH TIME ZONE IS NULL OR dish_served_at(d.id, $12)) AND
+			(d.tags || d.allergens) @> COALESCE($13::TEXT[], '{}') AND NOT (d.tags && $14::TEXT[] OR d.allergens && $14::TEXT[]) IS TRUE AND
+			($15::INT IS NULL OR d.spice_level <= $15)
 			ORDER BY $9
 			LIMIT $10
 			OFFSET $11`
@@ -474,6 +687,21 @@ func GetRestaurantDishByIDAndUserID(restaurantID, dishID, createdBy string) (*mo
 				d.quantity,
 				d.price,
 				d.discount,
+				d.station,
+				d.tax_category,
+				d.packaging_charge,
+				(SELECT r.currency FROM restaurants r WHERE r.id = d.restaurants_id) AS currency,
+				d.available OR COALESCE(d.unavailable_until <= NOW(), FALSE) AS available,
+				CASE WHEN NOT d.available AND d.unavailable_until > NOW() THEN d.unavailable_until END AS unavailable_until,
+				d.quantity <= 0 AS sold_out,
+				d.photo,
+				d.tags,
+				d.allergens,
+				d.spice_level,
+				d.nutrition,
+				dish_served_at(d.id, NOW()) AS served,
+				d.avg_rating,
+				d.rating_count,
        			d.created_at,
        			d.created_by
 			FROM dishes d
@@ -496,9 +724,14 @@ func GetRestaurantsCount(Filters models.Filters) (int64, error) {
        			COUNT(r.id)
 			FROM restaurants r
 			WHERE r.archived_at IS NULL AND r.created_by::text ILIKE '%' || $1 || '%'  AND
-				r.name ILIKE '%' || $2 || '%' AND  r.email ILIKE '%' || $3 || '%'`
+				r.name ILIKE '%' || $2 || '%' AND  r.email ILIKE '%' || $3 || '%' AND r.avg_rating >= $4 AND
+				(CARDINALITY(COALESCE($5::TEXT[], '{}')) = 0 OR EXISTS (SELECT 1 FROM restaurant_cuisines rc WHERE rc.restaurant_id = r.id AND rc.cuisine = ANY($5))) AND
+				r.features @> COALESCE($6::TEXT[], '{}') AND
+				(CARDINALITY(COALESCE($7::INT[], '{}')) = 0 OR r.price_range = ANY($7)) AND
+				($8::BIGINT IS NULL OR r.cost_for_two <= $8)`
 	var count int64
-	err := database.RMS.Get(&count, SQL, Filters.CreatedBy, Filters.Name, Filters.Email)
+	err := database.RMS.Get(&count, SQL, Filters.CreatedBy, Filters.Name, Filters.Email, Filters.MinRating,
+		pq.Array(Filters.Cuisines), pq.Array(Filters.Features), pq.Array(Filters.PriceRanges), Filters.MaxCostForTwo)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return 0, nil
@@ -516,6 +749,11 @@ func GetRestaurants(Filters models.Filters) ([]models.Restaurant, error) {
 		Filters.SortBy,
 		Filters.PageSize,
 		Filters.PageNumber * Filters.PageSize,
+		Filters.MinRating,
+		pq.Array(Filters.Cuisines),
+		pq.Array(Filters.Features),
+		pq.Array(Filters.PriceRanges),
+		Filters.MaxCostForTwo,
 	}
 	// language=SQL
 	SQL := `SELECT 
@@ -529,11 +767,25 @@ func GetRestaurants(Filters models.Filters) ([]models.Restaurant, error) {
 				r.city,
 				r.pin_code,
 				r.lat,
-				r.lng
+				r.lng,
+				r.avg_rating,
+				r.rating_count,
+				r.currency,
+				r.logo,
+				r.banner,
+				ARRAY(SELECT rc.cuisine FROM restaurant_cuisines rc WHERE rc.restaurant_id = r.id ORDER BY rc.cuisine) AS cuisines,
+				r.price_range,
+				r.cost_for_two,
+				r.features
 			FROM restaurants r
 			WHERE r.archived_at IS NULL AND r.created_by::text ILIKE '%' || $1 || '%'  AND
-				r.name ILIKE '%' || $2 || '%' AND  r.email ILIKE '%' || $3 || '%'
-			ORDER BY $4
+				r.name ILIKE '%' || $2 || '%' AND  r.email ILIKE '%' || $3 || '%' AND r.avg_rating >= $7 AND
+				(CARDINALITY(COALESCE($8::TEXT[], '{}')) = 0 OR EXISTS (SELECT 1 FROM restaurant_cuisines rc WHERE rc.restaurant_id = r.id AND rc.cuisine = ANY($8))) AND
+				r.features @> COALESCE($9::TEXT[], '{}') AND
+				(CARDINALITY(COALESCE($10::INT[], '{}')) = 0 OR r.price_range = ANY($10)) AND
+				($11::BIGINT IS NULL OR r.cost_for_two <= $11)
+			ORDER BY CASE WHEN $4::text = 'avg_rating' THEN r.avg_rating END DESC,
+				CASE $4::text WHEN 'name' THEN r.name WHEN 'email' THEN r.email WHEN 'created_by' THEN r.created_by::text ELSE r.id::text END
 			LIMIT $5
 			OFFSET $6`
 	restaurants := make([]models.Restaurant, 0)
@@ -0,0 +1,272 @@
+package dbHelper
+
+import (
+	"database/sql"
+	"errors"
+	"rms/database"
+	"rms/models"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+func IsOrderReviewed(orderID string) (bool, error) {
+	// language=SQL
+	SQL := `SELECT count(*) > 0 FROM reviews WHERE order_id = $1`
+	var reviewed bool
+	err := database.RMS.Get(&reviewed, SQL, orderID)
+	return reviewed, err
+}
+
+// CreateReview returns "" when the order was reviewed already
+func CreateReview(db sqlx.Ext, orderID, userID, restaurantID string, rating int64, comment string) (string, error) {
+	arguments := []interface{}{
+		orderID,
+		userID,
+		restaurantID,
+		rating,
+		comment,
+	}
+	// language=SQL
+	SQL := `INSERT INTO reviews(order_id, user_id, restaurant_id, rating, comment) VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (order_id) DO NOTHING RETURNING id`
+	var reviewID string
+	if err := db.QueryRowx(SQL, arguments...).Scan(&reviewID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+	return reviewID, nil
+}
+
+func CreateDishReview(db sqlx.Ext, reviewID, dishID string, rating int64, comment string) error {
+	// language=SQL
+	SQL := `INSERT INTO dish_reviews(review_id, dish_id, rating, comment) VALUES ($1, $2, $3, $4)`
+	_, err := db.Exec(SQL, reviewID, dishID, rating, comment)
+	return err
+}
+
+// AddRestaurantRating folds a rating into the stored aggregate, a negative count removes a previously added rating
+func AddRestaurantRating(db sqlx.Ext, restaurantID string, rating, count int64) error {
+	// language=SQL
+	SQL := `UPDATE restaurants
+		SET rating_count = rating_count + $2,
+			rating_total = rating_total + $1,
+			avg_rating = CASE WHEN rating_count + $2 > 0 THEN (rating_total + $1)::NUMERIC / (rating_count + $2) ELSE 0 END
+		WHERE id = $3`
+	_, err := db.Exec(SQL, rating*count, count, restaurantID)
+	return err
+}
+
+// AddDishRating folds a rating into the stored dish aggregate, a negative count removes a previously added rating
+func AddDishRating(db sqlx.Ext, dishID string, rating, count int64) error {
+	// language=SQL
+	SQL := `UPDATE dishes
+		SET rating_count = rating_count + $2,
+			rating_total = rating_total + $1,
+			avg_rating = CASE WHEN rating_count + $2 > 0 THEN (rating_total + $1)::NUMERIC / (rating_count + $2) ELSE 0 END
+		WHERE id = $3`
+	_, err := db.Exec(SQL, rating*count, count, dishID)
+	return err
+}
+
+func GetReviewByID(reviewID string) (*models.Review, error) {
+	// language=SQL
+	SQL := `SELECT
+				rv.id,
+				rv.order_id,
+				rv.user_id,
+				u.name AS user_name,
+				rv.restaurant_id,
+				rv.rating,
+				rv.comment,
+				rv.reply,
+				rv.replied_at,
+				rv.flag_reason,
+				rv.flagged_at,
+				rv.created_at
+			FROM reviews rv
+			JOIN users u on rv.user_id = u.id
+			WHERE rv.hidden_at IS NULL AND rv.id = $1`
+	var review models.Review
+	err := database.RMS.Get(&review, SQL, reviewID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	dishReviews, dishErr := GetDishReviewsByReviewIDs([]string{reviewID})
+	if dishErr != nil {
+		return nil, dishErr
+	}
+	review.Dishes = dishReviews
+	return &review, nil
+}
+
+func GetDishReviewsByReviewIDs(reviewIDs []string) ([]models.DishReview, error) {
+	// language=SQL
+	SQL := `SELECT
+				dr.id,
+				dr.review_id,
+				dr.dish_id,
+				d.name AS dish_name,
+				dr.rating,
+				dr.comment
+			FROM dish_reviews dr
+			JOIN dishes d on dr.dish_id = d.id
+			WHERE dr.review_id::text = any($1)`
+	dishReviews := make([]models.DishReview, 0)
+	err := database.RMS.Select(&dishReviews, SQL, pq.StringArray(reviewIDs))
+	if err != nil {
+		return nil, err
+	}
+	return dishReviews, nil
+}
+
+func GetRestaurantReviewsCount(restaurantID string) (int64, error) {
+	// language=SQL
+	SQL := `SELECT COUNT(rv.id) FROM reviews rv WHERE rv.hidden_at IS NULL AND rv.restaurant_id = $1`
+	var count int64
+	err := database.RMS.Get(&count, SQL, restaurantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return count, nil
+}
+
+func GetRestaurantReviews(restaurantID string, Filters models.Filters) ([]models.Review, error) {
+	// language=SQL
+	SQL := `SELECT
+				rv.id,
+				rv.order_id,
+				rv.user_id,
+				u.name AS user_name,
+				rv.restaurant_id,
+				rv.rating,
+				rv.comment,
+				rv.reply,
+				rv.replied_at,
+				rv.created_at
+			FROM reviews rv
+			JOIN users u on rv.user_id = u.id
+			WHERE rv.hidden_at IS NULL AND rv.restaurant_id = $1
+			ORDER BY rv.created_at DESC
+			LIMIT $2
+			OFFSET $3`
+	reviews := make([]models.Review, 0)
+	err := database.RMS.Select(&reviews, SQL, restaurantID, Filters.PageSize, Filters.PageSize*Filters.PageNumber)
+	if err != nil {
+		return nil, err
+	}
+	return attachDishReviews(reviews)
+}
+
+func GetFlaggedReviewsCount() (int64, error) {
+	// language=SQL
+	SQL := `SELECT COUNT(rv.id) FROM reviews rv WHERE rv.flagged_at IS NOT NULL AND rv.moderated_at IS NULL`
+	var count int64
+	err := database.RMS.Get(&count, SQL)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return count, nil
+}
+
+func GetFlaggedReviews(Filters models.Filters) ([]models.Review, error) {
+	// language=SQL
+	SQL := `SELECT
+				rv.id,
+				rv.order_id,
+				rv.user_id,
+				u.name AS user_name,
+				rv.restaurant_id,
+				rv.rating,
+				rv.comment,
+				rv.reply,
+				rv.replied_at,
+				rv.flag_reason,
+				rv.flagged_at,
+				rv.created_at
+			FROM reviews rv
+			JOIN users u on rv.user_id = u.id
+			WHERE rv.flagged_at IS NOT NULL AND rv.moderated_at IS NULL
+			ORDER BY rv.flagged_at
+			LIMIT $1
+			OFFSET $2`
+	reviews := make([]models.Review, 0)
+	err := database.RMS.Select(&reviews, SQL, Filters.PageSize, Filters.PageSize*Filters.PageNumber)
+	if err != nil {
+		return nil, err
+	}
+	return attachDishReviews(reviews)
+}
+
+func ReplyToReview(reviewID, repliedBy, reply string) error {
+	// language=SQL
+	SQL := `UPDATE reviews
+		SET reply = $1,
+			replied_by = $2,
+			replied_at = NOW()
+		WHERE id = $3 AND hidden_at IS NULL`
+	_, err := database.RMS.Exec(SQL, reply, repliedBy, reviewID)
+	return err
+}
+
+func FlagReview(reviewID, flaggedBy, reason string) error {
+	// language=SQL
+	SQL := `UPDATE reviews
+		SET flag_reason = $1,
+			flagged_by = $2,
+			flagged_at = NOW(),
+			moderated_by = NULL,
+			moderated_at = NULL
+		WHERE id = $3 AND hidden_at IS NULL`
+	_, err := database.RMS.Exec(SQL, reason, flaggedBy, reviewID)
+	return err
+}
+
+// ModerateReview closes the flag on a review and hides it when asked, returns false if the review was already hidden
+func ModerateReview(db sqlx.Ext, reviewID, moderatedBy string, hide bool) (bool, error) {
+	// language=SQL
+	SQL := `UPDATE reviews
+		SET moderated_by = $1,
+			moderated_at = NOW(),
+			hidden_at = CASE WHEN $2 THEN NOW() ELSE NULL END
+		WHERE id = $3 AND hidden_at IS NULL`
+	result, err := db.Exec(SQL, moderatedBy, hide, reviewID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func attachDishReviews(reviews []models.Review) ([]models.Review, error) {
+	reviewIDs := make([]string, 0, len(reviews))
+	for _, review := range reviews {
+		reviewIDs = append(reviewIDs, review.ID)
+	}
+	dishReviews, err := GetDishReviewsByReviewIDs(reviewIDs)
+	if err != nil {
+		return nil, err
+	}
+	dishReviewMap := make(map[string][]models.DishReview)
+	for _, dishReview := range dishReviews {
+		dishReviewMap[dishReview.ReviewID] = append(dishReviewMap[dishReview.ReviewID], dishReview)
+	}
+	for index := range reviews {
+		reviews[index].Dishes = dishReviewMap[reviews[index].ID]
+	}
+	return reviews, nil
+}
@@ -0,0 +1,257 @@
+package dbHelper
+
+import (
+	"database/sql"
+	"errors"
+	"rms/database"
+	"rms/models"
+	"rms/utils"
+	"sort"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrNoRiderFree is returned by AssignNearestRider when every rider is busy
+var ErrNoRiderFree = errors.New("no rider is free")
+
+// language=SQL
+const riderSelect = `SELECT
+				u.id,
+				u.name,
+				u.email,
+				COALESCE(rs.is_available, FALSE) AS is_available,
+				rs.lat,
+				rs.lng,
+				rs.located_at,
+				(SELECT o.id::TEXT FROM orders o WHERE o.rider_id = u.id AND o.status IN ('accepted', 'preparing', 'ready', 'out-for-delivery') LIMIT 1) AS active_order
+			FROM users u
+			JOIN user_roles ur ON ur.user_id = u.id AND ur.role_name = 'rider' AND ur.archived_at IS NULL
+			LEFT JOIN rider_status rs ON rs.user_id = u.id
+			WHERE u.archived_at IS NULL`
+
+func CreateRiderStatus(db sqlx.Ext, userID string) error {
+	// language=SQL
+	SQL := `INSERT INTO rider_status(user_id) VALUES ($1) ON CONFLICT (user_id) DO NOTHING`
+	_, err := db.Exec(SQL, userID)
+	return err
+}
+
+func UpdateRiderAvailability(userID string, available bool) error {
+	// language=SQL
+	SQL := `INSERT INTO rider_status(user_id, is_available) VALUES ($1, $2)
+			ON CONFLICT (user_id) DO UPDATE SET is_available = EXCLUDED.is_available, updated_at = NOW()`
+	_, err := database.RMS.Exec(SQL, userID, available)
+	return err
+}
+
+func UpdateRiderLocation(userID string, lat, lng float64) error {
+	// language=SQL
+	SQL := `INSERT INTO rider_status(user_id, lat, lng, located_at) VALUES ($1, $2, $3, NOW())
+			ON CONFLICT (user_id) DO UPDATE SET lat = EXCLUDED.lat, lng = EXCLUDED.lng, located_at = NOW(), updated_at = NOW()`
+	_, err := database.RMS.Exec(SQL, userID, lat, lng)
+	return err
+}
+
+func GetRiders() ([]models.Rider, error) {
+	SQL := riderSelect + ` ORDER BY u.name`
+	riders := make([]models.Rider, 0)
+	err := database.RMS.Select(&riders, SQL)
+	if err != nil {
+		return nil, err
+	}
+	return riders, nil
+}
+
+func GetRiderByID(riderID string) (*models.Rider, error) {
+	SQL := riderSelect + ` AND u.id = $1`
+	var rider models.Rider
+	err := database.RMS.Get(&rider, SQL, riderID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &rider, nil
+}
+
+// GetIdleRiders lists the available riders with a known position and no delivery in hand, nearest to the point first
+func GetIdleRiders(lat, lng float64) ([]models.Rider, error) {
+	SQL := riderSelect + ` AND rs.is_available AND rs.lat IS NOT NULL`
+	riders := make([]models.Rider, 0)
+	if err := database.RMS.Select(&riders, SQL); err != nil {
+		return nil, err
+	}
+	idle := make([]models.Rider, 0, len(riders))
+	for _, rider := range riders {
+		if rider.ActiveOrder != nil {
+			continue
+		}
+		distance := utils.DistanceKm(lat, lng, *rider.Lat, *rider.Lng)
+		rider.Distance = &distance
+		idle = append(idle, rider)
+	}
+	sort.Slice(idle, func(i, j int) bool {
+		return *idle[i].Distance < *idle[j].Distance
+	})
+	return idle, nil
+}
+
+// AssignNearestRider gives a delivery order without a rider to the nearest idle rider. Riders are locked one at a
+// time nearest first, a rider locked by a concurrent assignment is skipped for the next one so two orders never get
+// the same rider and neither waits on the other. It returns ErrNoRiderFree when no rider is free and "" when the order
+// has a rider already or can no longer get one.
+func AssignNearestRider(db sqlx.Ext, orderID string) (string, error) {
+	// language=SQL
+	SQL := `SELECT r.lat, r.lng
+			FROM orders o
+			JOIN restaurants r ON r.id = o.restaurant_id
+			WHERE o.id = $1 AND o.rider_id IS NULL AND o.order_type = 'delivery'`
+	var restaurant struct {
+		Lat float64 `db:"lat"`
+		Lng float64 `db:"lng"`
+	}
+	if err := sqlx.Get(db, &restaurant, SQL, orderID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+	// language=SQL
+	SQL = `SELECT rs.user_id, rs.lat, rs.lng
+			FROM rider_status rs
+			JOIN user_roles ur ON ur.user_id = rs.user_id AND ur.role_name = 'rider' AND ur.archived_at IS NULL
+			WHERE rs.is_available AND rs.lat IS NOT NULL
+				AND NOT EXISTS (SELECT 1 FROM orders o WHERE o.rider_id = rs.user_id AND o.status IN ('accepted', 'preparing', 'ready', 'out-for-delivery'))`
+	riders := make([]struct {
+		UserID   string  `db:"user_id"`
+		Lat      float64 `db:"lat"`
+		Lng      float64 `db:"lng"`
+		distance float64
+	}, 0)
+	if err := sqlx.Select(db, &riders, SQL); err != nil {
+		return "", err
+	}
+	for i := range riders {
+		riders[i].distance = utils.DistanceKm(restaurant.Lat, restaurant.Lng, riders[i].Lat, riders[i].Lng)
+	}
+	sort.Slice(riders, func(i, j int) bool {
+		return riders[i].distance < riders[j].distance
+	})
+	// the rider is checked again once locked, a concurrent assignment may have given them an order meanwhile
+	// language=SQL
+	SQL = `SELECT rs.user_id
+			FROM rider_status rs
+			WHERE rs.user_id = $1 AND rs.is_available
+				AND NOT EXISTS (SELECT 1 FROM orders o WHERE o.rider_id = rs.user_id AND o.status IN ('accepted', 'preparing', 'ready', 'out-for-delivery'))
+			FOR UPDATE SKIP LOCKED`
+	for _, rider := range riders {
+		var riderID string
+		if err := sqlx.Get(db, &riderID, SQL, rider.UserID); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				continue
+			}
+			return "", err
+		}
+		assigned, err := AssignOrderRider(db, orderID, riderID)
+		if err != nil || !assigned {
+			return "", err
+		}
+		return riderID, nil
+	}
+	return "", ErrNoRiderFree
+}
+
+// AssignOrderRider hands a delivery order to the rider, it is only possible until the rider picked the order up
+func AssignOrderRider(db sqlx.Ext, orderID, riderID string) (bool, error) {
+	// language=SQL
+	SQL := `UPDATE orders SET rider_id = $1, rider_assigned_at = NOW(), updated_at = NOW()
+			WHERE id = $2 AND order_type = 'delivery' AND status IN ('accepted', 'preparing', 'ready')`
+	result, err := db.Exec(SQL, riderID, orderID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// GetUnassignedReadyOrders lists the ready delivery orders still waiting for a rider
+func GetUnassignedReadyOrders() ([]models.Order, error) {
+	// language=SQL
+	SQL := `SELECT
+				o.id,
+				COALESCE(o.user_id::TEXT, '') AS user_id,
+				o.restaurant_id,
+				o.status
+			FROM orders o
+			WHERE o.status = 'ready' AND o.rider_id IS NULL AND o.order_type = 'delivery'
+			ORDER BY o.updated_at`
+	orders := make([]models.Order, 0)
+	err := database.RMS.Select(&orders, SQL)
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// GetRiderOrders returns the orders the rider still has to deliver with their items
+func GetRiderOrders(riderID string) ([]models.Order, error) {
+	// language=SQL
+	SQL := `SELECT
+				o.id,
+				COALESCE(o.user_id::TEXT, '') AS user_id,
+				o.restaurant_id,
+				COALESCE(o.address_id::TEXT, '') AS address_id,
+				o.status,
+				o.order_type,
+				o.dine_in_session_id,
+				o.rider_id,
+				o.estimated_delivery_at,
+				o.sub_total,
+				o.discount,
+				o.coupon_id,
+				o.coupon_discount,
+				o.points_redeemed,
+				o.packaging_charge,
+				o.delivery_fee,
+				o.tax,
+				o.tax_included,
+				o.rounding,
+				o.price_breakdown,
+				o.total,
+				o.scheduled_for,
+				o.release_at,
+				o.delivered_at,
+				o.created_at,
+				o.updated_at
+			FROM orders o
+			WHERE o.rider_id = $1 AND o.status IN ('accepted', 'preparing', 'ready', 'out-for-delivery')
+			ORDER BY o.rider_assigned_at`
+	orders := make([]models.Order, 0)
+	if err := database.RMS.Select(&orders, SQL, riderID); err != nil {
+		return nil, err
+	}
+	return attachOrderItems(orders)
+}
+
+func GetRiderPosition(riderID string) (*models.RiderPosition, error) {
+	// language=SQL
+	SQL := `SELECT
+				u.id,
+				u.name,
+				rs.lat,
+				rs.lng,
+				rs.located_at
+			FROM users u
+			LEFT JOIN rider_status rs ON rs.user_id = u.id
+			WHERE u.id = $1`
+	var position models.RiderPosition
+	err := database.RMS.Get(&position, SQL, riderID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &position, nil
+}
@@ -0,0 +1,133 @@
+package dbHelper
+
+import (
+	"database/sql"
+	"errors"
+	"rms/database"
+	"rms/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// GetRestaurantSchedule returns the scheduling settings of a restaurant, lock serialises bookings of its slots
+func GetRestaurantSchedule(db sqlx.Ext, restaurantID string, lock bool) (*models.RestaurantSchedule, error) {
+	// language=SQL
+	SQL := `SELECT
+				r.timezone,
+				r.slot_minutes,
+				r.slot_capacity,
+				r.lead_minutes,
+				r.reservation_minutes
+			FROM restaurants r
+			WHERE r.archived_at IS NULL AND r.id = $1`
+	if lock {
+		SQL += ` FOR UPDATE`
+	}
+	var schedule models.RestaurantSchedule
+	err := sqlx.Get(db, &schedule, SQL, restaurantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// GetRestaurantTimezone returns the timezone of a restaurant, archived ones included, "" when it does not exist
+func GetRestaurantTimezone(db sqlx.Ext, restaurantID string) (string, error) {
+	// language=SQL
+	SQL := `SELECT timezone FROM restaurants WHERE id = $1`
+	var timezone string
+	err := sqlx.Get(db, &timezone, SQL, restaurantID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		return "", err
+	}
+	return timezone, nil
+}
+
+func GetRestaurantHours(db sqlx.Ext, restaurantID string) ([]models.RestaurantHours, error) {
+	// language=SQL
+	SQL := `SELECT
+				rh.day_of_week,
+				TO_CHAR(rh.opens_at, 'HH24:MI') AS opens_at,
+				TO_CHAR(rh.closes_at, 'HH24:MI') AS closes_at
+			FROM restaurant_hours rh
+			WHERE rh.restaurant_id = $1
+			ORDER BY rh.day_of_week, rh.opens_at`
+	hours := make([]models.RestaurantHours, 0)
+	err := sqlx.Select(db, &hours, SQL, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	return hours, nil
+}
+
+func UpdateRestaurantSchedule(db sqlx.Ext, restaurantID string, schedule *models.RestaurantSchedule) error {
+	// language=SQL
+	SQL := `UPDATE restaurants SET timezone = $1, slot_minutes = $2, slot_capacity = $3, lead_minutes = $4, reservation_minutes = $5 WHERE id = $6`
+	_, err := db.Exec(SQL, schedule.Timezone, schedule.SlotMinutes, schedule.SlotCapacity, schedule.LeadMinutes, schedule.ReservationMinutes, restaurantID)
+	return err
+}
+
+// ReplaceRestaurantHours swaps the weekly opening hours of a restaurant for the given ones
+func ReplaceRestaurantHours(db sqlx.Ext, restaurantID string, hours []models.RestaurantHours) error {
+	// language=SQL
+	SQL := `DELETE FROM restaurant_hours WHERE restaurant_id = $1`
+	if _, err := db.Exec(SQL, restaurantID); err != nil {
+		return err
+	}
+	if len(hours) == 0 {
+		return nil
+	}
+	// language=SQL
+	SQL = `INSERT INTO restaurant_hours(restaurant_id, day_of_week, opens_at, closes_at) VALUES %s`
+	arguments := make([]interface{}, 0, len(hours)*4)
+	for _, window := range hours {
+		arguments = append(arguments, restaurantID, window.DayOfWeek, window.OpensAt, window.ClosesAt)
+	}
+	_, err := db.Exec(database.SetupBindVars(SQL, "(?, ?, ?, ?)", len(hours)), arguments...)
+	return err
+}
+
+// GetSlotOrderCounts counts the live scheduled orders of a restaurant per slot start between from and to
+func GetSlotOrderCounts(db sqlx.Ext, restaurantID string, from, to time.Time) (map[int64]int64, error) {
+	// language=SQL
+	SQL := `SELECT
+				o.scheduled_for,
+				COUNT(o.id) AS orders
+			FROM orders o
+			WHERE o.restaurant_id = $1 AND o.scheduled_for >= $2 AND o.scheduled_for < $3 AND o.status <> 'cancelled'
+			GROUP BY o.scheduled_for`
+	slots := make([]struct {
+		ScheduledFor time.Time `db:"scheduled_for"`
+		Orders       int64     `db:"orders"`
+	}, 0)
+	if err := sqlx.Select(db, &slots, SQL, restaurantID, from, to); err != nil {
+		return nil, err
+	}
+	counts := make(map[int64]int64, len(slots))
+	for _, slot := range slots {
+		counts[slot.ScheduledFor.Unix()] = slot.Orders
+	}
+	return counts, nil
+}
+
+// ReleaseScheduledOrders moves the scheduled orders whose release time passed to the restaurant queue
+func ReleaseScheduledOrders(db sqlx.Ext) ([]models.Order, error) {
+	// language=SQL
+	SQL := `UPDATE orders
+			SET status = 'placed', updated_at = NOW()
+			WHERE status = 'scheduled' AND release_at <= NOW()
+			RETURNING id, user_id, restaurant_id, status`
+	orders := make([]models.Order, 0)
+	err := sqlx.Select(db, &orders, SQL)
+	if err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
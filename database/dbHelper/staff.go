@@ -0,0 +1,81 @@
+package dbHelper
+
+import (
+	"database/sql"
+	"errors"
+	"rms/database"
+	"rms/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+func AddRestaurantStaff(db sqlx.Ext, restaurantID, userID, createdBy string, role models.Role) error {
+	// language=SQL
+	SQL := `INSERT INTO restaurant_staff(restaurant_id, user_id, role_name, created_by) VALUES ($1, $2, $3, $4)`
+	_, err := db.Exec(SQL, restaurantID, userID, role, createdBy)
+	return err
+}
+
+func IsRestaurantStaff(restaurantID, userID string, role models.Role) (bool, error) {
+	// language=SQL
+	SQL := `SELECT COUNT(*) > 0
+			FROM restaurant_staff rs
+			JOIN restaurants r ON r.id = rs.restaurant_id
+			WHERE rs.archived_at IS NULL AND r.archived_at IS NULL AND rs.restaurant_id = $1 AND rs.user_id = $2 AND rs.role_name = $3`
+	var isStaff bool
+	err := database.RMS.Get(&isStaff, SQL, restaurantID, userID, role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return isStaff, nil
+}
+
+// IsUserInAnyRestaurantStaff reports whether the user still works in the role at some restaurant
+func IsUserInAnyRestaurantStaff(db sqlx.Ext, userID string, role models.Role) (bool, error) {
+	// language=SQL
+	SQL := `SELECT COUNT(*) > 0 FROM restaurant_staff WHERE archived_at IS NULL AND user_id = $1 AND role_name = $2`
+	var isStaff bool
+	err := sqlx.Get(db, &isStaff, SQL, userID, role)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	return isStaff, nil
+}
+
+func GetRestaurantStaff(restaurantID string, role models.Role) ([]models.User, error) {
+	// language=SQL
+	SQL := `SELECT
+				u.id,
+				u.name,
+				u.email,
+				u.created_at,
+				rs.role_name AS user_current_role
+			FROM restaurant_staff rs
+			JOIN users u ON u.id = rs.user_id
+			WHERE rs.archived_at IS NULL AND u.archived_at IS NULL AND rs.restaurant_id = $1 AND rs.role_name = $2
+			ORDER BY u.name`
+	staff := make([]models.User, 0)
+	err := database.RMS.Select(&staff, SQL, restaurantID, role)
+	if err != nil {
+		return nil, err
+	}
+	return staff, nil
+}
+
+func RemoveRestaurantStaff(db sqlx.Ext, restaurantID, userID string, role models.Role) (bool, error) {
+	// language=SQL
+	SQL := `UPDATE restaurant_staff SET archived_at = NOW()
+			WHERE archived_at IS NULL AND restaurant_id = $1 AND user_id = $2 AND role_name = $3`
+	result, err := db.Exec(SQL, restaurantID, userID, role)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
@@ -22,6 +22,88 @@ func CreateUser(db sqlx.Ext, name, email, password string) (string, error) {
 	return userID, nil
 }
 
+func CreateUnverifiedUser(db sqlx.Ext, name, email, password string) (string, error) {
+	// language=SQL
+	SQL := `INSERT INTO users(name, email, password, email_verified_at) VALUES ($1, TRIM(LOWER($2)), $3, NULL) RETURNING id`
+	var userID string
+	if err := db.QueryRowx(SQL, name, email, password).Scan(&userID); err != nil {
+		return "", err
+	}
+	return userID, nil
+}
+
+func CreateEmailVerification(db sqlx.Ext, userID, token string, expiresAt time.Time) error {
+	// language=SQL
+	SQL := `INSERT INTO email_verifications(user_id, token, expires_at) VALUES ($1, $2, $3)`
+	_, err := db.Exec(SQL, userID, token, expiresAt)
+	return err
+}
+
+// GetUnverifiedUserByEmail finds the self signed up account of the email that was never verified
+func GetUnverifiedUserByEmail(email string) (*models.User, error) {
+	// language=SQL
+	SQL := `SELECT id, name, email FROM users WHERE email = TRIM(LOWER($1)) AND archived_at IS NULL AND email_verified_at IS NULL`
+	var user models.User
+	err := database.RMS.Get(&user, SQL, email)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateUnverifiedUser takes the name and password of a new sign up for an account nobody verified, the owner of the
+// email is whoever verifies it. It tells whether the account was still unverified
+func UpdateUnverifiedUser(db sqlx.Ext, userID, name, password string) (bool, error) {
+	// language=SQL
+	SQL := `UPDATE users SET name = $1, password = $2 WHERE id = $3 AND archived_at IS NULL AND email_verified_at IS NULL`
+	result, err := db.Exec(SQL, name, password, userID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	return rows > 0, err
+}
+
+// ExpireEmailVerifications ends the unused tokens of the user so only a newly sent one works
+func ExpireEmailVerifications(db sqlx.Ext, userID string) error {
+	// language=SQL
+	SQL := `UPDATE email_verifications SET expires_at = NOW() WHERE user_id = $1 AND verified_at IS NULL AND expires_at > NOW()`
+	_, err := db.Exec(SQL, userID)
+	return err
+}
+
+// VerifyEmail marks the user owning an unused and unexpired token as verified, returns false if no such token exists
+func VerifyEmail(db sqlx.Ext, token string) (bool, error) {
+	// language=SQL
+	SQL := `UPDATE email_verifications
+		SET verified_at = NOW()
+		WHERE token = $1 AND verified_at IS NULL AND expires_at > NOW()
+		RETURNING user_id`
+	var userID string
+	err := db.QueryRowx(SQL, token).Scan(&userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		return false, err
+	}
+	// language=SQL
+	SQL = `UPDATE users SET email_verified_at = NOW() WHERE id = $1 AND archived_at IS NULL`
+	_, err = db.Exec(SQL, userID)
+	return err == nil, err
+}
+
+func GetUserPasswordByEmail(email string) (string, error) {
+	// language=SQL
+	SQL := `SELECT password FROM users WHERE email = TRIM(LOWER($1)) AND archived_at IS NULL`
+	var password string
+	err := database.RMS.Get(&password, SQL, email)
+	return password, err
+}
+
 func CreateUserRole(db sqlx.Ext, userID, createdBy string, role models.Role) error {
 	// language=SQL
 	SQL := `INSERT INTO user_roles(user_id, created_by, role_name) VALUES ($1, $2, $3)`
@@ -164,6 +246,7 @@ func GetUserRoleIDByPassword(email, password string, role models.Role) (string,
 			WHERE
 				u.archived_at IS NULL
 				AND ur.archived_at IS NULL
+				AND u.email_verified_at IS NOT NULL
 				AND u.email = TRIM(LOWER($1))
 				AND ur.role_name = $2`
 	var user models.User
@@ -0,0 +1,289 @@
+package dbHelper
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"rms/database"
+	"rms/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+func CreateWebhook(restaurantID, createdBy, url, secret string, events []string) (*models.Webhook, error) {
+	// language=SQL
+	SQL := `INSERT INTO webhooks(restaurant_id, url, secret, events, created_by) VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, restaurant_id, url, secret, events, created_by, created_at`
+	var webhook models.Webhook
+	err := database.RMS.Get(&webhook, SQL, restaurantID, url, secret, pq.StringArray(events), createdBy)
+	if err != nil {
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func GetWebhooks(restaurantID string) ([]models.Webhook, error) {
+	// language=SQL
+	SQL := `SELECT
+				w.id,
+				w.restaurant_id,
+				w.url,
+				w.secret,
+				w.events,
+				w.created_by,
+				w.created_at
+			FROM webhooks w
+			WHERE w.archived_at IS NULL AND w.restaurant_id = $1
+			ORDER BY w.created_at`
+	webhooks := make([]models.Webhook, 0)
+	err := database.RMS.Select(&webhooks, SQL, restaurantID)
+	if err != nil {
+		return nil, err
+	}
+	return webhooks, nil
+}
+
+func GetWebhookByID(restaurantID, webhookID string) (*models.Webhook, error) {
+	// language=SQL
+	SQL := `SELECT
+				w.id,
+				w.restaurant_id,
+				w.url,
+				w.secret,
+				w.events,
+				w.created_by,
+				w.created_at
+			FROM webhooks w
+			WHERE w.archived_at IS NULL AND w.restaurant_id = $1 AND w.id = $2`
+	var webhook models.Webhook
+	err := database.RMS.Get(&webhook, SQL, restaurantID, webhookID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &webhook, nil
+}
+
+func ArchiveWebhook(restaurantID, webhookID string) error {
+	// language=SQL
+	SQL := `UPDATE webhooks SET archived_at = NOW() WHERE id = $1 AND restaurant_id = $2 AND archived_at IS NULL`
+	_, err := database.RMS.Exec(SQL, webhookID, restaurantID)
+	return err
+}
+
+// EnqueueWebhookEvent writes one outbox row per webhook of the restaurant subscribed to the event, call it in the
+// transaction of the change so the event is sent if and only if the change commits
+func EnqueueWebhookEvent(db sqlx.Ext, restaurantID, eventType string, data interface{}) error {
+	payload, err := json.Marshal(models.WebhookPayload{
+		Type:      eventType,
+		CreatedAt: time.Now(),
+		Data:      data,
+	})
+	if err != nil {
+		return err
+	}
+	// language=SQL
+	SQL := `INSERT INTO webhook_outbox(webhook_id, event_type, payload)
+			SELECT w.id, $2, $3
+			FROM webhooks w
+			WHERE w.archived_at IS NULL AND w.restaurant_id = $1 AND (CARDINALITY(w.events) = 0 OR $2 = ANY(w.events))`
+	_, err = db.Exec(SQL, restaurantID, eventType, string(payload))
+	return err
+}
+
+// GetWebhookDish reads the dish as dish.updated sends it, removed dishes included
+func GetWebhookDish(db sqlx.Ext, dishID string) (*models.WebhookDish, error) {
+	// language=SQL
+	SQL := `SELECT 
+       			d.id,
+				d.restaurants_id AS restaurant_id,
+       			d.name,
+       			d.description,
+				d.quantity,
+				d.price,
+				d.discount,
+				d.station,
+				d.tax_category,
+				d.packaging_charge,
+				r.currency,
+				d.available OR COALESCE(d.unavailable_until <= NOW(), FALSE) AS available,
+				CASE WHEN NOT d.available AND d.unavailable_until > NOW() THEN d.unavailable_until END AS unavailable_until,
+				d.quantity <= 0 AS sold_out,
+				d.photo,
+				d.tags,
+				d.allergens,
+				d.spice_level,
+				d.nutrition,
+				dish_served_at(d.id, NOW()) AS served,
+				d.avg_rating,
+				d.rating_count,
+       			d.created_at,
+       			d.created_by,
+				d.archived_at IS NOT NULL AS removed
+			FROM dishes d
+				JOIN restaurants r ON r.id = d.restaurants_id
+			WHERE d.id = $1`
+	var dish models.WebhookDish
+	err := sqlx.Get(db, &dish, SQL, dishID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &dish, nil
+}
+
+// ClaimDueWebhookDeliveries leases the pending deliveries that are due so no other instance sends them meanwhile
+func ClaimDueWebhookDeliveries(limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	// language=SQL
+	SQL := `WITH due AS (
+				SELECT id FROM webhook_outbox
+				WHERE status = 'pending' AND next_attempt_at <= NOW()
+				ORDER BY next_attempt_at
+				LIMIT $1
+				FOR UPDATE SKIP LOCKED
+			)
+			UPDATE webhook_outbox o
+			SET next_attempt_at = $2, updated_at = NOW()
+			FROM due, webhooks w
+			WHERE o.id = due.id AND w.id = o.webhook_id
+			RETURNING o.id, o.webhook_id, o.event_type, o.payload, o.status, o.attempts, o.next_attempt_at, o.last_error,
+				o.delivered_at, o.created_at, w.url, w.secret`
+	deliveries := make([]models.WebhookDelivery, 0)
+	err := database.RMS.Select(&deliveries, SQL, limit, time.Now().Add(lease))
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+func CreateWebhookAttempt(db sqlx.Ext, deliveryID string, statusCode *int64, attemptErr *string, duration time.Duration) error {
+	// language=SQL
+	SQL := `INSERT INTO webhook_delivery_attempts(delivery_id, status_code, error, duration_ms) VALUES ($1, $2, $3, $4)`
+	_, err := db.Exec(SQL, deliveryID, statusCode, attemptErr, duration.Milliseconds())
+	return err
+}
+
+func MarkWebhookDelivered(db sqlx.Ext, deliveryID string) error {
+	// language=SQL
+	SQL := `UPDATE webhook_outbox
+			SET status = 'delivered', attempts = attempts + 1, last_error = NULL, delivered_at = NOW(), updated_at = NOW()
+			WHERE id = $1`
+	_, err := db.Exec(SQL, deliveryID)
+	return err
+}
+
+// MarkWebhookFailed schedules the next attempt of a delivery, a nil nextAttemptAt moves it to the dead letters
+func MarkWebhookFailed(db sqlx.Ext, deliveryID, lastError string, nextAttemptAt *time.Time) error {
+	// language=SQL
+	SQL := `UPDATE webhook_outbox
+			SET status = CASE WHEN $3::TIMESTAMPTZ IS NULL THEN 'dead'::webhook_delivery_status ELSE status END,
+				attempts = attempts + 1,
+				last_error = $2,
+				next_attempt_at = COALESCE($3, next_attempt_at),
+				updated_at = NOW()
+			WHERE id = $1`
+	_, err := db.Exec(SQL, deliveryID, lastError, nextAttemptAt)
+	return err
+}
+
+// RedeliverWebhook queues a delivery again with a fresh set of retries
+func RedeliverWebhook(webhookID, deliveryID string) (bool, error) {
+	// language=SQL
+	SQL := `UPDATE webhook_outbox
+			SET status = 'pending', attempts = 0, next_attempt_at = NOW(), delivered_at = NULL, updated_at = NOW()
+			WHERE id = $1 AND webhook_id = $2`
+	result, err := database.RMS.Exec(SQL, deliveryID, webhookID)
+	if err != nil {
+		return false, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return rows > 0, nil
+}
+
+func GetWebhookDeliveriesCount(webhookID, status string) (int64, error) {
+	// language=SQL
+	SQL := `SELECT COUNT(o.id)
+			FROM webhook_outbox o
+			WHERE o.webhook_id = $1 AND ($2 = '' OR o.status::text = $2)`
+	var count int64
+	err := database.RMS.Get(&count, SQL, webhookID, status)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	return count, nil
+}
+
+func GetWebhookDeliveries(webhookID, status string, Filters models.Filters) ([]models.WebhookDelivery, error) {
+	// language=SQL
+	SQL := `SELECT
+				o.id,
+				o.webhook_id,
+				o.event_type,
+				o.payload,
+				o.status,
+				o.attempts,
+				o.next_attempt_at,
+				o.last_error,
+				o.delivered_at,
+				o.created_at
+			FROM webhook_outbox o
+			WHERE o.webhook_id = $1 AND ($2 = '' OR o.status::text = $2)
+			ORDER BY o.created_at DESC
+			LIMIT $3
+			OFFSET $4`
+	deliveries := make([]models.WebhookDelivery, 0)
+	err := database.RMS.Select(&deliveries, SQL, webhookID, status, Filters.PageSize, Filters.PageSize*Filters.PageNumber)
+	if err != nil {
+		return nil, err
+	}
+	return deliveries, attachWebhookAttempts(deliveries)
+}
+
+// attachWebhookAttempts fills the attempt log of every delivery with one query
+func attachWebhookAttempts(deliveries []models.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	deliveryIDs := make([]string, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		deliveryIDs = append(deliveryIDs, delivery.ID)
+	}
+	// language=SQL
+	SQL := `SELECT
+				a.id,
+				a.delivery_id,
+				a.status_code,
+				a.error,
+				a.duration_ms,
+				a.created_at
+			FROM webhook_delivery_attempts a
+			WHERE a.delivery_id = ANY($1)
+			ORDER BY a.created_at`
+	attempts := make([]models.WebhookDeliveryAttempt, 0)
+	if err := database.RMS.Select(&attempts, SQL, pq.Array(deliveryIDs)); err != nil {
+		return err
+	}
+	attemptsByDelivery := make(map[string][]models.WebhookDeliveryAttempt)
+	for _, attempt := range attempts {
+		attemptsByDelivery[attempt.DeliveryID] = append(attemptsByDelivery[attempt.DeliveryID], attempt)
+	}
+	for i := range deliveries {
+		deliveries[i].AttemptLog = attemptsByDelivery[deliveries[i].ID]
+		if deliveries[i].AttemptLog == nil {
+			deliveries[i].AttemptLog = make([]models.WebhookDeliveryAttempt, 0)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+BEGIN;
+
+-- Order Status Enum
+CREATE TYPE order_status AS ENUM (
+    'placed',
+    'accepted',
+    'preparing',
+    'ready',
+    'out-for-delivery',
+    'delivered',
+    'cancelled'
+);
+
+-- Orders Table
+CREATE TABLE IF NOT EXISTS orders (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) NOT NULL,
+    restaurant_id UUID REFERENCES restaurants(id) NOT NULL,
+    address_id UUID REFERENCES user_address(id) NOT NULL,
+    status order_status NOT NULL DEFAULT 'placed',
+    sub_total NUMERIC NOT NULL,
+    discount NUMERIC NOT NULL DEFAULT 0,
+    total NUMERIC NOT NULL,
+    delivered_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS orders_user ON orders(user_id, created_at);
+CREATE INDEX IF NOT EXISTS orders_restaurant ON orders(restaurant_id, status);
+
+-- Order Items Table, name and price are copied so later dish edits don't change past orders
+CREATE TABLE IF NOT EXISTS order_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID REFERENCES orders(id) NOT NULL,
+    dish_id UUID REFERENCES dishes(id) NOT NULL,
+    name TEXT NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    price NUMERIC NOT NULL,
+    discount INT NOT NULL DEFAULT 0,
+    total NUMERIC NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS unique_order_dish ON order_items(order_id, dish_id);
+
+COMMIT;
@@ -0,0 +1,44 @@
+BEGIN;
+
+-- Rating aggregates, maintained incrementally whenever a review is added or hidden
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS rating_count INT NOT NULL DEFAULT 0;
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS rating_total BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS avg_rating NUMERIC(3, 2) NOT NULL DEFAULT 0;
+ALTER TABLE dishes ADD COLUMN IF NOT EXISTS rating_count INT NOT NULL DEFAULT 0;
+ALTER TABLE dishes ADD COLUMN IF NOT EXISTS rating_total BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE dishes ADD COLUMN IF NOT EXISTS avg_rating NUMERIC(3, 2) NOT NULL DEFAULT 0;
+
+-- Restaurant Reviews Table
+CREATE TABLE IF NOT EXISTS reviews (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID REFERENCES orders(id) NOT NULL,
+    user_id UUID REFERENCES users(id) NOT NULL,
+    restaurant_id UUID REFERENCES restaurants(id) NOT NULL,
+    rating INT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    comment TEXT NOT NULL DEFAULT '',
+    reply TEXT,
+    replied_by UUID REFERENCES users(id),
+    replied_at TIMESTAMP WITH TIME ZONE,
+    flag_reason TEXT,
+    flagged_by UUID REFERENCES users(id),
+    flagged_at TIMESTAMP WITH TIME ZONE,
+    moderated_by UUID REFERENCES users(id),
+    moderated_at TIMESTAMP WITH TIME ZONE,
+    hidden_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS unique_order_review ON reviews(order_id);
+CREATE INDEX IF NOT EXISTS reviews_restaurant ON reviews(restaurant_id, created_at) WHERE hidden_at IS NULL;
+
+-- Dish Reviews Table
+CREATE TABLE IF NOT EXISTS dish_reviews (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    review_id UUID REFERENCES reviews(id) NOT NULL,
+    dish_id UUID REFERENCES dishes(id) NOT NULL,
+    rating INT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    comment TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS unique_dish_review ON dish_reviews(review_id, dish_id);
+
+COMMIT;
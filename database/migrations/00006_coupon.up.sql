@@ -0,0 +1,45 @@
+BEGIN;
+
+-- Coupon Discount Type Enum
+CREATE TYPE discount_type AS ENUM (
+    'percentage',
+    'flat'
+);
+
+-- Coupons Table, a coupon without restaurant is a platform coupon valid everywhere
+CREATE TABLE IF NOT EXISTS coupons (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code TEXT NOT NULL,
+    restaurant_id UUID REFERENCES restaurants(id),
+    discount_type discount_type NOT NULL,
+    value NUMERIC NOT NULL CHECK (value > 0),
+    min_order_value NUMERIC NOT NULL DEFAULT 0,
+    max_discount NUMERIC,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    ends_at TIMESTAMP WITH TIME ZONE,
+    usage_limit INT,
+    per_user_limit INT,
+    used_count INT NOT NULL DEFAULT 0,
+    first_order_only BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    archived_at TIMESTAMP WITH TIME ZONE,
+    CHECK (discount_type <> 'percentage' OR value <= 100)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS active_coupon ON coupons(UPPER(TRIM(code))) WHERE archived_at IS NULL;
+
+-- Coupon Redemptions Table
+CREATE TABLE IF NOT EXISTS coupon_redemptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    coupon_id UUID REFERENCES coupons(id) NOT NULL,
+    user_id UUID REFERENCES users(id) NOT NULL,
+    order_id UUID REFERENCES orders(id) NOT NULL,
+    discount NUMERIC NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS coupon_redemptions_user ON coupon_redemptions(coupon_id, user_id);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS coupon_id UUID REFERENCES coupons(id);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS coupon_discount NUMERIC NOT NULL DEFAULT 0;
+
+COMMIT;
@@ -0,0 +1,46 @@
+BEGIN;
+
+-- Loyalty Account Enum, every posting moves points between a customer and the platform
+CREATE TYPE loyalty_account AS ENUM (
+    'customer',
+    'platform'
+);
+
+-- Loyalty Entry Kind Enum
+CREATE TYPE loyalty_entry_kind AS ENUM (
+    'earn',
+    'redeem',
+    'refund',
+    'expire',
+    'adjust'
+);
+
+-- Loyalty Ledger Table, append only, the entries of one txn_id always sum to zero
+CREATE TABLE IF NOT EXISTS loyalty_ledger (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    txn_id UUID NOT NULL,
+    account loyalty_account NOT NULL,
+    user_id UUID REFERENCES users(id) NOT NULL,
+    kind loyalty_entry_kind NOT NULL,
+    points BIGINT NOT NULL CHECK (points <> 0),
+    order_id UUID REFERENCES orders(id),
+    reason TEXT NOT NULL DEFAULT '',
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS loyalty_ledger_user ON loyalty_ledger(user_id, account, created_at);
+CREATE INDEX IF NOT EXISTS loyalty_ledger_txn ON loyalty_ledger(txn_id);
+
+CREATE OR REPLACE FUNCTION loyalty_ledger_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'loyalty_ledger is append only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER loyalty_ledger_no_change BEFORE UPDATE OR DELETE ON loyalty_ledger
+    FOR EACH ROW EXECUTE FUNCTION loyalty_ledger_append_only();
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS points_redeemed BIGINT NOT NULL DEFAULT 0;
+
+COMMIT;
@@ -0,0 +1,28 @@
+BEGIN;
+
+-- scheduled orders wait for their release time before they reach the restaurant queue as placed
+ALTER TYPE order_status ADD VALUE IF NOT EXISTS 'scheduled' BEFORE 'placed';
+
+-- Restaurant scheduling settings, hours are in the restaurant's timezone
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT 'UTC';
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS slot_minutes INT NOT NULL DEFAULT 30 CHECK (slot_minutes > 0);
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS slot_capacity INT NOT NULL DEFAULT 10 CHECK (slot_capacity >= 0);
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS lead_minutes INT NOT NULL DEFAULT 45 CHECK (lead_minutes >= 0);
+
+-- Restaurant Hours Table, one row per opening window of a weekday (0 is Sunday)
+CREATE TABLE IF NOT EXISTS restaurant_hours (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    restaurant_id UUID REFERENCES restaurants(id) NOT NULL,
+    day_of_week INT NOT NULL CHECK (day_of_week BETWEEN 0 AND 6),
+    opens_at TIME NOT NULL,
+    closes_at TIME NOT NULL CHECK (closes_at > opens_at),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS restaurant_hours_day ON restaurant_hours(restaurant_id, day_of_week);
+
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS scheduled_for TIMESTAMP WITH TIME ZONE;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS release_at TIMESTAMP WITH TIME ZONE;
+CREATE INDEX IF NOT EXISTS orders_release ON orders(release_at) WHERE release_at IS NOT NULL;
+CREATE INDEX IF NOT EXISTS orders_slot ON orders(restaurant_id, scheduled_for) WHERE scheduled_for IS NOT NULL;
+
+COMMIT;
@@ -0,0 +1,46 @@
+BEGIN;
+
+ALTER TYPE role_type ADD VALUE IF NOT EXISTS 'kitchen-staff';
+
+-- Restaurant Staff Table, links staff accounts to the restaurant they work at in a role
+CREATE TABLE IF NOT EXISTS restaurant_staff (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    restaurant_id UUID REFERENCES restaurants(id) NOT NULL,
+    user_id UUID REFERENCES users(id) NOT NULL,
+    role_name role_type NOT NULL,
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    archived_at TIMESTAMP WITH TIME ZONE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS unique_restaurant_staff ON restaurant_staff(restaurant_id, user_id, role_name) WHERE archived_at IS NULL;
+
+-- the kitchen station that prepares the dish, like grill or fryer
+ALTER TABLE dishes ADD COLUMN IF NOT EXISTS station TEXT NOT NULL DEFAULT 'main';
+
+-- Kitchen Tickets Table, one ticket per accepted order
+CREATE TABLE IF NOT EXISTS kitchen_tickets (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID REFERENCES orders(id) NOT NULL UNIQUE,
+    restaurant_id UUID REFERENCES restaurants(id) NOT NULL,
+    bumped_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS kitchen_tickets_open ON kitchen_tickets(restaurant_id, created_at) WHERE bumped_at IS NULL;
+
+-- Kitchen Ticket Items Table, the prep timer of an item runs from started_at until it is bumped
+CREATE TABLE IF NOT EXISTS kitchen_ticket_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    ticket_id UUID REFERENCES kitchen_tickets(id) NOT NULL,
+    dish_id UUID REFERENCES dishes(id) NOT NULL,
+    name TEXT NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    station TEXT NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    bumped_at TIMESTAMP WITH TIME ZONE,
+    recall_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS kitchen_ticket_items_ticket ON kitchen_ticket_items(ticket_id);
+CREATE INDEX IF NOT EXISTS kitchen_ticket_items_dish ON kitchen_ticket_items(dish_id, bumped_at);
+
+COMMIT;
@@ -0,0 +1,54 @@
+BEGIN;
+
+-- Restaurant Areas Table, like terrace or first floor
+CREATE TABLE IF NOT EXISTS restaurant_areas (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    restaurant_id UUID REFERENCES restaurants(id) NOT NULL,
+    name TEXT NOT NULL,
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    archived_at TIMESTAMP WITH TIME ZONE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS active_restaurant_area ON restaurant_areas(restaurant_id, TRIM(LOWER(name))) WHERE archived_at IS NULL;
+
+-- Restaurant Tables Table, qr_nonce is signed into the table QR code and changes when the code is regenerated
+CREATE TABLE IF NOT EXISTS restaurant_tables (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    restaurant_id UUID REFERENCES restaurants(id) NOT NULL,
+    area_id UUID REFERENCES restaurant_areas(id),
+    name TEXT NOT NULL,
+    seats INT NOT NULL CHECK (seats > 0),
+    qr_nonce TEXT NOT NULL,
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    archived_at TIMESTAMP WITH TIME ZONE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS active_restaurant_table ON restaurant_tables(restaurant_id, TRIM(LOWER(name))) WHERE archived_at IS NULL;
+
+-- Dine In Sessions Table, the open bill of a table, guests that scan the table QR code join it
+CREATE TABLE IF NOT EXISTS dine_in_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    restaurant_id UUID REFERENCES restaurants(id) NOT NULL,
+    table_id UUID REFERENCES restaurant_tables(id) NOT NULL,
+    token TEXT NOT NULL,
+    opened_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    closed_at TIMESTAMP WITH TIME ZONE,
+    closed_by UUID REFERENCES users(id)
+);
+CREATE UNIQUE INDEX IF NOT EXISTS open_table_session ON dine_in_sessions(table_id) WHERE closed_at IS NULL;
+CREATE UNIQUE INDEX IF NOT EXISTS dine_in_session_token ON dine_in_sessions(token);
+
+-- Order Type Enum
+CREATE TYPE order_type AS ENUM (
+    'delivery',
+    'dine-in'
+);
+
+-- dine in orders may come from guests without an account and are served at the table
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS order_type order_type NOT NULL DEFAULT 'delivery';
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS dine_in_session_id UUID REFERENCES dine_in_sessions(id);
+ALTER TABLE orders ALTER COLUMN user_id DROP NOT NULL;
+ALTER TABLE orders ALTER COLUMN address_id DROP NOT NULL;
+CREATE INDEX IF NOT EXISTS orders_dine_in_session ON orders(dine_in_session_id) WHERE dine_in_session_id IS NOT NULL;
+
+COMMIT;
@@ -0,0 +1,44 @@
+BEGIN;
+
+-- default length of a table booking, the restaurant's opening hours and slot_minutes decide when bookings start
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS reservation_minutes INT NOT NULL DEFAULT 90 CHECK (reservation_minutes > 0);
+
+-- Reservation Status Enum
+CREATE TYPE reservation_status AS ENUM (
+    'booked',
+    'cancelled',
+    'no-show'
+);
+
+-- Reservations Table, every booking holds one table from starts_at until ends_at
+CREATE TABLE IF NOT EXISTS reservations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    restaurant_id UUID REFERENCES restaurants(id) NOT NULL,
+    user_id UUID REFERENCES users(id) NOT NULL,
+    table_id UUID REFERENCES restaurant_tables(id) NOT NULL,
+    party_size INT NOT NULL CHECK (party_size > 0),
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL CHECK (ends_at > starts_at),
+    status reservation_status NOT NULL DEFAULT 'booked',
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS reservations_restaurant_time ON reservations(restaurant_id, starts_at);
+CREATE INDEX IF NOT EXISTS reservations_user ON reservations(user_id, starts_at);
+
+-- Reservation Blocks Table, time ranges where a table, or the whole restaurant when table_id is null, can't be booked
+CREATE TABLE IF NOT EXISTS reservation_blocks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    restaurant_id UUID REFERENCES restaurants(id) NOT NULL,
+    table_id UUID REFERENCES restaurant_tables(id),
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL CHECK (ends_at > starts_at),
+    reason TEXT NOT NULL DEFAULT '',
+    created_by UUID REFERENCES users(id),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    archived_at TIMESTAMP WITH TIME ZONE
+);
+CREATE INDEX IF NOT EXISTS reservation_blocks_restaurant_time ON reservation_blocks(restaurant_id, starts_at) WHERE archived_at IS NULL;
+
+COMMIT;
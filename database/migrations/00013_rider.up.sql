@@ -0,0 +1,21 @@
+BEGIN;
+
+ALTER TYPE role_type ADD VALUE IF NOT EXISTS 'rider';
+
+-- Rider Status Table, one row per rider with their availability and last known position
+CREATE TABLE IF NOT EXISTS rider_status (
+    user_id UUID PRIMARY KEY REFERENCES users(id),
+    is_available BOOLEAN NOT NULL DEFAULT FALSE,
+    lat DOUBLE PRECISION CHECK (lat BETWEEN -90 AND 90),
+    lng DOUBLE PRECISION CHECK (lng BETWEEN -180 AND 180),
+    located_at TIMESTAMP WITH TIME ZONE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- the rider delivering the order, assigned once the order is ready or picked by the restaurant
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS rider_id UUID REFERENCES users(id);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS rider_assigned_at TIMESTAMP WITH TIME ZONE;
+CREATE INDEX IF NOT EXISTS orders_rider ON orders(rider_id, status) WHERE rider_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS orders_unassigned ON orders(status) WHERE rider_id IS NULL AND order_type = 'delivery';
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+-- when the order is expected at the customer, recomputed every time the order changes status
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS estimated_delivery_at TIMESTAMP WITH TIME ZONE;
+
+COMMIT;
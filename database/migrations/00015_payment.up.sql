@@ -0,0 +1,42 @@
+BEGIN;
+
+-- Payment Status Enum, authorized money is held until the order is delivered and then captured
+CREATE TYPE payment_status AS ENUM (
+    'pending',
+    'authorized',
+    'captured',
+    'failed',
+    'voided',
+    'refunded'
+);
+
+-- Payments Table, one payment per order, idempotency_key makes a retried checkout return the first order
+CREATE TABLE IF NOT EXISTS payments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID REFERENCES orders(id) NOT NULL UNIQUE,
+    user_id UUID REFERENCES users(id) NOT NULL,
+    provider TEXT NOT NULL,
+    method TEXT NOT NULL DEFAULT '',
+    provider_ref TEXT,
+    idempotency_key TEXT,
+    amount BIGINT NOT NULL CHECK (amount >= 0),
+    status payment_status NOT NULL DEFAULT 'pending',
+    failure_reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+CREATE UNIQUE INDEX IF NOT EXISTS payment_idempotency ON payments(user_id, idempotency_key);
+CREATE UNIQUE INDEX IF NOT EXISTS payment_provider_ref ON payments(provider, provider_ref);
+
+-- Payment Attempts Table, every call to a provider and every webhook received for a payment
+CREATE TABLE IF NOT EXISTS payment_attempts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id UUID REFERENCES payments(id) NOT NULL,
+    action TEXT NOT NULL,
+    succeeded BOOLEAN NOT NULL,
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS payment_attempts_payment ON payment_attempts(payment_id, created_at);
+
+COMMIT;
@@ -0,0 +1,43 @@
+BEGIN;
+
+-- items taken off an accepted order keep their line with quantity 0
+ALTER TABLE order_items DROP CONSTRAINT IF EXISTS order_items_quantity_check;
+ALTER TABLE order_items ADD CONSTRAINT order_items_quantity_check CHECK (quantity >= 0);
+
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS refunded_amount BIGINT NOT NULL DEFAULT 0;
+
+-- Order Adjustment Kind Enum
+CREATE TYPE order_adjustment_kind AS ENUM (
+    'item',
+    'coupon',
+    'points',
+    'refund'
+);
+
+-- Order Adjustments Table, append only. The item, coupon and points lines of one adjustment_id sum to the change of
+-- the order total, its refund line holds the money given back, so the total at placing plus all lines but refunds
+-- is always the current total
+CREATE TABLE IF NOT EXISTS order_adjustments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    adjustment_id UUID NOT NULL,
+    order_id UUID REFERENCES orders(id) NOT NULL,
+    kind order_adjustment_kind NOT NULL,
+    order_item_id UUID REFERENCES order_items(id),
+    quantity INT NOT NULL DEFAULT 0,
+    amount BIGINT NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_by UUID REFERENCES users(id) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS order_adjustments_order ON order_adjustments(order_id, created_at);
+
+CREATE OR REPLACE FUNCTION order_adjustments_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'order_adjustments is append only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER order_adjustments_no_change BEFORE UPDATE OR DELETE ON order_adjustments
+    FOR EACH ROW EXECUTE FUNCTION order_adjustments_append_only();
+
+COMMIT;
@@ -0,0 +1,38 @@
+BEGIN;
+
+-- the tax category picks the rates of a dish, packaging is charged per unit on delivery orders
+ALTER TABLE dishes ADD COLUMN IF NOT EXISTS tax_category TEXT NOT NULL DEFAULT '';
+ALTER TABLE dishes ADD COLUMN IF NOT EXISTS packaging_charge BIGINT NOT NULL DEFAULT 0 CHECK (packaging_charge >= 0);
+
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS delivery_fee BIGINT NOT NULL DEFAULT 0 CHECK (delivery_fee >= 0);
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS free_delivery_above BIGINT NOT NULL DEFAULT 0 CHECK (free_delivery_above >= 0);
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS rounding_unit BIGINT NOT NULL DEFAULT 1 CHECK (rounding_unit > 0);
+
+-- Restaurant Tax Rates Table, rates without a category apply to the dishes whose category has no rates of its own,
+-- the delivery and packaging categories tax the charges
+CREATE TABLE IF NOT EXISTS restaurant_tax_rates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    restaurant_id UUID REFERENCES restaurants(id) NOT NULL,
+    category TEXT NOT NULL DEFAULT '',
+    name TEXT NOT NULL,
+    rate_bps INT NOT NULL CHECK (rate_bps BETWEEN 0 AND 10000),
+    inclusive BOOLEAN NOT NULL DEFAULT FALSE,
+    created_by UUID REFERENCES users(id) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    archived_at TIMESTAMP WITH TIME ZONE
+);
+CREATE UNIQUE INDEX IF NOT EXISTS active_restaurant_tax_rate ON restaurant_tax_rates(restaurant_id, category, LOWER(name)) WHERE archived_at IS NULL;
+
+-- orders keep the breakdown they were priced with for invoices
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS packaging_charge BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS delivery_fee BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS tax BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS tax_included BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS rounding BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS price_breakdown JSONB;
+
+ALTER TYPE order_adjustment_kind ADD VALUE IF NOT EXISTS 'tax';
+ALTER TYPE order_adjustment_kind ADD VALUE IF NOT EXISTS 'fee';
+ALTER TYPE order_adjustment_kind ADD VALUE IF NOT EXISTS 'rounding';
+
+COMMIT;
@@ -0,0 +1,8 @@
+BEGIN;
+
+-- every amount is a whole number of minor units in the currency of its restaurant
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS currency TEXT NOT NULL DEFAULT 'INR' CHECK (currency ~ '^[A-Z]{3}$');
+
+ALTER TABLE dishes ALTER COLUMN price TYPE BIGINT USING ROUND(price);
+
+COMMIT;
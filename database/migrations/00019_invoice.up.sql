@@ -0,0 +1,26 @@
+BEGIN;
+
+-- Invoice Sequences Table, the last number given out per restaurant and fiscal year. It is bumped in the transaction
+-- that writes the invoice so a rolled back invoice gives its number back and the numbers have no gaps
+CREATE TABLE IF NOT EXISTS invoice_sequences (
+    restaurant_id UUID REFERENCES restaurants(id) NOT NULL,
+    fiscal_year INT NOT NULL,
+    last_number BIGINT NOT NULL,
+    PRIMARY KEY (restaurant_id, fiscal_year)
+);
+
+-- Invoices Table, one per completed order. Details is the snapshot the documents are rendered from, so an invoice
+-- reads the same however often it is downloaded
+CREATE TABLE IF NOT EXISTS invoices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    order_id UUID REFERENCES orders(id) NOT NULL UNIQUE,
+    restaurant_id UUID REFERENCES restaurants(id) NOT NULL,
+    fiscal_year INT NOT NULL,
+    sequence BIGINT NOT NULL CHECK (sequence > 0),
+    number TEXT NOT NULL,
+    details JSONB NOT NULL,
+    issued_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (restaurant_id, fiscal_year, sequence)
+);
+
+COMMIT;
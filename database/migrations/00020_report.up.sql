@@ -0,0 +1,54 @@
+BEGIN;
+
+-- report_bucket is the 15 minute slot a time falls in, every time zone offset in use is a multiple of 15 minutes so
+-- the slots add up to whole local hours, days, weeks and months in any zone
+CREATE OR REPLACE FUNCTION report_bucket(at TIMESTAMP WITH TIME ZONE) RETURNS TIMESTAMP WITH TIME ZONE AS $$
+    SELECT to_timestamp(floor(extract(EPOCH FROM at) / 900) * 900)
+$$ LANGUAGE SQL IMMUTABLE;
+
+-- Report Order Buckets Table, the orders of a restaurant placed in a slot. Scheduled orders count once released,
+-- revenue is what the delivered ones were paid
+CREATE TABLE IF NOT EXISTS report_order_buckets (
+    restaurant_id UUID REFERENCES restaurants(id) NOT NULL,
+    bucket_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    orders BIGINT NOT NULL,
+    delivered BIGINT NOT NULL,
+    cancelled BIGINT NOT NULL,
+    revenue BIGINT NOT NULL,
+    PRIMARY KEY (restaurant_id, bucket_start)
+);
+CREATE INDEX IF NOT EXISTS report_order_buckets_start ON report_order_buckets(bucket_start);
+
+-- Report Dish Buckets Table, what the delivered orders of a slot sold per dish
+CREATE TABLE IF NOT EXISTS report_dish_buckets (
+    restaurant_id UUID REFERENCES restaurants(id) NOT NULL,
+    bucket_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    dish_id UUID REFERENCES dishes(id) NOT NULL,
+    name TEXT NOT NULL,
+    quantity BIGINT NOT NULL,
+    revenue BIGINT NOT NULL,
+    PRIMARY KEY (restaurant_id, bucket_start, dish_id)
+);
+CREATE INDEX IF NOT EXISTS report_dish_buckets_start ON report_dish_buckets(bucket_start);
+
+-- Report Customer Buckets Table, the customers that ordered in a slot and did not cancel
+CREATE TABLE IF NOT EXISTS report_customer_buckets (
+    restaurant_id UUID REFERENCES restaurants(id) NOT NULL,
+    bucket_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    user_id UUID REFERENCES users(id) NOT NULL,
+    orders BIGINT NOT NULL,
+    PRIMARY KEY (restaurant_id, bucket_start, user_id)
+);
+CREATE INDEX IF NOT EXISTS report_customer_buckets_start ON report_customer_buckets(bucket_start);
+CREATE INDEX IF NOT EXISTS report_customer_buckets_user ON report_customer_buckets(user_id, bucket_start);
+
+-- Report Refresh Table, a single row with how far the order changes have been folded into the buckets
+CREATE TABLE IF NOT EXISTS report_refresh (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    refreshed_until TIMESTAMP WITH TIME ZONE NOT NULL
+);
+INSERT INTO report_refresh(refreshed_until) VALUES ('1970-01-01 00:00:00+00') ON CONFLICT DO NOTHING;
+
+CREATE INDEX IF NOT EXISTS orders_updated_at ON orders(updated_at);
+
+COMMIT;
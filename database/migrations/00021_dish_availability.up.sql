@@ -0,0 +1,8 @@
+BEGIN;
+
+-- a dish switched off stays on the menu but cannot be ordered, until unavailable_until passes when it is set. Sold
+-- out is not stored, a dish is sold out while its quantity is zero
+ALTER TABLE dishes ADD COLUMN IF NOT EXISTS available BOOLEAN NOT NULL DEFAULT TRUE;
+ALTER TABLE dishes ADD COLUMN IF NOT EXISTS unavailable_until TIMESTAMP WITH TIME ZONE;
+
+COMMIT;
@@ -0,0 +1,51 @@
+BEGIN;
+
+-- Menus Table, a named set of dishes a restaurant serves at set times of the week, like breakfast or dinner
+CREATE TABLE IF NOT EXISTS menus (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    restaurant_id UUID REFERENCES restaurants(id) NOT NULL,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    archived_at TIMESTAMP WITH TIME ZONE
+);
+CREATE INDEX IF NOT EXISTS menus_restaurant ON menus(restaurant_id) WHERE archived_at IS NULL;
+
+-- Menu Hours Table, one row per serving window of a weekday (0 is Sunday) in the restaurant's timezone
+CREATE TABLE IF NOT EXISTS menu_hours (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    menu_id UUID REFERENCES menus(id) NOT NULL,
+    day_of_week INT NOT NULL CHECK (day_of_week BETWEEN 0 AND 6),
+    starts_at TIME NOT NULL,
+    ends_at TIME NOT NULL CHECK (ends_at > starts_at)
+);
+CREATE INDEX IF NOT EXISTS menu_hours_day ON menu_hours(menu_id, day_of_week);
+
+CREATE TABLE IF NOT EXISTS menu_dishes (
+    menu_id UUID REFERENCES menus(id) NOT NULL,
+    dish_id UUID REFERENCES dishes(id) NOT NULL,
+    PRIMARY KEY (menu_id, dish_id)
+);
+CREATE INDEX IF NOT EXISTS menu_dishes_dish ON menu_dishes(dish_id);
+
+-- dish_served_at tells whether a dish can be ordered at a time, a dish on no menu is served whenever the restaurant
+-- is, one on menus only during their windows
+CREATE OR REPLACE FUNCTION dish_served_at(dish UUID, at TIMESTAMP WITH TIME ZONE) RETURNS BOOLEAN AS $$
+    SELECT NOT EXISTS (
+        SELECT 1
+        FROM menu_dishes md
+        JOIN menus m ON m.id = md.menu_id
+        WHERE md.dish_id = dish AND m.archived_at IS NULL
+    ) OR EXISTS (
+        SELECT 1
+        FROM menu_dishes md
+        JOIN menus m ON m.id = md.menu_id
+        JOIN restaurants r ON r.id = m.restaurant_id
+        JOIN menu_hours mh ON mh.menu_id = m.id
+        WHERE md.dish_id = dish AND m.archived_at IS NULL
+          AND mh.day_of_week = EXTRACT(DOW FROM at AT TIME ZONE r.timezone)
+          AND (at AT TIME ZONE r.timezone)::TIME >= mh.starts_at
+          AND (at AT TIME ZONE r.timezone)::TIME < mh.ends_at
+    )
+$$ LANGUAGE SQL STABLE;
+
+COMMIT;
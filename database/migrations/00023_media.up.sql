@@ -0,0 +1,8 @@
+BEGIN;
+
+-- uploaded images, the URLs of their variants with the keys they are stored under
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS logo JSONB;
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS banner JSONB;
+ALTER TABLE dishes ADD COLUMN IF NOT EXISTS photo JSONB;
+
+COMMIT;
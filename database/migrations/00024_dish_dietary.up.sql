@@ -0,0 +1,12 @@
+BEGIN;
+
+-- dietary tags and allergens come from the vocabularies kept in the code, spice level runs from 0 (not spicy) to 4
+-- and is unknown when NULL. Nutrition holds the calories and macros of a serving
+ALTER TABLE dishes ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE dishes ADD COLUMN IF NOT EXISTS allergens TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE dishes ADD COLUMN IF NOT EXISTS spice_level SMALLINT CHECK (spice_level BETWEEN 0 AND 4);
+ALTER TABLE dishes ADD COLUMN IF NOT EXISTS nutrition JSONB;
+CREATE INDEX IF NOT EXISTS dishes_tags ON dishes USING GIN (tags);
+CREATE INDEX IF NOT EXISTS dishes_allergens ON dishes USING GIN (allergens);
+
+COMMIT;
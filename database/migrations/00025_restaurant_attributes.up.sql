@@ -0,0 +1,47 @@
+BEGIN;
+
+-- Cuisines Table, the taxonomy restaurants are tagged and filtered with, admins add to it
+CREATE TABLE IF NOT EXISTS cuisines (
+    slug TEXT PRIMARY KEY CHECK (slug ~ '^[a-z0-9]+(-[a-z0-9]+)*$'),
+    name TEXT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+INSERT INTO cuisines(slug, name) VALUES
+    ('north-indian', 'North Indian'),
+    ('south-indian', 'South Indian'),
+    ('mughlai', 'Mughlai'),
+    ('biryani', 'Biryani'),
+    ('street-food', 'Street Food'),
+    ('chinese', 'Chinese'),
+    ('thai', 'Thai'),
+    ('japanese', 'Japanese'),
+    ('italian', 'Italian'),
+    ('continental', 'Continental'),
+    ('mexican', 'Mexican'),
+    ('fast-food', 'Fast Food'),
+    ('pizza', 'Pizza'),
+    ('burger', 'Burger'),
+    ('seafood', 'Seafood'),
+    ('healthy', 'Healthy'),
+    ('bakery', 'Bakery'),
+    ('desserts', 'Desserts'),
+    ('cafe', 'Cafe'),
+    ('beverages', 'Beverages')
+ON CONFLICT (slug) DO NOTHING;
+
+CREATE TABLE IF NOT EXISTS restaurant_cuisines (
+    restaurant_id UUID REFERENCES restaurants(id) NOT NULL,
+    cuisine TEXT REFERENCES cuisines(slug) NOT NULL,
+    PRIMARY KEY (restaurant_id, cuisine)
+);
+CREATE INDEX IF NOT EXISTS restaurant_cuisines_cuisine ON restaurant_cuisines(cuisine);
+
+-- price range runs from 1 (cheapest) to 4, cost for two is in minor units of the restaurant's currency, both are
+-- unknown when NULL. Features come from the vocabulary kept in the code
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS price_range SMALLINT CHECK (price_range BETWEEN 1 AND 4);
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS cost_for_two BIGINT CHECK (cost_for_two >= 0);
+ALTER TABLE restaurants ADD COLUMN IF NOT EXISTS features TEXT[] NOT NULL DEFAULT '{}';
+CREATE INDEX IF NOT EXISTS restaurants_features ON restaurants USING GIN (features);
+
+COMMIT;
@@ -0,0 +1,18 @@
+BEGIN;
+
+-- Favourite Restaurants and Dishes Tables, what each customer keeps coming back to
+CREATE TABLE IF NOT EXISTS favourite_restaurants (
+    user_id UUID REFERENCES users(id) NOT NULL,
+    restaurant_id UUID REFERENCES restaurants(id) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, restaurant_id)
+);
+
+CREATE TABLE IF NOT EXISTS favourite_dishes (
+    user_id UUID REFERENCES users(id) NOT NULL,
+    dish_id UUID REFERENCES dishes(id) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, dish_id)
+);
+
+COMMIT;
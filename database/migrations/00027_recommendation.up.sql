@@ -0,0 +1,48 @@
+BEGIN;
+
+-- distance_km is the haversine distance between two points in kilometers
+CREATE OR REPLACE FUNCTION distance_km(lat1 DOUBLE PRECISION, lng1 DOUBLE PRECISION, lat2 DOUBLE PRECISION, lng2 DOUBLE PRECISION)
+    RETURNS DOUBLE PRECISION AS $$
+    SELECT 6371 * 2 * ASIN(SQRT(
+        POWER(SIN(RADIANS(lat2 - lat1) / 2), 2) +
+        COS(RADIANS(lat1)) * COS(RADIANS(lat2)) * POWER(SIN(RADIANS(lng2 - lng1) / 2), 2)
+    ))
+$$ LANGUAGE SQL IMMUTABLE;
+
+-- Dish Pairs Table, how many recent delivered orders had both dishes, rebuilt by every recommendation run
+CREATE TABLE IF NOT EXISTS dish_pairs (
+    dish_id UUID REFERENCES dishes(id) NOT NULL,
+    paired_dish_id UUID REFERENCES dishes(id) NOT NULL,
+    orders BIGINT NOT NULL,
+    PRIMARY KEY (dish_id, paired_dish_id)
+);
+
+-- Dish and Restaurant Recommendations Tables, the best scored picks of every customer with why they were picked
+CREATE TABLE IF NOT EXISTS dish_recommendations (
+    user_id UUID REFERENCES users(id) NOT NULL,
+    dish_id UUID REFERENCES dishes(id) NOT NULL,
+    score DOUBLE PRECISION NOT NULL,
+    reasons TEXT[] NOT NULL,
+    computed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, dish_id)
+);
+CREATE INDEX IF NOT EXISTS dish_recommendations_score ON dish_recommendations(user_id, score DESC);
+
+CREATE TABLE IF NOT EXISTS restaurant_recommendations (
+    user_id UUID REFERENCES users(id) NOT NULL,
+    restaurant_id UUID REFERENCES restaurants(id) NOT NULL,
+    score DOUBLE PRECISION NOT NULL,
+    reasons TEXT[] NOT NULL,
+    computed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, restaurant_id)
+);
+CREATE INDEX IF NOT EXISTS restaurant_recommendations_score ON restaurant_recommendations(user_id, score DESC);
+
+-- Recommendation Refresh Table, a single row with when the last run started so instances take turns
+CREATE TABLE IF NOT EXISTS recommendation_refresh (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    refreshed_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
+INSERT INTO recommendation_refresh(refreshed_at) VALUES ('1970-01-01 00:00:00+00') ON CONFLICT DO NOTHING;
+
+COMMIT;
@@ -0,0 +1,6 @@
+BEGIN;
+
+-- money an adjustment gives back that the settlement job has not handed to the provider yet
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS pending_refund BIGINT NOT NULL DEFAULT 0 CHECK (pending_refund >= 0);
+
+COMMIT;
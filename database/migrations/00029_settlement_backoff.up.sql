@@ -0,0 +1,7 @@
+BEGIN;
+
+-- a payment the provider failed to settle waits before the next try so it does not hold back the others
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS settlement_failures INT NOT NULL DEFAULT 0;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS next_settlement_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW();
+
+COMMIT;
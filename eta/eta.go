@@ -0,0 +1,121 @@
+// Package eta estimates when orders arrive from the kitchen load and the distance to the customer
+package eta
+
+import (
+	"math"
+	"os"
+	"rms/database/dbHelper"
+	"rms/models"
+	"rms/utils"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Model is the speed model behind delivery estimates, every value can be tuned through the ETA_* env variables
+type Model struct {
+	// speedKmh is the average rider speed on the road
+	speedKmh float64
+	// roadFactor stretches the straight line distance to the usual road distance
+	roadFactor float64
+	// pickupMinutes is what a rider needs to pick an order up at the restaurant
+	pickupMinutes float64
+	// defaultPrepMinutes is used for restaurants without a prep time history yet
+	defaultPrepMinutes float64
+	// kitchenParallel is how many orders a kitchen works on at once
+	kitchenParallel float64
+}
+
+// Current reads the model on every use, the .env file is loaded after package initialisation
+func Current() Model {
+	return Model{
+		speedKmh:           env("ETA_SPEED_KMH", 20),
+		roadFactor:         env("ETA_ROAD_FACTOR", 1.3),
+		pickupMinutes:      env("ETA_PICKUP_MINUTES", 5),
+		defaultPrepMinutes: env("ETA_DEFAULT_PREP_MINUTES", 20),
+		kitchenParallel:    env("ETA_KITCHEN_PARALLEL", 3),
+	}
+}
+
+func env(key string, fallback float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil || value <= 0 {
+		return fallback
+	}
+	return value
+}
+
+func (m Model) prepMinutes(avgPrepSeconds float64) float64 {
+	if avgPrepSeconds <= 0 {
+		return m.defaultPrepMinutes
+	}
+	return avgPrepSeconds / 60
+}
+
+// queueMinutes is the wait behind the orders ahead in the kitchen
+func (m Model) queueMinutes(queue int64, prepMinutes float64) float64 {
+	return float64(queue) * prepMinutes / m.kitchenParallel
+}
+
+func (m Model) travelMinutes(km float64) float64 {
+	return km * m.roadFactor / m.speedKmh * 60
+}
+
+func (m Model) estimate(now time.Time, prepMinutes, travelMinutes float64) models.DeliveryETA {
+	prep := int64(math.Ceil(prepMinutes))
+	travel := int64(math.Ceil(travelMinutes))
+	return models.DeliveryETA{
+		PrepMinutes:   prep,
+		TravelMinutes: travel,
+		TotalMinutes:  prep + travel,
+		EstimatedAt:   now.Add(time.Duration(prep+travel) * time.Minute),
+	}
+}
+
+// Quote is the ETA of a new order from the restaurant to the address
+func (m Model) Quote(load models.KitchenLoad, km float64) models.DeliveryETA {
+	prep := m.prepMinutes(load.AvgPrepSeconds)
+	return m.estimate(time.Now(), prep+m.queueMinutes(load.QueueLength, prep), m.pickupMinutes+m.travelMinutes(km))
+}
+
+// OrderETA estimates the order from where it is now, finished orders have none and scheduled ones arrive in their slot
+func (m Model) OrderETA(input *models.OrderETAInput) *time.Time {
+	now := time.Now()
+	avgPrep := m.prepMinutes(input.AvgPrepSeconds)
+	var prep float64
+	switch input.Status {
+	case models.OrderDelivered, models.OrderCancelled:
+		return nil
+	case models.OrderScheduled:
+		return input.ScheduledFor
+	case models.OrderPlaced:
+		prep = avgPrep + m.queueMinutes(input.QueueAhead, avgPrep)
+	case models.OrderAccepted, models.OrderPreparing:
+		prep = avgPrep
+		if input.TicketCreatedAt != nil {
+			prep = math.Max(avgPrep-now.Sub(*input.TicketCreatedAt).Minutes(), 0)
+		}
+	}
+	var travel float64
+	if input.OrderType == models.OrderDelivery && input.AddressLat != nil && input.AddressLng != nil {
+		fromLat, fromLng := input.RestaurantLat, input.RestaurantLng
+		if input.Status == models.OrderOutForDelivery && input.RiderLat != nil && input.RiderLng != nil {
+			fromLat, fromLng = *input.RiderLat, *input.RiderLng
+		} else if input.Status != models.OrderOutForDelivery {
+			travel += m.pickupMinutes
+		}
+		travel += m.travelMinutes(utils.DistanceKm(fromLat, fromLng, *input.AddressLat, *input.AddressLng))
+	}
+	estimatedAt := m.estimate(now, prep, travel).EstimatedAt
+	return &estimatedAt
+}
+
+// RefreshOrder recomputes and stores the ETA of the order for its current status
+func RefreshOrder(db sqlx.Ext, orderID string) error {
+	input, err := dbHelper.GetOrderETAInput(db, orderID)
+	if err != nil || input == nil {
+		return err
+	}
+	return dbHelper.UpdateOrderETA(db, orderID, Current().OrderETA(input))
+}
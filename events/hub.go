@@ -0,0 +1,234 @@
+package events
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// subscriberBuffer is how many events a slow subscriber may lag behind before events to it are dropped
+const subscriberBuffer = 32
+
+// historySize is how many recent events are kept to replay to clients reconnecting with the last event they got
+const historySize = 512
+
+const (
+	OrderPlaced        = "order.placed"
+	OrderStatusChanged = "order.status"
+	DishChanged        = "dish.changed"
+	TicketCreated      = "ticket.created"
+	TicketUpdated      = "ticket.updated"
+	RiderAssigned      = "rider.assigned"
+	RiderLocation      = "rider.location"
+	OrderAdjusted      = "order.adjusted"
+)
+
+// Event is what subscribers of a topic receive, Data is sent to clients as JSON. ID is given by the publishing
+// instance so it is the same on every instance a client may reconnect to
+type Event struct {
+	ID    string          `json:"id"`
+	Topic string          `json:"topic"`
+	Type  string          `json:"type"`
+	Data  json.RawMessage `json:"data"`
+}
+
+// OrderData is the payload of order events
+type OrderData struct {
+	OrderID      string `json:"orderId"`
+	RestaurantID string `json:"restaurantId"`
+	Status       string `json:"status"`
+}
+
+// DishData is the payload of dish events, Removed is set once the dish left the menu
+type DishData struct {
+	DishID       string `json:"dishId"`
+	RestaurantID string `json:"restaurantId"`
+	Removed      bool   `json:"removed"`
+}
+
+// TicketData is the payload of kitchen ticket events
+type TicketData struct {
+	TicketID string `json:"ticketId"`
+	OrderID  string `json:"orderId,omitempty"`
+}
+
+// RiderData is the payload of rider events, the position is only set on location updates
+type RiderData struct {
+	OrderID string   `json:"orderId"`
+	RiderID string   `json:"riderId"`
+	Lat     *float64 `json:"lat,omitempty"`
+	Lng     *float64 `json:"lng,omitempty"`
+}
+
+// Backend carries published events to the hubs of every server instance
+type Backend interface {
+	Publish(event Event) error
+}
+
+// Hub fans events out to the subscribers of their topic within this process
+type Hub struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[chan Event]struct{}
+	backend     Backend
+	// history is a ring of the last dispatched events, historyHead is where the next one goes and historyLen how
+	// many of the slots are filled
+	history     [historySize]Event
+	historyHead int
+	historyLen  int
+}
+
+var defaultHub = &Hub{subscribers: make(map[string]map[chan Event]struct{})}
+
+// Subscribe registers for the events of the topics, call the returned function to stop receiving them
+func Subscribe(topics ...string) (<-chan Event, func()) {
+	return defaultHub.Subscribe(topics...)
+}
+
+// SubscribeAfter is Subscribe that also returns the events of the topics dispatched after the event lastID, none
+// when that event is unknown or too old to be kept
+func SubscribeAfter(lastID string, topics ...string) (<-chan Event, []Event, func()) {
+	return defaultHub.SubscribeAfter(lastID, topics...)
+}
+
+// Publish sends an event to the subscribers of the topic on every server instance, failures are only logged
+// since events are notifications and the database stays the source of truth
+func Publish(topic, eventType string, data interface{}) {
+	defaultHub.Publish(topic, eventType, data)
+}
+
+func (h *Hub) Subscribe(topics ...string) (<-chan Event, func()) {
+	ch, _, unsubscribe := h.SubscribeAfter("", topics...)
+	return ch, unsubscribe
+}
+
+func (h *Hub) SubscribeAfter(lastID string, topics ...string) (<-chan Event, []Event, func()) {
+	ch := make(chan Event, subscriberBuffer)
+	missed := make([]Event, 0)
+	h.mu.Lock()
+	// the history is read under the same lock dispatch takes, so no event is both missed and sent
+	if lastID != "" {
+		missed = h.eventsAfter(lastID, topics)
+	}
+	for _, topic := range topics {
+		if h.subscribers[topic] == nil {
+			h.subscribers[topic] = make(map[chan Event]struct{})
+		}
+		h.subscribers[topic][ch] = struct{}{}
+	}
+	h.mu.Unlock()
+	var once sync.Once
+	return ch, missed, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			for _, topic := range topics {
+				delete(h.subscribers[topic], ch)
+				if len(h.subscribers[topic]) == 0 {
+					delete(h.subscribers, topic)
+				}
+			}
+			h.mu.Unlock()
+		})
+	}
+}
+
+func (h *Hub) Publish(topic, eventType string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		logrus.Errorf("failed to encode %s event: %v", eventType, err)
+		return
+	}
+	event := Event{ID: newEventID(), Topic: topic, Type: eventType, Data: payload}
+	h.mu.RLock()
+	backend := h.backend
+	h.mu.RUnlock()
+	if backend == nil {
+		h.dispatch(event)
+		return
+	}
+	if err := backend.Publish(event); err != nil {
+		logrus.Errorf("failed to publish %s event: %v", eventType, err)
+	}
+}
+
+// dispatch hands the event to the local subscribers of its topic without blocking the publisher
+func (h *Hub) dispatch(event Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.history[h.historyHead] = event
+	h.historyHead = (h.historyHead + 1) % historySize
+	if h.historyLen < historySize {
+		h.historyLen++
+	}
+	for ch := range h.subscribers[event.Topic] {
+		select {
+		case ch <- event:
+		default:
+			logrus.Warnf("dropped %s event for a slow subscriber of %s", event.Type, event.Topic)
+		}
+	}
+}
+
+// eventsAfter copies the kept events of the topics dispatched after the event lastID oldest first, call it with the
+// lock held
+func (h *Hub) eventsAfter(lastID string, topics []string) []Event {
+	missed := make([]Event, 0)
+	// age 1 is the newest event, the last one sent to the client is searched from there
+	for age := 1; age <= h.historyLen; age++ {
+		if h.history[(h.historyHead-age+historySize)%historySize].ID != lastID {
+			continue
+		}
+		for newer := age - 1; newer >= 1; newer-- {
+			event := h.history[(h.historyHead-newer+historySize)%historySize]
+			if containsTopic(topics, event.Topic) {
+				missed = append(missed, event)
+			}
+		}
+		break
+	}
+	return missed
+}
+
+// newEventID orders by publish time and stays unique across instances publishing at the same moment
+func newEventID() string {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		logrus.Errorf("failed to generate event id: %v", err)
+	}
+	return strconv.FormatInt(time.Now().UnixNano(), 10) + "-" + hex.EncodeToString(suffix)
+}
+
+func containsTopic(topics []string, topic string) bool {
+	for _, t := range topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
+
+func setBackend(backend Backend) {
+	defaultHub.mu.Lock()
+	defaultHub.backend = backend
+	defaultHub.mu.Unlock()
+}
+
+func UserTopic(userID string) string {
+	return "user:" + userID
+}
+
+func RestaurantTopic(restaurantID string) string {
+	return "restaurant:" + restaurantID
+}
+
+func MenuTopic(restaurantID string) string {
+	return "menu:" + restaurantID
+}
+
+func KitchenTopic(restaurantID string) string {
+	return "kitchen:" + restaurantID
+}
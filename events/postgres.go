@@ -0,0 +1,75 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"rms/database"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	notifyChannel        = "rms_events"
+	listenerMinReconnect = 10 * time.Second
+	listenerMaxReconnect = time.Minute
+	listenerPingInterval = 90 * time.Second
+)
+
+// postgresBackend publishes with NOTIFY, every instance including this one gets the event back through LISTEN
+type postgresBackend struct{}
+
+func (postgresBackend) Publish(event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	// language=SQL
+	SQL := `SELECT pg_notify($1, $2)`
+	_, err = database.RMS.Exec(SQL, notifyChannel, string(payload))
+	return err
+}
+
+// StartPostgres switches the hub to fan events out through Postgres LISTEN/NOTIFY until the context is cancelled
+func StartPostgres(ctx context.Context) error {
+	listener := database.NewListener(listenerMinReconnect, listenerMaxReconnect, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			logrus.Errorf("events listener: %v", err)
+		}
+	})
+	if err := listener.Listen(notifyChannel); err != nil {
+		return err
+	}
+	setBackend(postgresBackend{})
+	go func() {
+		defer func() {
+			setBackend(nil)
+			if err := listener.Close(); err != nil {
+				logrus.Errorf("failed to close events listener: %v", err)
+			}
+		}()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case notification := <-listener.Notify:
+				// a nil notification means the connection was re-established and events may have been missed
+				if notification == nil {
+					continue
+				}
+				var event Event
+				if err := json.Unmarshal([]byte(notification.Extra), &event); err != nil {
+					logrus.Errorf("failed to decode event: %v", err)
+					continue
+				}
+				defaultHub.dispatch(event)
+			case <-time.After(listenerPingInterval):
+				if err := listener.Ping(); err != nil {
+					logrus.Errorf("events listener ping failed: %v", err)
+				}
+			}
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,362 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"rms/database"
+	"rms/database/dbHelper"
+	"rms/eta"
+	"rms/events"
+	"rms/middlewares"
+	"rms/models"
+	"rms/payments"
+	"rms/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	errAdjustmentInvalid = errors.New("invalid adjustment")
+	errRefundFailed      = errors.New("refund not possible")
+)
+
+// canAdjustOrder tells whether items can still be taken off, before acceptance the customer can cancel instead and
+// once the order left the kitchen nothing can be taken back
+func canAdjustOrder(status models.OrderStatus) bool {
+	return status == models.OrderAccepted || status == models.OrderPreparing || status == models.OrderReady
+}
+
+// orderAdjustment is the outcome of taking items off an order
+type orderAdjustment struct {
+	lines    []models.OrderAdjustment
+	refund   int64
+	ticketID string
+}
+
+// takeOffOrderItems removes the quantities from the locked order, restocks the dishes and reprices the order with what
+// is left. The coupon discount, packaging, taxes and rounding are worked out again, the delivery fee is kept as charged
+// and redeemed points that no longer fit are given back
+func takeOffOrderItems(tx *sqlx.Tx, order *models.Order, body *models.AdjustOrderBody, createdBy string) (orderAdjustment, error) {
+	var adjustment orderAdjustment
+	items := make(map[string]*models.OrderItem)
+	for index := range order.Items {
+		items[order.Items[index].DishID] = &order.Items[index]
+	}
+	line := func(kind models.OrderAdjustmentKind, itemID *string, quantity, amount int64) {
+		if amount == 0 && kind != models.AdjustmentItem {
+			return
+		}
+		adjustment.lines = append(adjustment.lines, models.OrderAdjustment{
+			OrderID:     order.ID,
+			Kind:        kind,
+			OrderItemID: itemID,
+			Quantity:    quantity,
+			Amount:      amount,
+			Reason:      body.Reason,
+			CreatedBy:   createdBy,
+		})
+	}
+	previous := *order
+	for _, adjusted := range body.Items {
+		item, ok := items[adjusted.DishID]
+		if !ok || item.Quantity == 0 {
+			return adjustment, fmt.Errorf("%w: dish %s is not on the order", errAdjustmentInvalid, adjusted.DishID)
+		}
+		if adjusted.Quantity > item.Quantity {
+			return adjustment, fmt.Errorf("%w: only %d of %s are on the order", errAdjustmentInvalid, item.Quantity, item.Name)
+		}
+		quantity := item.Quantity - adjusted.Quantity
+		lineSubTotal := item.Price * quantity
+		total := lineSubTotal - lineSubTotal*item.Discount/100
+		line(models.AdjustmentItem, &item.ID, adjusted.Quantity, total-item.Total)
+		if err := dbHelper.UpdateOrderItemQuantity(tx, item.ID, quantity, total); err != nil {
+			return adjustment, err
+		}
+		backInStock, stockErr := dbHelper.UpdateDishQuantity(tx, item.DishID, adjusted.Quantity)
+		if stockErr != nil {
+			return adjustment, stockErr
+		}
+		if backInStock {
+			if err := enqueueDishUpdated(tx, order.RestaurantID, item.DishID); err != nil {
+				return adjustment, err
+			}
+		}
+		ticketID, ticketErr := dbHelper.ReduceKitchenTicketItem(tx, order.ID, item.DishID, adjusted.Quantity)
+		if ticketErr != nil {
+			return adjustment, ticketErr
+		}
+		if ticketID != "" {
+			adjustment.ticketID = ticketID
+		}
+		item.Quantity = quantity
+		item.Total = total
+	}
+	lines := remainingPriceLines(order)
+	if len(lines) == 0 {
+		return adjustment, fmt.Errorf("%w: cancel the order instead of taking off every item", errAdjustmentInvalid)
+	}
+
+	restaurantPricing, pricingErr := dbHelper.GetRestaurantPricing(tx, order.RestaurantID)
+	if pricingErr != nil {
+		return adjustment, pricingErr
+	}
+	if restaurantPricing == nil {
+		return adjustment, errRestaurantNotFound
+	}
+	// a smaller order must never start paying for delivery
+	restaurantPricing.DeliveryFee = order.DeliveryFee
+	restaurantPricing.FreeDeliveryAbove = 0
+	var coupon *models.Coupon
+	if order.CouponID != nil {
+		var couponErr error
+		coupon, couponErr = dbHelper.GetCouponByID(tx, *order.CouponID)
+		if couponErr != nil {
+			return adjustment, couponErr
+		}
+	}
+	delivery := order.OrderType == models.OrderDelivery
+	breakdown, err := quote(*restaurantPricing, lines, coupon, 0, delivery)
+	if err != nil {
+		return adjustment, err
+	}
+	if points := minInt64(order.PointsRedeemed, breakdown.GrandTotal); points > 0 {
+		breakdown, err = quote(*restaurantPricing, lines, coupon, points, delivery)
+		if err != nil {
+			return adjustment, err
+		}
+	}
+	applyBreakdown(order, breakdown)
+
+	line(models.AdjustmentCoupon, nil, 0, previous.CouponDiscount-order.CouponDiscount)
+	line(models.AdjustmentFee, nil, 0, order.PackagingCharge+order.DeliveryFee-previous.PackagingCharge-previous.DeliveryFee)
+	line(models.AdjustmentTax, nil, 0, order.Tax-order.TaxIncluded-previous.Tax+previous.TaxIncluded)
+	line(models.AdjustmentRounding, nil, 0, order.Rounding-previous.Rounding)
+	if points := previous.PointsRedeemed - order.PointsRedeemed; points > 0 {
+		if err := dbHelper.PostLoyaltyTransaction(tx, order.UserID, models.LoyaltyRefund, points, &order.ID, &createdBy, body.Reason, nil); err != nil {
+			return adjustment, err
+		}
+		line(models.AdjustmentPoints, nil, 0, points)
+	}
+	if err := dbHelper.UpdateOrderTotals(tx, order); err != nil {
+		return adjustment, err
+	}
+
+	// dine-in orders have no payment, their bill simply shrinks
+	if refund := previous.Total - order.Total; refund > 0 {
+		payment, paymentErr := dbHelper.GetPaymentByOrderID(tx, order.ID, true)
+		if paymentErr != nil {
+			return adjustment, paymentErr
+		}
+		if payment != nil {
+			if refundErr := refundPayment(tx, payment, refund); refundErr != nil {
+				return adjustment, refundErr
+			}
+			adjustment.refund = refund
+			line(models.AdjustmentRefund, nil, 0, refund)
+		}
+	}
+	return adjustment, dbHelper.CreateOrderAdjustments(tx, adjustment.lines)
+}
+
+// remainingPriceLines turns the items still on the order back into price lines, the tax category and packaging are
+// taken from the breakdown the order was priced with so a later change to the dish does not reprice it
+func remainingPriceLines(order *models.Order) []models.PriceLine {
+	priced := make(map[string]models.PriceLine)
+	if order.Breakdown != nil {
+		for _, line := range order.Breakdown.Items {
+			priced[line.DishID] = line
+		}
+	}
+	lines := make([]models.PriceLine, 0, len(order.Items))
+	for _, item := range order.Items {
+		if item.Quantity == 0 {
+			continue
+		}
+		line := models.PriceLine{OrderItem: item}
+		if previous, ok := priced[item.DishID]; ok {
+			line.TaxCategory = previous.TaxCategory
+			if previous.Quantity > 0 {
+				line.Packaging = previous.Packaging / previous.Quantity
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// refundPayment owes part of a held or captured payment back while the payment is locked. The provider is only
+// called by the settlement job once the adjustment committed, so a rolled back adjustment never gives money back
+func refundPayment(tx *sqlx.Tx, payment *models.Payment, amount int64) error {
+	if (payment.Status != models.PaymentAuthorized && payment.Status != models.PaymentCaptured) || payment.ProviderRef == nil {
+		return fmt.Errorf("%w: payment is %s", errRefundFailed, payment.Status)
+	}
+	if _, providerErr := payments.Get(payment.Provider); providerErr != nil {
+		return fmt.Errorf("%w: %s", errRefundFailed, providerErr)
+	}
+	return dbHelper.AddPendingRefund(tx, payment.ID, amount)
+}
+
+func AdjustOrderItems(w http.ResponseWriter, r *http.Request) {
+	restaurantID := chi.URLParam(r, "restaurantId")
+	orderID := chi.URLParam(r, "orderId")
+	adminCtx := middlewares.UserContext(r)
+	var body models.AdjustOrderBody
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+
+	if len(body.Items) == 0 {
+		logrus.Errorf("Adjustment must have at least one item.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Adjustment must have at least one item.")
+		return
+	}
+
+	dishIDs := make(map[string]bool)
+	for _, item := range body.Items {
+		if item.Quantity <= 0 || dishIDs[item.DishID] {
+			logrus.Errorf("Invalid Item: %s", item.DishID)
+			utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Item.")
+			return
+		}
+		dishIDs[item.DishID] = true
+	}
+
+	if body.Reason == "" {
+		logrus.Errorf("Reason is required.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Reason is required.")
+		return
+	}
+
+	if _, ok := getManagedRestaurant(w, r); !ok {
+		return
+	}
+
+	order, orderErr := dbHelper.GetOrderByID(database.RMS, orderID)
+	if orderErr != nil {
+		logrus.Errorf("Failed to get order: %s", orderErr)
+		utils.RespondError(w, http.StatusInternalServerError, orderErr, "Failed to get order")
+		return
+	}
+	if order == nil || order.RestaurantID != restaurantID {
+		logrus.Errorf("Order not exist: %s", orderID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Order not exist")
+		return
+	}
+
+	var adjustment orderAdjustment
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		if lockErr := dbHelper.LockOrder(tx, orderID); lockErr != nil {
+			return lockErr
+		}
+		var loadErr error
+		order, loadErr = dbHelper.GetOrderByID(tx, orderID)
+		if loadErr != nil {
+			return loadErr
+		}
+		if !canAdjustOrder(order.Status) {
+			return fmt.Errorf("%w: order is %s", errAdjustmentInvalid, order.Status)
+		}
+		var adjustErr error
+		adjustment, adjustErr = takeOffOrderItems(tx, order, &body, adminCtx.ID)
+		if adjustErr != nil {
+			return adjustErr
+		}
+		if etaErr := eta.RefreshOrder(tx, order.ID); etaErr != nil {
+			return etaErr
+		}
+		return dbHelper.EnqueueWebhookEvent(tx, order.RestaurantID, models.WebhookOrderAdjusted, order)
+	})
+	if txErr != nil {
+		if errors.Is(txErr, errAdjustmentInvalid) {
+			logrus.Errorf("Failed to adjust order: %s", txErr)
+			utils.RespondError(w, http.StatusBadRequest, txErr, txErr.Error())
+			return
+		}
+		if errors.Is(txErr, errRefundFailed) {
+			logrus.Errorf("Failed to adjust order: %s", txErr)
+			utils.RespondError(w, http.StatusConflict, txErr, txErr.Error())
+			return
+		}
+		logrus.Errorf("Failed to adjust order: %s", txErr)
+		utils.RespondError(w, http.StatusInternalServerError, txErr, "Failed to adjust order")
+		return
+	}
+	publishOrderEvent(events.OrderAdjusted, order.ID, order.UserID, order.RestaurantID, order.Status)
+	if adjustment.ticketID != "" {
+		publishTicketEvent(order.RestaurantID, events.TicketUpdated, adjustment.ticketID, order.ID)
+	}
+	order.Items = removeEmptyOrderItems(order.Items)
+	logrus.Infof("Order adjusted successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.AdjustOrder{
+		Message: "Order adjusted successfully.",
+		Order:   *order,
+		Refund:  adjustment.refund,
+	})
+}
+
+// removeEmptyOrderItems drops the lines that were taken off entirely, like the order queries do
+func removeEmptyOrderItems(items []models.OrderItem) []models.OrderItem {
+	kept := make([]models.OrderItem, 0, len(items))
+	for _, item := range items {
+		if item.Quantity > 0 {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+func GetOrderAdjustments(w http.ResponseWriter, r *http.Request) {
+	restaurantID := chi.URLParam(r, "restaurantId")
+	orderID := chi.URLParam(r, "orderId")
+	if _, ok := getManagedRestaurant(w, r); !ok {
+		return
+	}
+	order, orderErr := dbHelper.GetOrderByID(database.RMS, orderID)
+	if orderErr != nil {
+		logrus.Errorf("Failed to get order: %s", orderErr)
+		utils.RespondError(w, http.StatusInternalServerError, orderErr, "Failed to get order")
+		return
+	}
+	if order == nil || order.RestaurantID != restaurantID {
+		logrus.Errorf("Order not exist: %s", orderID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Order not exist")
+		return
+	}
+	respondOrderAdjustments(w, orderID)
+}
+
+func GetMyOrderAdjustments(w http.ResponseWriter, r *http.Request) {
+	orderID := chi.URLParam(r, "orderId")
+	userCtx := middlewares.UserContext(r)
+	order, orderErr := dbHelper.GetOrderByID(database.RMS, orderID)
+	if orderErr != nil {
+		logrus.Errorf("Failed to get order: %s", orderErr)
+		utils.RespondError(w, http.StatusInternalServerError, orderErr, "Failed to get order")
+		return
+	}
+	if order == nil || order.UserID != userCtx.ID {
+		logrus.Errorf("Order not exist: %s", orderID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Order not exist")
+		return
+	}
+	respondOrderAdjustments(w, orderID)
+}
+
+func respondOrderAdjustments(w http.ResponseWriter, orderID string) {
+	adjustments, err := dbHelper.GetOrderAdjustments(orderID)
+	if err != nil {
+		logrus.Errorf("Unable to get Order Adjustments: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Unable to get Order Adjustments")
+		return
+	}
+	logrus.Infof("Get Order Adjustments successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetOrderAdjustments{
+		Message:     "Get Order Adjustments successfully.",
+		Adjustments: adjustments,
+	})
+}
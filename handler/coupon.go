@@ -0,0 +1,287 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"rms/database"
+	"rms/database/dbHelper"
+	"rms/middlewares"
+	"rms/models"
+	"rms/utils"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+var errCouponInvalid = errors.New("coupon not applicable")
+
+// evaluateCoupon checks every rule of the coupon against the order amount and returns the discount it gives
+func evaluateCoupon(db sqlx.Ext, code, userID, restaurantID string, amount int64, lock bool) (*models.Coupon, int64, error) {
+	coupon, couponErr := dbHelper.GetCouponByCode(db, code, lock)
+	if couponErr != nil {
+		return nil, 0, couponErr
+	}
+	if coupon == nil {
+		return nil, 0, fmt.Errorf("%w: coupon not found", errCouponInvalid)
+	}
+	if coupon.RestaurantID != nil && *coupon.RestaurantID != restaurantID {
+		return nil, 0, fmt.Errorf("%w: coupon is not valid for this restaurant", errCouponInvalid)
+	}
+	now := time.Now()
+	if now.Before(coupon.StartsAt) || (coupon.EndsAt != nil && now.After(*coupon.EndsAt)) {
+		return nil, 0, fmt.Errorf("%w: coupon is not active", errCouponInvalid)
+	}
+	if amount < coupon.MinOrderValue {
+		return nil, 0, fmt.Errorf("%w: minimum order value is %d", errCouponInvalid, coupon.MinOrderValue)
+	}
+	if coupon.UsageLimit != nil && coupon.UsedCount >= *coupon.UsageLimit {
+		return nil, 0, fmt.Errorf("%w: usage limit reached", errCouponInvalid)
+	}
+	if coupon.PerUserLimit != nil {
+		used, usedErr := dbHelper.GetUserCouponRedemptionsCount(db, coupon.ID, userID)
+		if usedErr != nil {
+			return nil, 0, usedErr
+		}
+		if used >= *coupon.PerUserLimit {
+			return nil, 0, fmt.Errorf("%w: you already used this coupon", errCouponInvalid)
+		}
+	}
+	if coupon.FirstOrderOnly {
+		ordersCount, ordersErr := dbHelper.GetUserPlacedOrdersCount(db, userID)
+		if ordersErr != nil {
+			return nil, 0, ordersErr
+		}
+		if ordersCount > 0 {
+			return nil, 0, fmt.Errorf("%w: coupon is valid on first order only", errCouponInvalid)
+		}
+	}
+	return coupon, coupon.Discount(amount), nil
+}
+
+func ApplyCoupon(w http.ResponseWriter, r *http.Request) {
+	var body models.ApplyCouponBody
+	userCtx := middlewares.UserContext(r)
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+	if len(body.Items) == 0 {
+		logrus.Errorf("Cart must have at least one item.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Cart must have at least one item.")
+		return
+	}
+	for _, item := range body.Items {
+		if item.Quantity <= 0 {
+			logrus.Errorf("Invalid Quantity.")
+			utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Quantity.")
+			return
+		}
+	}
+	if body.CouponCode == "" {
+		logrus.Errorf("Invalid Coupon Code.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Coupon Code.")
+		return
+	}
+
+	lines, itemsErr := priceCartItems(func(dishID string) (*models.Dishes, error) {
+		return dbHelper.GetRestaurantDishById(body.RestaurantID, dishID, cartServedAt(body.ScheduledFor))
+	}, body.Items)
+	if itemsErr != nil {
+		if errors.Is(itemsErr, errDishNotFound) || errors.Is(itemsErr, errDishOutOfStock) || errors.Is(itemsErr, errDishUnavailable) {
+			logrus.Errorf("Failed to price cart: %s", itemsErr)
+			utils.RespondError(w, http.StatusBadRequest, itemsErr, itemsErr.Error())
+			return
+		}
+		logrus.Errorf("Failed to price cart: %s", itemsErr)
+		utils.RespondError(w, http.StatusInternalServerError, itemsErr, "Failed to price cart")
+		return
+	}
+
+	coupon, _, couponErr := evaluateCoupon(database.RMS, body.CouponCode, userCtx.ID, body.RestaurantID, itemsTotal(lines), false)
+	if couponErr != nil {
+		if errors.Is(couponErr, errCouponInvalid) {
+			logrus.Errorf("Failed to apply coupon: %s", couponErr)
+			utils.RespondError(w, http.StatusBadRequest, couponErr, couponErr.Error())
+			return
+		}
+		logrus.Errorf("Failed to apply coupon: %s", couponErr)
+		utils.RespondError(w, http.StatusInternalServerError, couponErr, "Failed to apply coupon")
+		return
+	}
+
+	breakdown, quoteErr := quoteLines(database.RMS, body.RestaurantID, lines, coupon, 0, true)
+	if quoteErr != nil {
+		logrus.Errorf("Failed to price cart: %s", quoteErr)
+		utils.RespondError(w, http.StatusInternalServerError, quoteErr, "Failed to price cart")
+		return
+	}
+	logrus.Infof("Coupon applied successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.ApplyCoupon{
+		Message:   "Coupon applied successfully.",
+		Breakdown: breakdown,
+	})
+}
+
+// createCoupon validates and saves a coupon, a nil restaurantID creates a platform coupon
+func createCoupon(w http.ResponseWriter, r *http.Request, restaurantID *string) {
+	var body models.AddCouponBody
+	adminCtx := middlewares.UserContext(r)
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+
+	if body.Code == "" {
+		logrus.Errorf("Invalid Coupon Code.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Coupon Code.")
+		return
+	}
+
+	if !body.DiscountType.IsValid() {
+		logrus.Errorf("Invalid Discount Type.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Discount Type.")
+		return
+	}
+
+	if body.Value <= 0 || (body.DiscountType == models.DiscountPercentage && body.Value > 100) {
+		logrus.Errorf("Invalid Coupon Value.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Coupon Value.")
+		return
+	}
+
+	if body.MinOrderValue < 0 || (body.MaxDiscount != nil && *body.MaxDiscount <= 0) {
+		logrus.Errorf("Invalid Coupon Limits.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Coupon Limits.")
+		return
+	}
+
+	if (body.UsageLimit != nil && *body.UsageLimit <= 0) || (body.PerUserLimit != nil && *body.PerUserLimit <= 0) {
+		logrus.Errorf("Invalid Coupon Usage Limit.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Coupon Usage Limit.")
+		return
+	}
+
+	if body.EndsAt != nil && (body.EndsAt.Before(time.Now()) || (body.StartsAt != nil && body.EndsAt.Before(*body.StartsAt))) {
+		logrus.Errorf("Invalid Coupon Validity.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Coupon Validity.")
+		return
+	}
+
+	exists, existsErr := dbHelper.IsCouponCodeExists(body.Code)
+	if existsErr != nil {
+		logrus.Errorf("Failed to check Coupon existence: %s", existsErr)
+		utils.RespondError(w, http.StatusInternalServerError, existsErr, "Failed to check Coupon existence")
+		return
+	}
+	if exists {
+		logrus.Errorf("Coupon already exists.")
+		utils.RespondError(w, http.StatusConflict, nil, "Coupon already exists")
+		return
+	}
+
+	if _, saveErr := dbHelper.CreateCoupon(restaurantID, adminCtx.ID, &body); saveErr != nil {
+		logrus.Errorf("Failed to create Coupon: %s", saveErr)
+		utils.RespondError(w, http.StatusInternalServerError, saveErr, "Failed to create Coupon")
+		return
+	}
+	logrus.Infof("Coupon created successfully.")
+	utils.RespondJSON(w, http.StatusCreated, models.Message{
+		Message: "Coupon created successfully.",
+	})
+}
+
+// listCoupons responds with the coupons of a restaurant, a nil restaurantID lists platform coupons
+func listCoupons(w http.ResponseWriter, r *http.Request, restaurantID *string) {
+	Filters := utils.GetFilters(r)
+	var couponsCount int64
+	coupons := make([]models.Coupon, 0)
+	var errGroup errgroup.Group
+	errGroup.Go(func() error {
+		var err error
+		couponsCount, err = dbHelper.GetCouponsCount(restaurantID)
+		if err != nil {
+			logrus.Errorf("Unable to get Coupons Count: %s", err)
+		}
+		return err
+	})
+	errGroup.Go(func() error {
+		var err error
+		coupons, err = dbHelper.GetCoupons(restaurantID, Filters)
+		if err != nil {
+			logrus.Errorf("Unable to get Coupons: %s", err)
+		}
+		return err
+	})
+	if err := errGroup.Wait(); err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "Unable to get Coupons")
+		return
+	}
+	logrus.Infof("Get Coupons successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetCoupons{
+		Message:    "Get Coupons successfully.",
+		Coupons:    coupons,
+		TotalCount: couponsCount,
+		PageNumber: Filters.PageNumber,
+		PageSize:   Filters.PageSize,
+	})
+}
+
+func AddRestaurantCoupon(w http.ResponseWriter, r *http.Request) {
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	createCoupon(w, r, &restaurant.ID)
+}
+
+func GetRestaurantCoupons(w http.ResponseWriter, r *http.Request) {
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	listCoupons(w, r, &restaurant.ID)
+}
+
+func RemoveRestaurantCoupon(w http.ResponseWriter, r *http.Request) {
+	couponID := chi.URLParam(r, "couponId")
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	if err := dbHelper.ArchiveCoupon(couponID, &restaurant.ID); err != nil {
+		logrus.Errorf("Failed to remove Coupon: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to remove Coupon")
+		return
+	}
+	logrus.Infof("Coupon removed successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Coupon removed successfully.",
+	})
+}
+
+func AddPlatformCoupon(w http.ResponseWriter, r *http.Request) {
+	createCoupon(w, r, nil)
+}
+
+func GetPlatformCoupons(w http.ResponseWriter, r *http.Request) {
+	listCoupons(w, r, nil)
+}
+
+func RemovePlatformCoupon(w http.ResponseWriter, r *http.Request) {
+	couponID := chi.URLParam(r, "couponId")
+	if err := dbHelper.ArchiveCoupon(couponID, nil); err != nil {
+		logrus.Errorf("Failed to remove Coupon: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to remove Coupon")
+		return
+	}
+	logrus.Infof("Coupon removed successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Coupon removed successfully.",
+	})
+}
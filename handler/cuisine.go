@@ -0,0 +1,170 @@
+package handler
+
+import (
+	"net/http"
+	"regexp"
+	"rms/database"
+	"rms/database/dbHelper"
+	"rms/models"
+	"rms/utils"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+var cuisineSlugRegex = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+func GetCuisines(w http.ResponseWriter, r *http.Request) {
+	cuisines, err := dbHelper.GetCuisines()
+	if err != nil {
+		logrus.Errorf("Failed to get Cuisines: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to get Cuisines")
+		return
+	}
+	logrus.Infof("Get Cuisines successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetCuisines{
+		Message:  "Get Cuisines successfully.",
+		Cuisines: cuisines,
+	})
+}
+
+func AddCuisine(w http.ResponseWriter, r *http.Request) {
+	var body models.CuisineBody
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+	body.Slug = strings.ToLower(strings.TrimSpace(body.Slug))
+	body.Name = strings.TrimSpace(body.Name)
+	if !cuisineSlugRegex.MatchString(body.Slug) {
+		logrus.Errorf("Invalid Slug: %s", body.Slug)
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Slug, use lowercase letters and digits joined by hyphens.")
+		return
+	}
+	if body.Name == "" {
+		logrus.Errorf("Invalid Name.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Name.")
+		return
+	}
+
+	created, err := dbHelper.CreateCuisine(database.RMS, body.Slug, body.Name)
+	if err != nil {
+		logrus.Errorf("Failed to add Cuisine: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to add Cuisine")
+		return
+	}
+	if !created {
+		logrus.Errorf("Cuisine already exists: %s", body.Slug)
+		utils.RespondError(w, http.StatusConflict, nil, "Cuisine already exists")
+		return
+	}
+	logrus.Infof("Cuisine added successfully.")
+	utils.RespondJSON(w, http.StatusCreated, models.Message{
+		Message: "Cuisine added successfully.",
+	})
+}
+
+// UpdateRestaurantAttributes replaces the cuisines, price range, cost for two and features customers find the
+// restaurant by
+func UpdateRestaurantAttributes(w http.ResponseWriter, r *http.Request) {
+	var body models.RestaurantAttributesBody
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+
+	features, featuresOk := uniqueVocabulary(body.Features, models.IsRestaurantFeature)
+	if !featuresOk {
+		logrus.Errorf("Invalid Features: %v", body.Features)
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Features, use: "+strings.Join(models.RestaurantFeatures, ", "))
+		return
+	}
+	if features == nil {
+		features = make([]string, 0)
+	}
+	if body.PriceRange != nil && (*body.PriceRange < models.MinPriceRange || *body.PriceRange > models.MaxPriceRange) {
+		logrus.Errorf("Invalid Price Range: %d", *body.PriceRange)
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Price Range.")
+		return
+	}
+	var costForTwo *int64
+	if body.CostForTwo != nil {
+		cost, costErr := body.CostForTwo.In(restaurant.Currency)
+		if costErr != nil {
+			logrus.Errorf("Invalid Cost For Two: %s", costErr)
+			utils.RespondError(w, http.StatusBadRequest, costErr, costErr.Error())
+			return
+		}
+		if cost.IsNegative() {
+			logrus.Errorf("Invalid Cost For Two.")
+			utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Cost For Two.")
+			return
+		}
+		costForTwo = &cost.Amount
+	}
+
+	cuisines, _ := uniqueVocabulary(body.Cuisines, func(string) bool { return true })
+	if len(cuisines) > 0 {
+		count, countErr := dbHelper.CountCuisines(database.RMS, cuisines)
+		if countErr != nil {
+			logrus.Errorf("Failed to get Cuisines: %s", countErr)
+			utils.RespondError(w, http.StatusInternalServerError, countErr, "Failed to get Cuisines")
+			return
+		}
+		if count != int64(len(cuisines)) {
+			logrus.Errorf("Invalid Cuisines: %v", cuisines)
+			utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Cuisines.")
+			return
+		}
+	}
+
+	var updated bool
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		var updateErr error
+		updated, updateErr = dbHelper.UpdateRestaurantAttributes(tx, restaurant.ID, body.PriceRange, costForTwo, features)
+		if updateErr != nil || !updated {
+			return updateErr
+		}
+		return dbHelper.ReplaceRestaurantCuisines(tx, restaurant.ID, cuisines)
+	})
+	if txErr != nil {
+		logrus.Errorf("Failed to update Restaurant Attributes: %s", txErr)
+		utils.RespondError(w, http.StatusInternalServerError, txErr, "Failed to update Restaurant Attributes")
+		return
+	}
+	if !updated {
+		logrus.Errorf("Restaurant not exist: %s", restaurant.ID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Restaurant not exist")
+		return
+	}
+	logrus.Infof("Restaurant Attributes updated successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Restaurant Attributes updated successfully.",
+	})
+}
+
+// restaurantFiltersValid checks the discovery filters of a restaurant listing, it responds with the error itself
+func restaurantFiltersValid(w http.ResponseWriter, Filters models.Filters) bool {
+	for _, feature := range Filters.Features {
+		if !models.IsRestaurantFeature(feature) {
+			logrus.Errorf("Invalid Feature Filter: %s", feature)
+			utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Feature Filter: "+feature)
+			return false
+		}
+	}
+	for _, priceRange := range Filters.PriceRanges {
+		if priceRange < models.MinPriceRange || priceRange > models.MaxPriceRange {
+			logrus.Errorf("Invalid Price Range Filter: %d", priceRange)
+			utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Price Range Filter.")
+			return false
+		}
+	}
+	return true
+}
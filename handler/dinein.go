@@ -0,0 +1,537 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"rms/database"
+	"rms/database/dbHelper"
+	"rms/eta"
+	"rms/events"
+	"rms/middlewares"
+	"rms/models"
+	"rms/orderstatus"
+	"rms/utils"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	tableNonceLength   = 16
+	dineInTokenLength  = 32
+	dineInQRPath       = "/dine-in"
+	dineInQRQueryParam = "qr"
+)
+
+var (
+	errDineInClosed = errors.New("table session is closed")
+	errDineInBusy   = errors.New("table session has orders in progress")
+)
+
+// newDineInBill totals the orders of a table session, cancelled orders are left off the bill
+func newDineInBill(session models.DineInSession, orders []models.Order) models.DineInBill {
+	bill := models.DineInBill{
+		Session: session,
+		Orders:  make([]models.Order, 0, len(orders)),
+	}
+	for _, order := range orders {
+		if order.DineInSessionID == nil || *order.DineInSessionID != session.ID || order.Status == models.OrderCancelled {
+			continue
+		}
+		bill.Orders = append(bill.Orders, order)
+		bill.SubTotal += order.SubTotal
+		bill.Discount += order.Discount
+		bill.Tax += order.Tax
+		bill.Total += order.Total
+	}
+	return bill
+}
+
+// Restaurant Areas And Tables
+
+func AddRestaurantArea(w http.ResponseWriter, r *http.Request) {
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	adminCtx := middlewares.UserContext(r)
+	var body models.AddAreaBody
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+
+	if body.Name == "" {
+		logrus.Errorf("Name is required.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Name is required.")
+		return
+	}
+
+	if saveErr := dbHelper.CreateRestaurantArea(restaurant.ID, body.Name, adminCtx.ID); saveErr != nil {
+		logrus.Errorf("Failed to add Area: %s", saveErr)
+		utils.RespondError(w, http.StatusInternalServerError, saveErr, "Failed to add Area")
+		return
+	}
+	logrus.Infof("Area added successfully.")
+	utils.RespondJSON(w, http.StatusCreated, models.Message{
+		Message: "Area added successfully.",
+	})
+}
+
+func GetRestaurantAreas(w http.ResponseWriter, r *http.Request) {
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	areas, err := dbHelper.GetRestaurantAreas(restaurant.ID)
+	if err != nil {
+		logrus.Errorf("Failed to get Areas: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to get Areas")
+		return
+	}
+	logrus.Infof("Get Areas successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetAreas{
+		Message: "Get Areas successfully.",
+		Areas:   areas,
+	})
+}
+
+func RemoveRestaurantArea(w http.ResponseWriter, r *http.Request) {
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	areaID := chi.URLParam(r, "areaId")
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		return dbHelper.ArchiveRestaurantArea(tx, restaurant.ID, areaID)
+	})
+	if txErr != nil {
+		logrus.Errorf("Failed to remove Area: %s", txErr)
+		utils.RespondError(w, http.StatusInternalServerError, txErr, "Failed to remove Area")
+		return
+	}
+	logrus.Infof("Area removed successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Area removed successfully.",
+	})
+}
+
+func AddRestaurantTable(w http.ResponseWriter, r *http.Request) {
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	adminCtx := middlewares.UserContext(r)
+	var body models.AddTableBody
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+
+	if body.Name == "" {
+		logrus.Errorf("Name is required.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Name is required.")
+		return
+	}
+
+	if body.Seats <= 0 {
+		logrus.Errorf("Invalid Seats.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Seats.")
+		return
+	}
+
+	if body.AreaID != nil {
+		exists, existsErr := dbHelper.IsRestaurantAreaExists(restaurant.ID, *body.AreaID)
+		if existsErr != nil {
+			logrus.Errorf("Failed to check Area existence: %s", existsErr)
+			utils.RespondError(w, http.StatusInternalServerError, existsErr, "Failed to check Area existence")
+			return
+		}
+		if !exists {
+			logrus.Errorf("Area not exist: %s", *body.AreaID)
+			utils.RespondError(w, http.StatusBadRequest, nil, "Area not exist")
+			return
+		}
+	}
+
+	nonce, nonceErr := utils.GenerateToken(tableNonceLength)
+	if nonceErr != nil {
+		logrus.Errorf("Failed to generate QR nonce: %s", nonceErr)
+		utils.RespondError(w, http.StatusInternalServerError, nonceErr, "Failed to add Table")
+		return
+	}
+
+	if _, saveErr := dbHelper.CreateRestaurantTable(restaurant.ID, body.AreaID, body.Name, body.Seats, nonce, adminCtx.ID); saveErr != nil {
+		logrus.Errorf("Failed to add Table: %s", saveErr)
+		utils.RespondError(w, http.StatusInternalServerError, saveErr, "Failed to add Table")
+		return
+	}
+	logrus.Infof("Table added successfully.")
+	utils.RespondJSON(w, http.StatusCreated, models.Message{
+		Message: "Table added successfully.",
+	})
+}
+
+func GetRestaurantTables(w http.ResponseWriter, r *http.Request) {
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	tables, err := dbHelper.GetRestaurantTables(database.RMS, restaurant.ID)
+	if err != nil {
+		logrus.Errorf("Failed to get Tables: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to get Tables")
+		return
+	}
+	logrus.Infof("Get Tables successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetTables{
+		Message: "Get Tables successfully.",
+		Tables:  tables,
+	})
+}
+
+func RemoveRestaurantTable(w http.ResponseWriter, r *http.Request) {
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	tableID := chi.URLParam(r, "tableId")
+	if err := dbHelper.ArchiveRestaurantTable(restaurant.ID, tableID); err != nil {
+		logrus.Errorf("Failed to remove Table: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to remove Table")
+		return
+	}
+	logrus.Infof("Table removed successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Table removed successfully.",
+	})
+}
+
+// GenerateTableQR rotates the nonce of the table and returns its new signed QR token, the old codes stop working
+func GenerateTableQR(w http.ResponseWriter, r *http.Request) {
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	tableID := chi.URLParam(r, "tableId")
+	nonce, nonceErr := utils.GenerateToken(tableNonceLength)
+	if nonceErr != nil {
+		logrus.Errorf("Failed to generate QR nonce: %s", nonceErr)
+		utils.RespondError(w, http.StatusInternalServerError, nonceErr, "Failed to generate QR")
+		return
+	}
+	updated, updateErr := dbHelper.UpdateRestaurantTableNonce(restaurant.ID, tableID, nonce)
+	if updateErr != nil {
+		logrus.Errorf("Failed to generate QR: %s", updateErr)
+		utils.RespondError(w, http.StatusInternalServerError, updateErr, "Failed to generate QR")
+		return
+	}
+	if !updated {
+		logrus.Errorf("Table not exist: %s", tableID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Table not exist")
+		return
+	}
+	token, tokenErr := utils.TableQRToken(tableID, nonce)
+	if tokenErr != nil {
+		logrus.Errorf("Failed to sign QR: %s", tokenErr)
+		utils.RespondError(w, http.StatusInternalServerError, tokenErr, "Failed to generate QR")
+		return
+	}
+	logrus.Infof("QR generated successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.TableQR{
+		Message: "QR generated successfully.",
+		Token:   token,
+		URL:     fmt.Sprintf("%s%s?%s=%s", os.Getenv("APP_BASE_URL"), dineInQRPath, dineInQRQueryParam, url.QueryEscape(token)),
+	})
+}
+
+// Dine In Sessions
+
+// OpenDineInSession lets a guest that scanned a table QR join the open bill of the table, opening one when there is none
+func OpenDineInSession(w http.ResponseWriter, r *http.Request) {
+	var body models.OpenDineInBody
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+
+	tableID, nonce, parseErr := utils.ParseTableQRToken(body.QRToken)
+	if parseErr != nil {
+		logrus.Errorf("Invalid QR token: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Invalid QR code")
+		return
+	}
+
+	table, tableErr := dbHelper.GetRestaurantTableByID(tableID)
+	if tableErr != nil {
+		logrus.Errorf("Failed to get Table: %s", tableErr)
+		utils.RespondError(w, http.StatusInternalServerError, tableErr, "Failed to get Table")
+		return
+	}
+	if table == nil || table.QRNonce != nonce {
+		logrus.Errorf("QR code no longer valid for table: %s", tableID)
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid QR code")
+		return
+	}
+
+	token, tokenErr := utils.GenerateToken(dineInTokenLength)
+	if tokenErr != nil {
+		logrus.Errorf("Failed to generate session token: %s", tokenErr)
+		utils.RespondError(w, http.StatusInternalServerError, tokenErr, "Failed to open table session")
+		return
+	}
+
+	var session *models.DineInSession
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		var err error
+		session, err = dbHelper.OpenDineInSession(tx, table.RestaurantID, table.ID, token)
+		return err
+	})
+	if txErr != nil {
+		logrus.Errorf("Failed to open table session: %s", txErr)
+		utils.RespondError(w, http.StatusInternalServerError, txErr, "Failed to open table session")
+		return
+	}
+	logrus.Infof("Table session opened successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.OpenDineIn{
+		Message:      "Table session opened successfully.",
+		SessionToken: session.Token,
+		Session:      *session,
+	})
+}
+
+func GetDineInDishes(w http.ResponseWriter, r *http.Request) {
+	Filters := utils.GetDishFilters(r)
+	session := middlewares.DineInContext(r)
+	if !dishFiltersIn(w, session.RestaurantID, Filters) {
+		return
+	}
+	servedAt := time.Now()
+	Filters.ServedAt = &servedAt
+	DishesCount, DishesCountErr := dbHelper.GetRestaurantDishesCount(session.RestaurantID, Filters)
+	if DishesCountErr != nil {
+		logrus.Errorf("Failed to get Restaurant Dishes Count: %s", DishesCountErr)
+		utils.RespondError(w, http.StatusInternalServerError, DishesCountErr, "Failed to get Restaurant Dishes Count.")
+		return
+	}
+	Dishes, err := dbHelper.GetRestaurantDishes(session.RestaurantID, Filters)
+	if err != nil {
+		logrus.Errorf("Failed to get Restaurant Dishes: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to get Restaurant Dishes")
+		return
+	}
+	logrus.Infof("Get Dishes successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetDishes{
+		Message:    "Get Dishes successfully.",
+		Dishes:     Dishes,
+		TotalCount: DishesCount,
+		PageSize:   Filters.PageSize,
+		PageNumber: Filters.PageNumber,
+	})
+}
+
+// PlaceDineInOrder adds a guest order to the bill of the table session
+func PlaceDineInOrder(w http.ResponseWriter, r *http.Request) {
+	var body models.DineInOrderBody
+	session := middlewares.DineInContext(r)
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+
+	if len(body.Items) == 0 {
+		logrus.Errorf("Order must have at least one item.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Order must have at least one item.")
+		return
+	}
+
+	for _, item := range body.Items {
+		if item.Quantity <= 0 {
+			logrus.Errorf("Invalid Quantity.")
+			utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Quantity.")
+			return
+		}
+	}
+
+	var orderID string
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		// the session row stays locked so staff can't close the bill while the order is added to it
+		openSession, sessionErr := dbHelper.GetOpenDineInSessionByToken(tx, session.Token, true)
+		if sessionErr != nil {
+			return sessionErr
+		}
+		if openSession == nil {
+			return errDineInClosed
+		}
+		lines, itemsErr := buildOrderItems(tx, openSession.RestaurantID, body.Items, time.Now())
+		if itemsErr != nil {
+			return itemsErr
+		}
+		breakdown, quoteErr := quoteLines(tx, openSession.RestaurantID, lines, nil, 0, false)
+		if quoteErr != nil {
+			return quoteErr
+		}
+		order := &models.Order{
+			OrderType:       models.OrderDineIn,
+			DineInSessionID: &openSession.ID,
+			RestaurantID:    openSession.RestaurantID,
+			Status:          models.OrderPlaced,
+		}
+		applyBreakdown(order, breakdown)
+		var orderErr error
+		orderID, orderErr = dbHelper.CreateOrder(tx, order)
+		if orderErr != nil {
+			return orderErr
+		}
+		if itemsErr := dbHelper.CreateOrderItems(tx, orderID, breakdownItems(breakdown)); itemsErr != nil {
+			return itemsErr
+		}
+		if etaErr := eta.RefreshOrder(tx, orderID); etaErr != nil {
+			return etaErr
+		}
+		return orderstatus.EnqueuePlaced(tx, orderID)
+	})
+	if txErr != nil {
+		if errors.Is(txErr, errDineInClosed) {
+			logrus.Errorf("Failed to place order: %s", txErr)
+			utils.RespondError(w, http.StatusConflict, txErr, txErr.Error())
+			return
+		}
+		if errors.Is(txErr, errDishNotFound) || errors.Is(txErr, errDishOutOfStock) || errors.Is(txErr, errDishUnavailable) {
+			logrus.Errorf("Failed to place order: %s", txErr)
+			utils.RespondError(w, http.StatusBadRequest, txErr, txErr.Error())
+			return
+		}
+		logrus.Errorf("Failed to place order: %s", txErr)
+		utils.RespondError(w, http.StatusInternalServerError, txErr, "Failed to place order")
+		return
+	}
+
+	order, orderErr := dbHelper.GetOrderByID(database.RMS, orderID)
+	if orderErr != nil {
+		logrus.Errorf("Failed to get order: %s", orderErr)
+		utils.RespondError(w, http.StatusInternalServerError, orderErr, "Failed to get order")
+		return
+	}
+	publishOrderEvent(events.OrderPlaced, order.ID, order.UserID, order.RestaurantID, order.Status)
+	logrus.Infof("Order placed successfully.")
+	utils.RespondJSON(w, http.StatusCreated, models.PlaceOrder{
+		Message: "Order placed successfully.",
+		Order:   *order,
+	})
+}
+
+func GetDineInBill(w http.ResponseWriter, r *http.Request) {
+	session := middlewares.DineInContext(r)
+	orders, err := dbHelper.GetDineInSessionOrders(database.RMS, []string{session.ID})
+	if err != nil {
+		logrus.Errorf("Failed to get Bill: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to get Bill")
+		return
+	}
+	logrus.Infof("Get Bill successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetDineInBill{
+		Message: "Get Bill successfully.",
+		Bill:    newDineInBill(*session, orders),
+	})
+}
+
+// GetOpenDineInBills lists the running bills of the restaurant tables
+func GetOpenDineInBills(w http.ResponseWriter, r *http.Request) {
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	sessions, sessionsErr := dbHelper.GetOpenDineInSessions(restaurant.ID)
+	if sessionsErr != nil {
+		logrus.Errorf("Failed to get table sessions: %s", sessionsErr)
+		utils.RespondError(w, http.StatusInternalServerError, sessionsErr, "Failed to get table sessions")
+		return
+	}
+	sessionIDs := make([]string, 0, len(sessions))
+	for _, session := range sessions {
+		sessionIDs = append(sessionIDs, session.ID)
+	}
+	orders, ordersErr := dbHelper.GetDineInSessionOrders(database.RMS, sessionIDs)
+	if ordersErr != nil {
+		logrus.Errorf("Failed to get Bills: %s", ordersErr)
+		utils.RespondError(w, http.StatusInternalServerError, ordersErr, "Failed to get Bills")
+		return
+	}
+	bills := make([]models.DineInBill, 0, len(sessions))
+	for _, session := range sessions {
+		bills = append(bills, newDineInBill(session, orders))
+	}
+	logrus.Infof("Get Bills successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetDineInBills{
+		Message: "Get Bills successfully.",
+		Bills:   bills,
+	})
+}
+
+// CloseDineInSession settles the bill of a table, every order on it has to be served or cancelled first
+func CloseDineInSession(w http.ResponseWriter, r *http.Request) {
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	adminCtx := middlewares.UserContext(r)
+	sessionID := chi.URLParam(r, "sessionId")
+	var session *models.DineInSession
+	var orders []models.Order
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		var sessionErr error
+		session, sessionErr = dbHelper.GetDineInSessionByID(tx, restaurant.ID, sessionID, true)
+		if sessionErr != nil {
+			return sessionErr
+		}
+		if session == nil || session.ClosedAt != nil {
+			return errDineInClosed
+		}
+		var ordersErr error
+		orders, ordersErr = dbHelper.GetDineInSessionOrders(tx, []string{session.ID})
+		if ordersErr != nil {
+			return ordersErr
+		}
+		for _, order := range orders {
+			if order.Status != models.OrderDelivered && order.Status != models.OrderCancelled {
+				return fmt.Errorf("%w: order %s is %s", errDineInBusy, order.ID, order.Status)
+			}
+		}
+		closedAt, closeErr := dbHelper.CloseDineInSession(tx, session.ID, adminCtx.ID)
+		if closeErr != nil {
+			return closeErr
+		}
+		session.ClosedAt = &closedAt
+		return nil
+	})
+	if txErr != nil {
+		if errors.Is(txErr, errDineInClosed) {
+			logrus.Errorf("Failed to close table session: %s", txErr)
+			utils.RespondError(w, http.StatusNotFound, txErr, "Table session not exist")
+			return
+		}
+		if errors.Is(txErr, errDineInBusy) {
+			logrus.Errorf("Failed to close table session: %s", txErr)
+			utils.RespondError(w, http.StatusConflict, txErr, txErr.Error())
+			return
+		}
+		logrus.Errorf("Failed to close table session: %s", txErr)
+		utils.RespondError(w, http.StatusInternalServerError, txErr, "Failed to close table session")
+		return
+	}
+	logrus.Infof("Table session closed successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetDineInBill{
+		Message: "Table session closed successfully.",
+		Bill:    newDineInBill(*session, orders),
+	})
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"rms/database/dbHelper"
+	"rms/eta"
+	"rms/models"
+	"rms/utils"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// annotateRestaurantETAs sets the distance and delivery quote of every restaurant to the address
+func annotateRestaurantETAs(restaurants []models.Restaurant, address *models.UserAddress) error {
+	restaurantIDs := make([]string, 0, len(restaurants))
+	for _, restaurant := range restaurants {
+		restaurantIDs = append(restaurantIDs, restaurant.ID)
+	}
+	loads, err := dbHelper.GetKitchenLoads(restaurantIDs)
+	if err != nil {
+		return err
+	}
+	model := eta.Current()
+	for i := range restaurants {
+		km := utils.DistanceKm(restaurants[i].Lat, restaurants[i].Lng, address.Lat, address.Lng)
+		quote := model.Quote(loads[restaurants[i].ID], km)
+		restaurants[i].Distance = &km
+		restaurants[i].ETA = &quote
+	}
+	return nil
+}
+
+// refreshRiderETAs moves the ETA of the orders a rider is carrying after the rider moved
+func refreshRiderETAs(db sqlx.Ext, orders []models.Order) {
+	for _, order := range orders {
+		if order.Status != models.OrderOutForDelivery {
+			continue
+		}
+		if err := eta.RefreshOrder(db, order.ID); err != nil {
+			logrus.Errorf("Failed to refresh ETA of order %s: %s", order.ID, err)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"net/http"
+	"rms/database"
+	"rms/database/dbHelper"
+	"rms/middlewares"
+	"rms/models"
+	"rms/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// flagFavouriteRestaurants marks the restaurants of a listing the customer favourited
+func flagFavouriteRestaurants(userID string, restaurants []models.Restaurant) error {
+	restaurantIDs := make([]string, 0, len(restaurants))
+	for _, restaurant := range restaurants {
+		restaurantIDs = append(restaurantIDs, restaurant.ID)
+	}
+	favourites, err := dbHelper.GetFavouriteRestaurantIDs(userID, restaurantIDs)
+	if err != nil {
+		return err
+	}
+	for i := range restaurants {
+		restaurants[i].Favourite = favourites[restaurants[i].ID]
+	}
+	return nil
+}
+
+// flagFavouriteDishes marks the dishes of a listing the customer favourited
+func flagFavouriteDishes(userID string, dishes []models.Dishes) error {
+	dishIDs := make([]string, 0, len(dishes))
+	for _, dish := range dishes {
+		dishIDs = append(dishIDs, dish.ID)
+	}
+	favourites, err := dbHelper.GetFavouriteDishIDs(userID, dishIDs)
+	if err != nil {
+		return err
+	}
+	for i := range dishes {
+		dishes[i].Favourite = favourites[dishes[i].ID]
+	}
+	return nil
+}
+
+func GetMyFavourites(w http.ResponseWriter, r *http.Request) {
+	userCtx := middlewares.UserContext(r)
+	restaurants, restaurantsErr := dbHelper.GetFavouriteRestaurants(userCtx.ID)
+	if restaurantsErr != nil {
+		logrus.Errorf("Failed to get Favourite Restaurants: %s", restaurantsErr)
+		utils.RespondError(w, http.StatusInternalServerError, restaurantsErr, "Failed to get Favourites")
+		return
+	}
+	dishes, dishesErr := dbHelper.GetFavouriteDishes(userCtx.ID)
+	if dishesErr != nil {
+		logrus.Errorf("Failed to get Favourite Dishes: %s", dishesErr)
+		utils.RespondError(w, http.StatusInternalServerError, dishesErr, "Failed to get Favourites")
+		return
+	}
+	logrus.Infof("Get Favourites successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetFavourites{
+		Message:     "Get Favourites successfully.",
+		Restaurants: restaurants,
+		Dishes:      dishes,
+	})
+}
+
+func AddFavouriteRestaurant(w http.ResponseWriter, r *http.Request) {
+	restaurantID := chi.URLParam(r, "restaurantId")
+	userCtx := middlewares.UserContext(r)
+	exists, existsErr := dbHelper.IsRestaurantIDExists(restaurantID)
+	if existsErr != nil {
+		logrus.Errorf("Failed to check Restaurant existence: %s", existsErr)
+		utils.RespondError(w, http.StatusInternalServerError, existsErr, "Failed to check Restaurant existence")
+		return
+	}
+	if !exists {
+		logrus.Errorf("Restaurant not exist: %s", restaurantID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Restaurant not exist")
+		return
+	}
+	if err := dbHelper.AddFavouriteRestaurant(database.RMS, userCtx.ID, restaurantID); err != nil {
+		logrus.Errorf("Failed to add Favourite Restaurant: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to add Favourite Restaurant")
+		return
+	}
+	logrus.Infof("Favourite Restaurant added successfully.")
+	utils.RespondJSON(w, http.StatusCreated, models.Message{
+		Message: "Favourite Restaurant added successfully.",
+	})
+}
+
+func RemoveFavouriteRestaurant(w http.ResponseWriter, r *http.Request) {
+	restaurantID := chi.URLParam(r, "restaurantId")
+	userCtx := middlewares.UserContext(r)
+	removed, err := dbHelper.RemoveFavouriteRestaurant(database.RMS, userCtx.ID, restaurantID)
+	if err != nil {
+		logrus.Errorf("Failed to remove Favourite Restaurant: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to remove Favourite Restaurant")
+		return
+	}
+	if !removed {
+		logrus.Errorf("Favourite Restaurant not exist: %s", restaurantID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Favourite Restaurant not exist")
+		return
+	}
+	logrus.Infof("Favourite Restaurant removed successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Favourite Restaurant removed successfully.",
+	})
+}
+
+func AddFavouriteDish(w http.ResponseWriter, r *http.Request) {
+	dishID := chi.URLParam(r, "dishId")
+	userCtx := middlewares.UserContext(r)
+	dish, dishErr := dbHelper.GetDishByID(dishID)
+	if dishErr != nil {
+		logrus.Errorf("Failed to get Dish: %s", dishErr)
+		utils.RespondError(w, http.StatusInternalServerError, dishErr, "Failed to get Dish")
+		return
+	}
+	if dish == nil {
+		logrus.Errorf("Dish not exist: %s", dishID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Dish not exist")
+		return
+	}
+	if err := dbHelper.AddFavouriteDish(database.RMS, userCtx.ID, dishID); err != nil {
+		logrus.Errorf("Failed to add Favourite Dish: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to add Favourite Dish")
+		return
+	}
+	logrus.Infof("Favourite Dish added successfully.")
+	utils.RespondJSON(w, http.StatusCreated, models.Message{
+		Message: "Favourite Dish added successfully.",
+	})
+}
+
+func RemoveFavouriteDish(w http.ResponseWriter, r *http.Request) {
+	dishID := chi.URLParam(r, "dishId")
+	userCtx := middlewares.UserContext(r)
+	removed, err := dbHelper.RemoveFavouriteDish(database.RMS, userCtx.ID, dishID)
+	if err != nil {
+		logrus.Errorf("Failed to remove Favourite Dish: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to remove Favourite Dish")
+		return
+	}
+	if !removed {
+		logrus.Errorf("Favourite Dish not exist: %s", dishID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Favourite Dish not exist")
+		return
+	}
+	logrus.Infof("Favourite Dish removed successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Favourite Dish removed successfully.",
+	})
+}
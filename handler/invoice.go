@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"rms/database"
+	"rms/database/dbHelper"
+	"rms/invoice"
+	"rms/middlewares"
+	"rms/models"
+	"rms/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+var errInvoiceUnavailable = errors.New("invoices are only issued for delivered orders")
+
+// invoiceFiscalYear returns the fiscal year a date falls in with its label, the year starts in the month set by
+// INVOICE_FISCAL_YEAR_START_MONTH, April when unset, and is named after the calendar year it starts in
+func invoiceFiscalYear(date time.Time) (int, string) {
+	startMonth := time.April
+	if month, err := strconv.Atoi(os.Getenv("INVOICE_FISCAL_YEAR_START_MONTH")); err == nil && month >= 1 && month <= 12 {
+		startMonth = time.Month(month)
+	}
+	year := date.Year()
+	if date.Month() < startMonth {
+		year--
+	}
+	if startMonth == time.January {
+		return year, strconv.Itoa(year)
+	}
+	return year, fmt.Sprintf("%d-%02d", year, (year+1)%100)
+}
+
+// orderBreakdown is the price breakdown of the order, orders priced before breakdowns were kept get one from their
+// totals
+func orderBreakdown(order *models.Order, currency string) models.PriceBreakdown {
+	if order.Breakdown != nil {
+		breakdown := *order.Breakdown
+		if breakdown.Currency == "" {
+			breakdown.Currency = currency
+		}
+		return breakdown
+	}
+	items := make([]models.PriceLine, 0, len(order.Items))
+	for _, item := range order.Items {
+		items = append(items, models.PriceLine{OrderItem: item})
+	}
+	return models.PriceBreakdown{
+		Currency:       currency,
+		Items:          items,
+		SubTotal:       order.SubTotal,
+		DishDiscount:   order.Discount,
+		CouponDiscount: order.CouponDiscount,
+		Packaging:      order.PackagingCharge,
+		DeliveryFee:    order.DeliveryFee,
+		Taxes:          make([]models.TaxLine, 0),
+		Tax:            order.Tax,
+		TaxIncluded:    order.TaxIncluded,
+		Rounding:       order.Rounding,
+		GrandTotal:     order.Total + order.PointsRedeemed,
+		PointsRedeemed: order.PointsRedeemed,
+		Total:          order.Total,
+	}
+}
+
+// issueInvoice numbers and saves the invoice of a delivered order, an order that has one keeps it. The order has to
+// be locked so two requests cannot both number it
+func issueInvoice(tx *sqlx.Tx, orderID string) (*models.Invoice, error) {
+	existing, err := dbHelper.GetInvoiceByOrderID(tx, orderID)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+	order, err := dbHelper.GetOrderByID(tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	if order == nil || order.Status != models.OrderDelivered {
+		return nil, errInvoiceUnavailable
+	}
+	restaurant, err := dbHelper.GetInvoiceRestaurant(tx, order.RestaurantID)
+	if err != nil {
+		return nil, err
+	}
+	if restaurant == nil {
+		return nil, errRestaurantNotFound
+	}
+	var customer *models.InvoiceCustomer
+	if order.UserID != "" {
+		customer, err = dbHelper.GetInvoiceCustomer(tx, order.UserID, order.AddressID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	timezone, err := dbHelper.GetRestaurantTimezone(tx, order.RestaurantID)
+	if err != nil {
+		return nil, err
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, err
+	}
+	issuedAt := time.Now().Truncate(time.Second)
+	// the fiscal year turns at midnight where the restaurant is, not where the server runs
+	fiscalYear, fiscalYearLabel := invoiceFiscalYear(issuedAt.In(location))
+	sequence, err := dbHelper.NextInvoiceSequence(tx, order.RestaurantID, fiscalYear)
+	if err != nil {
+		return nil, err
+	}
+	issued := &models.Invoice{
+		OrderID:      order.ID,
+		RestaurantID: order.RestaurantID,
+		FiscalYear:   fiscalYear,
+		Sequence:     sequence,
+		Number:       fmt.Sprintf("INV/%s/%06d", fiscalYearLabel, sequence),
+		Details: models.InvoiceDetails{
+			Restaurant: *restaurant,
+			Customer:   customer,
+			OrderType:  order.OrderType,
+			OrderedAt:  order.CreatedAt,
+			Breakdown:  orderBreakdown(order, restaurant.Currency),
+		},
+		IssuedAt: issuedAt,
+	}
+	issued.ID, err = dbHelper.CreateInvoice(tx, issued)
+	if err != nil {
+		return nil, err
+	}
+	return issued, nil
+}
+
+// getOrderInvoice returns the invoice of the order, issuing it for orders delivered before invoices were kept
+func getOrderInvoice(orderID string) (*models.Invoice, error) {
+	existing, err := dbHelper.GetInvoiceByOrderID(database.RMS, orderID)
+	if err != nil || existing != nil {
+		return existing, err
+	}
+	var issued *models.Invoice
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		if lockErr := dbHelper.LockOrder(tx, orderID); lockErr != nil {
+			return lockErr
+		}
+		var issueErr error
+		issued, issueErr = issueInvoice(tx, orderID)
+		return issueErr
+	})
+	return issued, txErr
+}
+
+func GetMyOrderInvoice(w http.ResponseWriter, r *http.Request) {
+	orderID := chi.URLParam(r, "orderId")
+	userCtx := middlewares.UserContext(r)
+	order, orderErr := dbHelper.GetOrderByID(database.RMS, orderID)
+	if orderErr != nil {
+		logrus.Errorf("Failed to get order: %s", orderErr)
+		utils.RespondError(w, http.StatusInternalServerError, orderErr, "Failed to get order")
+		return
+	}
+	if order == nil || order.UserID != userCtx.ID {
+		logrus.Errorf("Order not exist: %s", orderID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Order not exist")
+		return
+	}
+	respondInvoice(w, r, order)
+}
+
+func GetOrderInvoice(w http.ResponseWriter, r *http.Request) {
+	restaurantID := chi.URLParam(r, "restaurantId")
+	orderID := chi.URLParam(r, "orderId")
+	if _, ok := getManagedRestaurant(w, r); !ok {
+		return
+	}
+	order, orderErr := dbHelper.GetOrderByID(database.RMS, orderID)
+	if orderErr != nil {
+		logrus.Errorf("Failed to get order: %s", orderErr)
+		utils.RespondError(w, http.StatusInternalServerError, orderErr, "Failed to get order")
+		return
+	}
+	if order == nil || order.RestaurantID != restaurantID {
+		logrus.Errorf("Order not exist: %s", orderID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Order not exist")
+		return
+	}
+	respondInvoice(w, r, order)
+}
+
+// respondInvoice sends the invoice of the order in the format asked for, JSON unless the format query says text,
+// html or pdf
+func respondInvoice(w http.ResponseWriter, r *http.Request, order *models.Order) {
+	format := models.InvoiceFormat(strings.ToLower(r.URL.Query().Get("format")))
+	if format == "" {
+		format = models.InvoiceJSON
+	}
+	if !format.IsValid() {
+		logrus.Errorf("Invalid Invoice Format: %s", format)
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Invoice Format.")
+		return
+	}
+	if order.Status != models.OrderDelivered {
+		logrus.Errorf("Invoice not available for order: %s", order.ID)
+		utils.RespondError(w, http.StatusConflict, errInvoiceUnavailable, errInvoiceUnavailable.Error())
+		return
+	}
+	issued, err := getOrderInvoice(order.ID)
+	if err != nil {
+		logrus.Errorf("Failed to get invoice: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to get invoice")
+		return
+	}
+	filename := strings.ReplaceAll(issued.Number, "/", "-")
+	switch format {
+	case models.InvoiceText:
+		utils.RespondFile(w, "text/plain; charset=utf-8", filename+".txt", []byte(invoice.Text(*issued)))
+	case models.InvoiceHTML:
+		page, htmlErr := invoice.HTML(*issued)
+		if htmlErr != nil {
+			logrus.Errorf("Failed to render invoice: %s", htmlErr)
+			utils.RespondError(w, http.StatusInternalServerError, htmlErr, "Failed to render invoice")
+			return
+		}
+		utils.RespondFile(w, "text/html; charset=utf-8", filename+".html", page)
+	case models.InvoicePDF:
+		utils.RespondFile(w, "application/pdf", filename+".pdf", invoice.PDF(*issued))
+	default:
+		logrus.Infof("Get Invoice successfully.")
+		utils.RespondJSON(w, http.StatusOK, models.GetInvoice{
+			Message: "Get Invoice successfully.",
+			Invoice: *issued,
+		})
+	}
+}
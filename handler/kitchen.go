@@ -0,0 +1,361 @@
+package handler
+
+import (
+	"net/http"
+	"rms/database"
+	"rms/database/dbHelper"
+	"rms/events"
+	"rms/middlewares"
+	"rms/models"
+	"rms/utils"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultStation         = "main"
+	bumpedTicketsLimit     = 20
+	openTicketsLimit       = 200
+	defaultPrepMetricsDays = 30
+)
+
+// kitchenStations regroups the open items of the tickets per station, the way the station screens show them
+func kitchenStations(tickets []models.KitchenTicket) []models.KitchenStation {
+	stations := make([]models.KitchenStation, 0)
+	stationIndex := make(map[string]int)
+	for _, ticket := range tickets {
+		for _, item := range ticket.Items {
+			if item.BumpedAt != nil {
+				continue
+			}
+			index, ok := stationIndex[item.Station]
+			if !ok {
+				index = len(stations)
+				stationIndex[item.Station] = index
+				stations = append(stations, models.KitchenStation{Station: item.Station})
+			}
+			stations[index].Items = append(stations[index].Items, item)
+		}
+	}
+	return stations
+}
+
+func publishTicketEvent(restaurantID, eventType, ticketID, orderID string) {
+	events.Publish(events.KitchenTopic(restaurantID), eventType, events.TicketData{TicketID: ticketID, OrderID: orderID})
+}
+
+// GetKitchenTickets lists the open tickets, bumped=true lists the latest bumped ones to recall from
+func GetKitchenTickets(w http.ResponseWriter, r *http.Request) {
+	restaurantID := chi.URLParam(r, "restaurantId")
+	bumped := r.URL.Query().Get("bumped") == "true"
+	limit := int64(openTicketsLimit)
+	if bumped {
+		limit = bumpedTicketsLimit
+	}
+	tickets, err := dbHelper.GetKitchenTickets(restaurantID, bumped, limit)
+	if err != nil {
+		logrus.Errorf("Unable to get Kitchen Tickets: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Unable to get Kitchen Tickets")
+		return
+	}
+	logrus.Infof("Get Kitchen Tickets successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetKitchenTickets{
+		Message:  "Get Kitchen Tickets successfully.",
+		Tickets:  tickets,
+		Stations: kitchenStations(tickets),
+	})
+}
+
+// StreamKitchenTickets is the live feed of ticket changes of the kitchen
+func StreamKitchenTickets(w http.ResponseWriter, r *http.Request) {
+	streamTopics(w, r, events.KitchenTopic(chi.URLParam(r, "restaurantId")))
+}
+
+func BumpKitchenItem(w http.ResponseWriter, r *http.Request) {
+	restaurantID := chi.URLParam(r, "restaurantId")
+	itemID := chi.URLParam(r, "itemId")
+	var ticketID string
+	err := database.Tx(func(tx *sqlx.Tx) error {
+		var bumpErr error
+		ticketID, bumpErr = dbHelper.BumpKitchenItem(tx, restaurantID, itemID)
+		return bumpErr
+	})
+	if err != nil {
+		logrus.Errorf("Failed to bump item: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to bump item")
+		return
+	}
+	if ticketID == "" {
+		logrus.Errorf("Open item not exist: %s", itemID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Open item not exist")
+		return
+	}
+	publishTicketEvent(restaurantID, events.TicketUpdated, ticketID, "")
+	logrus.Infof("Item bumped successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Item bumped successfully.",
+	})
+}
+
+func RecallKitchenItem(w http.ResponseWriter, r *http.Request) {
+	restaurantID := chi.URLParam(r, "restaurantId")
+	itemID := chi.URLParam(r, "itemId")
+	var ticketID string
+	err := database.Tx(func(tx *sqlx.Tx) error {
+		var recallErr error
+		ticketID, recallErr = dbHelper.RecallKitchenItem(tx, restaurantID, itemID)
+		return recallErr
+	})
+	if err != nil {
+		logrus.Errorf("Failed to recall item: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to recall item")
+		return
+	}
+	if ticketID == "" {
+		logrus.Errorf("Bumped item not exist: %s", itemID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Bumped item not exist")
+		return
+	}
+	publishTicketEvent(restaurantID, events.TicketUpdated, ticketID, "")
+	logrus.Infof("Item recalled successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Item recalled successfully.",
+	})
+}
+
+func BumpKitchenTicket(w http.ResponseWriter, r *http.Request) {
+	restaurantID := chi.URLParam(r, "restaurantId")
+	ticketID := chi.URLParam(r, "ticketId")
+	var bumped bool
+	err := database.Tx(func(tx *sqlx.Tx) error {
+		var bumpErr error
+		bumped, bumpErr = dbHelper.BumpKitchenTicket(tx, restaurantID, ticketID)
+		return bumpErr
+	})
+	if err != nil {
+		logrus.Errorf("Failed to bump ticket: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to bump ticket")
+		return
+	}
+	if !bumped {
+		logrus.Errorf("Open ticket not exist: %s", ticketID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Open ticket not exist")
+		return
+	}
+	publishTicketEvent(restaurantID, events.TicketUpdated, ticketID, "")
+	logrus.Infof("Ticket bumped successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Ticket bumped successfully.",
+	})
+}
+
+func RecallKitchenTicket(w http.ResponseWriter, r *http.Request) {
+	restaurantID := chi.URLParam(r, "restaurantId")
+	ticketID := chi.URLParam(r, "ticketId")
+	var recalled bool
+	err := database.Tx(func(tx *sqlx.Tx) error {
+		var recallErr error
+		recalled, recallErr = dbHelper.RecallKitchenTicket(tx, restaurantID, ticketID)
+		return recallErr
+	})
+	if err != nil {
+		logrus.Errorf("Failed to recall ticket: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to recall ticket")
+		return
+	}
+	if !recalled {
+		logrus.Errorf("Bumped ticket not exist: %s", ticketID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Bumped ticket not exist")
+		return
+	}
+	publishTicketEvent(restaurantID, events.TicketUpdated, ticketID, "")
+	logrus.Infof("Ticket recalled successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Ticket recalled successfully.",
+	})
+}
+
+// GetDishPrepTimes reports how long every dish takes to prepare over the last days, 30 by default
+func GetDishPrepTimes(w http.ResponseWriter, r *http.Request) {
+	restaurantID := chi.URLParam(r, "restaurantId")
+	days := int64(defaultPrepMetricsDays)
+	if daysParam := r.URL.Query().Get("days"); daysParam != "" {
+		parsed, parseErr := strconv.ParseInt(daysParam, 10, 64)
+		if parseErr != nil || parsed <= 0 {
+			logrus.Errorf("Invalid Days: %s", daysParam)
+			utils.RespondError(w, http.StatusBadRequest, parseErr, "Invalid Days.")
+			return
+		}
+		days = parsed
+	}
+	prepTimes, err := dbHelper.GetDishPrepTimes(restaurantID, time.Now().AddDate(0, 0, -int(days)))
+	if err != nil {
+		logrus.Errorf("Unable to get Dish Prep Times: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Unable to get Dish Prep Times")
+		return
+	}
+	logrus.Infof("Get Dish Prep Times successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetDishPrepTimes{
+		Message: "Get Dish Prep Times successfully.",
+		Dishes:  prepTimes,
+	})
+}
+
+// addRestaurantStaff gives an account the staff role at the managed restaurant, creating the account if needed
+func addRestaurantStaff(w http.ResponseWriter, r *http.Request, role models.Role) {
+	var body models.AddStaffBody
+	adminCtx := middlewares.UserContext(r)
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+	if body.Name == "" {
+		logrus.Errorf("Invalid Name.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Name.")
+		return
+	}
+	if !utils.IsEmailValid(body.Email) {
+		logrus.Errorf("Invalid Email.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Email.")
+		return
+	}
+	if len(body.Password) < 6 {
+		logrus.Errorf("password must be 6 chars long")
+		utils.RespondError(w, http.StatusBadRequest, nil, "password must be 6 chars long")
+		return
+	}
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+
+	userID, existsErr := dbHelper.IsUserExists(body.Email)
+	if existsErr != nil {
+		logrus.Errorf("Failed to check user existence: %s", existsErr)
+		utils.RespondError(w, http.StatusInternalServerError, existsErr, "Failed to check user existence")
+		return
+	}
+	hasRole := false
+	if userID != "" {
+		isStaff, staffErr := dbHelper.IsRestaurantStaff(restaurant.ID, userID, role)
+		if staffErr != nil {
+			logrus.Errorf("Failed to check restaurant staff: %s", staffErr)
+			utils.RespondError(w, http.StatusInternalServerError, staffErr, "Failed to check restaurant staff")
+			return
+		}
+		if isStaff {
+			logrus.Errorf("staff already exists")
+			utils.RespondError(w, http.StatusConflict, nil, "staff already exists")
+			return
+		}
+		var roleErr error
+		hasRole, roleErr = dbHelper.IsUserRoleWithUserIDExists(userID, role)
+		if roleErr != nil {
+			logrus.Errorf("Failed to check user role existence: %s", roleErr)
+			utils.RespondError(w, http.StatusInternalServerError, roleErr, "Failed to check user role existence")
+			return
+		}
+	}
+	hashedPassword, hashErr := utils.HashPassword(body.Password)
+	if hashErr != nil {
+		logrus.Errorf("Failed to secure password: %s", hashErr)
+		utils.RespondError(w, http.StatusInternalServerError, hashErr, "Failed to secure password")
+		return
+	}
+
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		if userID == "" {
+			var saveErr error
+			userID, saveErr = dbHelper.CreateUser(tx, body.Name, body.Email, hashedPassword)
+			if saveErr != nil {
+				return saveErr
+			}
+		}
+		if !hasRole {
+			if roleErr := dbHelper.CreateUserRole(tx, userID, adminCtx.ID, role); roleErr != nil {
+				return roleErr
+			}
+		}
+		return dbHelper.AddRestaurantStaff(tx, restaurant.ID, userID, adminCtx.ID, role)
+	})
+	if txErr != nil {
+		logrus.Errorf("Failed to add staff: %s", txErr)
+		utils.RespondError(w, http.StatusInternalServerError, txErr, "Failed to add staff")
+		return
+	}
+	logrus.Infof("Staff added successfully.")
+	utils.RespondJSON(w, http.StatusCreated, models.Message{
+		Message: "Staff added successfully.",
+	})
+}
+
+func getRestaurantStaff(w http.ResponseWriter, r *http.Request, role models.Role) {
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	staff, err := dbHelper.GetRestaurantStaff(restaurant.ID, role)
+	if err != nil {
+		logrus.Errorf("Unable to get Staff: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Unable to get Staff")
+		return
+	}
+	logrus.Infof("Get Staff successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetStaff{
+		Message: "Get Staff successfully.",
+		Staff:   staff,
+	})
+}
+
+// removeRestaurantStaff takes the user off the restaurant, the role goes away with the last restaurant
+func removeRestaurantStaff(w http.ResponseWriter, r *http.Request, role models.Role) {
+	userID := chi.URLParam(r, "userId")
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	var removed bool
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		var removeErr error
+		removed, removeErr = dbHelper.RemoveRestaurantStaff(tx, restaurant.ID, userID, role)
+		if removeErr != nil || !removed {
+			return removeErr
+		}
+		stillStaff, staffErr := dbHelper.IsUserInAnyRestaurantStaff(tx, userID, role)
+		if staffErr != nil || stillStaff {
+			return staffErr
+		}
+		return dbHelper.RemoveRole(tx, userID, role)
+	})
+	if txErr != nil {
+		logrus.Errorf("Failed to remove staff: %s", txErr)
+		utils.RespondError(w, http.StatusInternalServerError, txErr, "Failed to remove staff")
+		return
+	}
+	if !removed {
+		logrus.Errorf("Staff not exist: %s", userID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Staff not exist")
+		return
+	}
+	logrus.Infof("Staff removed successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Staff removed successfully.",
+	})
+}
+
+func AddKitchenStaff(w http.ResponseWriter, r *http.Request) {
+	addRestaurantStaff(w, r, models.RoleKitchen)
+}
+
+func GetKitchenStaff(w http.ResponseWriter, r *http.Request) {
+	getRestaurantStaff(w, r, models.RoleKitchen)
+}
+
+func RemoveKitchenStaff(w http.ResponseWriter, r *http.Request) {
+	removeRestaurantStaff(w, r, models.RoleKitchen)
+}
@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"rms/database"
+	"rms/database/dbHelper"
+	"rms/middlewares"
+	"rms/models"
+	"rms/utils"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// loyaltyAmountPerPoint is the order total that earns one point, one point redeems one unit of currency
+	loyaltyAmountPerPoint = 10
+	loyaltyPointsTTL      = 365 * 24 * time.Hour
+)
+
+var errInsufficientPoints = errors.New("insufficient loyalty points")
+
+// checkRedeemablePoints locks the loyalty of the user and makes sure the points can be spent on the amount
+func checkRedeemablePoints(tx *sqlx.Tx, userID string, points, amount int64) error {
+	if lockErr := dbHelper.LockUserLoyalty(tx, userID); lockErr != nil {
+		return lockErr
+	}
+	balance, balanceErr := dbHelper.GetLoyaltyBalance(tx, userID)
+	if balanceErr != nil {
+		return balanceErr
+	}
+	if points > balance {
+		return fmt.Errorf("%w: balance is %d", errInsufficientPoints, balance)
+	}
+	if points > amount {
+		return fmt.Errorf("%w: at most %d points can be redeemed on this order", errInsufficientPoints, amount)
+	}
+	return nil
+}
+
+// earnLoyaltyPoints credits the customer of a delivered order, guest orders earn nothing
+func earnLoyaltyPoints(tx *sqlx.Tx, order *models.Order) error {
+	points := order.Total / loyaltyAmountPerPoint
+	if points <= 0 || order.UserID == "" {
+		return nil
+	}
+	expiresAt := time.Now().Add(loyaltyPointsTTL)
+	return dbHelper.PostLoyaltyTransaction(tx, order.UserID, models.LoyaltyEarn, points, &order.ID, nil, "", &expiresAt)
+}
+
+func GetMyLoyalty(w http.ResponseWriter, r *http.Request) {
+	Filters := utils.GetFilters(r)
+	userCtx := middlewares.UserContext(r)
+	var balance, historyCount int64
+	history := make([]models.LoyaltyEntry, 0)
+	var errGroup errgroup.Group
+	errGroup.Go(func() error {
+		var err error
+		balance, err = dbHelper.GetLoyaltyBalance(database.RMS, userCtx.ID)
+		if err != nil {
+			logrus.Errorf("Unable to get Loyalty Balance: %s", err)
+		}
+		return err
+	})
+	errGroup.Go(func() error {
+		var err error
+		historyCount, err = dbHelper.GetLoyaltyHistoryCount(userCtx.ID)
+		if err != nil {
+			logrus.Errorf("Unable to get Loyalty History Count: %s", err)
+		}
+		return err
+	})
+	errGroup.Go(func() error {
+		var err error
+		history, err = dbHelper.GetLoyaltyHistory(userCtx.ID, Filters)
+		if err != nil {
+			logrus.Errorf("Unable to get Loyalty History: %s", err)
+		}
+		return err
+	})
+	if err := errGroup.Wait(); err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "Unable to get Loyalty")
+		return
+	}
+	logrus.Infof("Get Loyalty successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetLoyalty{
+		Message:    "Get Loyalty successfully.",
+		Balance:    balance,
+		History:    history,
+		TotalCount: historyCount,
+		PageNumber: Filters.PageNumber,
+		PageSize:   Filters.PageSize,
+	})
+}
+
+func AdjustUserLoyalty(w http.ResponseWriter, r *http.Request) {
+	userID := chi.URLParam(r, "userId")
+	adminCtx := middlewares.UserContext(r)
+	var body models.AdjustLoyaltyBody
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+
+	if body.Points == 0 {
+		logrus.Errorf("Invalid Points.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Points.")
+		return
+	}
+
+	if body.Reason == "" {
+		logrus.Errorf("Reason is required.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Reason is required.")
+		return
+	}
+
+	exists, existsErr := dbHelper.IsUserRoleWithUserIDExists(userID, models.RoleUser)
+	if existsErr != nil {
+		logrus.Errorf("Failed to check User existence: %s", existsErr)
+		utils.RespondError(w, http.StatusInternalServerError, existsErr, "Failed to check User existence")
+		return
+	}
+	if !exists {
+		logrus.Errorf("User not exist: %s", userID)
+		utils.RespondError(w, http.StatusNotFound, nil, "User not exist")
+		return
+	}
+
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		if body.Points < 0 {
+			if checkErr := checkRedeemablePoints(tx, userID, -body.Points, -body.Points); checkErr != nil {
+				return checkErr
+			}
+		} else if lockErr := dbHelper.LockUserLoyalty(tx, userID); lockErr != nil {
+			return lockErr
+		}
+		return dbHelper.PostLoyaltyTransaction(tx, userID, models.LoyaltyAdjust, body.Points, nil, &adminCtx.ID, body.Reason, nil)
+	})
+	if txErr != nil {
+		if errors.Is(txErr, errInsufficientPoints) {
+			logrus.Errorf("Failed to adjust loyalty: %s", txErr)
+			utils.RespondError(w, http.StatusBadRequest, txErr, txErr.Error())
+			return
+		}
+		logrus.Errorf("Failed to adjust loyalty: %s", txErr)
+		utils.RespondError(w, http.StatusInternalServerError, txErr, "Failed to adjust loyalty")
+		return
+	}
+	logrus.Infof("Loyalty adjusted successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Loyalty adjusted successfully.",
+	})
+}
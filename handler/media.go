@@ -0,0 +1,284 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"rms/database"
+	"rms/database/dbHelper"
+	"rms/media"
+	"rms/middlewares"
+	"rms/models"
+	"rms/utils"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	imageUploadField       = "image"
+	defaultMaxUploadBytes  = 5 << 20
+	imageUploadMemoryBytes = 1 << 20
+	// multipartOverhead leaves room in the request for the boundaries and headers around the file
+	multipartOverhead = 64 << 10
+	mediaTimeout      = time.Minute
+)
+
+var errImageTooBig = errors.New("image is too big")
+
+// maxUploadBytes is the size limit of an uploaded image, MEDIA_MAX_UPLOAD_BYTES or 5 MiB
+func maxUploadBytes() int64 {
+	if limit, err := strconv.ParseInt(os.Getenv("MEDIA_MAX_UPLOAD_BYTES"), 10, 64); err == nil && limit > 0 {
+		return limit
+	}
+	return defaultMaxUploadBytes
+}
+
+// readImageUpload reads the image part of a multipart request, it responds with the error itself
+func readImageUpload(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	limit := maxUploadBytes()
+	if r.ContentLength > limit+multipartOverhead {
+		logrus.Errorf("Upload is over %d bytes.", limit)
+		utils.RespondError(w, http.StatusRequestEntityTooLarge, errImageTooBig, "Image can be at most "+strconv.FormatInt(limit, 10)+" bytes.")
+		return nil, false
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, limit+multipartOverhead)
+	if parseErr := r.ParseMultipartForm(imageUploadMemoryBytes); parseErr != nil {
+		logrus.Errorf("Failed to parse upload: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Upload the image as a multipart form of at most "+strconv.FormatInt(limit, 10)+" bytes.")
+		return nil, false
+	}
+	defer func() {
+		_ = r.MultipartForm.RemoveAll()
+	}()
+	file, _, fileErr := r.FormFile(imageUploadField)
+	if fileErr != nil {
+		logrus.Errorf("Failed to get uploaded image: %s", fileErr)
+		utils.RespondError(w, http.StatusBadRequest, fileErr, "Upload the image in the image field.")
+		return nil, false
+	}
+	defer file.Close()
+	data, readErr := ioutil.ReadAll(io.LimitReader(file, limit+1))
+	if readErr != nil {
+		logrus.Errorf("Failed to read uploaded image: %s", readErr)
+		utils.RespondError(w, http.StatusBadRequest, readErr, "Failed to read uploaded image")
+		return nil, false
+	}
+	if int64(len(data)) > limit {
+		logrus.Errorf("Uploaded image is over %d bytes.", limit)
+		utils.RespondError(w, http.StatusRequestEntityTooLarge, errImageTooBig, "Image can be at most "+strconv.FormatInt(limit, 10)+" bytes.")
+		return nil, false
+	}
+	return data, true
+}
+
+// storeImage renders the variants of an upload and puts them under the prefix with a fresh token, a new upload never
+// reuses the keys of an old one so cached copies can't go stale. It responds with the error itself
+func storeImage(w http.ResponseWriter, store media.BlobStore, prefix string, data []byte) (*models.Image, bool) {
+	renditions, processErr := media.Process(data)
+	if processErr != nil {
+		if errors.Is(processErr, media.ErrUnsupportedImage) || errors.Is(processErr, media.ErrImageTooLarge) {
+			logrus.Errorf("Invalid image: %s", processErr)
+			utils.RespondError(w, http.StatusUnsupportedMediaType, processErr, processErr.Error())
+			return nil, false
+		}
+		logrus.Errorf("Failed to process image: %s", processErr)
+		utils.RespondError(w, http.StatusInternalServerError, processErr, "Failed to process image")
+		return nil, false
+	}
+	token, tokenErr := utils.GenerateToken(8)
+	if tokenErr != nil {
+		logrus.Errorf("Failed to generate image key: %s", tokenErr)
+		utils.RespondError(w, http.StatusInternalServerError, tokenErr, "Failed to store image")
+		return nil, false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), mediaTimeout)
+	defer cancel()
+	image := &models.Image{Keys: make([]string, 0, len(renditions))}
+	for _, rendition := range renditions {
+		key := fmt.Sprintf("%s/%s/%s%s", prefix, token, rendition.Variant.Name, rendition.Extension)
+		if putErr := store.Put(ctx, key, rendition.ContentType, rendition.Data); putErr != nil {
+			deleteImage(store, image)
+			logrus.Errorf("Failed to store image: %s", putErr)
+			utils.RespondError(w, http.StatusInternalServerError, putErr, "Failed to store image")
+			return nil, false
+		}
+		image.Keys = append(image.Keys, key)
+		switch rendition.Variant {
+		case media.VariantOriginal:
+			image.Original = store.URL(key)
+		case media.VariantMedium:
+			image.Medium = store.URL(key)
+		case media.VariantThumbnail:
+			image.Thumbnail = store.URL(key)
+		}
+	}
+	return image, true
+}
+
+// deleteImage removes the files of an image that is no longer used, failures only leave files behind so they are
+// logged
+func deleteImage(store media.BlobStore, image *models.Image) {
+	if image == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), mediaTimeout)
+	defer cancel()
+	for _, key := range image.Keys {
+		if err := store.Delete(ctx, key); err != nil {
+			logrus.Errorf("Failed to delete image %s: %s", key, err)
+		}
+	}
+}
+
+// mediaStore returns the configured store, it responds with the error itself
+func mediaStore(w http.ResponseWriter) (media.BlobStore, bool) {
+	store, err := media.Store()
+	if err != nil {
+		logrus.Errorf("Failed to get media store: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to store image")
+		return nil, false
+	}
+	return store, true
+}
+
+// putRestaurantImage uploads the image of a restaurant slot from the request, or clears the slot when upload is false
+func putRestaurantImage(w http.ResponseWriter, r *http.Request, slot models.RestaurantImage, upload bool) {
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	store, ok := mediaStore(w)
+	if !ok {
+		return
+	}
+	var image *models.Image
+	if upload {
+		data, ok := readImageUpload(w, r)
+		if !ok {
+			return
+		}
+		image, ok = storeImage(w, store, fmt.Sprintf("restaurants/%s/%s", restaurant.ID, slot), data)
+		if !ok {
+			return
+		}
+	}
+
+	replaced, found, err := dbHelper.SwapRestaurantImage(database.RMS, restaurant.ID, slot, image)
+	if err != nil || !found {
+		deleteImage(store, image)
+		if err != nil {
+			logrus.Errorf("Failed to save Restaurant %s: %s", slot, err)
+			utils.RespondError(w, http.StatusInternalServerError, err, "Failed to save Restaurant "+string(slot))
+			return
+		}
+		logrus.Errorf("Restaurant not exist: %s", restaurant.ID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Restaurant not exist")
+		return
+	}
+	deleteImage(store, replaced)
+	logrus.Infof("Restaurant %s saved successfully.", slot)
+	utils.RespondJSON(w, http.StatusOK, models.GetImage{
+		Message: "Restaurant " + string(slot) + " saved successfully.",
+		Image:   image,
+	})
+}
+
+func UploadRestaurantLogo(w http.ResponseWriter, r *http.Request) {
+	putRestaurantImage(w, r, models.RestaurantLogo, true)
+}
+
+func RemoveRestaurantLogo(w http.ResponseWriter, r *http.Request) {
+	putRestaurantImage(w, r, models.RestaurantLogo, false)
+}
+
+func UploadRestaurantBanner(w http.ResponseWriter, r *http.Request) {
+	putRestaurantImage(w, r, models.RestaurantBanner, true)
+}
+
+func RemoveRestaurantBanner(w http.ResponseWriter, r *http.Request) {
+	putRestaurantImage(w, r, models.RestaurantBanner, false)
+}
+
+// putDishPhoto uploads the photo of a dish from the request, or clears the photo when upload is false
+func putDishPhoto(w http.ResponseWriter, r *http.Request, upload bool) {
+	restaurantID := chi.URLParam(r, "restaurantId")
+	dishID := chi.URLParam(r, "dishId")
+	adminCtx := middlewares.UserContext(r)
+	dish, dishErr := dbHelper.GetDishByID(dishID)
+	if dishErr != nil {
+		logrus.Errorf("Failed to get Dish: %s", dishErr)
+		utils.RespondError(w, http.StatusInternalServerError, dishErr, "Failed to get Dish")
+		return
+	}
+	if dish == nil {
+		logrus.Errorf("Dish not exist: %s", dishID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Dish not exist")
+		return
+	}
+	if adminCtx.CurrentRole != models.RoleAdmin && dish.CreatedBy != adminCtx.ID {
+		logrus.Errorf("Dish not Created by %s", adminCtx.CurrentRole)
+		utils.RespondError(w, http.StatusBadRequest, nil, "Dish not Created by: "+string(adminCtx.CurrentRole))
+		return
+	}
+	store, ok := mediaStore(w)
+	if !ok {
+		return
+	}
+	var photo *models.Image
+	if upload {
+		data, ok := readImageUpload(w, r)
+		if !ok {
+			return
+		}
+		photo, ok = storeImage(w, store, fmt.Sprintf("restaurants/%s/dishes/%s", restaurantID, dishID), data)
+		if !ok {
+			return
+		}
+	}
+
+	var replaced *models.Image
+	var found bool
+	err := database.Tx(func(tx *sqlx.Tx) error {
+		var swapErr error
+		replaced, found, swapErr = dbHelper.SwapDishPhoto(tx, dishID, restaurantID, photo)
+		if swapErr != nil || !found {
+			return swapErr
+		}
+		return enqueueDishUpdated(tx, restaurantID, dishID)
+	})
+	if err != nil || !found {
+		deleteImage(store, photo)
+		if err != nil {
+			logrus.Errorf("Failed to save Dish photo: %s", err)
+			utils.RespondError(w, http.StatusInternalServerError, err, "Failed to save Dish photo")
+			return
+		}
+		logrus.Errorf("Dish not exist: %s", dishID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Dish not exist")
+		return
+	}
+	deleteImage(store, replaced)
+	publishDishEvent(dishID, restaurantID, false)
+	logrus.Infof("Dish photo saved successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetImage{
+		Message: "Dish photo saved successfully.",
+		Image:   photo,
+	})
+}
+
+func UploadDishPhoto(w http.ResponseWriter, r *http.Request) {
+	putDishPhoto(w, r, true)
+}
+
+func RemoveDishPhoto(w http.ResponseWriter, r *http.Request) {
+	putDishPhoto(w, r, false)
+}
@@ -0,0 +1,226 @@
+package handler
+
+import (
+	"net/http"
+	"rms/database"
+	"rms/database/dbHelper"
+	"rms/models"
+	"rms/utils"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// menuEndOfDay ends a window that runs to midnight
+const menuEndOfDay = "24:00"
+
+// parseMenuClock reads a HH:MM window bound as the time since midnight
+func parseMenuClock(clock string) (time.Duration, error) {
+	if clock == menuEndOfDay {
+		return 24 * time.Hour, nil
+	}
+	parsed, err := time.Parse(hoursLayout, clock)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(parsed.Hour())*time.Hour + time.Duration(parsed.Minute())*time.Minute, nil
+}
+
+// cartServedAt is the time a cart is priced for, the time it is scheduled for or now
+func cartServedAt(scheduledFor *time.Time) time.Time {
+	if scheduledFor != nil {
+		return *scheduledFor
+	}
+	return time.Now()
+}
+
+// dishesServedAt reads the ?at= time of a dish listing. Customers see what they can order now or at a later time
+// they ask for, staff see every dish unless they preview a time, past ones included
+func dishesServedAt(w http.ResponseWriter, r *http.Request, staff bool) (*time.Time, bool) {
+	now := time.Now()
+	value := r.URL.Query().Get("at")
+	if value == "" {
+		if staff {
+			return nil, true
+		}
+		return &now, true
+	}
+	at, atErr := time.Parse(time.RFC3339, value)
+	if atErr != nil {
+		logrus.Errorf("Invalid At: %s", atErr)
+		utils.RespondError(w, http.StatusBadRequest, atErr, "Invalid At, use an RFC3339 time.")
+		return nil, false
+	}
+	if !staff && at.Before(now) {
+		at = now
+	}
+	return &at, true
+}
+
+// validMenuBody checks the name, the windows and the dishes of a menu, the dishes have to be the restaurant's own.
+// Repeated dishes are dropped
+func validMenuBody(w http.ResponseWriter, restaurantID string, body *models.MenuBody) bool {
+	body.Name = strings.TrimSpace(body.Name)
+	if body.Name == "" {
+		logrus.Errorf("Invalid Name.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Name.")
+		return false
+	}
+
+	for _, window := range body.Hours {
+		startsAt, startsErr := parseMenuClock(window.StartsAt)
+		endsAt, endsErr := parseMenuClock(window.EndsAt)
+		if window.DayOfWeek < 0 || window.DayOfWeek > 6 || startsErr != nil || endsErr != nil || endsAt <= startsAt {
+			logrus.Errorf("Invalid Menu Hours: %+v", window)
+			utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Menu Hours, use HH:MM with the end after the start.")
+			return false
+		}
+	}
+
+	seen := make(map[string]bool, len(body.DishIDs))
+	dishIDs := make([]string, 0, len(body.DishIDs))
+	for _, dishID := range body.DishIDs {
+		if !seen[dishID] {
+			seen[dishID] = true
+			dishIDs = append(dishIDs, dishID)
+		}
+	}
+	body.DishIDs = dishIDs
+	if len(dishIDs) == 0 {
+		return true
+	}
+	count, countErr := dbHelper.CountRestaurantDishes(database.RMS, restaurantID, dishIDs)
+	if countErr != nil {
+		logrus.Errorf("Failed to get Restaurant Dishes: %s", countErr)
+		utils.RespondError(w, http.StatusInternalServerError, countErr, "Failed to get Restaurant Dishes")
+		return false
+	}
+	if count != int64(len(dishIDs)) {
+		logrus.Errorf("Dishes not on Restaurant: %s", restaurantID)
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Dishes.")
+		return false
+	}
+	return true
+}
+
+func GetMenus(w http.ResponseWriter, r *http.Request) {
+	restaurantID := chi.URLParam(r, "restaurantId")
+	menus, err := dbHelper.GetMenus(restaurantID)
+	if err != nil {
+		logrus.Errorf("Failed to get Menus: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to get Menus")
+		return
+	}
+	logrus.Infof("Get Menus successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetMenus{
+		Message: "Get Menus successfully.",
+		Menus:   menus,
+	})
+}
+
+func AddMenu(w http.ResponseWriter, r *http.Request) {
+	var body models.MenuBody
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	if !validMenuBody(w, restaurant.ID, &body) {
+		return
+	}
+
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		menuID, createErr := dbHelper.CreateMenu(tx, restaurant.ID, body.Name)
+		if createErr != nil {
+			return createErr
+		}
+		if hoursErr := dbHelper.ReplaceMenuHours(tx, menuID, body.Hours); hoursErr != nil {
+			return hoursErr
+		}
+		return dbHelper.ReplaceMenuDishes(tx, menuID, body.DishIDs)
+	})
+	if txErr != nil {
+		logrus.Errorf("Failed to add Menu: %s", txErr)
+		utils.RespondError(w, http.StatusInternalServerError, txErr, "Failed to add Menu")
+		return
+	}
+	logrus.Infof("Menu added successfully.")
+	utils.RespondJSON(w, http.StatusCreated, models.Message{
+		Message: "Menu added successfully.",
+	})
+}
+
+// UpdateMenu replaces the name, the windows and the dishes of a menu
+func UpdateMenu(w http.ResponseWriter, r *http.Request) {
+	menuID := chi.URLParam(r, "menuId")
+	var body models.MenuBody
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	if !validMenuBody(w, restaurant.ID, &body) {
+		return
+	}
+
+	var updated bool
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		var updateErr error
+		updated, updateErr = dbHelper.UpdateMenu(tx, restaurant.ID, menuID, body.Name)
+		if updateErr != nil || !updated {
+			return updateErr
+		}
+		if hoursErr := dbHelper.ReplaceMenuHours(tx, menuID, body.Hours); hoursErr != nil {
+			return hoursErr
+		}
+		return dbHelper.ReplaceMenuDishes(tx, menuID, body.DishIDs)
+	})
+	if txErr != nil {
+		logrus.Errorf("Failed to update Menu: %s", txErr)
+		utils.RespondError(w, http.StatusInternalServerError, txErr, "Failed to update Menu")
+		return
+	}
+	if !updated {
+		logrus.Errorf("Menu not exist: %s", menuID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Menu not exist")
+		return
+	}
+	logrus.Infof("Menu updated successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Menu updated successfully.",
+	})
+}
+
+func RemoveMenu(w http.ResponseWriter, r *http.Request) {
+	menuID := chi.URLParam(r, "menuId")
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	removed, err := dbHelper.ArchiveMenu(database.RMS, restaurant.ID, menuID)
+	if err != nil {
+		logrus.Errorf("Failed to remove Menu: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to remove Menu")
+		return
+	}
+	if !removed {
+		logrus.Errorf("Menu not exist: %s", menuID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Menu not exist")
+		return
+	}
+	logrus.Infof("Menu removed successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Menu removed successfully.",
+	})
+}
@@ -0,0 +1,715 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"rms/database"
+	"rms/database/dbHelper"
+	"rms/eta"
+	"rms/events"
+	"rms/middlewares"
+	"rms/models"
+	"rms/orderstatus"
+	"rms/payments"
+	"rms/utils"
+	"sort"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+var (
+	errDishNotFound    = errors.New("dish not found")
+	errDishOutOfStock  = errors.New("dish out of stock")
+	errDishUnavailable = errors.New("dish is not available")
+	errOrderMoved      = errors.New("order status changed, please retry")
+)
+
+// priceCartItems merges repeated dishes into priced lines in cart order, it fails when a dish is missing, not served
+// or short on stock. Dishes are loaded in id order so checkouts that lock them never wait on each other in a cycle
+func priceCartItems(loadDish func(dishID string) (*models.Dishes, error), cartItems []models.CartItem) ([]models.PriceLine, error) {
+	quantities := make(map[string]int64)
+	dishIDs := make([]string, 0, len(cartItems))
+	for _, cartItem := range cartItems {
+		if _, ok := quantities[cartItem.DishID]; !ok {
+			dishIDs = append(dishIDs, cartItem.DishID)
+		}
+		quantities[cartItem.DishID] += cartItem.Quantity
+	}
+	lockOrder := append([]string(nil), dishIDs...)
+	sort.Strings(lockOrder)
+	dishes := make(map[string]*models.Dishes, len(lockOrder))
+	for _, dishID := range lockOrder {
+		dish, dishErr := loadDish(dishID)
+		if dishErr != nil {
+			return nil, dishErr
+		}
+		if dish == nil {
+			return nil, fmt.Errorf("%w: %s", errDishNotFound, dishID)
+		}
+		if !dish.Available {
+			return nil, fmt.Errorf("%w: %s", errDishUnavailable, dish.Name)
+		}
+		if !dish.Served {
+			return nil, fmt.Errorf("%w: %s is not on the menu at that time", errDishUnavailable, dish.Name)
+		}
+		if dish.Quantity < quantities[dishID] {
+			return nil, fmt.Errorf("%w: %s", errDishOutOfStock, dish.Name)
+		}
+		dishes[dishID] = dish
+	}
+	lines := make([]models.PriceLine, 0, len(dishIDs))
+	for _, dishID := range dishIDs {
+		dish := dishes[dishID]
+		quantity := quantities[dishID]
+		lineSubTotal := dish.Price * quantity
+		lines = append(lines, models.PriceLine{
+			OrderItem: models.OrderItem{
+				DishID:   dishID,
+				Name:     dish.Name,
+				Quantity: quantity,
+				Price:    dish.Price,
+				Discount: dish.Discount,
+				Total:    lineSubTotal - lineSubTotal*dish.Discount/100,
+			},
+			TaxCategory: dish.TaxCategory,
+			Packaging:   dish.PackagingCharge,
+		})
+	}
+	return lines, nil
+}
+
+// buildOrderItems locks and takes the ordered dishes out of stock, returns the priced lines. The dishes have to be on
+// the menu at servedAt, when the order is placed or scheduled for
+func buildOrderItems(tx *sqlx.Tx, restaurantID string, cartItems []models.CartItem, servedAt time.Time) ([]models.PriceLine, error) {
+	lines, err := priceCartItems(func(dishID string) (*models.Dishes, error) {
+		return dbHelper.GetDishForUpdate(tx, restaurantID, dishID, servedAt)
+	}, cartItems)
+	if err != nil {
+		return nil, err
+	}
+	for _, line := range lines {
+		soldOut, stockErr := dbHelper.UpdateDishQuantity(tx, line.DishID, -line.Quantity)
+		if stockErr != nil {
+			return nil, stockErr
+		}
+		if soldOut {
+			if webhookErr := enqueueDishUpdated(tx, restaurantID, line.DishID); webhookErr != nil {
+				return nil, webhookErr
+			}
+		}
+	}
+	return lines, nil
+}
+
+// releaseCancelledOrder puts the items of a cancelled order back in stock and gives back its coupon use and points
+func releaseCancelledOrder(tx *sqlx.Tx, order *models.Order) error {
+	for _, item := range order.Items {
+		backInStock, err := dbHelper.UpdateDishQuantity(tx, item.DishID, item.Quantity)
+		if err != nil {
+			return err
+		}
+		if backInStock {
+			if webhookErr := enqueueDishUpdated(tx, order.RestaurantID, item.DishID); webhookErr != nil {
+				return webhookErr
+			}
+		}
+	}
+	if order.CouponID != nil {
+		if err := dbHelper.ReleaseCouponUsage(tx, *order.CouponID); err != nil {
+			return err
+		}
+	}
+	if order.PointsRedeemed > 0 {
+		return dbHelper.PostLoyaltyTransaction(tx, order.UserID, models.LoyaltyRefund, order.PointsRedeemed, &order.ID, nil, "", nil)
+	}
+	return nil
+}
+
+// orderMove carries what a status change created besides the new status
+type orderMove struct {
+	ticketID string
+	riderID  string
+}
+
+// moveOrderStatus moves the order on from the status it was loaded in and runs the side effects of the new status
+func moveOrderStatus(tx *sqlx.Tx, order *models.Order, status models.OrderStatus) (orderMove, error) {
+	var move orderMove
+	moved, moveErr := dbHelper.UpdateOrderStatus(tx, order.ID, order.Status, status)
+	if moveErr != nil {
+		return move, moveErr
+	}
+	if !moved {
+		return move, errOrderMoved
+	}
+	var sideEffectErr error
+	switch status {
+	case models.OrderAccepted:
+		if sideEffectErr = checkOrderPaid(tx, order.ID); sideEffectErr == nil {
+			move.ticketID, sideEffectErr = dbHelper.CreateKitchenTicket(tx, order.ID, order.RestaurantID)
+		}
+	case models.OrderReady:
+		if order.RiderID == nil {
+			// without a free rider the order waits for the rider assignment job
+			if move.riderID, sideEffectErr = dbHelper.AssignNearestRider(tx, order.ID); errors.Is(sideEffectErr, dbHelper.ErrNoRiderFree) {
+				sideEffectErr = nil
+			}
+		}
+	case models.OrderCancelled:
+		sideEffectErr = releaseCancelledOrder(tx, order)
+	case models.OrderDelivered:
+		if sideEffectErr = earnLoyaltyPoints(tx, order); sideEffectErr == nil {
+			_, sideEffectErr = issueInvoice(tx, order.ID)
+		}
+	}
+	if sideEffectErr != nil {
+		return move, sideEffectErr
+	}
+	return move, orderstatus.Moved(tx, order, status)
+}
+
+// publishOrderMove tells everyone watching the order about a committed status change
+func publishOrderMove(order *models.Order, status models.OrderStatus, move orderMove) {
+	orderstatus.Publish(order, status)
+	if move.ticketID != "" {
+		publishTicketEvent(order.RestaurantID, events.TicketCreated, move.ticketID, order.ID)
+	}
+	if move.riderID != "" {
+		orderstatus.PublishRiderAssigned(order.ID, order.UserID, move.riderID)
+	}
+}
+
+func PlaceOrder(w http.ResponseWriter, r *http.Request) {
+	var body models.PlaceOrderBody
+	userCtx := middlewares.UserContext(r)
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+
+	if len(body.Items) == 0 {
+		logrus.Errorf("Order must have at least one item.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Order must have at least one item.")
+		return
+	}
+
+	for _, item := range body.Items {
+		if item.Quantity <= 0 {
+			logrus.Errorf("Invalid Quantity.")
+			utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Quantity.")
+			return
+		}
+	}
+
+	if body.RedeemPoints < 0 {
+		logrus.Errorf("Invalid Redeem Points.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Redeem Points.")
+		return
+	}
+
+	if _, addressErr := utils.GetUserAddressById(body.AddressID, userCtx.UserAddresses); addressErr != nil {
+		logrus.Errorf("Address not exist: %s", addressErr)
+		utils.RespondError(w, http.StatusBadRequest, nil, "Address not exist")
+		return
+	}
+
+	exists, existsErr := dbHelper.IsRestaurantIDExists(body.RestaurantID)
+	if existsErr != nil {
+		logrus.Errorf("Failed to check Restaurant existence: %s", existsErr)
+		utils.RespondError(w, http.StatusInternalServerError, existsErr, "Failed to check Restaurant existence")
+		return
+	}
+	if !exists {
+		logrus.Errorf("Restaurant not exists.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Restaurant not exists")
+		return
+	}
+
+	if body.Payment.Provider == "" {
+		body.Payment.Provider = payments.ProviderCOD
+	}
+	provider, providerErr := payments.Get(body.Payment.Provider)
+	if providerErr != nil {
+		logrus.Errorf("Invalid Payment Provider: %s", body.Payment.Provider)
+		utils.RespondError(w, http.StatusBadRequest, providerErr, "Invalid Payment Provider.")
+		return
+	}
+
+	var orderID string
+	var payment, replayed *models.Payment
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		if body.IdempotencyKey != "" {
+			if lockErr := dbHelper.LockIdempotencyKey(tx, userCtx.ID, body.IdempotencyKey); lockErr != nil {
+				return lockErr
+			}
+			var replayErr error
+			replayed, replayErr = dbHelper.GetPaymentByIdempotencyKey(tx, userCtx.ID, body.IdempotencyKey)
+			if replayErr != nil || replayed != nil {
+				return replayErr
+			}
+		}
+		status := models.OrderPlaced
+		var releaseAt *time.Time
+		servedAt := time.Now()
+		if body.ScheduledFor != nil {
+			servedAt = *body.ScheduledFor
+			slotReleaseAt, slotErr := reserveDeliverySlot(tx, body.RestaurantID, *body.ScheduledFor)
+			if slotErr != nil {
+				return slotErr
+			}
+			status = models.OrderScheduled
+			releaseAt = &slotReleaseAt
+		}
+		lines, itemsErr := buildOrderItems(tx, body.RestaurantID, body.Items, servedAt)
+		if itemsErr != nil {
+			return itemsErr
+		}
+		var coupon *models.Coupon
+		var couponID *string
+		if body.CouponCode != "" {
+			var couponErr error
+			coupon, _, couponErr = evaluateCoupon(tx, body.CouponCode, userCtx.ID, body.RestaurantID, itemsTotal(lines), true)
+			if couponErr != nil {
+				return couponErr
+			}
+			used, usageErr := dbHelper.IncrementCouponUsage(tx, coupon.ID)
+			if usageErr != nil {
+				return usageErr
+			}
+			if !used {
+				return fmt.Errorf("%w: usage limit reached", errCouponInvalid)
+			}
+			couponID = &coupon.ID
+		}
+		breakdown, quoteErr := quoteLines(tx, body.RestaurantID, lines, coupon, body.RedeemPoints, true)
+		if quoteErr != nil {
+			return quoteErr
+		}
+		if body.RedeemPoints > 0 {
+			if pointsErr := checkRedeemablePoints(tx, userCtx.ID, body.RedeemPoints, breakdown.GrandTotal); pointsErr != nil {
+				return pointsErr
+			}
+		}
+		order := &models.Order{
+			OrderType:    models.OrderDelivery,
+			UserID:       userCtx.ID,
+			RestaurantID: body.RestaurantID,
+			AddressID:    body.AddressID,
+			Status:       status,
+			CouponID:     couponID,
+			ScheduledFor: body.ScheduledFor,
+			ReleaseAt:    releaseAt,
+		}
+		applyBreakdown(order, breakdown)
+		var orderErr error
+		orderID, orderErr = dbHelper.CreateOrder(tx, order)
+		if orderErr != nil {
+			return orderErr
+		}
+		if itemsErr := dbHelper.CreateOrderItems(tx, orderID, breakdownItems(breakdown)); itemsErr != nil {
+			return itemsErr
+		}
+		if coupon != nil {
+			if redemptionErr := dbHelper.CreateCouponRedemption(tx, coupon.ID, userCtx.ID, orderID, breakdown.CouponDiscount); redemptionErr != nil {
+				return redemptionErr
+			}
+		}
+		if body.RedeemPoints > 0 {
+			if pointsErr := dbHelper.PostLoyaltyTransaction(tx, userCtx.ID, models.LoyaltyRedeem, -body.RedeemPoints, &orderID, nil, "", nil); pointsErr != nil {
+				return pointsErr
+			}
+		}
+		payment = &models.Payment{
+			OrderID:  orderID,
+			UserID:   userCtx.ID,
+			Provider: body.Payment.Provider,
+			Method:   body.Payment.Method,
+			Amount:   breakdown.Total,
+			Status:   models.PaymentPending,
+		}
+		var paymentErr error
+		payment.ID, paymentErr = dbHelper.CreatePayment(tx, payment, body.IdempotencyKey)
+		if paymentErr != nil {
+			return paymentErr
+		}
+		if etaErr := eta.RefreshOrder(tx, orderID); etaErr != nil {
+			return etaErr
+		}
+		if status == models.OrderPlaced {
+			return orderstatus.EnqueuePlaced(tx, orderID)
+		}
+		return nil
+	})
+	if txErr != nil {
+		if errors.Is(txErr, errDishNotFound) || errors.Is(txErr, errDishOutOfStock) || errors.Is(txErr, errDishUnavailable) || errors.Is(txErr, errCouponInvalid) ||
+			errors.Is(txErr, errInsufficientPoints) || errors.Is(txErr, errSlotUnavailable) || errors.Is(txErr, errRestaurantNotFound) ||
+			errors.Is(txErr, models.ErrMoneyOverflow) {
+			logrus.Errorf("Failed to place order: %s", txErr)
+			utils.RespondError(w, http.StatusBadRequest, txErr, txErr.Error())
+			return
+		}
+		logrus.Errorf("Failed to place order: %s", txErr)
+		utils.RespondError(w, http.StatusInternalServerError, txErr, "Failed to place order")
+		return
+	}
+
+	// a retried checkout gets the order the key created first
+	if replayed != nil {
+		order, orderErr := dbHelper.GetOrderByID(database.RMS, replayed.OrderID)
+		if orderErr != nil {
+			logrus.Errorf("Failed to get order: %s", orderErr)
+			utils.RespondError(w, http.StatusInternalServerError, orderErr, "Failed to get order")
+			return
+		}
+		logrus.Infof("Order already placed.")
+		utils.RespondJSON(w, http.StatusOK, models.PlaceOrder{
+			Message: "Order already placed.",
+			Order:   *order,
+			Payment: replayed,
+		})
+		return
+	}
+
+	if paymentErr := collectPayment(provider, payment); paymentErr != nil {
+		if errors.Is(paymentErr, errPaymentDeclined) {
+			logrus.Errorf("Failed to collect payment: %s", paymentErr)
+			utils.RespondError(w, http.StatusPaymentRequired, paymentErr, paymentErr.Error())
+			return
+		}
+		logrus.Errorf("Failed to collect payment: %s", paymentErr)
+		utils.RespondError(w, http.StatusInternalServerError, paymentErr, "Failed to collect payment")
+		return
+	}
+
+	order, orderErr := dbHelper.GetOrderByID(database.RMS, orderID)
+	if orderErr != nil {
+		logrus.Errorf("Failed to get order: %s", orderErr)
+		utils.RespondError(w, http.StatusInternalServerError, orderErr, "Failed to get order")
+		return
+	}
+	// scheduled orders reach the restaurant when the scheduler releases them
+	if order.Status == models.OrderPlaced {
+		publishOrderEvent(events.OrderPlaced, order.ID, order.UserID, order.RestaurantID, order.Status)
+	}
+	logrus.Infof("Order placed successfully.")
+	utils.RespondJSON(w, http.StatusCreated, models.PlaceOrder{
+		Message: "Order placed successfully.",
+		Order:   *order,
+		Payment: payment,
+	})
+}
+
+func GetMyOrders(w http.ResponseWriter, r *http.Request) {
+	Filters := utils.GetFilters(r)
+	userCtx := middlewares.UserContext(r)
+	var ordersCount int64
+	orders := make([]models.Order, 0)
+	var errGroup errgroup.Group
+	errGroup.Go(func() error {
+		var err error
+		ordersCount, err = dbHelper.GetOrdersCountByUserID(userCtx.ID)
+		if err != nil {
+			logrus.Errorf("Unable to get Orders Count: %s", err)
+		}
+		return err
+	})
+	errGroup.Go(func() error {
+		var err error
+		orders, err = dbHelper.GetOrdersByUserID(userCtx.ID, Filters)
+		if err != nil {
+			logrus.Errorf("Unable to get Orders: %s", err)
+		}
+		return err
+	})
+	if err := errGroup.Wait(); err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "Unable to get Orders")
+		return
+	}
+	logrus.Infof("Get Orders successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetOrders{
+		Message:    "Get Orders successfully.",
+		Orders:     orders,
+		TotalCount: ordersCount,
+		PageNumber: Filters.PageNumber,
+		PageSize:   Filters.PageSize,
+	})
+}
+
+func GetMyOrder(w http.ResponseWriter, r *http.Request) {
+	orderID := chi.URLParam(r, "orderId")
+	userCtx := middlewares.UserContext(r)
+	order, err := dbHelper.GetOrderByID(database.RMS, orderID)
+	if err != nil {
+		logrus.Errorf("Failed to get order: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to get order")
+		return
+	}
+	if order == nil || order.UserID != userCtx.ID {
+		logrus.Errorf("Order not exist: %s", orderID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Order not exist")
+		return
+	}
+	logrus.Infof("Get Order successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetOrder{
+		Message: "Get Order successfully.",
+		Order:   *order,
+	})
+}
+
+func CancelMyOrder(w http.ResponseWriter, r *http.Request) {
+	orderID := chi.URLParam(r, "orderId")
+	userCtx := middlewares.UserContext(r)
+	order, err := dbHelper.GetOrderByID(database.RMS, orderID)
+	if err != nil {
+		logrus.Errorf("Failed to get order: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to get order")
+		return
+	}
+	if order == nil || order.UserID != userCtx.ID {
+		logrus.Errorf("Order not exist: %s", orderID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Order not exist")
+		return
+	}
+	// once the restaurant accepts the order only the restaurant can cancel it
+	if order.Status != models.OrderPlaced && order.Status != models.OrderScheduled {
+		logrus.Errorf("Order can't be cancelled in status: %s", order.Status)
+		utils.RespondError(w, http.StatusBadRequest, nil, "Order can't be cancelled now")
+		return
+	}
+	var move orderMove
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		var moveErr error
+		move, moveErr = moveOrderStatus(tx, order, models.OrderCancelled)
+		return moveErr
+	})
+	if txErr != nil {
+		if errors.Is(txErr, errOrderMoved) {
+			logrus.Errorf("Failed to cancel order: %s", txErr)
+			utils.RespondError(w, http.StatusConflict, txErr, txErr.Error())
+			return
+		}
+		logrus.Errorf("Failed to cancel order: %s", txErr)
+		utils.RespondError(w, http.StatusInternalServerError, txErr, "Failed to cancel order")
+		return
+	}
+	publishOrderMove(order, models.OrderCancelled, move)
+	logrus.Infof("Order cancelled successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Order cancelled successfully.",
+	})
+}
+
+// ReorderMyOrder rebuilds the cart of a past order at current prices. Items that can't be ordered now are left out
+// and, like items that go in with a smaller quantity or a new price, reported as changes
+func ReorderMyOrder(w http.ResponseWriter, r *http.Request) {
+	orderID := chi.URLParam(r, "orderId")
+	userCtx := middlewares.UserContext(r)
+	order, err := dbHelper.GetOrderByID(database.RMS, orderID)
+	if err != nil {
+		logrus.Errorf("Failed to get order: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to get order")
+		return
+	}
+	if order == nil || order.UserID != userCtx.ID {
+		logrus.Errorf("Order not exist: %s", orderID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Order not exist")
+		return
+	}
+
+	now := time.Now()
+	cartItems := make([]models.CartItem, 0, len(order.Items))
+	changes := make([]models.ReorderChange, 0)
+	dishes := make(map[string]*models.Dishes)
+	// stock is what is left of each dish once the earlier lines of the order took their share
+	stock := make(map[string]int64)
+	for _, item := range order.Items {
+		change := models.ReorderChange{
+			DishID:      item.DishID,
+			Name:        item.Name,
+			Quantity:    item.Quantity,
+			OldPrice:    item.Price,
+			OldDiscount: item.Discount,
+		}
+		dish, loaded := dishes[item.DishID]
+		if !loaded {
+			var dishErr error
+			dish, dishErr = dbHelper.GetRestaurantDishById(order.RestaurantID, item.DishID, now)
+			if dishErr != nil {
+				logrus.Errorf("Failed to get Dish: %s", dishErr)
+				utils.RespondError(w, http.StatusInternalServerError, dishErr, "Failed to reorder")
+				return
+			}
+			dishes[item.DishID] = dish
+			if dish != nil {
+				stock[dish.ID] = dish.Quantity
+			}
+		}
+		if dish == nil {
+			change.Reason = models.ReorderDishRemoved
+			changes = append(changes, change)
+			continue
+		}
+		change.Name, change.NewPrice, change.NewDiscount = dish.Name, dish.Price, dish.Discount
+		switch {
+		case !dish.Available:
+			change.Reason = models.ReorderDishUnavailable
+		case !dish.Served:
+			change.Reason = models.ReorderDishNotServed
+		case stock[dish.ID] <= 0:
+			change.Reason = models.ReorderDishSoldOut
+		}
+		if change.Reason != "" {
+			changes = append(changes, change)
+			continue
+		}
+		change.NewQuantity = item.Quantity
+		if stock[dish.ID] < item.Quantity {
+			change.NewQuantity = stock[dish.ID]
+			change.Reason = models.ReorderQuantityReduced
+		} else if dish.Price != item.Price || dish.Discount != item.Discount {
+			change.Reason = models.ReorderDishPriceChanged
+		}
+		if change.Reason != "" {
+			changes = append(changes, change)
+		}
+		stock[dish.ID] -= change.NewQuantity
+		cartItems = append(cartItems, models.CartItem{DishID: dish.ID, Quantity: change.NewQuantity})
+	}
+
+	reorder := models.Reorder{
+		Message:      "Reorder priced successfully.",
+		RestaurantID: order.RestaurantID,
+		Items:        cartItems,
+		Changes:      changes,
+	}
+	if len(cartItems) > 0 {
+		lines, itemsErr := priceCartItems(func(dishID string) (*models.Dishes, error) {
+			return dishes[dishID], nil
+		}, cartItems)
+		if itemsErr != nil {
+			logrus.Errorf("Failed to reorder: %s", itemsErr)
+			utils.RespondError(w, http.StatusInternalServerError, itemsErr, "Failed to reorder")
+			return
+		}
+		breakdown, quoteErr := quoteLines(database.RMS, order.RestaurantID, lines, nil, 0, true)
+		if quoteErr != nil {
+			if errors.Is(quoteErr, errRestaurantNotFound) {
+				logrus.Errorf("Failed to reorder: %s", quoteErr)
+				utils.RespondError(w, http.StatusBadRequest, quoteErr, "Restaurant not exists")
+				return
+			}
+			logrus.Errorf("Failed to reorder: %s", quoteErr)
+			utils.RespondError(w, http.StatusInternalServerError, quoteErr, "Failed to reorder")
+			return
+		}
+		reorder.Breakdown = &breakdown
+	}
+	logrus.Infof("Reorder priced successfully.")
+	utils.RespondJSON(w, http.StatusOK, reorder)
+}
+
+func GetRestaurantOrders(w http.ResponseWriter, r *http.Request) {
+	Filters := utils.GetFilters(r)
+	restaurantID := chi.URLParam(r, "restaurantId")
+	status := r.URL.Query().Get("status")
+	if _, ok := getManagedRestaurant(w, r); !ok {
+		return
+	}
+
+	var ordersCount int64
+	orders := make([]models.Order, 0)
+	var errGroup errgroup.Group
+	errGroup.Go(func() error {
+		var err error
+		ordersCount, err = dbHelper.GetRestaurantOrdersCount(restaurantID, status)
+		if err != nil {
+			logrus.Errorf("Unable to get Orders Count: %s", err)
+		}
+		return err
+	})
+	errGroup.Go(func() error {
+		var err error
+		orders, err = dbHelper.GetRestaurantOrders(restaurantID, status, Filters)
+		if err != nil {
+			logrus.Errorf("Unable to get Orders: %s", err)
+		}
+		return err
+	})
+	if err := errGroup.Wait(); err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "Unable to get Orders")
+		return
+	}
+	logrus.Infof("Get Restaurant Orders successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetOrders{
+		Message:    "Get Restaurant Orders successfully.",
+		Orders:     orders,
+		TotalCount: ordersCount,
+		PageNumber: Filters.PageNumber,
+		PageSize:   Filters.PageSize,
+	})
+}
+
+func UpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
+	restaurantID := chi.URLParam(r, "restaurantId")
+	orderID := chi.URLParam(r, "orderId")
+	var body models.UpdateOrderStatusBody
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+
+	if !body.Status.IsValid() {
+		logrus.Errorf("Invalid Order Status: %s", body.Status)
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Order Status.")
+		return
+	}
+
+	if _, ok := getManagedRestaurant(w, r); !ok {
+		return
+	}
+
+	order, orderErr := dbHelper.GetOrderByID(database.RMS, orderID)
+	if orderErr != nil {
+		logrus.Errorf("Failed to get order: %s", orderErr)
+		utils.RespondError(w, http.StatusInternalServerError, orderErr, "Failed to get order")
+		return
+	}
+	if order == nil || order.RestaurantID != restaurantID {
+		logrus.Errorf("Order not exist: %s", orderID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Order not exist")
+		return
+	}
+	if !order.CanMoveTo(body.Status) {
+		logrus.Errorf("Order can't move from %s to %s", order.Status, body.Status)
+		utils.RespondError(w, http.StatusBadRequest, nil, fmt.Sprintf("Order can't move from %s to %s", order.Status, body.Status))
+		return
+	}
+
+	var move orderMove
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		var moveErr error
+		move, moveErr = moveOrderStatus(tx, order, body.Status)
+		return moveErr
+	})
+	if txErr != nil {
+		if errors.Is(txErr, errOrderMoved) || errors.Is(txErr, errPaymentPending) {
+			logrus.Errorf("Failed to update order status: %s", txErr)
+			utils.RespondError(w, http.StatusConflict, txErr, txErr.Error())
+			return
+		}
+		logrus.Errorf("Failed to update order status: %s", txErr)
+		utils.RespondError(w, http.StatusInternalServerError, txErr, "Failed to update order status")
+		return
+	}
+	publishOrderMove(order, body.Status, move)
+	logrus.Infof("Order status updated successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Order status updated successfully.",
+	})
+}
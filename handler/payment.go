@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"rms/database"
+	"rms/database/dbHelper"
+	"rms/middlewares"
+	"rms/models"
+	"rms/payments"
+	"rms/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+// maxPaymentWebhookBytes bounds the body read before the signature is checked
+const maxPaymentWebhookBytes = 1 << 16
+
+var (
+	errPaymentDeclined = errors.New("payment declined")
+	errPaymentPending  = errors.New("order is not paid yet")
+)
+
+// checkOrderPaid keeps the restaurant from accepting an order whose payment is not authorized, orders placed
+// without a payment are dine-in orders that are paid at the table
+func checkOrderPaid(tx *sqlx.Tx, orderID string) error {
+	payment, err := dbHelper.GetPaymentByOrderID(tx, orderID, true)
+	if err != nil {
+		return err
+	}
+	if payment != nil && payment.Status != models.PaymentAuthorized && payment.Status != models.PaymentCaptured {
+		return fmt.Errorf("%w: payment is %s", errPaymentPending, payment.Status)
+	}
+	return nil
+}
+
+// failPayment marks a pending payment failed and cancels its order if the restaurant has not accepted it yet
+func failPayment(paymentID, reason string) error {
+	var order *models.Order
+	var move orderMove
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		payment, paymentErr := dbHelper.GetPaymentByID(tx, paymentID, true)
+		if paymentErr != nil {
+			return paymentErr
+		}
+		failed, failErr := dbHelper.UpdatePaymentStatus(tx, payment.ID, []models.PaymentStatus{models.PaymentPending}, models.PaymentFailed, reason)
+		if failErr != nil || !failed {
+			return failErr
+		}
+		var orderErr error
+		order, orderErr = dbHelper.GetOrderByID(tx, payment.OrderID)
+		if orderErr != nil {
+			return orderErr
+		}
+		if order.Status != models.OrderPlaced && order.Status != models.OrderScheduled {
+			order = nil
+			return nil
+		}
+		var moveErr error
+		move, moveErr = moveOrderStatus(tx, order, models.OrderCancelled)
+		return moveErr
+	})
+	if txErr != nil {
+		return txErr
+	}
+	if order != nil {
+		publishOrderMove(order, models.OrderCancelled, move)
+	}
+	return nil
+}
+
+// collectPayment asks the provider for an intent for a freshly placed order. A declined intent fails the payment and
+// cancels the order, the returned error then wraps errPaymentDeclined
+func collectPayment(provider payments.Provider, payment *models.Payment) error {
+	intent, intentErr := provider.CreateIntent(payments.IntentRequest{
+		PaymentID: payment.ID,
+		Amount:    payment.Amount,
+		Method:    payment.Method,
+	})
+	if intentErr == nil && intent.Status == payments.IntentFailed {
+		intentErr = errors.New("intent failed at the provider")
+	}
+	if attemptErr := dbHelper.CreatePaymentAttempt(database.RMS, payment.ID, models.PaymentActionCreate, intentErr); attemptErr != nil {
+		logrus.Errorf("Failed to record payment attempt: %s", attemptErr)
+	}
+	if intentErr != nil {
+		if failErr := failPayment(payment.ID, intentErr.Error()); failErr != nil {
+			return failErr
+		}
+		payment.Status = models.PaymentFailed
+		payment.FailureReason = intentErr.Error()
+		return fmt.Errorf("%w: %s", errPaymentDeclined, intentErr)
+	}
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		if refErr := dbHelper.SetPaymentProviderRef(tx, payment.ID, intent.ProviderRef); refErr != nil {
+			return refErr
+		}
+		if intent.Status != payments.IntentAuthorized {
+			return nil
+		}
+		_, authorizeErr := dbHelper.UpdatePaymentStatus(tx, payment.ID, []models.PaymentStatus{models.PaymentPending}, models.PaymentAuthorized, "")
+		return authorizeErr
+	})
+	if txErr != nil {
+		return txErr
+	}
+	payment.ProviderRef = &intent.ProviderRef
+	payment.ClientSecret = intent.ClientSecret
+	if intent.Status == payments.IntentAuthorized {
+		payment.Status = models.PaymentAuthorized
+	}
+	return nil
+}
+
+func PaymentWebhook(w http.ResponseWriter, r *http.Request) {
+	providerName := chi.URLParam(r, "provider")
+	provider, providerErr := payments.Get(providerName)
+	if providerErr != nil {
+		logrus.Errorf("Unknown payment provider: %s", providerName)
+		utils.RespondError(w, http.StatusNotFound, providerErr, "Unknown payment provider")
+		return
+	}
+
+	body, readErr := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, maxPaymentWebhookBytes))
+	if readErr != nil {
+		logrus.Errorf("Failed to read webhook body: %s", readErr)
+		utils.RespondError(w, http.StatusBadRequest, readErr, "Failed to read webhook body")
+		return
+	}
+
+	event, verifyErr := provider.VerifyWebhook(r.Header, body)
+	if verifyErr != nil {
+		if errors.Is(verifyErr, payments.ErrNoWebhooks) {
+			logrus.Errorf("Payment provider sends no webhooks: %s", providerName)
+			utils.RespondError(w, http.StatusNotFound, verifyErr, "Payment provider sends no webhooks")
+			return
+		}
+		logrus.Errorf("Failed to verify payment webhook: %s", verifyErr)
+		utils.RespondError(w, http.StatusUnauthorized, verifyErr, "Invalid webhook signature")
+		return
+	}
+
+	var eventErr error
+	if event.Status == payments.IntentFailed {
+		eventErr = errors.New(event.Reason)
+	}
+	var found, failed bool
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		payment, paymentErr := dbHelper.GetPaymentByID(tx, event.PaymentID, true)
+		if paymentErr != nil || payment == nil || payment.Provider != providerName {
+			return paymentErr
+		}
+		if payment.ProviderRef != nil && event.ProviderRef != "" && *payment.ProviderRef != event.ProviderRef {
+			return nil
+		}
+		found = true
+		if attemptErr := dbHelper.CreatePaymentAttempt(tx, payment.ID, models.PaymentActionWebhook, eventErr); attemptErr != nil {
+			return attemptErr
+		}
+		// the webhook can arrive before the intent reference is stored, so it records the reference too
+		if event.ProviderRef != "" {
+			if refErr := dbHelper.SetPaymentProviderRef(tx, payment.ID, event.ProviderRef); refErr != nil {
+				return refErr
+			}
+		}
+		switch event.Status {
+		case payments.IntentAuthorized:
+			_, authorizeErr := dbHelper.UpdatePaymentStatus(tx, payment.ID, []models.PaymentStatus{models.PaymentPending}, models.PaymentAuthorized, "")
+			return authorizeErr
+		case payments.IntentFailed:
+			failed = payment.Status == models.PaymentPending
+		}
+		return nil
+	})
+	if txErr != nil {
+		logrus.Errorf("Failed to process payment webhook: %s", txErr)
+		utils.RespondError(w, http.StatusInternalServerError, txErr, "Failed to process payment webhook")
+		return
+	}
+	if !found {
+		logrus.Errorf("Payment not exist: %s", event.PaymentID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Payment not exist")
+		return
+	}
+	if failed {
+		if failErr := failPayment(event.PaymentID, event.Reason); failErr != nil {
+			logrus.Errorf("Failed to fail payment: %s", failErr)
+			utils.RespondError(w, http.StatusInternalServerError, failErr, "Failed to process payment webhook")
+			return
+		}
+	}
+	logrus.Infof("Payment webhook processed successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Payment webhook processed successfully.",
+	})
+}
+
+func GetMyOrderPayment(w http.ResponseWriter, r *http.Request) {
+	orderID := chi.URLParam(r, "orderId")
+	userCtx := middlewares.UserContext(r)
+	payment, err := dbHelper.GetPaymentByOrderID(database.RMS, orderID, false)
+	if err != nil {
+		logrus.Errorf("Failed to get payment: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to get payment")
+		return
+	}
+	if payment == nil || payment.UserID != userCtx.ID {
+		logrus.Errorf("Payment not exist for order: %s", orderID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Payment not exist")
+		return
+	}
+	logrus.Infof("Get Payment successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetPayment{
+		Message: "Get Payment successfully.",
+		Payment: *payment,
+	})
+}
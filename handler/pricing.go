@@ -0,0 +1,314 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"rms/database"
+	"rms/database/dbHelper"
+	"rms/middlewares"
+	"rms/models"
+	"rms/pricing"
+	"rms/utils"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+var errRestaurantNotFound = errors.New("restaurant not exists")
+
+// itemsTotal is what the lines cost after their dish discounts, coupons are checked against it
+func itemsTotal(lines []models.PriceLine) int64 {
+	var total int64
+	for _, line := range lines {
+		total += line.Total
+	}
+	return total
+}
+
+// quoteLines prices the lines with the charges and taxes of the restaurant, the same breakdown backs the cart, the
+// order and its invoice
+func quoteLines(db sqlx.Ext, restaurantID string, lines []models.PriceLine, coupon *models.Coupon, points int64, delivery bool) (models.PriceBreakdown, error) {
+	restaurantPricing, err := dbHelper.GetRestaurantPricing(db, restaurantID)
+	if err != nil {
+		return models.PriceBreakdown{}, err
+	}
+	if restaurantPricing == nil {
+		return models.PriceBreakdown{}, errRestaurantNotFound
+	}
+	return quote(*restaurantPricing, lines, coupon, points, delivery)
+}
+
+func quote(restaurantPricing models.RestaurantPricing, lines []models.PriceLine, coupon *models.Coupon, points int64, delivery bool) (models.PriceBreakdown, error) {
+	input := pricing.Input{
+		Items:    lines,
+		Points:   points,
+		Delivery: delivery,
+		Pricing:  restaurantPricing,
+	}
+	// a nil coupon must stay a nil Discounter
+	if coupon != nil {
+		input.Coupon = coupon
+		input.CouponCode = coupon.Code
+	}
+	return pricing.Compute(input)
+}
+
+// applyBreakdown copies the amounts of a breakdown onto the order
+func applyBreakdown(order *models.Order, breakdown models.PriceBreakdown) {
+	order.SubTotal = breakdown.SubTotal
+	order.Discount = breakdown.DishDiscount
+	order.CouponDiscount = breakdown.CouponDiscount
+	order.PackagingCharge = breakdown.Packaging
+	order.DeliveryFee = breakdown.DeliveryFee
+	order.Tax = breakdown.Tax
+	order.TaxIncluded = breakdown.TaxIncluded
+	order.Rounding = breakdown.Rounding
+	order.PointsRedeemed = breakdown.PointsRedeemed
+	order.Total = breakdown.Total
+	order.Breakdown = &breakdown
+}
+
+func breakdownItems(breakdown models.PriceBreakdown) []models.OrderItem {
+	items := make([]models.OrderItem, 0, len(breakdown.Items))
+	for _, line := range breakdown.Items {
+		items = append(items, line.OrderItem)
+	}
+	return items
+}
+
+// PriceCart shows the full price of a cart before checkout, with the coupon and points the customer picked
+func PriceCart(w http.ResponseWriter, r *http.Request) {
+	var body models.PriceCartBody
+	userCtx := middlewares.UserContext(r)
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+	if len(body.Items) == 0 {
+		logrus.Errorf("Cart must have at least one item.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Cart must have at least one item.")
+		return
+	}
+	for _, item := range body.Items {
+		if item.Quantity <= 0 {
+			logrus.Errorf("Invalid Quantity.")
+			utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Quantity.")
+			return
+		}
+	}
+	if body.RedeemPoints < 0 {
+		logrus.Errorf("Invalid Redeem Points.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Redeem Points.")
+		return
+	}
+
+	lines, itemsErr := priceCartItems(func(dishID string) (*models.Dishes, error) {
+		return dbHelper.GetRestaurantDishById(body.RestaurantID, dishID, cartServedAt(body.ScheduledFor))
+	}, body.Items)
+	if itemsErr != nil {
+		if errors.Is(itemsErr, errDishNotFound) || errors.Is(itemsErr, errDishOutOfStock) || errors.Is(itemsErr, errDishUnavailable) {
+			logrus.Errorf("Failed to price cart: %s", itemsErr)
+			utils.RespondError(w, http.StatusBadRequest, itemsErr, itemsErr.Error())
+			return
+		}
+		logrus.Errorf("Failed to price cart: %s", itemsErr)
+		utils.RespondError(w, http.StatusInternalServerError, itemsErr, "Failed to price cart")
+		return
+	}
+
+	var coupon *models.Coupon
+	if body.CouponCode != "" {
+		var couponErr error
+		coupon, _, couponErr = evaluateCoupon(database.RMS, body.CouponCode, userCtx.ID, body.RestaurantID, itemsTotal(lines), false)
+		if couponErr != nil {
+			if errors.Is(couponErr, errCouponInvalid) {
+				logrus.Errorf("Failed to apply coupon: %s", couponErr)
+				utils.RespondError(w, http.StatusBadRequest, couponErr, couponErr.Error())
+				return
+			}
+			logrus.Errorf("Failed to apply coupon: %s", couponErr)
+			utils.RespondError(w, http.StatusInternalServerError, couponErr, "Failed to apply coupon")
+			return
+		}
+	}
+
+	breakdown, quoteErr := quoteLines(database.RMS, body.RestaurantID, lines, coupon, body.RedeemPoints, true)
+	if quoteErr != nil {
+		if errors.Is(quoteErr, errRestaurantNotFound) {
+			logrus.Errorf("Failed to price cart: %s", quoteErr)
+			utils.RespondError(w, http.StatusBadRequest, quoteErr, "Restaurant not exists")
+			return
+		}
+		if errors.Is(quoteErr, models.ErrMoneyOverflow) {
+			logrus.Errorf("Failed to price cart: %s", quoteErr)
+			utils.RespondError(w, http.StatusBadRequest, quoteErr, "Cart total is too large")
+			return
+		}
+		logrus.Errorf("Failed to price cart: %s", quoteErr)
+		utils.RespondError(w, http.StatusInternalServerError, quoteErr, "Failed to price cart")
+		return
+	}
+
+	if body.RedeemPoints > 0 {
+		balance, balanceErr := dbHelper.GetLoyaltyBalance(database.RMS, userCtx.ID)
+		if balanceErr != nil {
+			logrus.Errorf("Unable to get Loyalty Balance: %s", balanceErr)
+			utils.RespondError(w, http.StatusInternalServerError, balanceErr, "Unable to get Loyalty Balance")
+			return
+		}
+		if body.RedeemPoints > balance || body.RedeemPoints > breakdown.GrandTotal {
+			pointsErr := fmt.Errorf("%w: at most %d points can be redeemed on this order", errInsufficientPoints, minInt64(balance, breakdown.GrandTotal))
+			logrus.Errorf("Failed to price cart: %s", pointsErr)
+			utils.RespondError(w, http.StatusBadRequest, pointsErr, pointsErr.Error())
+			return
+		}
+	}
+
+	logrus.Infof("Cart priced successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.PriceCart{
+		Message:   "Cart priced successfully.",
+		Breakdown: breakdown,
+	})
+}
+
+func minInt64(a, b int64) int64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Restaurant Pricing
+
+func GetRestaurantPricing(w http.ResponseWriter, r *http.Request) {
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	restaurantPricing, err := dbHelper.GetRestaurantPricing(database.RMS, restaurant.ID)
+	if err != nil || restaurantPricing == nil {
+		logrus.Errorf("Failed to get Pricing: %v", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to get Pricing")
+		return
+	}
+	logrus.Infof("Get Pricing successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetRestaurantPricing{
+		Message: "Get Pricing successfully.",
+		Pricing: *restaurantPricing,
+	})
+}
+
+func UpdateRestaurantPricing(w http.ResponseWriter, r *http.Request) {
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	var body models.UpdateRestaurantPricingBody
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+
+	if body.DeliveryFee < 0 || body.FreeDeliveryAbove < 0 {
+		logrus.Errorf("Invalid Delivery Fee.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Delivery Fee.")
+		return
+	}
+
+	if body.RoundingUnit == 0 {
+		body.RoundingUnit = 1
+	}
+	if body.RoundingUnit < 0 {
+		logrus.Errorf("Invalid Rounding Unit.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Rounding Unit.")
+		return
+	}
+
+	if err := dbHelper.UpdateRestaurantPricing(restaurant.ID, &body); err != nil {
+		logrus.Errorf("Failed to update Pricing: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to update Pricing")
+		return
+	}
+	logrus.Infof("Pricing updated successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Pricing updated successfully.",
+	})
+}
+
+func AddTaxRate(w http.ResponseWriter, r *http.Request) {
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	adminCtx := middlewares.UserContext(r)
+	var body models.AddTaxRateBody
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+
+	body.Name = strings.TrimSpace(body.Name)
+	body.Category = strings.TrimSpace(body.Category)
+	if body.Name == "" {
+		logrus.Errorf("Name is required.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Name is required.")
+		return
+	}
+
+	if body.RateBps <= 0 || body.RateBps > 10000 {
+		logrus.Errorf("Invalid Rate: %d", body.RateBps)
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Rate.")
+		return
+	}
+
+	exists, existsErr := dbHelper.IsTaxRateExists(restaurant.ID, body.Category, body.Name)
+	if existsErr != nil {
+		logrus.Errorf("Failed to check Tax Rate existence: %s", existsErr)
+		utils.RespondError(w, http.StatusInternalServerError, existsErr, "Failed to check Tax Rate existence")
+		return
+	}
+	if exists {
+		logrus.Errorf("Tax Rate already exists: %s", body.Name)
+		utils.RespondError(w, http.StatusConflict, nil, "Tax Rate already exists")
+		return
+	}
+
+	if _, saveErr := dbHelper.CreateTaxRate(restaurant.ID, adminCtx.ID, &body); saveErr != nil {
+		logrus.Errorf("Failed to add Tax Rate: %s", saveErr)
+		utils.RespondError(w, http.StatusInternalServerError, saveErr, "Failed to add Tax Rate")
+		return
+	}
+	logrus.Infof("Tax Rate added successfully.")
+	utils.RespondJSON(w, http.StatusCreated, models.Message{
+		Message: "Tax Rate added successfully.",
+	})
+}
+
+func RemoveTaxRate(w http.ResponseWriter, r *http.Request) {
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	taxRateID := chi.URLParam(r, "taxRateId")
+	removed, err := dbHelper.ArchiveTaxRate(restaurant.ID, taxRateID)
+	if err != nil {
+		logrus.Errorf("Failed to remove Tax Rate: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to remove Tax Rate")
+		return
+	}
+	if !removed {
+		logrus.Errorf("Tax Rate not exist: %s", taxRateID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Tax Rate not exist")
+		return
+	}
+	logrus.Infof("Tax Rate removed successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Tax Rate removed successfully.",
+	})
+}
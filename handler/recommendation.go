@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"net/http"
+	"rms/database/dbHelper"
+	"rms/middlewares"
+	"rms/models"
+	"rms/utils"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+// maxRecommendations is as many picks of each kind as the recommendation job keeps
+const maxRecommendations = 20
+
+// GetMyRecommendations reads the picks the recommendation job last worked out for the customer, customers that
+// signed up since get theirs on the next run
+func GetMyRecommendations(w http.ResponseWriter, r *http.Request) {
+	userCtx := middlewares.UserContext(r)
+	limit := int64(maxRecommendations)
+	if value := r.URL.Query().Get("limit"); value != "" {
+		parsed, parseErr := strconv.ParseInt(value, 10, 64)
+		if parseErr != nil || parsed <= 0 || parsed > maxRecommendations {
+			logrus.Errorf("Invalid Limit: %s", value)
+			utils.RespondError(w, http.StatusBadRequest, parseErr, "Invalid Limit, use 1 to "+strconv.Itoa(maxRecommendations)+".")
+			return
+		}
+		limit = parsed
+	}
+
+	var dishes []models.RecommendedDish
+	var restaurants []models.RecommendedRestaurant
+	var favouriteDishes, favouriteRestaurants map[string]bool
+	var errGroup errgroup.Group
+	errGroup.Go(func() error {
+		var err error
+		dishes, err = dbHelper.GetDishRecommendations(userCtx.ID, limit)
+		if err != nil {
+			return err
+		}
+		dishIDs := make([]string, 0, len(dishes))
+		for _, dish := range dishes {
+			dishIDs = append(dishIDs, dish.ID)
+		}
+		favouriteDishes, err = dbHelper.GetFavouriteDishIDs(userCtx.ID, dishIDs)
+		return err
+	})
+	errGroup.Go(func() error {
+		var err error
+		restaurants, err = dbHelper.GetRestaurantRecommendations(userCtx.ID, limit)
+		if err != nil {
+			return err
+		}
+		restaurantIDs := make([]string, 0, len(restaurants))
+		for _, restaurant := range restaurants {
+			restaurantIDs = append(restaurantIDs, restaurant.ID)
+		}
+		favouriteRestaurants, err = dbHelper.GetFavouriteRestaurantIDs(userCtx.ID, restaurantIDs)
+		return err
+	})
+	if err := errGroup.Wait(); err != nil {
+		logrus.Errorf("Failed to get Recommendations: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to get Recommendations")
+		return
+	}
+	computedAt, computedErr := dbHelper.GetRecommendationsComputedAt(userCtx.ID)
+	if computedErr != nil {
+		logrus.Errorf("Failed to get Recommendations: %s", computedErr)
+		utils.RespondError(w, http.StatusInternalServerError, computedErr, "Failed to get Recommendations")
+		return
+	}
+	for i := range dishes {
+		dishes[i].Favourite = favouriteDishes[dishes[i].ID]
+	}
+	for i := range restaurants {
+		restaurants[i].Favourite = favouriteRestaurants[restaurants[i].ID]
+	}
+	logrus.Infof("Get Recommendations successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetRecommendations{
+		Message:     "Get Recommendations successfully.",
+		Dishes:      dishes,
+		Restaurants: restaurants,
+		ComputedAt:  computedAt,
+	})
+}
@@ -0,0 +1,233 @@
+package handler
+
+import (
+	"math"
+	"net/http"
+	"rms/database/dbHelper"
+	"rms/models"
+	"rms/utils"
+	"strconv"
+	"time"
+	// time zones are looked up without relying on the zone database of the host
+	_ "time/tzdata"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	reportDateLayout       = "2006-01-02"
+	reportDefaultDays      = 30
+	reportMaxDays          = 366
+	reportDefaultTopDishes = 10
+	reportMaxTopDishes     = 100
+)
+
+// reportQuery is the scope and the range of a report, to is the start of the day after the last one
+type reportQuery struct {
+	restaurantID *string
+	from         time.Time
+	to           time.Time
+	timeZone     string
+	reportRange  models.ReportRange
+}
+
+// parseReportQuery reads the restaurant, the dates and the time zone of a report. Restaurant routes report on the
+// restaurant, the admin routes without one on the whole platform. The range defaults to the last 30 days in UTC.
+func parseReportQuery(w http.ResponseWriter, r *http.Request) (reportQuery, bool) {
+	var query reportQuery
+	if chi.URLParam(r, "restaurantId") != "" {
+		restaurant, ok := getManagedRestaurant(w, r)
+		if !ok {
+			return query, false
+		}
+		query.restaurantID = &restaurant.ID
+	}
+
+	timeZone := r.URL.Query().Get("timeZone")
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
+	location, locationErr := time.LoadLocation(timeZone)
+	if locationErr != nil || timeZone == "Local" {
+		logrus.Errorf("Invalid Time Zone: %s", timeZone)
+		utils.RespondError(w, http.StatusBadRequest, locationErr, "Invalid Time Zone.")
+		return query, false
+	}
+
+	now := time.Now().In(location)
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, location)
+	if value := r.URL.Query().Get("to"); value != "" {
+		var toErr error
+		to, toErr = time.ParseInLocation(reportDateLayout, value, location)
+		if toErr != nil {
+			logrus.Errorf("Invalid To Date: %s", toErr)
+			utils.RespondError(w, http.StatusBadRequest, toErr, "Invalid To Date.")
+			return query, false
+		}
+	}
+	from := to.AddDate(0, 0, 1-reportDefaultDays)
+	if value := r.URL.Query().Get("from"); value != "" {
+		var fromErr error
+		from, fromErr = time.ParseInLocation(reportDateLayout, value, location)
+		if fromErr != nil {
+			logrus.Errorf("Invalid From Date: %s", fromErr)
+			utils.RespondError(w, http.StatusBadRequest, fromErr, "Invalid From Date.")
+			return query, false
+		}
+	}
+	if to.Before(from) {
+		logrus.Errorf("From Date is after To Date.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "From Date is after To Date.")
+		return query, false
+	}
+	if !to.Before(from.AddDate(0, 0, reportMaxDays)) {
+		logrus.Errorf("Report range is longer than %d days.", reportMaxDays)
+		utils.RespondError(w, http.StatusBadRequest, nil, "Report range can be at most 366 days.")
+		return query, false
+	}
+
+	refreshedUntil, refreshErr := dbHelper.GetReportRefreshedUntil()
+	if refreshErr != nil {
+		logrus.Errorf("Failed to get report refresh: %s", refreshErr)
+		utils.RespondError(w, http.StatusInternalServerError, refreshErr, "Failed to get report")
+		return query, false
+	}
+	query.from = from
+	query.to = to.AddDate(0, 0, 1)
+	query.timeZone = location.String()
+	query.reportRange = models.ReportRange{
+		From:           from.Format(reportDateLayout),
+		To:             to.Format(reportDateLayout),
+		TimeZone:       location.String(),
+		RefreshedUntil: refreshedUntil,
+	}
+	return query, true
+}
+
+func GetRevenueReport(w http.ResponseWriter, r *http.Request) {
+	query, ok := parseReportQuery(w, r)
+	if !ok {
+		return
+	}
+	granularity := models.ReportGranularity(r.URL.Query().Get("granularity"))
+	if granularity == "" {
+		granularity = models.ReportDay
+	}
+	if !granularity.IsValid() {
+		logrus.Errorf("Invalid Granularity: %s", granularity)
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Granularity.")
+		return
+	}
+	revenue, err := dbHelper.GetRevenueReport(query.restaurantID, query.from, query.to, granularity, query.timeZone)
+	if err != nil {
+		logrus.Errorf("Unable to get Revenue Report: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Unable to get Revenue Report")
+		return
+	}
+	logrus.Infof("Get Revenue Report successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetRevenueReport{
+		Message:     "Get Revenue Report successfully.",
+		Range:       query.reportRange,
+		Granularity: granularity,
+		Revenue:     revenue,
+	})
+}
+
+func GetTopDishesReport(w http.ResponseWriter, r *http.Request) {
+	query, ok := parseReportQuery(w, r)
+	if !ok {
+		return
+	}
+	limit, limitErr := strconv.ParseInt(r.URL.Query().Get("limit"), 10, 64)
+	if limitErr != nil || limit <= 0 {
+		limit = reportDefaultTopDishes
+	}
+	if limit > reportMaxTopDishes {
+		limit = reportMaxTopDishes
+	}
+	dishes, err := dbHelper.GetTopDishesReport(query.restaurantID, query.from, query.to, limit)
+	if err != nil {
+		logrus.Errorf("Unable to get Top Dishes Report: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Unable to get Top Dishes Report")
+		return
+	}
+	logrus.Infof("Get Top Dishes Report successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetTopDishesReport{
+		Message: "Get Top Dishes Report successfully.",
+		Range:   query.reportRange,
+		Dishes:  dishes,
+	})
+}
+
+func GetOrderSummaryReport(w http.ResponseWriter, r *http.Request) {
+	query, ok := parseReportQuery(w, r)
+	if !ok {
+		return
+	}
+	summary, err := dbHelper.GetOrderSummaryReport(query.restaurantID, query.from, query.to)
+	if err != nil {
+		logrus.Errorf("Unable to get Order Summary Report: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Unable to get Order Summary Report")
+		return
+	}
+	if summary.Orders > 0 {
+		summary.CancellationRate = math.Round(float64(summary.Cancelled)/float64(summary.Orders)*10000) / 10000
+	}
+	for index := range summary.Revenue {
+		revenue := &summary.Revenue[index]
+		revenue.AverageOrderValue = revenue.Revenue / revenue.Delivered
+	}
+	logrus.Infof("Get Order Summary Report successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetOrderSummaryReport{
+		Message: "Get Order Summary Report successfully.",
+		Range:   query.reportRange,
+		Summary: summary,
+	})
+}
+
+func GetPeakHoursReport(w http.ResponseWriter, r *http.Request) {
+	query, ok := parseReportQuery(w, r)
+	if !ok {
+		return
+	}
+	busy, err := dbHelper.GetPeakHoursReport(query.restaurantID, query.from, query.to, query.timeZone)
+	if err != nil {
+		logrus.Errorf("Unable to get Peak Hours Report: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Unable to get Peak Hours Report")
+		return
+	}
+	// every hour of the day is listed, quiet ones with no orders
+	hours := make([]models.PeakHour, 24)
+	for hour := range hours {
+		hours[hour].Hour = hour
+	}
+	for _, hour := range busy {
+		hours[hour.Hour].Orders = hour.Orders
+	}
+	logrus.Infof("Get Peak Hours Report successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetPeakHoursReport{
+		Message:   "Get Peak Hours Report successfully.",
+		Range:     query.reportRange,
+		PeakHours: hours,
+	})
+}
+
+func GetCustomerReport(w http.ResponseWriter, r *http.Request) {
+	query, ok := parseReportQuery(w, r)
+	if !ok {
+		return
+	}
+	customers, err := dbHelper.GetCustomerReport(query.restaurantID, query.from, query.to)
+	if err != nil {
+		logrus.Errorf("Unable to get Customer Report: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Unable to get Customer Report")
+		return
+	}
+	logrus.Infof("Get Customer Report successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetCustomerReport{
+		Message:   "Get Customer Report successfully.",
+		Range:     query.reportRange,
+		Customers: customers,
+	})
+}
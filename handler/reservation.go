@@ -0,0 +1,584 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"rms/database"
+	"rms/database/dbHelper"
+	"rms/middlewares"
+	"rms/models"
+	"rms/utils"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	defaultReservationMinutes = 90
+	maxReservationMinutes     = 6 * 60
+)
+
+var (
+	errReservationUnavailable = errors.New("no table available")
+	errReservationMoved       = errors.New("reservation can no longer be changed")
+)
+
+// reservationOption is a bookable start time with the tables that are free for the whole stay, smallest first
+type reservationOption struct {
+	startsAt time.Time
+	endsAt   time.Time
+	tables   []models.RestaurantTable
+}
+
+// freeTables picks the tables that seat the party and are neither booked nor blocked between start and end,
+// ignoreID leaves a reservation that is being moved out of the check
+func freeTables(tables []models.RestaurantTable, reservations []models.Reservation, blocks []models.ReservationBlock,
+	partySize int64, start, end time.Time, ignoreID string) []models.RestaurantTable {
+	for _, block := range blocks {
+		if block.TableID == nil && block.StartsAt.Before(end) && block.EndsAt.After(start) {
+			return nil
+		}
+	}
+	free := make([]models.RestaurantTable, 0)
+	for _, table := range tables {
+		if table.Seats < partySize {
+			continue
+		}
+		taken := false
+		for _, reservation := range reservations {
+			if reservation.ID != ignoreID && reservation.TableID == table.ID && reservation.StartsAt.Before(end) && reservation.EndsAt.After(start) {
+				taken = true
+				break
+			}
+		}
+		for _, block := range blocks {
+			if block.TableID != nil && *block.TableID == table.ID && block.StartsAt.Before(end) && block.EndsAt.After(start) {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			free = append(free, table)
+		}
+	}
+	sort.SliceStable(free, func(i, j int) bool {
+		return free[i].Seats < free[j].Seats
+	})
+	return free
+}
+
+// reservationOptions lists the start times on the local day of the given time where the party finds a free table,
+// a stay starts on the restaurant's slot grid and has to end before closing
+func reservationOptions(db sqlx.Ext, restaurantID string, schedule *models.RestaurantSchedule, day time.Time, partySize int64,
+	duration time.Duration, ignoreID string) ([]reservationOption, error) {
+	location, locationErr := time.LoadLocation(schedule.Timezone)
+	if locationErr != nil {
+		return nil, locationErr
+	}
+	day = day.In(location)
+	dayStart := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, location)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+	hours, hoursErr := dbHelper.GetRestaurantHours(db, restaurantID)
+	if hoursErr != nil {
+		return nil, hoursErr
+	}
+	windows, windowsErr := openingWindows(hours, dayStart)
+	if windowsErr != nil {
+		return nil, windowsErr
+	}
+	tables, tablesErr := dbHelper.GetRestaurantTables(db, restaurantID)
+	if tablesErr != nil {
+		return nil, tablesErr
+	}
+	reservations, reservationsErr := dbHelper.GetBookedReservations(db, restaurantID, dayStart, dayEnd.Add(duration))
+	if reservationsErr != nil {
+		return nil, reservationsErr
+	}
+	blocks, blocksErr := dbHelper.GetReservationBlocks(db, restaurantID, dayStart, dayEnd.Add(duration))
+	if blocksErr != nil {
+		return nil, blocksErr
+	}
+	step := time.Duration(schedule.SlotMinutes) * time.Minute
+	now := time.Now()
+	options := make([]reservationOption, 0)
+	for _, window := range windows {
+		for start := window[0]; !start.Add(duration).After(window[1]); start = start.Add(step) {
+			if start.Before(now) {
+				continue
+			}
+			free := freeTables(tables, reservations, blocks, partySize, start, start.Add(duration), ignoreID)
+			if len(free) == 0 {
+				continue
+			}
+			options = append(options, reservationOption{
+				startsAt: start,
+				endsAt:   start.Add(duration),
+				tables:   free,
+			})
+		}
+	}
+	return options, nil
+}
+
+// bookTable locks the restaurant's bookings and assigns the smallest free table that fits the party at the time
+func bookTable(tx *sqlx.Tx, reservation *models.Reservation, duration time.Duration) error {
+	schedule, scheduleErr := dbHelper.GetRestaurantSchedule(tx, reservation.RestaurantID, true)
+	if scheduleErr != nil {
+		return scheduleErr
+	}
+	if schedule == nil {
+		return fmt.Errorf("%w: restaurant not exists", errReservationUnavailable)
+	}
+	if duration == 0 {
+		duration = time.Duration(schedule.ReservationMinutes) * time.Minute
+	}
+	options, optionsErr := reservationOptions(tx, reservation.RestaurantID, schedule, reservation.StartsAt, reservation.PartySize, duration, reservation.ID)
+	if optionsErr != nil {
+		return optionsErr
+	}
+	for _, option := range options {
+		if option.startsAt.Equal(reservation.StartsAt) {
+			reservation.TableID = option.tables[0].ID
+			reservation.EndsAt = option.endsAt
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: for %d at %s", errReservationUnavailable, reservation.PartySize, reservation.StartsAt.Format(time.RFC3339))
+}
+
+// validateReservationBody checks the party and stay of a reservation body, it responds with the error itself
+func validateReservationBody(w http.ResponseWriter, body *models.ReservationBody) bool {
+	if body.PartySize <= 0 {
+		logrus.Errorf("Invalid Party Size.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Party Size.")
+		return false
+	}
+	if body.DurationMinutes < 0 || body.DurationMinutes > maxReservationMinutes {
+		logrus.Errorf("Invalid Duration.")
+		utils.RespondError(w, http.StatusBadRequest, nil, fmt.Sprintf("Invalid Duration, at most %d minutes.", maxReservationMinutes))
+		return false
+	}
+	if !body.StartsAt.After(time.Now()) {
+		logrus.Errorf("Reservation must be in the future.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Reservation must be in the future.")
+		return false
+	}
+	return true
+}
+
+// getMyReservation loads a reservation of the current user that can still be changed, it responds with the error itself
+func getMyReservation(w http.ResponseWriter, r *http.Request) (*models.Reservation, bool) {
+	reservationID := chi.URLParam(r, "reservationId")
+	userCtx := middlewares.UserContext(r)
+	reservation, reservationErr := dbHelper.GetReservationByID(database.RMS, reservationID)
+	if reservationErr != nil {
+		logrus.Errorf("Failed to get Reservation: %s", reservationErr)
+		utils.RespondError(w, http.StatusInternalServerError, reservationErr, "Failed to get Reservation")
+		return nil, false
+	}
+	if reservation == nil || reservation.UserID != userCtx.ID {
+		logrus.Errorf("Reservation not exist: %s", reservationID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Reservation not exist")
+		return nil, false
+	}
+	if reservation.Status != models.ReservationBooked || !reservation.StartsAt.After(time.Now()) {
+		logrus.Errorf("Reservation %s can't be changed in status %s", reservation.ID, reservation.Status)
+		utils.RespondError(w, http.StatusBadRequest, nil, "Reservation can no longer be changed")
+		return nil, false
+	}
+	return reservation, true
+}
+
+func respondReservationTxError(w http.ResponseWriter, txErr error, message string) {
+	logrus.Errorf("%s: %s", message, txErr)
+	if errors.Is(txErr, errReservationUnavailable) || errors.Is(txErr, errReservationMoved) {
+		utils.RespondError(w, http.StatusConflict, txErr, txErr.Error())
+		return
+	}
+	utils.RespondError(w, http.StatusInternalServerError, txErr, message)
+}
+
+func GetReservationSlots(w http.ResponseWriter, r *http.Request) {
+	restaurantID := chi.URLParam(r, "restaurantId")
+	partySize, partySizeErr := strconv.ParseInt(r.URL.Query().Get("partySize"), 10, 64)
+	if partySizeErr != nil || partySize <= 0 {
+		logrus.Errorf("Invalid Party Size: %s", partySizeErr)
+		utils.RespondError(w, http.StatusBadRequest, partySizeErr, "Invalid Party Size.")
+		return
+	}
+	var durationMinutes int64
+	if durationParam := r.URL.Query().Get("durationMinutes"); durationParam != "" {
+		parsed, parseErr := strconv.ParseInt(durationParam, 10, 64)
+		if parseErr != nil || parsed <= 0 || parsed > maxReservationMinutes {
+			logrus.Errorf("Invalid Duration: %s", durationParam)
+			utils.RespondError(w, http.StatusBadRequest, parseErr, fmt.Sprintf("Invalid Duration, at most %d minutes.", maxReservationMinutes))
+			return
+		}
+		durationMinutes = parsed
+	}
+
+	schedule, scheduleErr := dbHelper.GetRestaurantSchedule(database.RMS, restaurantID, false)
+	if scheduleErr != nil {
+		logrus.Errorf("Unable to get Restaurant schedule: %s", scheduleErr)
+		utils.RespondError(w, http.StatusInternalServerError, scheduleErr, "Unable to get Restaurant schedule")
+		return
+	}
+	if schedule == nil {
+		logrus.Errorf("Restaurant not exist: %s", restaurantID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Restaurant not exist")
+		return
+	}
+	if durationMinutes == 0 {
+		durationMinutes = schedule.ReservationMinutes
+	}
+	location, locationErr := time.LoadLocation(schedule.Timezone)
+	if locationErr != nil {
+		logrus.Errorf("Invalid Restaurant timezone: %s", locationErr)
+		utils.RespondError(w, http.StatusInternalServerError, locationErr, "Invalid Restaurant timezone")
+		return
+	}
+	day, dateErr := parseSlotDay(r, location)
+	if dateErr != nil {
+		logrus.Errorf("Invalid Date: %s", dateErr)
+		utils.RespondError(w, http.StatusBadRequest, dateErr, "Invalid Date, use YYYY-MM-DD.")
+		return
+	}
+
+	options, optionsErr := reservationOptions(database.RMS, restaurantID, schedule, day, partySize, time.Duration(durationMinutes)*time.Minute, "")
+	if optionsErr != nil {
+		logrus.Errorf("Unable to get Reservation Slots: %s", optionsErr)
+		utils.RespondError(w, http.StatusInternalServerError, optionsErr, "Unable to get Reservation Slots")
+		return
+	}
+	slots := make([]models.ReservationSlot, 0, len(options))
+	for _, option := range options {
+		slots = append(slots, models.ReservationSlot{
+			StartsAt:   option.startsAt,
+			EndsAt:     option.endsAt,
+			FreeTables: int64(len(option.tables)),
+		})
+	}
+	logrus.Infof("Get Reservation Slots successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetReservationSlots{
+		Message: "Get Reservation Slots successfully.",
+		Slots:   slots,
+	})
+}
+
+func CreateReservation(w http.ResponseWriter, r *http.Request) {
+	var body models.ReservationBody
+	userCtx := middlewares.UserContext(r)
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+	if !validateReservationBody(w, &body) {
+		return
+	}
+
+	reservation := models.Reservation{
+		RestaurantID: body.RestaurantID,
+		UserID:       userCtx.ID,
+		PartySize:    body.PartySize,
+		StartsAt:     body.StartsAt,
+		Note:         body.Note,
+	}
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		if bookErr := bookTable(tx, &reservation, time.Duration(body.DurationMinutes)*time.Minute); bookErr != nil {
+			return bookErr
+		}
+		var createErr error
+		reservation.ID, createErr = dbHelper.CreateReservation(tx, &reservation)
+		return createErr
+	})
+	if txErr != nil {
+		respondReservationTxError(w, txErr, "Failed to create Reservation")
+		return
+	}
+
+	created, createdErr := dbHelper.GetReservationByID(database.RMS, reservation.ID)
+	if createdErr != nil {
+		logrus.Errorf("Failed to get Reservation: %s", createdErr)
+		utils.RespondError(w, http.StatusInternalServerError, createdErr, "Failed to get Reservation")
+		return
+	}
+	logrus.Infof("Reservation created successfully.")
+	utils.RespondJSON(w, http.StatusCreated, models.GetReservation{
+		Message:     "Reservation created successfully.",
+		Reservation: *created,
+	})
+}
+
+func UpdateMyReservation(w http.ResponseWriter, r *http.Request) {
+	var body models.ReservationBody
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+	if !validateReservationBody(w, &body) {
+		return
+	}
+	reservation, ok := getMyReservation(w, r)
+	if !ok {
+		return
+	}
+
+	reservation.PartySize = body.PartySize
+	reservation.StartsAt = body.StartsAt
+	reservation.Note = body.Note
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		if bookErr := bookTable(tx, reservation, time.Duration(body.DurationMinutes)*time.Minute); bookErr != nil {
+			return bookErr
+		}
+		updated, updateErr := dbHelper.UpdateReservation(tx, reservation)
+		if updateErr != nil {
+			return updateErr
+		}
+		if !updated {
+			return errReservationMoved
+		}
+		return nil
+	})
+	if txErr != nil {
+		respondReservationTxError(w, txErr, "Failed to update Reservation")
+		return
+	}
+
+	updated, updatedErr := dbHelper.GetReservationByID(database.RMS, reservation.ID)
+	if updatedErr != nil {
+		logrus.Errorf("Failed to get Reservation: %s", updatedErr)
+		utils.RespondError(w, http.StatusInternalServerError, updatedErr, "Failed to get Reservation")
+		return
+	}
+	logrus.Infof("Reservation updated successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetReservation{
+		Message:     "Reservation updated successfully.",
+		Reservation: *updated,
+	})
+}
+
+func CancelMyReservation(w http.ResponseWriter, r *http.Request) {
+	reservation, ok := getMyReservation(w, r)
+	if !ok {
+		return
+	}
+	cancelled, cancelErr := dbHelper.UpdateReservationStatus(database.RMS, reservation.ID, models.ReservationBooked, models.ReservationCancelled)
+	if cancelErr != nil {
+		logrus.Errorf("Failed to cancel Reservation: %s", cancelErr)
+		utils.RespondError(w, http.StatusInternalServerError, cancelErr, "Failed to cancel Reservation")
+		return
+	}
+	if !cancelled {
+		logrus.Errorf("Reservation %s already changed", reservation.ID)
+		utils.RespondError(w, http.StatusConflict, errReservationMoved, errReservationMoved.Error())
+		return
+	}
+	logrus.Infof("Reservation cancelled successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Reservation cancelled successfully.",
+	})
+}
+
+func GetMyReservations(w http.ResponseWriter, r *http.Request) {
+	Filters := utils.GetFilters(r)
+	userCtx := middlewares.UserContext(r)
+	var reservationsCount int64
+	reservations := make([]models.Reservation, 0)
+	var errGroup errgroup.Group
+	errGroup.Go(func() error {
+		var err error
+		reservationsCount, err = dbHelper.GetReservationsCountByUserID(userCtx.ID)
+		if err != nil {
+			logrus.Errorf("Unable to get Reservations Count: %s", err)
+		}
+		return err
+	})
+	errGroup.Go(func() error {
+		var err error
+		reservations, err = dbHelper.GetReservationsByUserID(userCtx.ID, Filters)
+		if err != nil {
+			logrus.Errorf("Unable to get Reservations: %s", err)
+		}
+		return err
+	})
+	if err := errGroup.Wait(); err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "Unable to get Reservations")
+		return
+	}
+	logrus.Infof("Get Reservations successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetReservations{
+		Message:      "Get Reservations successfully.",
+		Reservations: reservations,
+		TotalCount:   reservationsCount,
+		PageNumber:   Filters.PageNumber,
+		PageSize:     Filters.PageSize,
+	})
+}
+
+// GetRestaurantReservationDay returns the bookings and blocks of one local day of the restaurant
+func GetRestaurantReservationDay(w http.ResponseWriter, r *http.Request) {
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	schedule, scheduleErr := dbHelper.GetRestaurantSchedule(database.RMS, restaurant.ID, false)
+	if scheduleErr != nil || schedule == nil {
+		logrus.Errorf("Unable to get Restaurant schedule: %v", scheduleErr)
+		utils.RespondError(w, http.StatusInternalServerError, scheduleErr, "Unable to get Restaurant schedule")
+		return
+	}
+	location, locationErr := time.LoadLocation(schedule.Timezone)
+	if locationErr != nil {
+		logrus.Errorf("Invalid Restaurant timezone: %s", locationErr)
+		utils.RespondError(w, http.StatusInternalServerError, locationErr, "Invalid Restaurant timezone")
+		return
+	}
+	day, dateErr := parseSlotDay(r, location)
+	if dateErr != nil {
+		logrus.Errorf("Invalid Date: %s", dateErr)
+		utils.RespondError(w, http.StatusBadRequest, dateErr, "Invalid Date, use YYYY-MM-DD.")
+		return
+	}
+	dayStart := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, location)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	var reservations []models.Reservation
+	var blocks []models.ReservationBlock
+	var errGroup errgroup.Group
+	errGroup.Go(func() error {
+		var err error
+		reservations, err = dbHelper.GetRestaurantReservations(restaurant.ID, dayStart, dayEnd)
+		if err != nil {
+			logrus.Errorf("Unable to get Reservations: %s", err)
+		}
+		return err
+	})
+	errGroup.Go(func() error {
+		var err error
+		blocks, err = dbHelper.GetReservationBlocks(database.RMS, restaurant.ID, dayStart, dayEnd)
+		if err != nil {
+			logrus.Errorf("Unable to get Reservation Blocks: %s", err)
+		}
+		return err
+	})
+	if err := errGroup.Wait(); err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "Unable to get Reservations")
+		return
+	}
+	logrus.Infof("Get Reservations successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetReservationDay{
+		Message:      "Get Reservations successfully.",
+		Reservations: reservations,
+		Blocks:       blocks,
+	})
+}
+
+// MarkReservationNoShow records that the party didn't come, it frees the table for the rest of the stay
+func MarkReservationNoShow(w http.ResponseWriter, r *http.Request) {
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	reservationID := chi.URLParam(r, "reservationId")
+	reservation, reservationErr := dbHelper.GetReservationByID(database.RMS, reservationID)
+	if reservationErr != nil {
+		logrus.Errorf("Failed to get Reservation: %s", reservationErr)
+		utils.RespondError(w, http.StatusInternalServerError, reservationErr, "Failed to get Reservation")
+		return
+	}
+	if reservation == nil || reservation.RestaurantID != restaurant.ID {
+		logrus.Errorf("Reservation not exist: %s", reservationID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Reservation not exist")
+		return
+	}
+	if reservation.StartsAt.After(time.Now()) {
+		logrus.Errorf("Reservation %s has not started yet", reservation.ID)
+		utils.RespondError(w, http.StatusBadRequest, nil, "Reservation has not started yet")
+		return
+	}
+	marked, markErr := dbHelper.UpdateReservationStatus(database.RMS, reservation.ID, models.ReservationBooked, models.ReservationNoShow)
+	if markErr != nil {
+		logrus.Errorf("Failed to mark Reservation: %s", markErr)
+		utils.RespondError(w, http.StatusInternalServerError, markErr, "Failed to mark Reservation")
+		return
+	}
+	if !marked {
+		logrus.Errorf("Reservation %s is %s", reservation.ID, reservation.Status)
+		utils.RespondError(w, http.StatusConflict, errReservationMoved, errReservationMoved.Error())
+		return
+	}
+	logrus.Infof("Reservation marked as no-show successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Reservation marked as no-show successfully.",
+	})
+}
+
+// AddReservationBlock stops new bookings of a table, or the whole restaurant, in a time range. Existing bookings stay
+func AddReservationBlock(w http.ResponseWriter, r *http.Request) {
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	adminCtx := middlewares.UserContext(r)
+	var body models.AddReservationBlockBody
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+
+	if body.StartsAt.IsZero() || !body.EndsAt.After(body.StartsAt) {
+		logrus.Errorf("Invalid Block Range.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Block Range, ending must be after starting.")
+		return
+	}
+
+	if body.TableID != nil {
+		table, tableErr := dbHelper.GetRestaurantTableByID(*body.TableID)
+		if tableErr != nil {
+			logrus.Errorf("Failed to get Table: %s", tableErr)
+			utils.RespondError(w, http.StatusInternalServerError, tableErr, "Failed to get Table")
+			return
+		}
+		if table == nil || table.RestaurantID != restaurant.ID {
+			logrus.Errorf("Table not exist: %s", *body.TableID)
+			utils.RespondError(w, http.StatusBadRequest, nil, "Table not exist")
+			return
+		}
+	}
+
+	if saveErr := dbHelper.CreateReservationBlock(restaurant.ID, &body, adminCtx.ID); saveErr != nil {
+		logrus.Errorf("Failed to add Reservation Block: %s", saveErr)
+		utils.RespondError(w, http.StatusInternalServerError, saveErr, "Failed to add Reservation Block")
+		return
+	}
+	logrus.Infof("Reservation Block added successfully.")
+	utils.RespondJSON(w, http.StatusCreated, models.Message{
+		Message: "Reservation Block added successfully.",
+	})
+}
+
+func RemoveReservationBlock(w http.ResponseWriter, r *http.Request) {
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	blockID := chi.URLParam(r, "blockId")
+	if err := dbHelper.ArchiveReservationBlock(restaurant.ID, blockID); err != nil {
+		logrus.Errorf("Failed to remove Reservation Block: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to remove Reservation Block")
+		return
+	}
+	logrus.Infof("Reservation Block removed successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Reservation Block removed successfully.",
+	})
+}
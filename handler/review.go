@@ -0,0 +1,327 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"rms/database"
+	"rms/database/dbHelper"
+	"rms/middlewares"
+	"rms/models"
+	"rms/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+var errOrderReviewed = errors.New("order already reviewed")
+
+func isRatingValid(rating int64) bool {
+	return rating >= 1 && rating <= 5
+}
+
+func AddOrderReview(w http.ResponseWriter, r *http.Request) {
+	orderID := chi.URLParam(r, "orderId")
+	var body models.AddReviewBody
+	userCtx := middlewares.UserContext(r)
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+
+	if !isRatingValid(body.Rating) {
+		logrus.Errorf("Invalid Rating.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Rating must be between 1 and 5.")
+		return
+	}
+
+	order, orderErr := dbHelper.GetOrderByID(database.RMS, orderID)
+	if orderErr != nil {
+		logrus.Errorf("Failed to get order: %s", orderErr)
+		utils.RespondError(w, http.StatusInternalServerError, orderErr, "Failed to get order")
+		return
+	}
+	if order == nil || order.UserID != userCtx.ID {
+		logrus.Errorf("Order not exist: %s", orderID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Order not exist")
+		return
+	}
+	if order.Status != models.OrderDelivered {
+		logrus.Errorf("Order not delivered yet: %s", orderID)
+		utils.RespondError(w, http.StatusBadRequest, nil, "Only delivered orders can be reviewed")
+		return
+	}
+
+	orderedDishes := make(map[string]bool)
+	for _, item := range order.Items {
+		orderedDishes[item.DishID] = true
+	}
+	reviewedDishes := make(map[string]bool)
+	for _, dish := range body.Dishes {
+		if !orderedDishes[dish.DishID] || reviewedDishes[dish.DishID] {
+			logrus.Errorf("Dish not part of order: %s", dish.DishID)
+			utils.RespondError(w, http.StatusBadRequest, nil, "Dish not part of order or reviewed twice")
+			return
+		}
+		if !isRatingValid(dish.Rating) {
+			logrus.Errorf("Invalid Dish Rating.")
+			utils.RespondError(w, http.StatusBadRequest, nil, "Dish rating must be between 1 and 5.")
+			return
+		}
+		reviewedDishes[dish.DishID] = true
+	}
+
+	reviewed, reviewedErr := dbHelper.IsOrderReviewed(orderID)
+	if reviewedErr != nil {
+		logrus.Errorf("Failed to check review existence: %s", reviewedErr)
+		utils.RespondError(w, http.StatusInternalServerError, reviewedErr, "Failed to check review existence")
+		return
+	}
+	if reviewed {
+		logrus.Errorf("Order already reviewed: %s", orderID)
+		utils.RespondError(w, http.StatusConflict, nil, "Order already reviewed")
+		return
+	}
+
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		reviewID, reviewErr := dbHelper.CreateReview(tx, order.ID, userCtx.ID, order.RestaurantID, body.Rating, body.Comment)
+		if reviewErr != nil {
+			return reviewErr
+		}
+		// a concurrent submit got in after the check above
+		if reviewID == "" {
+			return errOrderReviewed
+		}
+		if ratingErr := dbHelper.AddRestaurantRating(tx, order.RestaurantID, body.Rating, 1); ratingErr != nil {
+			return ratingErr
+		}
+		for _, dish := range body.Dishes {
+			if dishErr := dbHelper.CreateDishReview(tx, reviewID, dish.DishID, dish.Rating, dish.Comment); dishErr != nil {
+				return dishErr
+			}
+			if ratingErr := dbHelper.AddDishRating(tx, dish.DishID, dish.Rating, 1); ratingErr != nil {
+				return ratingErr
+			}
+		}
+		return nil
+	})
+	if errors.Is(txErr, errOrderReviewed) {
+		logrus.Errorf("Order already reviewed: %s", orderID)
+		utils.RespondError(w, http.StatusConflict, nil, "Order already reviewed")
+		return
+	}
+	if txErr != nil {
+		logrus.Errorf("Failed to add review: %s", txErr)
+		utils.RespondError(w, http.StatusInternalServerError, txErr, "Failed to add review")
+		return
+	}
+	logrus.Infof("Review added successfully.")
+	utils.RespondJSON(w, http.StatusCreated, models.Message{
+		Message: "Review added successfully.",
+	})
+}
+
+func GetRestaurantReviews(w http.ResponseWriter, r *http.Request) {
+	Filters := utils.GetFilters(r)
+	restaurantID := chi.URLParam(r, "restaurantId")
+	var reviewsCount int64
+	reviews := make([]models.Review, 0)
+	var errGroup errgroup.Group
+	errGroup.Go(func() error {
+		var err error
+		reviewsCount, err = dbHelper.GetRestaurantReviewsCount(restaurantID)
+		if err != nil {
+			logrus.Errorf("Unable to get Reviews Count: %s", err)
+		}
+		return err
+	})
+	errGroup.Go(func() error {
+		var err error
+		reviews, err = dbHelper.GetRestaurantReviews(restaurantID, Filters)
+		if err != nil {
+			logrus.Errorf("Unable to get Reviews: %s", err)
+		}
+		return err
+	})
+	if err := errGroup.Wait(); err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "Unable to get Reviews")
+		return
+	}
+	logrus.Infof("Get Reviews successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetReviews{
+		Message:    "Get Reviews successfully.",
+		Reviews:    reviews,
+		TotalCount: reviewsCount,
+		PageNumber: Filters.PageNumber,
+		PageSize:   Filters.PageSize,
+	})
+}
+
+// getManagedReview loads a review the current sub-admin may act on, it responds with the error itself
+func getManagedReview(w http.ResponseWriter, r *http.Request) (*models.Review, bool) {
+	reviewID := chi.URLParam(r, "reviewId")
+	adminCtx := middlewares.UserContext(r)
+	review, reviewErr := dbHelper.GetReviewByID(reviewID)
+	if reviewErr != nil {
+		logrus.Errorf("Failed to get review: %s", reviewErr)
+		utils.RespondError(w, http.StatusInternalServerError, reviewErr, "Failed to get review")
+		return nil, false
+	}
+	if review == nil {
+		logrus.Errorf("Review not exist: %s", reviewID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Review not exist")
+		return nil, false
+	}
+	restaurant, restaurantErr := dbHelper.GetRestaurantByID(review.RestaurantID)
+	if restaurantErr != nil {
+		logrus.Errorf("Unable to get Restaurant: %s", restaurantErr)
+		utils.RespondError(w, http.StatusInternalServerError, restaurantErr, "Unable to get Restaurant")
+		return nil, false
+	}
+	if !canManageRestaurant(adminCtx, restaurant) {
+		logrus.Errorf("Restaurant not managed by: %s", adminCtx.ID)
+		utils.RespondError(w, http.StatusForbidden, nil, "Review not exist")
+		return nil, false
+	}
+	return review, true
+}
+
+func ReplyToReview(w http.ResponseWriter, r *http.Request) {
+	var body models.ReplyReviewBody
+	adminCtx := middlewares.UserContext(r)
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+	if body.Reply == "" {
+		logrus.Errorf("Invalid Reply.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Reply can't be empty.")
+		return
+	}
+	review, ok := getManagedReview(w, r)
+	if !ok {
+		return
+	}
+	if err := dbHelper.ReplyToReview(review.ID, adminCtx.ID, body.Reply); err != nil {
+		logrus.Errorf("Failed to reply review: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to reply review")
+		return
+	}
+	logrus.Infof("Review replied successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Review replied successfully.",
+	})
+}
+
+func FlagReview(w http.ResponseWriter, r *http.Request) {
+	var body models.FlagReviewBody
+	adminCtx := middlewares.UserContext(r)
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+	if body.Reason == "" {
+		logrus.Errorf("Invalid Reason.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Reason can't be empty.")
+		return
+	}
+	review, ok := getManagedReview(w, r)
+	if !ok {
+		return
+	}
+	if err := dbHelper.FlagReview(review.ID, adminCtx.ID, body.Reason); err != nil {
+		logrus.Errorf("Failed to flag review: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to flag review")
+		return
+	}
+	logrus.Infof("Review flagged successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Review flagged successfully.",
+	})
+}
+
+func GetFlaggedReviews(w http.ResponseWriter, r *http.Request) {
+	Filters := utils.GetFilters(r)
+	var reviewsCount int64
+	reviews := make([]models.Review, 0)
+	var errGroup errgroup.Group
+	errGroup.Go(func() error {
+		var err error
+		reviewsCount, err = dbHelper.GetFlaggedReviewsCount()
+		if err != nil {
+			logrus.Errorf("Unable to get flagged Reviews Count: %s", err)
+		}
+		return err
+	})
+	errGroup.Go(func() error {
+		var err error
+		reviews, err = dbHelper.GetFlaggedReviews(Filters)
+		if err != nil {
+			logrus.Errorf("Unable to get flagged Reviews: %s", err)
+		}
+		return err
+	})
+	if err := errGroup.Wait(); err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "Unable to get flagged Reviews")
+		return
+	}
+	logrus.Infof("Get flagged Reviews successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetReviews{
+		Message:    "Get flagged Reviews successfully.",
+		Reviews:    reviews,
+		TotalCount: reviewsCount,
+		PageNumber: Filters.PageNumber,
+		PageSize:   Filters.PageSize,
+	})
+}
+
+// ModerateReview resolves a flag, hiding a review also takes its ratings out of the aggregates
+func ModerateReview(w http.ResponseWriter, r *http.Request) {
+	reviewID := chi.URLParam(r, "reviewId")
+	var body models.ModerateReviewBody
+	adminCtx := middlewares.UserContext(r)
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+	review, reviewErr := dbHelper.GetReviewByID(reviewID)
+	if reviewErr != nil {
+		logrus.Errorf("Failed to get review: %s", reviewErr)
+		utils.RespondError(w, http.StatusInternalServerError, reviewErr, "Failed to get review")
+		return
+	}
+	if review == nil {
+		logrus.Errorf("Review not exist: %s", reviewID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Review not exist")
+		return
+	}
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		moderated, moderateErr := dbHelper.ModerateReview(tx, review.ID, adminCtx.ID, body.Hide)
+		if moderateErr != nil || !moderated || !body.Hide {
+			return moderateErr
+		}
+		if ratingErr := dbHelper.AddRestaurantRating(tx, review.RestaurantID, review.Rating, -1); ratingErr != nil {
+			return ratingErr
+		}
+		for _, dish := range review.Dishes {
+			if ratingErr := dbHelper.AddDishRating(tx, dish.DishID, dish.Rating, -1); ratingErr != nil {
+				return ratingErr
+			}
+		}
+		return nil
+	})
+	if txErr != nil {
+		logrus.Errorf("Failed to moderate review: %s", txErr)
+		utils.RespondError(w, http.StatusInternalServerError, txErr, "Failed to moderate review")
+		return
+	}
+	logrus.Infof("Review moderated successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Review moderated successfully.",
+	})
+}
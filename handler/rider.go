@@ -0,0 +1,373 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"rms/database"
+	"rms/database/dbHelper"
+	"rms/events"
+	"rms/middlewares"
+	"rms/models"
+	"rms/orderstatus"
+	"rms/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+func RegisterRider(w http.ResponseWriter, r *http.Request) {
+	var body models.RegisterUserBody
+	adminCtx := middlewares.UserContext(r)
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+	if len(body.Password) < 6 {
+		logrus.Errorf("password must be 6 chars long.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "password must be 6 chars long")
+		return
+	}
+
+	if !utils.IsEmailValid(body.Email) {
+		logrus.Errorf("Invalid Email.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Email.")
+		return
+	}
+
+	exists, existsErr := dbHelper.IsUserRoleExists(body.Email, models.RoleRider)
+	if existsErr != nil {
+		logrus.Errorf("Failed to check user role existence: %s", existsErr)
+		utils.RespondError(w, http.StatusInternalServerError, existsErr, "Failed to check Rider existence")
+		return
+	}
+	if exists {
+		logrus.Errorf("Rider already exists")
+		utils.RespondError(w, http.StatusConflict, nil, "Rider already exists")
+		return
+	}
+	hashedPassword, hasErr := utils.HashPassword(body.Password)
+	if hasErr != nil {
+		logrus.Errorf("Failed to secure password: %s", hasErr)
+		utils.RespondError(w, http.StatusInternalServerError, hasErr, "Failed to secure password")
+		return
+	}
+	userID, userExistsErr := dbHelper.IsUserExists(body.Email)
+	if userExistsErr != nil {
+		logrus.Errorf("Failed to check user existence: %s", userExistsErr)
+		utils.RespondError(w, http.StatusInternalServerError, userExistsErr, "Failed to check user existence")
+		return
+	}
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		if userID == "" {
+			var saveErr error
+			userID, saveErr = dbHelper.CreateUser(tx, body.Name, body.Email, hashedPassword)
+			if saveErr != nil {
+				return saveErr
+			}
+		}
+		if roleErr := dbHelper.CreateUserRole(tx, userID, adminCtx.ID, models.RoleRider); roleErr != nil {
+			return roleErr
+		}
+		return dbHelper.CreateRiderStatus(tx, userID)
+	})
+	if txErr != nil {
+		logrus.Errorf("Failed to create Rider: %s", txErr)
+		utils.RespondError(w, http.StatusInternalServerError, txErr, "Failed to create Rider")
+		return
+	}
+	logrus.Infof("Rider created successfully.")
+	utils.RespondJSON(w, http.StatusCreated, models.Message{
+		Message: "Rider created successfully.",
+	})
+}
+
+func GetRiders(w http.ResponseWriter, r *http.Request) {
+	riders, err := dbHelper.GetRiders()
+	if err != nil {
+		logrus.Errorf("Unable to get Riders: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Unable to get Riders")
+		return
+	}
+	logrus.Infof("Get Riders successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetRiders{
+		Message: "Get Riders successfully.",
+		Riders:  riders,
+	})
+}
+
+// RemoveRider takes the rider role away, riders in the middle of a delivery have to finish it first
+func RemoveRider(w http.ResponseWriter, r *http.Request) {
+	riderID := chi.URLParam(r, "riderId")
+	rider, riderErr := dbHelper.GetRiderByID(riderID)
+	if riderErr != nil {
+		logrus.Errorf("Unable to get Rider: %s", riderErr)
+		utils.RespondError(w, http.StatusInternalServerError, riderErr, "Unable to get Rider")
+		return
+	}
+	if rider == nil {
+		logrus.Errorf("Rider not exist: %s", riderID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Rider not exist")
+		return
+	}
+	if rider.ActiveOrder != nil {
+		logrus.Errorf("Rider %s is delivering order %s", riderID, *rider.ActiveOrder)
+		utils.RespondError(w, http.StatusConflict, nil, "Rider has a delivery in progress")
+		return
+	}
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		return dbHelper.RemoveRole(tx, riderID, models.RoleRider)
+	})
+	if txErr != nil {
+		logrus.Errorf("Failed to remove Rider: %s", txErr)
+		utils.RespondError(w, http.StatusInternalServerError, txErr, "Failed to remove Rider")
+		return
+	}
+	if availabilityErr := dbHelper.UpdateRiderAvailability(riderID, false); availabilityErr != nil {
+		logrus.Errorf("Failed to update Rider availability: %s", availabilityErr)
+	}
+	logrus.Infof("Rider removed successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Rider removed successfully.",
+	})
+}
+
+func UpdateMyAvailability(w http.ResponseWriter, r *http.Request) {
+	var body models.RiderAvailabilityBody
+	riderCtx := middlewares.UserContext(r)
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+	if err := dbHelper.UpdateRiderAvailability(riderCtx.ID, body.Available); err != nil {
+		logrus.Errorf("Failed to update availability: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to update availability")
+		return
+	}
+	logrus.Infof("Availability updated successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Availability updated successfully.",
+	})
+}
+
+// UpdateMyLocation stores the rider's position and pushes it to the customers whose order is on the way
+func UpdateMyLocation(w http.ResponseWriter, r *http.Request) {
+	var body models.RiderLocationBody
+	riderCtx := middlewares.UserContext(r)
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+
+	if body.Lat < -90 || body.Lat > 90 || body.Lng < -180 || body.Lng > 180 {
+		logrus.Errorf("Invalid Location.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Location.")
+		return
+	}
+
+	if err := dbHelper.UpdateRiderLocation(riderCtx.ID, body.Lat, body.Lng); err != nil {
+		logrus.Errorf("Failed to update location: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to update location")
+		return
+	}
+	orders, ordersErr := dbHelper.GetRiderOrders(riderCtx.ID)
+	if ordersErr != nil {
+		logrus.Errorf("Failed to get rider orders: %s", ordersErr)
+	}
+	refreshRiderETAs(database.RMS, orders)
+	for _, order := range orders {
+		if order.Status == models.OrderOutForDelivery && order.UserID != "" {
+			events.Publish(events.UserTopic(order.UserID), events.RiderLocation, events.RiderData{
+				OrderID: order.ID,
+				RiderID: riderCtx.ID,
+				Lat:     &body.Lat,
+				Lng:     &body.Lng,
+			})
+		}
+	}
+	logrus.Infof("Location updated successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Location updated successfully.",
+	})
+}
+
+func GetMyDeliveries(w http.ResponseWriter, r *http.Request) {
+	riderCtx := middlewares.UserContext(r)
+	orders, err := dbHelper.GetRiderOrders(riderCtx.ID)
+	if err != nil {
+		logrus.Errorf("Unable to get Deliveries: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Unable to get Deliveries")
+		return
+	}
+	logrus.Infof("Get Deliveries successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetOrders{
+		Message:    "Get Deliveries successfully.",
+		Orders:     orders,
+		TotalCount: int64(len(orders)),
+	})
+}
+
+// moveMyDelivery moves an order assigned to the current rider from one status to the next
+func moveMyDelivery(w http.ResponseWriter, r *http.Request, from, to models.OrderStatus) {
+	orderID := chi.URLParam(r, "orderId")
+	riderCtx := middlewares.UserContext(r)
+	order, orderErr := dbHelper.GetOrderByID(database.RMS, orderID)
+	if orderErr != nil {
+		logrus.Errorf("Failed to get order: %s", orderErr)
+		utils.RespondError(w, http.StatusInternalServerError, orderErr, "Failed to get order")
+		return
+	}
+	if order == nil || order.RiderID == nil || *order.RiderID != riderCtx.ID {
+		logrus.Errorf("Order not exist: %s", orderID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Order not exist")
+		return
+	}
+	if order.Status != from {
+		logrus.Errorf("Order can't move from %s to %s", order.Status, to)
+		utils.RespondError(w, http.StatusBadRequest, nil, fmt.Sprintf("Order can't move from %s to %s", order.Status, to))
+		return
+	}
+	var move orderMove
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		var moveErr error
+		move, moveErr = moveOrderStatus(tx, order, to)
+		return moveErr
+	})
+	if txErr != nil {
+		if errors.Is(txErr, errOrderMoved) {
+			logrus.Errorf("Failed to update order status: %s", txErr)
+			utils.RespondError(w, http.StatusConflict, txErr, txErr.Error())
+			return
+		}
+		logrus.Errorf("Failed to update order status: %s", txErr)
+		utils.RespondError(w, http.StatusInternalServerError, txErr, "Failed to update order status")
+		return
+	}
+	publishOrderMove(order, to, move)
+	logrus.Infof("Order status updated successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Order status updated successfully.",
+	})
+}
+
+func PickUpOrder(w http.ResponseWriter, r *http.Request) {
+	moveMyDelivery(w, r, models.OrderReady, models.OrderOutForDelivery)
+}
+
+func DeliverOrder(w http.ResponseWriter, r *http.Request) {
+	moveMyDelivery(w, r, models.OrderOutForDelivery, models.OrderDelivered)
+}
+
+// GetNearbyRiders lists the idle riders nearest to the restaurant first, for picking a rider by hand
+func GetNearbyRiders(w http.ResponseWriter, r *http.Request) {
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	riders, err := dbHelper.GetIdleRiders(restaurant.Lat, restaurant.Lng)
+	if err != nil {
+		logrus.Errorf("Unable to get Riders: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Unable to get Riders")
+		return
+	}
+	logrus.Infof("Get Riders successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetRiders{
+		Message: "Get Riders successfully.",
+		Riders:  riders,
+	})
+}
+
+// AssignOrderRider overrides the automatic assignment and hands the order to the chosen rider
+func AssignOrderRider(w http.ResponseWriter, r *http.Request) {
+	restaurantID := chi.URLParam(r, "restaurantId")
+	orderID := chi.URLParam(r, "orderId")
+	var body models.AssignRiderBody
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+
+	if _, ok := getManagedRestaurant(w, r); !ok {
+		return
+	}
+
+	order, orderErr := dbHelper.GetOrderByID(database.RMS, orderID)
+	if orderErr != nil {
+		logrus.Errorf("Failed to get order: %s", orderErr)
+		utils.RespondError(w, http.StatusInternalServerError, orderErr, "Failed to get order")
+		return
+	}
+	if order == nil || order.RestaurantID != restaurantID {
+		logrus.Errorf("Order not exist: %s", orderID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Order not exist")
+		return
+	}
+
+	rider, riderErr := dbHelper.GetRiderByID(body.RiderID)
+	if riderErr != nil {
+		logrus.Errorf("Unable to get Rider: %s", riderErr)
+		utils.RespondError(w, http.StatusInternalServerError, riderErr, "Unable to get Rider")
+		return
+	}
+	if rider == nil {
+		logrus.Errorf("Rider not exist: %s", body.RiderID)
+		utils.RespondError(w, http.StatusBadRequest, nil, "Rider not exist")
+		return
+	}
+
+	assigned, assignErr := dbHelper.AssignOrderRider(database.RMS, order.ID, rider.ID)
+	if assignErr != nil {
+		logrus.Errorf("Failed to assign Rider: %s", assignErr)
+		utils.RespondError(w, http.StatusInternalServerError, assignErr, "Failed to assign Rider")
+		return
+	}
+	if !assigned {
+		logrus.Errorf("Order %s can't get a rider in status %s", order.ID, order.Status)
+		utils.RespondError(w, http.StatusConflict, nil, "Only delivery orders that are not picked up yet can get a rider")
+		return
+	}
+	orderstatus.PublishRiderAssigned(order.ID, order.UserID, rider.ID)
+	logrus.Infof("Rider assigned successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Rider assigned successfully.",
+	})
+}
+
+// TrackMyOrder shows the customer where the rider of their order is until it is delivered
+func TrackMyOrder(w http.ResponseWriter, r *http.Request) {
+	orderID := chi.URLParam(r, "orderId")
+	userCtx := middlewares.UserContext(r)
+	order, err := dbHelper.GetOrderByID(database.RMS, orderID)
+	if err != nil {
+		logrus.Errorf("Failed to get order: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to get order")
+		return
+	}
+	if order == nil || order.UserID != userCtx.ID {
+		logrus.Errorf("Order not exist: %s", orderID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Order not exist")
+		return
+	}
+	var position *models.RiderPosition
+	if order.RiderID != nil && (order.Status == models.OrderReady || order.Status == models.OrderOutForDelivery) {
+		var positionErr error
+		position, positionErr = dbHelper.GetRiderPosition(*order.RiderID)
+		if positionErr != nil {
+			logrus.Errorf("Failed to get rider position: %s", positionErr)
+			utils.RespondError(w, http.StatusInternalServerError, positionErr, "Failed to get rider position")
+			return
+		}
+	}
+	logrus.Infof("Get Order Tracking successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.OrderTracking{
+		Message: "Get Order Tracking successfully.",
+		OrderID: order.ID,
+		Status:  order.Status,
+		Rider:   position,
+	})
+}
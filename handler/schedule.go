@@ -0,0 +1,228 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"rms/database"
+	"rms/database/dbHelper"
+	"rms/models"
+	"rms/utils"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	hoursLayout   = "15:04"
+	slotDayLayout = "2006-01-02"
+)
+
+var errSlotUnavailable = errors.New("delivery slot not available")
+
+// clockOn places a HH:MM clock time on the given day of the day's location
+func clockOn(day time.Time, clock string) (time.Time, error) {
+	parsed, err := time.Parse(hoursLayout, clock)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), parsed.Hour(), parsed.Minute(), 0, 0, day.Location()), nil
+}
+
+// openingWindows returns the opening and closing times of the restaurant on the given local day
+func openingWindows(hours []models.RestaurantHours, dayStart time.Time) ([][2]time.Time, error) {
+	windows := make([][2]time.Time, 0)
+	for _, window := range hours {
+		if window.DayOfWeek != int(dayStart.Weekday()) {
+			continue
+		}
+		opensAt, opensErr := clockOn(dayStart, window.OpensAt)
+		if opensErr != nil {
+			return nil, opensErr
+		}
+		closesAt, closesErr := clockOn(dayStart, window.ClosesAt)
+		if closesErr != nil {
+			return nil, closesErr
+		}
+		windows = append(windows, [2]time.Time{opensAt, closesAt})
+	}
+	return windows, nil
+}
+
+// restaurantSlots lists the bookable delivery slots of a restaurant on the local day of the given time
+func restaurantSlots(db sqlx.Ext, restaurantID string, schedule *models.RestaurantSchedule, day time.Time) ([]models.DeliverySlot, error) {
+	location, locationErr := time.LoadLocation(schedule.Timezone)
+	if locationErr != nil {
+		return nil, locationErr
+	}
+	day = day.In(location)
+	dayStart := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, location)
+	hours, hoursErr := dbHelper.GetRestaurantHours(db, restaurantID)
+	if hoursErr != nil {
+		return nil, hoursErr
+	}
+	counts, countsErr := dbHelper.GetSlotOrderCounts(db, restaurantID, dayStart, dayStart.AddDate(0, 0, 1))
+	if countsErr != nil {
+		return nil, countsErr
+	}
+	slotLength := time.Duration(schedule.SlotMinutes) * time.Minute
+	earliest := time.Now().Add(time.Duration(schedule.LeadMinutes) * time.Minute)
+	windows, windowsErr := openingWindows(hours, dayStart)
+	if windowsErr != nil {
+		return nil, windowsErr
+	}
+	slots := make([]models.DeliverySlot, 0)
+	for _, window := range windows {
+		for start := window[0]; !start.Add(slotLength).After(window[1]); start = start.Add(slotLength) {
+			if start.Before(earliest) {
+				continue
+			}
+			available := schedule.SlotCapacity - counts[start.Unix()]
+			if available < 0 {
+				available = 0
+			}
+			slots = append(slots, models.DeliverySlot{
+				StartsAt:  start,
+				EndsAt:    start.Add(slotLength),
+				Available: available,
+			})
+		}
+	}
+	return slots, nil
+}
+
+// reserveDeliverySlot locks the restaurant's slots and checks the requested time is a free slot, it returns when
+// the order has to be released to the restaurant
+func reserveDeliverySlot(tx *sqlx.Tx, restaurantID string, scheduledFor time.Time) (time.Time, error) {
+	schedule, scheduleErr := dbHelper.GetRestaurantSchedule(tx, restaurantID, true)
+	if scheduleErr != nil {
+		return time.Time{}, scheduleErr
+	}
+	if schedule == nil {
+		return time.Time{}, fmt.Errorf("%w: restaurant not exists", errSlotUnavailable)
+	}
+	slots, slotsErr := restaurantSlots(tx, restaurantID, schedule, scheduledFor)
+	if slotsErr != nil {
+		return time.Time{}, slotsErr
+	}
+	for _, slot := range slots {
+		if !slot.StartsAt.Equal(scheduledFor) {
+			continue
+		}
+		if slot.Available <= 0 {
+			return time.Time{}, fmt.Errorf("%w: slot is full", errSlotUnavailable)
+		}
+		return scheduledFor.Add(-time.Duration(schedule.LeadMinutes) * time.Minute), nil
+	}
+	return time.Time{}, fmt.Errorf("%w: restaurant doesn't deliver at %s", errSlotUnavailable, scheduledFor.Format(time.RFC3339))
+}
+
+// parseSlotDay reads the ?date=YYYY-MM-DD query param in the restaurant's location, today when it is missing
+func parseSlotDay(r *http.Request, location *time.Location) (time.Time, error) {
+	date := r.URL.Query().Get("date")
+	if date == "" {
+		return time.Now().In(location), nil
+	}
+	return time.ParseInLocation(slotDayLayout, date, location)
+}
+
+func GetRestaurantSlots(w http.ResponseWriter, r *http.Request) {
+	restaurantID := chi.URLParam(r, "restaurantId")
+	schedule, scheduleErr := dbHelper.GetRestaurantSchedule(database.RMS, restaurantID, false)
+	if scheduleErr != nil {
+		logrus.Errorf("Unable to get Restaurant schedule: %s", scheduleErr)
+		utils.RespondError(w, http.StatusInternalServerError, scheduleErr, "Unable to get Restaurant schedule")
+		return
+	}
+	if schedule == nil {
+		logrus.Errorf("Restaurant not exist: %s", restaurantID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Restaurant not exist")
+		return
+	}
+	location, locationErr := time.LoadLocation(schedule.Timezone)
+	if locationErr != nil {
+		logrus.Errorf("Invalid Restaurant timezone: %s", locationErr)
+		utils.RespondError(w, http.StatusInternalServerError, locationErr, "Invalid Restaurant timezone")
+		return
+	}
+	day, dateErr := parseSlotDay(r, location)
+	if dateErr != nil {
+		logrus.Errorf("Invalid Date: %s", dateErr)
+		utils.RespondError(w, http.StatusBadRequest, dateErr, "Invalid Date, use YYYY-MM-DD.")
+		return
+	}
+
+	slots, slotsErr := restaurantSlots(database.RMS, restaurantID, schedule, day)
+	if slotsErr != nil {
+		logrus.Errorf("Unable to get Delivery Slots: %s", slotsErr)
+		utils.RespondError(w, http.StatusInternalServerError, slotsErr, "Unable to get Delivery Slots")
+		return
+	}
+	logrus.Infof("Get Delivery Slots successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetDeliverySlots{
+		Message: "Get Delivery Slots successfully.",
+		Slots:   slots,
+	})
+}
+
+func UpdateRestaurantSchedule(w http.ResponseWriter, r *http.Request) {
+	var body models.UpdateScheduleBody
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+
+	if body.Timezone == "" {
+		body.Timezone = "UTC"
+	}
+	if _, locationErr := time.LoadLocation(body.Timezone); locationErr != nil {
+		logrus.Errorf("Invalid Timezone: %s", locationErr)
+		utils.RespondError(w, http.StatusBadRequest, locationErr, "Invalid Timezone.")
+		return
+	}
+
+	if body.ReservationMinutes == 0 {
+		body.ReservationMinutes = defaultReservationMinutes
+	}
+
+	if body.SlotMinutes <= 0 || body.SlotMinutes > 24*60 || body.SlotCapacity < 0 || body.LeadMinutes < 0 ||
+		body.ReservationMinutes < 0 || body.ReservationMinutes > maxReservationMinutes {
+		logrus.Errorf("Invalid Slot Settings.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Slot Settings.")
+		return
+	}
+
+	for _, window := range body.Hours {
+		opensAt, opensErr := time.Parse(hoursLayout, window.OpensAt)
+		closesAt, closesErr := time.Parse(hoursLayout, window.ClosesAt)
+		if window.DayOfWeek < 0 || window.DayOfWeek > 6 || opensErr != nil || closesErr != nil || !closesAt.After(opensAt) {
+			logrus.Errorf("Invalid Opening Hours: %+v", window)
+			utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Opening Hours, use HH:MM with closing after opening.")
+			return
+		}
+	}
+
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		if updateErr := dbHelper.UpdateRestaurantSchedule(tx, restaurant.ID, &body.RestaurantSchedule); updateErr != nil {
+			return updateErr
+		}
+		return dbHelper.ReplaceRestaurantHours(tx, restaurant.ID, body.Hours)
+	})
+	if txErr != nil {
+		logrus.Errorf("Failed to update Restaurant schedule: %s", txErr)
+		utils.RespondError(w, http.StatusInternalServerError, txErr, "Failed to update Restaurant schedule")
+		return
+	}
+	logrus.Infof("Restaurant schedule updated successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Restaurant schedule updated successfully.",
+	})
+}
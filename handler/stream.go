@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"rms/database/dbHelper"
+	"rms/events"
+	"rms/middlewares"
+	"rms/models"
+	"rms/utils"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// streamHeartbeat keeps idle streams from being closed by proxies
+const streamHeartbeat = 25 * time.Second
+
+// publishOrderEvent tells the customer and the restaurant staff about an order, guest orders have no customer topic
+func publishOrderEvent(eventType, orderID, userID, restaurantID string, status models.OrderStatus) {
+	data := events.OrderData{OrderID: orderID, RestaurantID: restaurantID, Status: string(status)}
+	if userID != "" {
+		events.Publish(events.UserTopic(userID), eventType, data)
+	}
+	events.Publish(events.RestaurantTopic(restaurantID), eventType, data)
+}
+
+func publishDishEvent(dishID, restaurantID string, removed bool) {
+	events.Publish(events.MenuTopic(restaurantID), events.DishChanged, events.DishData{DishID: dishID, RestaurantID: restaurantID, Removed: removed})
+}
+
+// StreamEvents pushes events as Server-Sent Events. Every user gets their own order updates, restaurantId adds the
+// incoming orders of a managed restaurant and menuOf adds the dish changes of any restaurant.
+func StreamEvents(w http.ResponseWriter, r *http.Request) {
+	userCtx := middlewares.UserContext(r)
+	topics := []string{events.UserTopic(userCtx.ID)}
+	if restaurantID := r.URL.Query().Get("restaurantId"); restaurantID != "" {
+		restaurant, restaurantErr := dbHelper.GetRestaurantByID(restaurantID)
+		if restaurantErr != nil {
+			logrus.Errorf("Unable to get Restaurant: %s", restaurantErr)
+			utils.RespondError(w, http.StatusInternalServerError, restaurantErr, "Unable to get Restaurant")
+			return
+		}
+		if userCtx.CurrentRole == models.RoleUser || !canManageRestaurant(userCtx, restaurant) {
+			logrus.Errorf("Restaurant not managed by: %s", userCtx.ID)
+			utils.RespondError(w, http.StatusForbidden, nil, "Restaurant not exist")
+			return
+		}
+		topics = append(topics, events.RestaurantTopic(restaurantID))
+	}
+	if menuOf := r.URL.Query().Get("menuOf"); menuOf != "" {
+		topics = append(topics, events.MenuTopic(menuOf))
+	}
+	streamTopics(w, r, topics...)
+}
+
+// streamTopics writes the events of the topics to the response until the client goes away. Every event carries its
+// id so a client reconnecting with Last-Event-ID, after a network drop or the server write timeout, first gets the
+// events it missed
+func streamTopics(w http.ResponseWriter, r *http.Request, topics ...string) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		logrus.Errorf("Streaming not supported.")
+		utils.RespondError(w, http.StatusInternalServerError, nil, "Streaming not supported")
+		return
+	}
+	stream, missed, unsubscribe := events.SubscribeAfter(r.Header.Get("Last-Event-ID"), topics...)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	for _, event := range missed {
+		if err := writeEvent(w, event); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case event := <-stream:
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+		}
+		flusher.Flush()
+	}
+}
+
+func writeEvent(w http.ResponseWriter, event events.Event) error {
+	_, err := fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+	return err
+}
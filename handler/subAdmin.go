@@ -7,6 +7,8 @@ import (
 	"rms/middlewares"
 	"rms/models"
 	"rms/utils"
+	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/jmoiron/sqlx"
@@ -300,7 +302,16 @@ func OpenRestaurant(w http.ResponseWriter, r *http.Request) {
 		utils.RespondError(w, http.StatusExpectationFailed, nil, "Invalid Longitude.")
 		return
 	}
-	_, saveErr := dbHelper.CreateRestaurant(body.Name, body.Email, adminCtx.ID, body.Address, body.State, body.City, body.PinCode, body.Lat, body.Lng)
+	body.Currency = strings.ToUpper(body.Currency)
+	if body.Currency == "" {
+		body.Currency = models.DefaultCurrency
+	}
+	if !models.IsValidCurrency(body.Currency) {
+		logrus.Errorf("Invalid Currency: %s", body.Currency)
+		utils.RespondError(w, http.StatusExpectationFailed, nil, "Invalid Currency.")
+		return
+	}
+	_, saveErr := dbHelper.CreateRestaurant(body.Name, body.Email, adminCtx.ID, body.Address, body.State, body.City, body.PinCode, body.Currency, body.Lat, body.Lng)
 	if saveErr != nil {
 		logrus.Errorf("Failed to open Restaurant: %s", saveErr)
 		utils.RespondError(w, http.StatusInternalServerError, saveErr, "Failed to open Restaurant")
@@ -315,12 +326,19 @@ func OpenRestaurant(w http.ResponseWriter, r *http.Request) {
 func CloseRestaurant(w http.ResponseWriter, r *http.Request) {
 	id := chi.URLParam(r, "restaurantId")
 	adminCtx := middlewares.UserContext(r)
-	var err error
-	if adminCtx.CurrentRole == models.RoleAdmin {
-		err = dbHelper.CloseRestaurant(id)
-	} else {
-		err = dbHelper.CloseMyRestaurant(id, adminCtx.ID)
-	}
+	err := database.Tx(func(tx *sqlx.Tx) error {
+		var closed bool
+		var closeErr error
+		if adminCtx.CurrentRole == models.RoleAdmin {
+			closed, closeErr = dbHelper.CloseRestaurant(tx, id)
+		} else {
+			closed, closeErr = dbHelper.CloseMyRestaurant(tx, id, adminCtx.ID)
+		}
+		if closeErr != nil || !closed {
+			return closeErr
+		}
+		return dbHelper.EnqueueWebhookEvent(tx, id, models.WebhookRestaurantClosed, map[string]string{"restaurantId": id})
+	})
 	if err != nil {
 		logrus.Errorf("Unable to get Restaurant: %s", err)
 		utils.RespondError(w, http.StatusInternalServerError, err, "Unable to get Restaurant")
@@ -339,12 +357,24 @@ func GetRestaurants(w http.ResponseWriter, r *http.Request) {
 		utils.RespondError(w, http.StatusInternalServerError, nil, "Invalid Restaurants Filter Email.")
 		return
 	}
+	if !restaurantFiltersValid(w, Filters) {
+		return
+	}
 	adminCtx := middlewares.UserContext(r)
 	//TODO use errgroup when mutiple db calls for less time consuming **DONE**
 	var RestaurantsCount int64
 	var Restaurants []models.Restaurant
+	var Facets *models.RestaurantFacets
 	var errGroup errgroup.Group
 	if adminCtx.CurrentRole == models.RoleAdmin || adminCtx.CurrentRole == models.RoleUser {
+		errGroup.Go(func() error {
+			var err error
+			Facets, err = dbHelper.GetRestaurantFacets(Filters)
+			if err != nil {
+				logrus.Errorf("Unable to get Restaurant Facets: %s", err)
+			}
+			return err
+		})
 		errGroup.Go(func() error {
 			var err error
 			RestaurantsCount, err = dbHelper.GetRestaurantsCount(Filters)
@@ -375,6 +405,27 @@ func GetRestaurants(w http.ResponseWriter, r *http.Request) {
 		utils.RespondError(w, http.StatusInternalServerError, err, "Unable to get Restaurants")
 		return
 	}
+	if adminCtx.CurrentRole == models.RoleUser {
+		if favouriteErr := flagFavouriteRestaurants(adminCtx.ID, Restaurants); favouriteErr != nil {
+			logrus.Errorf("Unable to get Favourite Restaurants: %s", favouriteErr)
+			utils.RespondError(w, http.StatusInternalServerError, favouriteErr, "Unable to get Restaurants")
+			return
+		}
+	}
+	// customers listing restaurants for an address get the distance and a delivery quote of each
+	if addressID := r.URL.Query().Get("addressId"); addressID != "" && adminCtx.CurrentRole == models.RoleUser {
+		address, addressErr := utils.GetUserAddressById(addressID, adminCtx.UserAddresses)
+		if addressErr != nil {
+			logrus.Errorf("Address not exist: %s", addressErr)
+			utils.RespondError(w, http.StatusBadRequest, nil, "Address not exist")
+			return
+		}
+		if etaErr := annotateRestaurantETAs(Restaurants, address); etaErr != nil {
+			logrus.Errorf("Unable to estimate delivery: %s", etaErr)
+			utils.RespondError(w, http.StatusInternalServerError, etaErr, "Unable to estimate delivery")
+			return
+		}
+	}
 	//TODO  write this  message below else one time **DONE**
 	logrus.Infof("Get Restaurants successfully.")
 	utils.RespondJSON(w, http.StatusCreated, models.GetRestaurants{
@@ -383,6 +434,7 @@ func GetRestaurants(w http.ResponseWriter, r *http.Request) {
 		TotalCount:  RestaurantsCount,
 		PageNumber:  Filters.PageNumber,
 		PageSize:    Filters.PageSize,
+		Facets:      Facets,
 	})
 }
 
@@ -469,8 +521,201 @@ func UpdateRestaurant(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// canManageRestaurant reports whether the user may act on the restaurant's orders, menu and reviews
+func canManageRestaurant(user *models.User, restaurant *models.Restaurant) bool {
+	return restaurant != nil && (user.CurrentRole == models.RoleAdmin || restaurant.CreatedBy == user.ID)
+}
+
+// getManagedRestaurant loads the restaurant in the URL if the current sub-admin manages it, it responds with the error itself
+func getManagedRestaurant(w http.ResponseWriter, r *http.Request) (*models.Restaurant, bool) {
+	restaurantID := chi.URLParam(r, "restaurantId")
+	adminCtx := middlewares.UserContext(r)
+	restaurant, restaurantErr := dbHelper.GetRestaurantByID(restaurantID)
+	if restaurantErr != nil {
+		logrus.Errorf("Unable to get Restaurant: %s", restaurantErr)
+		utils.RespondError(w, http.StatusInternalServerError, restaurantErr, "Unable to get Restaurant")
+		return nil, false
+	}
+	if !canManageRestaurant(adminCtx, restaurant) {
+		logrus.Errorf("Restaurant not managed by: %s", adminCtx.ID)
+		utils.RespondError(w, http.StatusForbidden, nil, "Restaurant not exist")
+		return nil, false
+	}
+	return restaurant, true
+}
+
 // Restaurant Dishes
 
+// dishPricesIn puts the prices of the body in the currency of the restaurant, prices sent in another currency are
+// refused rather than converted
+func dishPricesIn(w http.ResponseWriter, restaurantID string, body *models.AddDishesBody) bool {
+	currency, currencyErr := dbHelper.GetRestaurantCurrency(restaurantID)
+	if currencyErr != nil {
+		logrus.Errorf("Failed to get Restaurant Currency: %s", currencyErr)
+		utils.RespondError(w, http.StatusInternalServerError, currencyErr, "Failed to get Restaurant Currency")
+		return false
+	}
+	if currency == "" {
+		logrus.Errorf("Restaurant not exists.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Restaurant not exists")
+		return false
+	}
+	price, priceErr := body.Price.In(currency)
+	if priceErr != nil {
+		logrus.Errorf("Invalid Price: %s", priceErr)
+		utils.RespondError(w, http.StatusBadRequest, priceErr, priceErr.Error())
+		return false
+	}
+	body.Price = price
+	if body.PackagingCharge != nil {
+		packagingCharge, packagingErr := body.PackagingCharge.In(currency)
+		if packagingErr != nil {
+			logrus.Errorf("Invalid Packaging Charge: %s", packagingErr)
+			utils.RespondError(w, http.StatusBadRequest, packagingErr, packagingErr.Error())
+			return false
+		}
+		body.PackagingCharge = &packagingCharge
+	}
+	return true
+}
+
+// dishFiltersIn checks the tag filters are in the vocabularies, the currency filter is a known one and price filters
+// given in it are in the currency of the restaurant, price filters without a currency are taken as minor units of it
+func dishFiltersIn(w http.ResponseWriter, restaurantID string, Filters models.DishFilters) bool {
+	for _, tags := range [][]string{Filters.IncludeTags, Filters.ExcludeTags} {
+		for _, tag := range tags {
+			if !models.IsDietaryTag(tag) && !models.IsAllergen(tag) {
+				logrus.Errorf("Invalid Tag Filter: %s", tag)
+				utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Tag Filter: "+tag)
+				return false
+			}
+		}
+	}
+	if Filters.MaxSpiceLevel != nil && (*Filters.MaxSpiceLevel < models.MinSpiceLevel || *Filters.MaxSpiceLevel > models.MaxSpiceLevel) {
+		logrus.Errorf("Invalid Spice Level Filter: %d", *Filters.MaxSpiceLevel)
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Spice Level Filter.")
+		return false
+	}
+	if Filters.Currency != "" && !models.IsValidCurrency(Filters.Currency) {
+		logrus.Errorf("Invalid Currency Filter: %s", Filters.Currency)
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Currency Filter: "+Filters.Currency)
+		return false
+	}
+	// only prices given in the currency have to match the restaurant, the defaults carry none
+	if Filters.MinPrice.Currency == "" && Filters.MaxPrice.Currency == "" {
+		return true
+	}
+	currency, currencyErr := dbHelper.GetRestaurantCurrency(restaurantID)
+	if currencyErr != nil {
+		logrus.Errorf("Failed to get Restaurant Currency: %s", currencyErr)
+		utils.RespondError(w, http.StatusInternalServerError, currencyErr, "Failed to get Restaurant Currency")
+		return false
+	}
+	for _, price := range []models.Money{Filters.MinPrice, Filters.MaxPrice} {
+		if _, priceErr := price.In(currency); currency != "" && priceErr != nil {
+			logrus.Errorf("Invalid Price Filter: %s", priceErr)
+			utils.RespondError(w, http.StatusBadRequest, priceErr, priceErr.Error())
+			return false
+		}
+	}
+	return true
+}
+
+// validDishDiet checks the tags and allergens of a dish are in the vocabularies and agree with each other, and that
+// the spice level and nutrition make sense. Repeated tags and allergens are dropped
+func validDishDiet(w http.ResponseWriter, body *models.AddDishesBody) bool {
+	var tagsOk, allergensOk bool
+	body.Tags, tagsOk = uniqueVocabulary(body.Tags, models.IsDietaryTag)
+	body.Allergens, allergensOk = uniqueVocabulary(body.Allergens, models.IsAllergen)
+	if !tagsOk {
+		logrus.Errorf("Invalid Tags: %v", body.Tags)
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Tags, use: "+strings.Join(models.DietaryTags, ", "))
+		return false
+	}
+	if !allergensOk {
+		logrus.Errorf("Invalid Allergens: %v", body.Allergens)
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Allergens, use: "+strings.Join(models.Allergens, ", "))
+		return false
+	}
+	diets := 0
+	for _, diet := range []string{models.TagVeg, models.TagEgg, models.TagNonVeg} {
+		if hasTag(body.Tags, diet) {
+			diets++
+		}
+	}
+	if diets > 1 || (hasTag(body.Tags, models.TagVegan) && (hasTag(body.Tags, models.TagEgg) || hasTag(body.Tags, models.TagNonVeg))) {
+		logrus.Errorf("Conflicting Tags: %v", body.Tags)
+		utils.RespondError(w, http.StatusBadRequest, nil, "Conflicting Tags, a dish is one of veg, egg or non-veg and vegan dishes are veg.")
+		return false
+	}
+	if body.SpiceLevel != nil && (*body.SpiceLevel < models.MinSpiceLevel || *body.SpiceLevel > models.MaxSpiceLevel) {
+		logrus.Errorf("Invalid Spice Level: %d", *body.SpiceLevel)
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Spice Level.")
+		return false
+	}
+	if nutrition := body.Nutrition; nutrition != nil {
+		if (nutrition.Calories != nil && *nutrition.Calories < 0) || (nutrition.ProteinGrams != nil && *nutrition.ProteinGrams < 0) ||
+			(nutrition.CarbsGrams != nil && *nutrition.CarbsGrams < 0) || (nutrition.FatGrams != nil && *nutrition.FatGrams < 0) {
+			logrus.Errorf("Invalid Nutrition.")
+			utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Nutrition.")
+			return false
+		}
+	}
+	return true
+}
+
+// uniqueVocabulary lowercases the values and drops repeats, false when any is not in the vocabulary. A nil list stays
+// nil so an update can tell it was left out
+func uniqueVocabulary(values []string, known func(string) bool) ([]string, bool) {
+	if values == nil {
+		return nil, true
+	}
+	unique := make([]string, 0, len(values))
+	valid := true
+	for _, value := range values {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if !known(value) {
+			valid = false
+		}
+		if !hasTag(unique, value) {
+			unique = append(unique, value)
+		}
+	}
+	return unique, valid
+}
+
+func hasTag(tags []string, tag string) bool {
+	for _, existing := range tags {
+		if existing == tag {
+			return true
+		}
+	}
+	return false
+}
+
+func GetDishVocabulary(w http.ResponseWriter, r *http.Request) {
+	spiceLevels := make([]models.SpiceLevel, 0, len(models.SpiceLevels))
+	for level, name := range models.SpiceLevels {
+		spiceLevels = append(spiceLevels, models.SpiceLevel{Level: int64(models.MinSpiceLevel + level), Name: name})
+	}
+	logrus.Infof("Get Dish Vocabulary successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetDishVocabulary{
+		Message:     "Get Dish Vocabulary successfully.",
+		Tags:        models.DietaryTags,
+		Allergens:   models.Allergens,
+		SpiceLevels: spiceLevels,
+	})
+}
+
+// enqueueDishUpdated sends the dish as it is in the transaction to the restaurant's webhooks
+func enqueueDishUpdated(tx *sqlx.Tx, restaurantID, dishID string) error {
+	dish, err := dbHelper.GetWebhookDish(tx, dishID)
+	if err != nil || dish == nil {
+		return err
+	}
+	return dbHelper.EnqueueWebhookEvent(tx, restaurantID, models.WebhookDishUpdated, dish)
+}
+
 func AddRestaurantDish(w http.ResponseWriter, r *http.Request) {
 	restaurantId := chi.URLParam(r, "restaurantId")
 	var body models.AddDishesBody
@@ -512,7 +757,7 @@ func AddRestaurantDish(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if body.Price <= 0 {
+	if body.Price.Amount <= 0 {
 		logrus.Errorf("Invalid Dish Price.")
 		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Dish Price.")
 		return
@@ -523,12 +768,41 @@ func AddRestaurantDish(w http.ResponseWriter, r *http.Request) {
 		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Discount.")
 		return
 	}
-	_, saveErr := dbHelper.CreateDish(restaurantId, adminCtx.ID, body.Name, body.Description, body.Quantity, body.Price, body.Discount)
+	if body.PackagingCharge != nil && body.PackagingCharge.IsNegative() {
+		logrus.Errorf("Invalid Packaging Charge.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Packaging Charge.")
+		return
+	}
+	if body.Station == "" {
+		body.Station = defaultStation
+	}
+	if !validDishDiet(w, &body) {
+		return
+	}
+	if body.Tags == nil {
+		body.Tags = make([]string, 0)
+	}
+	if body.Allergens == nil {
+		body.Allergens = make([]string, 0)
+	}
+	if !dishPricesIn(w, restaurantId, &body) {
+		return
+	}
+	var dishID string
+	saveErr := database.Tx(func(tx *sqlx.Tx) error {
+		var createErr error
+		dishID, createErr = dbHelper.CreateDish(tx, restaurantId, adminCtx.ID, &body)
+		if createErr != nil {
+			return createErr
+		}
+		return enqueueDishUpdated(tx, restaurantId, dishID)
+	})
 	if saveErr != nil {
 		logrus.Errorf("Failed to add Restaurant Dish: %s", saveErr)
 		utils.RespondError(w, http.StatusInternalServerError, saveErr, "Failed to add Restaurant Dish.")
 		return
 	}
+	publishDishEvent(dishID, restaurantId, false)
 	logrus.Infof("Restaurant Dishes added successfully")
 	utils.RespondJSON(w, http.StatusCreated, models.Message{
 		Message: "Restaurant Dish added successfully",
@@ -578,7 +852,7 @@ func UpdateDish(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if body.Price <= 0 {
+	if body.Price.Amount <= 0 {
 		logrus.Errorf("Invalid Price.")
 		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Price.")
 		return
@@ -590,37 +864,144 @@ func UpdateDish(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := dbHelper.UpdateDish(dishId, restaurantId, body.Name, body.Description, body.Quantity, body.Price, body.Discount)
+	if body.PackagingCharge != nil && body.PackagingCharge.IsNegative() {
+		logrus.Errorf("Invalid Packaging Charge.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Packaging Charge.")
+		return
+	}
+
+	if body.Station == "" {
+		body.Station = dish.Station
+	}
+	if body.TaxCategory == nil {
+		body.TaxCategory = &dish.TaxCategory
+	}
+	if body.PackagingCharge == nil {
+		body.PackagingCharge = &models.Money{Amount: dish.PackagingCharge, Currency: dish.Currency}
+	}
+	if !validDishDiet(w, &body) {
+		return
+	}
+	if body.Tags == nil {
+		body.Tags = dish.Tags
+	}
+	if body.Allergens == nil {
+		body.Allergens = dish.Allergens
+	}
+	if body.SpiceLevel == nil {
+		body.SpiceLevel = dish.SpiceLevel
+	}
+	if body.Nutrition == nil {
+		body.Nutrition = dish.Nutrition
+	}
+	if !dishPricesIn(w, restaurantId, &body) {
+		return
+	}
+	err := database.Tx(func(tx *sqlx.Tx) error {
+		if updateErr := dbHelper.UpdateDish(tx, dishId, restaurantId, &body); updateErr != nil {
+			return updateErr
+		}
+		return enqueueDishUpdated(tx, restaurantId, dishId)
+	})
 	if err != nil {
 		logrus.Errorf("Failed update Restaurant Dish: %s", err)
 		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to update Restaurant Dish")
 		return
 	}
+	publishDishEvent(dishId, restaurantId, false)
 	logrus.Infof("Restaurant Dish Updated successfully")
 	utils.RespondJSON(w, http.StatusCreated, models.Message{
 		Message: "Restaurant Dish Updated successfully",
 	})
 }
 
+// UpdateDishAvailability switches a dish on or off, a dish switched off with a back at time comes back on its own
+// once that time passes
+func UpdateDishAvailability(w http.ResponseWriter, r *http.Request) {
+	restaurantId := chi.URLParam(r, "restaurantId")
+	dishId := chi.URLParam(r, "dishId")
+	var body models.DishAvailabilityBody
+
+	adminCtx := middlewares.UserContext(r)
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+
+	if body.BackAt != nil && (body.Available || !body.BackAt.After(time.Now())) {
+		logrus.Errorf("Invalid Back At.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Back At.")
+		return
+	}
+
+	dish, dishErr := dbHelper.GetDishByID(dishId)
+	if dishErr != nil {
+		logrus.Errorf("Failed to get Dish: %s", dishErr)
+		utils.RespondError(w, http.StatusInternalServerError, dishErr, "Failed to get Dish")
+		return
+	}
+	if dish == nil {
+		logrus.Errorf("Dish not exist: %s", dishId)
+		utils.RespondError(w, http.StatusNotFound, nil, "Dish not exist")
+		return
+	}
+
+	if adminCtx.CurrentRole != models.RoleAdmin && dish.CreatedBy != adminCtx.ID {
+		logrus.Errorf("Dish not Created by %s", adminCtx.CurrentRole)
+		utils.RespondError(w, http.StatusBadRequest, nil, "Dish not Created by: "+string(adminCtx.CurrentRole))
+		return
+	}
+
+	var updated bool
+	err := database.Tx(func(tx *sqlx.Tx) error {
+		var updateErr error
+		updated, updateErr = dbHelper.SetDishAvailability(tx, dishId, restaurantId, body.Available, body.BackAt)
+		if updateErr != nil || !updated {
+			return updateErr
+		}
+		return enqueueDishUpdated(tx, restaurantId, dishId)
+	})
+	if err != nil {
+		logrus.Errorf("Failed to update Dish Availability: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to update Dish Availability")
+		return
+	}
+	if !updated {
+		logrus.Errorf("Dish not exist: %s", dishId)
+		utils.RespondError(w, http.StatusNotFound, nil, "Dish not exist")
+		return
+	}
+	publishDishEvent(dishId, restaurantId, false)
+	logrus.Infof("Dish Availability Updated successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Dish Availability Updated successfully.",
+	})
+}
+
 func RemoveDish(w http.ResponseWriter, r *http.Request) {
 	restaurantId := chi.URLParam(r, "restaurantId")
 	dishId := chi.URLParam(r, "dishId")
 	adminCtx := middlewares.UserContext(r)
-	if adminCtx.CurrentRole == models.RoleAdmin {
-		err := dbHelper.RemoveDish(dishId, restaurantId)
-		if err != nil {
-			logrus.Errorf("Failed to get Restaurant Dish: %s", err)
-			utils.RespondError(w, http.StatusInternalServerError, err, "Failed to get Restaurant Dish")
-			return
+	err := database.Tx(func(tx *sqlx.Tx) error {
+		var removed bool
+		var removeErr error
+		if adminCtx.CurrentRole == models.RoleAdmin {
+			removed, removeErr = dbHelper.RemoveDish(tx, dishId, restaurantId)
+		} else {
+			removed, removeErr = dbHelper.RemoveDishByUserID(tx, dishId, restaurantId, adminCtx.ID)
 		}
-	} else {
-		err := dbHelper.RemoveDishByUserID(dishId, restaurantId, adminCtx.ID)
-		if err != nil {
-			logrus.Errorf("Failed to get Restaurant Dish: %s", err)
-			utils.RespondError(w, http.StatusInternalServerError, err, "Failed to get Restaurant Dish")
-			return
+		if removeErr != nil || !removed {
+			return removeErr
 		}
+		return enqueueDishUpdated(tx, restaurantId, dishId)
+	})
+	if err != nil {
+		logrus.Errorf("Failed to get Restaurant Dish: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to get Restaurant Dish")
+		return
 	}
+	publishDishEvent(dishId, restaurantId, true)
 	logrus.Infof("Restaurant Dish Removed successfully.")
 	utils.RespondJSON(w, http.StatusCreated, models.Message{
 		Message: "Restaurant Dish Removed successfully.",
@@ -631,6 +1012,14 @@ func GetRestaurantsDishes(w http.ResponseWriter, r *http.Request) {
 	Filters := utils.GetDishFilters(r)
 	restaurantId := chi.URLParam(r, "restaurantId")
 	adminCtx := middlewares.UserContext(r)
+	if !dishFiltersIn(w, restaurantId, Filters) {
+		return
+	}
+	servedAt, ok := dishesServedAt(w, r, adminCtx.CurrentRole == models.RoleAdmin || adminCtx.CurrentRole == models.RoleSubAdmin)
+	if !ok {
+		return
+	}
+	Filters.ServedAt = servedAt
 	if adminCtx.CurrentRole == models.RoleAdmin || adminCtx.CurrentRole == models.RoleUser {
 		DishesCount, DishesCountErr := dbHelper.GetRestaurantDishesCount(restaurantId, Filters)
 		if DishesCountErr != nil {
@@ -644,6 +1033,13 @@ func GetRestaurantsDishes(w http.ResponseWriter, r *http.Request) {
 			utils.RespondError(w, http.StatusInternalServerError, err, "Failed to get Restaurant Dishes")
 			return
 		}
+		if adminCtx.CurrentRole == models.RoleUser {
+			if favouriteErr := flagFavouriteDishes(adminCtx.ID, Dishes); favouriteErr != nil {
+				logrus.Errorf("Failed to get Favourite Dishes: %s", favouriteErr)
+				utils.RespondError(w, http.StatusInternalServerError, favouriteErr, "Failed to get Restaurant Dishes")
+				return
+			}
+		}
 		logrus.Errorf("Get Restaurants successfully.")
 		utils.RespondJSON(w, http.StatusCreated, models.GetDishes{
 			Message:    "Get Restaurants successfully.",
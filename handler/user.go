@@ -1,18 +1,28 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
+	"os"
 	"rms/database"
 	"rms/database/dbHelper"
+	"rms/eta"
 	"rms/middlewares"
 	"rms/models"
 	"rms/utils"
 	"strings"
+	"time"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/jmoiron/sqlx"
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	verificationTokenLength = 32
+	verificationTokenTTL    = 24 * time.Hour
+)
+
 func LoginUser(w http.ResponseWriter, r *http.Request) {
 	//TODO :this will made in model and at the time of login role is not taken From the user **DONE**
 	var body models.LoginBody
@@ -51,6 +61,245 @@ func LoginUser(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// SignupUser lets a customer register itself, if the email already belongs to an admin or sub-admin the user role is attached to that account
+func SignupUser(w http.ResponseWriter, r *http.Request) {
+	var body models.RegisterUserBody
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+	if body.Name == "" {
+		logrus.Errorf("Invalid Name.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Name.")
+		return
+	}
+	if len(body.Password) < 6 {
+		logrus.Errorf("password must be 6 chars long")
+		utils.RespondError(w, http.StatusBadRequest, nil, "password must be 6 chars long")
+		return
+	}
+	if !utils.IsEmailValid(body.Email) {
+		logrus.Errorf("Invalid Email.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Email.")
+		return
+	}
+
+	unverified, unverifiedErr := dbHelper.GetUnverifiedUserByEmail(body.Email)
+	if unverifiedErr != nil {
+		logrus.Errorf("Failed to check user existence: %s", unverifiedErr)
+		utils.RespondError(w, http.StatusInternalServerError, unverifiedErr, "Failed to check user existence")
+		return
+	}
+	if unverified != nil {
+		// nobody proved they own the email yet, so the latest sign up takes the account over and gets a new link
+		signupUnverifiedUser(w, unverified.ID, &body)
+		return
+	}
+
+	exists, existsErr := dbHelper.IsUserRoleExists(body.Email, models.RoleUser)
+	if existsErr != nil {
+		logrus.Errorf("Failed to check user role existence: %s", existsErr)
+		utils.RespondError(w, http.StatusInternalServerError, existsErr, "Failed to check user role existence")
+		return
+	}
+	if exists {
+		logrus.Errorf("user already exists")
+		utils.RespondError(w, http.StatusConflict, nil, "user already exists")
+		return
+	}
+	userID, existsErr := dbHelper.IsUserExists(body.Email)
+	if existsErr != nil {
+		logrus.Errorf("Failed to check user existence: %s", existsErr)
+		utils.RespondError(w, http.StatusInternalServerError, existsErr, "Failed to check user existence")
+		return
+	}
+
+	if len(userID) > 0 {
+		// the account is already verified, so only the owner's password may attach a new role to it
+		hashedPassword, passwordErr := dbHelper.GetUserPasswordByEmail(body.Email)
+		if passwordErr != nil {
+			logrus.Errorf("Failed to get user password: %s", passwordErr)
+			utils.RespondError(w, http.StatusInternalServerError, passwordErr, "Failed to check user existence")
+			return
+		}
+		if checkErr := utils.CheckPassword(body.Password, hashedPassword); checkErr != nil {
+			logrus.Errorf("Password does not match existing account: %s", checkErr)
+			utils.RespondError(w, http.StatusConflict, nil, "Email already registered, use the existing account password")
+			return
+		}
+		roleErr := dbHelper.CreateUserRole(database.RMS, userID, userID, models.RoleUser)
+		if roleErr != nil {
+			logrus.Errorf("Failed to create User Role: %s", roleErr)
+			utils.RespondError(w, http.StatusInternalServerError, roleErr, "Failed to create user")
+			return
+		}
+		logrus.Infof("User role added to existing account successfully.")
+		utils.RespondJSON(w, http.StatusCreated, models.Message{
+			Message: "Signup successfully, login with your existing password.",
+		})
+		return
+	}
+
+	hashedPassword, hasErr := utils.HashPassword(body.Password)
+	if hasErr != nil {
+		logrus.Errorf("Failed to secure password: %s", hasErr)
+		utils.RespondError(w, http.StatusInternalServerError, hasErr, "Failed to secure password")
+		return
+	}
+	var token string
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		newUserID, saveErr := dbHelper.CreateUnverifiedUser(tx, body.Name, body.Email, hashedPassword)
+		if saveErr != nil {
+			logrus.Errorf("Failed to save user: %s", saveErr)
+			return saveErr
+		}
+		roleErr := dbHelper.CreateUserRole(tx, newUserID, newUserID, models.RoleUser)
+		if roleErr != nil {
+			logrus.Errorf("Failed to create User Role: %s", roleErr)
+			return roleErr
+		}
+		var tokenErr error
+		token, tokenErr = issueEmailVerification(tx, newUserID)
+		return tokenErr
+	})
+	if txErr != nil {
+		logrus.Errorf("Failed to signup user: %s", txErr)
+		utils.RespondError(w, http.StatusInternalServerError, txErr, "Failed to create user")
+		return
+	}
+	sendVerificationEmail(body.Name, body.Email, token)
+	logrus.Infof("User signup successfully.")
+	utils.RespondJSON(w, http.StatusCreated, models.Message{
+		Message: "Signup successfully, please verify your email before login.",
+	})
+}
+
+// sendVerificationEmail only logs a failed mail, the user can ask for the link again through /signup/resend
+func sendVerificationEmail(name, email, token string) {
+	mailBody := fmt.Sprintf("Hi %s,\n\nVerify your email by opening %s/verify-email?token=%s\n", name, os.Getenv("APP_BASE_URL"), token)
+	if mailErr := utils.SendEmail(email, "Verify your email", mailBody); mailErr != nil {
+		logrus.Errorf("Failed to send verification email: %s", mailErr)
+	}
+}
+
+// issueEmailVerification replaces the unused tokens of the user with a new one
+func issueEmailVerification(tx *sqlx.Tx, userID string) (string, error) {
+	token, tokenErr := utils.GenerateToken(verificationTokenLength)
+	if tokenErr != nil {
+		return "", tokenErr
+	}
+	if expireErr := dbHelper.ExpireEmailVerifications(tx, userID); expireErr != nil {
+		return "", expireErr
+	}
+	return token, dbHelper.CreateEmailVerification(tx, userID, token, time.Now().Add(verificationTokenTTL))
+}
+
+// signupUnverifiedUser signs up again on an account that was never verified
+func signupUnverifiedUser(w http.ResponseWriter, userID string, body *models.RegisterUserBody) {
+	hashedPassword, hasErr := utils.HashPassword(body.Password)
+	if hasErr != nil {
+		logrus.Errorf("Failed to secure password: %s", hasErr)
+		utils.RespondError(w, http.StatusInternalServerError, hasErr, "Failed to secure password")
+		return
+	}
+	var token string
+	var updated bool
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		var updateErr error
+		updated, updateErr = dbHelper.UpdateUnverifiedUser(tx, userID, body.Name, hashedPassword)
+		if updateErr != nil || !updated {
+			return updateErr
+		}
+		token, updateErr = issueEmailVerification(tx, userID)
+		return updateErr
+	})
+	if txErr != nil {
+		logrus.Errorf("Failed to signup user: %s", txErr)
+		utils.RespondError(w, http.StatusInternalServerError, txErr, "Failed to create user")
+		return
+	}
+	if !updated {
+		logrus.Errorf("user already exists")
+		utils.RespondError(w, http.StatusConflict, nil, "user already exists")
+		return
+	}
+	sendVerificationEmail(body.Name, body.Email, token)
+	logrus.Infof("User signup successfully.")
+	utils.RespondJSON(w, http.StatusCreated, models.Message{
+		Message: "Signup successfully, please verify your email before login.",
+	})
+}
+
+// ResendEmailVerification mails a new verification link to an unverified account, the answer is the same whether or
+// not the email has one so it can't be used to find accounts
+func ResendEmailVerification(w http.ResponseWriter, r *http.Request) {
+	var body models.ResendVerificationBody
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+	if !utils.IsEmailValid(body.Email) {
+		logrus.Errorf("Invalid Email.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Email.")
+		return
+	}
+	user, userErr := dbHelper.GetUnverifiedUserByEmail(body.Email)
+	if userErr != nil {
+		logrus.Errorf("Failed to check user existence: %s", userErr)
+		utils.RespondError(w, http.StatusInternalServerError, userErr, "Failed to send verification email")
+		return
+	}
+	if user != nil {
+		var token string
+		txErr := database.Tx(func(tx *sqlx.Tx) error {
+			var issueErr error
+			token, issueErr = issueEmailVerification(tx, user.ID)
+			return issueErr
+		})
+		if txErr != nil {
+			logrus.Errorf("Failed to issue verification token: %s", txErr)
+			utils.RespondError(w, http.StatusInternalServerError, txErr, "Failed to send verification email")
+			return
+		}
+		sendVerificationEmail(user.Name, user.Email, token)
+	}
+	logrus.Infof("Verification email resent.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "If the email waits for verification a new link was sent.",
+	})
+}
+
+func VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	var body models.VerifyEmailBody
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+	var verified bool
+	txErr := database.Tx(func(tx *sqlx.Tx) error {
+		var err error
+		verified, err = dbHelper.VerifyEmail(tx, body.Token)
+		return err
+	})
+	if txErr != nil {
+		logrus.Errorf("Failed to verify email: %s", txErr)
+		utils.RespondError(w, http.StatusInternalServerError, txErr, "Failed to verify email")
+		return
+	}
+	if !verified {
+		logrus.Errorf("Invalid or expired verification token.")
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid or expired verification token.")
+		return
+	}
+	logrus.Infof("Email verified successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Email verified successfully.",
+	})
+}
+
 func GetInfo(w http.ResponseWriter, r *http.Request) {
 	userCtx := middlewares.UserContext(r)
 	logrus.Infof("Get information Successfully.")
@@ -254,11 +503,19 @@ func GetRestaurantDistance(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	loads, loadsErr := dbHelper.GetKitchenLoads([]string{Restaurant.ID})
+	if loadsErr != nil {
+		logrus.Errorf("Unable to get Restaurant kitchen load: %s", loadsErr)
+		utils.RespondError(w, http.StatusInternalServerError, loadsErr, "Unable to get Restaurant kitchen load")
+		return
+	}
+
 	Distance, Unit := utils.CalculateDistance(userAddress.Lat, userAddress.Lng, Restaurant.Lat, Restaurant.Lng)
 	logrus.Infof("Restaurant Distance Calculated in %s successfully.", Unit)
 	utils.RespondJSON(w, http.StatusOK, models.RestaurantDistance{
 		Message:      "Restaurant Distance Calculated successfully.",
 		Distance:     Distance,
 		DistanceUnit: Unit,
+		ETA:          eta.Current().Quote(loads[Restaurant.ID], utils.DistanceKm(userAddress.Lat, userAddress.Lng, Restaurant.Lat, Restaurant.Lng)),
 	})
 }
@@ -0,0 +1,227 @@
+package handler
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"rms/database/dbHelper"
+	"rms/middlewares"
+	"rms/models"
+	"rms/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+const webhookSecretLength = 32
+
+func isWebhookEventValid(eventType string) bool {
+	for _, event := range models.WebhookEvents {
+		if event == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// isWebhookHostPublic resolves the host of the webhook URL, every address it points to has to be public so the
+// delivery job can't be made to call into the internal network
+func isWebhookHostPublic(host string) bool {
+	if ip := net.ParseIP(host); ip != nil {
+		return utils.IsPublicIP(ip)
+	}
+	ips, err := net.LookupIP(host)
+	if err != nil || len(ips) == 0 {
+		return false
+	}
+	for _, ip := range ips {
+		if !utils.IsPublicIP(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+func AddWebhook(w http.ResponseWriter, r *http.Request) {
+	var body models.AddWebhookBody
+	adminCtx := middlewares.UserContext(r)
+	if parseErr := utils.ParseBody(r.Body, &body); parseErr != nil {
+		logrus.Errorf("Failed to parse request body: %s", parseErr)
+		utils.RespondError(w, http.StatusBadRequest, parseErr, "Failed to parse request body")
+		return
+	}
+
+	endpoint, urlErr := url.Parse(body.URL)
+	if urlErr != nil || (endpoint.Scheme != "https" && endpoint.Scheme != "http") || endpoint.Host == "" {
+		logrus.Errorf("Invalid Webhook URL: %s", body.URL)
+		utils.RespondError(w, http.StatusBadRequest, urlErr, "Invalid Webhook URL.")
+		return
+	}
+	if !isWebhookHostPublic(endpoint.Hostname()) {
+		logrus.Errorf("Webhook URL not public: %s", body.URL)
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Webhook URL, the host has to resolve to public addresses.")
+		return
+	}
+
+	for _, event := range body.Events {
+		if !isWebhookEventValid(event) {
+			logrus.Errorf("Invalid Webhook Event: %s", event)
+			utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Webhook Event: "+event)
+			return
+		}
+	}
+
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+
+	secret, secretErr := utils.GenerateToken(webhookSecretLength)
+	if secretErr != nil {
+		logrus.Errorf("Failed to generate Webhook secret: %s", secretErr)
+		utils.RespondError(w, http.StatusInternalServerError, secretErr, "Failed to generate Webhook secret")
+		return
+	}
+	if body.Events == nil {
+		body.Events = make([]string, 0)
+	}
+	webhook, saveErr := dbHelper.CreateWebhook(restaurant.ID, adminCtx.ID, body.URL, secret, body.Events)
+	if saveErr != nil {
+		logrus.Errorf("Failed to create Webhook: %s", saveErr)
+		utils.RespondError(w, http.StatusInternalServerError, saveErr, "Failed to create Webhook")
+		return
+	}
+	logrus.Infof("Webhook created successfully.")
+	utils.RespondJSON(w, http.StatusCreated, models.AddWebhook{
+		Message: "Webhook created successfully, keep the secret, it is shown only once.",
+		Webhook: *webhook,
+		Secret:  secret,
+	})
+}
+
+func GetWebhooks(w http.ResponseWriter, r *http.Request) {
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	webhooks, err := dbHelper.GetWebhooks(restaurant.ID)
+	if err != nil {
+		logrus.Errorf("Unable to get Webhooks: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Unable to get Webhooks")
+		return
+	}
+	logrus.Infof("Get Webhooks successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetWebhooks{
+		Message:  "Get Webhooks successfully.",
+		Webhooks: webhooks,
+	})
+}
+
+func RemoveWebhook(w http.ResponseWriter, r *http.Request) {
+	webhookID := chi.URLParam(r, "webhookId")
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return
+	}
+	if err := dbHelper.ArchiveWebhook(restaurant.ID, webhookID); err != nil {
+		logrus.Errorf("Failed to remove Webhook: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to remove Webhook")
+		return
+	}
+	logrus.Infof("Webhook removed successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Webhook removed successfully.",
+	})
+}
+
+// getManagedWebhook loads the webhook in the URL if it belongs to a restaurant the current sub-admin manages
+func getManagedWebhook(w http.ResponseWriter, r *http.Request) (*models.Webhook, bool) {
+	webhookID := chi.URLParam(r, "webhookId")
+	restaurant, ok := getManagedRestaurant(w, r)
+	if !ok {
+		return nil, false
+	}
+	webhook, err := dbHelper.GetWebhookByID(restaurant.ID, webhookID)
+	if err != nil {
+		logrus.Errorf("Unable to get Webhook: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Unable to get Webhook")
+		return nil, false
+	}
+	if webhook == nil {
+		logrus.Errorf("Webhook not exist: %s", webhookID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Webhook not exist")
+		return nil, false
+	}
+	return webhook, true
+}
+
+// GetWebhookDeliveries is the delivery log of a webhook, status=dead lists its dead letters
+func GetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	Filters := utils.GetFilters(r)
+	status := r.URL.Query().Get("status")
+	if status != "" && !models.WebhookDeliveryStatus(status).IsValid() {
+		logrus.Errorf("Invalid Delivery Status: %s", status)
+		utils.RespondError(w, http.StatusBadRequest, nil, "Invalid Delivery Status.")
+		return
+	}
+	webhook, ok := getManagedWebhook(w, r)
+	if !ok {
+		return
+	}
+
+	var deliveriesCount int64
+	deliveries := make([]models.WebhookDelivery, 0)
+	var errGroup errgroup.Group
+	errGroup.Go(func() error {
+		var err error
+		deliveriesCount, err = dbHelper.GetWebhookDeliveriesCount(webhook.ID, status)
+		if err != nil {
+			logrus.Errorf("Unable to get Webhook Deliveries Count: %s", err)
+		}
+		return err
+	})
+	errGroup.Go(func() error {
+		var err error
+		deliveries, err = dbHelper.GetWebhookDeliveries(webhook.ID, status, Filters)
+		if err != nil {
+			logrus.Errorf("Unable to get Webhook Deliveries: %s", err)
+		}
+		return err
+	})
+	if err := errGroup.Wait(); err != nil {
+		utils.RespondError(w, http.StatusInternalServerError, err, "Unable to get Webhook Deliveries")
+		return
+	}
+	logrus.Infof("Get Webhook Deliveries successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.GetWebhookDeliveries{
+		Message:    "Get Webhook Deliveries successfully.",
+		Deliveries: deliveries,
+		TotalCount: deliveriesCount,
+		PageNumber: Filters.PageNumber,
+		PageSize:   Filters.PageSize,
+	})
+}
+
+func RedeliverWebhook(w http.ResponseWriter, r *http.Request) {
+	deliveryID := chi.URLParam(r, "deliveryId")
+	webhook, ok := getManagedWebhook(w, r)
+	if !ok {
+		return
+	}
+	queued, err := dbHelper.RedeliverWebhook(webhook.ID, deliveryID)
+	if err != nil {
+		logrus.Errorf("Failed to redeliver Webhook: %s", err)
+		utils.RespondError(w, http.StatusInternalServerError, err, "Failed to redeliver Webhook")
+		return
+	}
+	if !queued {
+		logrus.Errorf("Delivery not exist: %s", deliveryID)
+		utils.RespondError(w, http.StatusNotFound, nil, "Delivery not exist")
+		return
+	}
+	logrus.Infof("Webhook redelivery queued successfully.")
+	utils.RespondJSON(w, http.StatusOK, models.Message{
+		Message: "Webhook redelivery queued successfully.",
+	})
+}
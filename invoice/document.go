@@ -0,0 +1,142 @@
+// Package invoice renders issued invoices as plain text, HTML and PDF. Rendering only reads the invoice, so the same
+// invoice always gives the same documents.
+package invoice
+
+import (
+	"fmt"
+	"rms/models"
+	"strconv"
+	"strings"
+)
+
+// row is a labelled amount of the totals block
+type row struct {
+	Label  string
+	Amount string
+	Strong bool
+}
+
+// item is one line of the items table
+type item struct {
+	Name     string
+	Note     string
+	Quantity string
+	Price    string
+	Amount   string
+}
+
+// document is the invoice laid out once for every format
+type document struct {
+	Title      string
+	Number     string
+	Date       string
+	OrderID    string
+	Currency   string
+	Restaurant []string
+	Customer   []string
+	Items      []item
+	Totals     []row
+}
+
+func newDocument(invoice models.Invoice) document {
+	details := invoice.Details
+	breakdown := details.Breakdown
+	currency := breakdown.Currency
+	if currency == "" {
+		currency = details.Restaurant.Currency
+	}
+	money := func(amount int64) string {
+		return models.NewMoney(amount, currency).Major()
+	}
+	restaurant := details.Restaurant
+	doc := document{
+		Title:    "TAX INVOICE",
+		Number:   invoice.Number,
+		Date:     invoice.IssuedAt.Format("02 Jan 2006"),
+		OrderID:  invoice.OrderID,
+		Currency: currency,
+		Restaurant: nonEmpty(
+			restaurant.Name,
+			restaurant.Address,
+			joinNonEmpty(", ", restaurant.City, restaurant.State, restaurant.PinCode),
+			restaurant.Email,
+		),
+	}
+	if details.OrderType == models.OrderDineIn {
+		doc.Customer = []string{"Dine-in guest"}
+	}
+	if customer := details.Customer; customer != nil {
+		doc.Customer = nonEmpty(customer.Name, customer.Email)
+		if address := customer.Address; address != nil {
+			doc.Customer = append(doc.Customer, nonEmpty(
+				address.Address,
+				joinNonEmpty(", ", address.City, address.State, address.PinCode),
+			)...)
+		}
+	}
+
+	for _, line := range breakdown.Items {
+		var notes []string
+		if line.Discount > 0 {
+			notes = append(notes, fmt.Sprintf("%d%% off", line.Discount))
+		}
+		if line.CouponDiscount > 0 {
+			notes = append(notes, "coupon -"+money(line.CouponDiscount))
+		}
+		doc.Items = append(doc.Items, item{
+			Name:     line.Name,
+			Note:     strings.Join(notes, ", "),
+			Quantity: strconv.FormatInt(line.Quantity, 10),
+			Price:    money(line.Price),
+			Amount:   money(line.Total),
+		})
+	}
+
+	add := func(label string, amount int64, always bool) {
+		if amount != 0 || always {
+			doc.Totals = append(doc.Totals, row{Label: label, Amount: money(amount)})
+		}
+	}
+	add("Sub Total", breakdown.SubTotal, true)
+	add("Dish Discount", -breakdown.DishDiscount, false)
+	couponLabel := "Coupon"
+	if breakdown.CouponCode != "" {
+		couponLabel += " (" + breakdown.CouponCode + ")"
+	}
+	add(couponLabel, -breakdown.CouponDiscount, false)
+	add("Packaging", breakdown.Packaging, false)
+	add("Delivery Fee", breakdown.DeliveryFee, false)
+	for _, tax := range breakdown.Taxes {
+		label := fmt.Sprintf("%s %s%%", tax.Name, percent(tax.RateBps))
+		if tax.Inclusive {
+			label += " (included)"
+		}
+		add(label, tax.Amount, true)
+	}
+	add("Rounding", breakdown.Rounding, false)
+	doc.Totals = append(doc.Totals, row{Label: "Grand Total", Amount: money(breakdown.GrandTotal), Strong: true})
+	if breakdown.PointsRedeemed > 0 {
+		add("Points Redeemed", -breakdown.PointsRedeemed, true)
+		doc.Totals = append(doc.Totals, row{Label: "Amount Paid", Amount: money(breakdown.Total), Strong: true})
+	}
+	return doc
+}
+
+// percent writes basis points as a percentage without trailing zeros, 250 is "2.5"
+func percent(bps int64) string {
+	return strconv.FormatFloat(float64(bps)/100, 'f', -1, 64)
+}
+
+func nonEmpty(values ...string) []string {
+	kept := make([]string, 0, len(values))
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			kept = append(kept, value)
+		}
+	}
+	return kept
+}
+
+func joinNonEmpty(separator string, values ...string) string {
+	return strings.Join(nonEmpty(values...), separator)
+}
@@ -0,0 +1,55 @@
+package invoice
+
+import (
+	"bytes"
+	"html/template"
+	"rms/models"
+)
+
+var htmlTemplate = template.Must(template.New("invoice").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Invoice {{.Number}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em auto; max-width: 720px; color: #222; }
+table { width: 100%; border-collapse: collapse; }
+th, td { padding: 4px 6px; text-align: left; }
+.amount { text-align: right; }
+.items th { border-bottom: 1px solid #999; }
+.items td { border-bottom: 1px solid #eee; }
+.note { color: #666; font-size: 0.85em; }
+.strong td { font-weight: bold; border-top: 1px solid #999; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p>{{range .Restaurant}}{{.}}<br>{{end}}</p>
+<table>
+<tr><th>Invoice No</th><td>{{.Number}}</td></tr>
+<tr><th>Date</th><td>{{.Date}}</td></tr>
+<tr><th>Order</th><td>{{.OrderID}}</td></tr>
+<tr><th>Currency</th><td>{{.Currency}}</td></tr>
+{{if .Customer}}<tr><th>Bill To</th><td>{{range .Customer}}{{.}}<br>{{end}}</td></tr>{{end}}
+</table>
+<br>
+<table class="items">
+<tr><th>Item</th><th class="amount">Qty</th><th class="amount">Price</th><th class="amount">Amount</th></tr>
+{{range .Items}}<tr><td>{{.Name}}{{if .Note}}<br><span class="note">{{.Note}}</span>{{end}}</td><td class="amount">{{.Quantity}}</td><td class="amount">{{.Price}}</td><td class="amount">{{.Amount}}</td></tr>
+{{end}}</table>
+<br>
+<table>
+{{range .Totals}}<tr{{if .Strong}} class="strong"{{end}}><td>{{.Label}}</td><td class="amount">{{.Amount}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// HTML renders the invoice as a standalone HTML page
+func HTML(invoice models.Invoice) ([]byte, error) {
+	var page bytes.Buffer
+	if err := htmlTemplate.Execute(&page, newDocument(invoice)); err != nil {
+		return nil, err
+	}
+	return page.Bytes(), nil
+}
@@ -0,0 +1,91 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"rms/models"
+	"strings"
+)
+
+// the PDF is the text invoice set in Courier on A4 pages
+const (
+	pageWidth    = 595
+	pageHeight   = 842
+	pageMargin   = 48
+	fontSize     = 9
+	lineHeight   = 12
+	linesPerPage = (pageHeight - 2*pageMargin) / lineHeight
+)
+
+// PDF renders the invoice as a PDF document, it has no creation date so the same invoice gives the same bytes
+func PDF(invoice models.Invoice) []byte {
+	lines := textLines(newDocument(invoice))
+	pages := make([][]string, 0)
+	for len(lines) > linesPerPage {
+		pages = append(pages, lines[:linesPerPage])
+		lines = lines[linesPerPage:]
+	}
+	pages = append(pages, lines)
+
+	// objects 1 to 3 are the catalog, the page tree and the font, every page then takes a page and a content object
+	objects := make([]string, 3, 3+2*len(pages))
+	kids := make([]string, 0, len(pages))
+	for index := range pages {
+		kids = append(kids, fmt.Sprintf("%d 0 R", 4+2*index))
+	}
+	objects[0] = "<< /Type /Catalog /Pages 2 0 R >>"
+	objects[1] = fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages))
+	objects[2] = "<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>"
+	for index, page := range pages {
+		objects = append(objects, fmt.Sprintf(
+			"<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R >> >> /Contents %d 0 R >>",
+			pageWidth, pageHeight, 5+2*index,
+		))
+		content := pageContent(page)
+		objects = append(objects, fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", len(content), content))
+	}
+
+	var document bytes.Buffer
+	document.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for index, object := range objects {
+		offsets[index] = document.Len()
+		fmt.Fprintf(&document, "%d 0 obj\n%s\nendobj\n", index+1, object)
+	}
+	xref := document.Len()
+	fmt.Fprintf(&document, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&document, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&document, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return document.Bytes()
+}
+
+func pageContent(lines []string) string {
+	var content strings.Builder
+	fmt.Fprintf(&content, "BT\n/F1 %d Tf\n%d TL\n%d %d Td\n", fontSize, lineHeight, pageMargin, pageHeight-pageMargin)
+	for _, line := range lines {
+		fmt.Fprintf(&content, "(%s) '\n", pdfString(line))
+	}
+	content.WriteString("ET")
+	return content.String()
+}
+
+// pdfString escapes a line for a PDF string, characters the standard font cannot show become question marks
+func pdfString(value string) string {
+	var escaped strings.Builder
+	for _, char := range value {
+		switch {
+		case char == '(' || char == ')' || char == '\\':
+			escaped.WriteRune('\\')
+			escaped.WriteRune(char)
+		case char >= 0x20 && char < 0x7f:
+			escaped.WriteRune(char)
+		case char >= 0xa0 && char <= 0xff:
+			fmt.Fprintf(&escaped, "\\%03o", char)
+		default:
+			escaped.WriteRune('?')
+		}
+	}
+	return escaped.String()
+}
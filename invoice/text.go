@@ -0,0 +1,108 @@
+package invoice
+
+import (
+	"fmt"
+	"rms/models"
+	"strings"
+	"unicode/utf8"
+)
+
+// textWidth is the width of a plain text invoice, it fits a receipt printer and a PDF page
+const textWidth = 64
+
+// Text renders the invoice as a plain text receipt
+func Text(invoice models.Invoice) string {
+	return strings.Join(textLines(newDocument(invoice)), "\n") + "\n"
+}
+
+func textLines(doc document) []string {
+	lines := make([]string, 0)
+	rule := strings.Repeat("-", textWidth)
+	lines = append(lines, center(doc.Title), "")
+	lines = append(lines, doc.Restaurant...)
+	lines = append(lines, "",
+		field("Invoice No", doc.Number),
+		field("Date", doc.Date),
+		field("Order", doc.OrderID),
+		field("Currency", doc.Currency),
+	)
+	for index, customer := range doc.Customer {
+		label := ""
+		if index == 0 {
+			label = "Bill To"
+		}
+		lines = append(lines, field(label, customer))
+	}
+	lines = append(lines, "", itemLine("Item", "Qty", "Price", "Amount"), rule)
+	for _, item := range doc.Items {
+		names := wrap(item.Name, 30)
+		lines = append(lines, itemLine(names[0], item.Quantity, item.Price, item.Amount))
+		for _, name := range names[1:] {
+			lines = append(lines, "  "+name)
+		}
+		if item.Note != "" {
+			lines = append(lines, "  ("+item.Note+")")
+		}
+	}
+	lines = append(lines, rule)
+	for _, total := range doc.Totals {
+		if total.Strong {
+			lines = append(lines, rule)
+		}
+		lines = append(lines, totalLine(total.Label, total.Amount))
+	}
+	return lines
+}
+
+func center(value string) string {
+	padding := (textWidth - utf8.RuneCountInString(value)) / 2
+	if padding < 0 {
+		padding = 0
+	}
+	return strings.Repeat(" ", padding) + value
+}
+
+func field(label, value string) string {
+	if label != "" {
+		label += ":"
+	}
+	return fmt.Sprintf("%-12s%s", label, value)
+}
+
+func itemLine(name, quantity, price, amount string) string {
+	return fmt.Sprintf("%-30s%6s%14s%14s", name, quantity, price, amount)
+}
+
+func totalLine(label, amount string) string {
+	return fmt.Sprintf("%-50s%14s", label, amount)
+}
+
+// wrap breaks value into lines of at most width runes, on spaces where it can
+func wrap(value string, width int) []string {
+	lines := make([]string, 0, 1)
+	line := ""
+	for _, word := range strings.Fields(value) {
+		for utf8.RuneCountInString(word) > width {
+			runes := []rune(word)
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			lines = append(lines, string(runes[:width]))
+			word = string(runes[width:])
+		}
+		switch {
+		case line == "":
+			line = word
+		case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" || len(lines) == 0 {
+		lines = append(lines, line)
+	}
+	return lines
+}
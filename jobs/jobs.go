@@ -0,0 +1,37 @@
+package jobs
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// runEvery calls job right away and then once per interval until the context is cancelled, jobs keep their pending
+// work in Postgres so the first run picks up whatever was due while the server was down
+func runEvery(ctx context.Context, name string, interval time.Duration, job func() error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := job(); err != nil {
+			logrus.Errorf("job %s failed: %v", name, err)
+		}
+		select {
+		case <-ctx.Done():
+			logrus.Infof("stopped job %s", name)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Start runs every background job until the context is cancelled
+func Start(ctx context.Context) {
+	go runEvery(ctx, "expire loyalty points", loyaltyExpiryInterval, ExpireLoyaltyPoints)
+	go runEvery(ctx, "release scheduled orders", scheduledOrdersInterval, ReleaseScheduledOrders)
+	go runEvery(ctx, "deliver webhooks", webhookInterval, DeliverWebhooks)
+	go runEvery(ctx, "assign riders", riderAssignmentInterval, AssignRiders)
+	go runEvery(ctx, "settle payments", paymentSettlementInterval, SettlePayments)
+	go runEvery(ctx, "refresh reports", reportRefreshInterval, RefreshReports)
+	go runEvery(ctx, "refresh recommendations", recommendationInterval, RefreshRecommendations)
+}
@@ -0,0 +1,38 @@
+package jobs
+
+import (
+	"rms/database"
+	"rms/database/dbHelper"
+	"rms/models"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const loyaltyExpiryInterval = time.Hour
+
+// ExpireLoyaltyPoints writes off the earned points that passed their expiry and were not spent
+func ExpireLoyaltyPoints() error {
+	userIDs, err := dbHelper.GetUsersWithExpiredPoints()
+	if err != nil {
+		return err
+	}
+	for _, userID := range userIDs {
+		txErr := database.Tx(func(tx *sqlx.Tx) error {
+			// the amount is read again under the lock so a checkout running meanwhile is accounted for
+			if lockErr := dbHelper.LockUserLoyalty(tx, userID); lockErr != nil {
+				return lockErr
+			}
+			points, pointsErr := dbHelper.GetExpiredPoints(tx, userID)
+			if pointsErr != nil || points <= 0 {
+				return pointsErr
+			}
+			return dbHelper.PostLoyaltyTransaction(tx, userID, models.LoyaltyExpire, -points, nil, nil, "points expired", nil)
+		})
+		if txErr != nil {
+			logrus.Errorf("Failed to expire loyalty points of user %s: %v", userID, txErr)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package jobs
+
+import (
+	"rms/database"
+	"rms/database/dbHelper"
+	"rms/models"
+	"rms/orderstatus"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const scheduledOrdersInterval = time.Minute
+
+// ReleaseScheduledOrders hands the scheduled orders that reached their lead time over to the restaurants
+func ReleaseScheduledOrders() error {
+	var orders []models.Order
+	err := database.Tx(func(tx *sqlx.Tx) error {
+		var releaseErr error
+		orders, releaseErr = dbHelper.ReleaseScheduledOrders(tx)
+		if releaseErr != nil {
+			return releaseErr
+		}
+		for i := range orders {
+			// the estimate made at booking was the slot, it now runs from the kitchen queue
+			if movedErr := orderstatus.Moved(tx, &orders[i], models.OrderPlaced); movedErr != nil {
+				return movedErr
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for i := range orders {
+		orderstatus.Publish(&orders[i], models.OrderPlaced)
+	}
+	if len(orders) > 0 {
+		logrus.Infof("released %d scheduled orders", len(orders))
+	}
+	return nil
+}
@@ -0,0 +1,114 @@
+package jobs
+
+import (
+	"fmt"
+	"rms/database"
+	"rms/database/dbHelper"
+	"rms/models"
+	"rms/payments"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	paymentSettlementInterval = 15 * time.Second
+	paymentSettlementBatch    = 50
+	// paymentSettlementBackoff is the wait after the first failed provider call, it doubles up to the max after that
+	paymentSettlementBackoff    = time.Minute
+	paymentSettlementMaxBackoff = 6 * time.Hour
+)
+
+// settlementBackoff doubles the wait after every failed provider call
+func settlementBackoff(failures int64) time.Duration {
+	backoff := paymentSettlementBackoff
+	for i := int64(1); i < failures && backoff < paymentSettlementMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > paymentSettlementMaxBackoff {
+		return paymentSettlementMaxBackoff
+	}
+	return backoff
+}
+
+// SettlePayments captures the held payments of delivered orders and gives back the payments of cancelled ones, a
+// failed provider call is recorded as an attempt and retried after a backoff. A pending partial refund is given back
+// on a run of its own before anything else happens to the payment
+func SettlePayments() error {
+	settlements, err := dbHelper.GetDuePaymentSettlements(paymentSettlementBatch)
+	if err != nil {
+		return err
+	}
+	settled := 0
+	for _, settlement := range settlements {
+		provider, providerErr := payments.Get(settlement.Provider)
+		if providerErr != nil {
+			logrus.Errorf("Failed to settle payment %s: %s", settlement.ID, providerErr)
+			continue
+		}
+		var done bool
+		txErr := database.Tx(func(tx *sqlx.Tx) error {
+			// the lock keeps another instance from calling the provider for the same payment
+			payment, paymentErr := dbHelper.GetPaymentByID(tx, settlement.ID, true)
+			if paymentErr != nil || payment == nil || payment.Status != settlement.Status || payment.ProviderRef == nil {
+				return paymentErr
+			}
+			if payment.PendingRefund > 0 {
+				callErr := provider.Refund(*payment.ProviderRef, payment.PendingRefund)
+				if attemptErr := dbHelper.CreatePaymentAttempt(tx, payment.ID, models.PaymentActionPartialRefund, callErr); attemptErr != nil {
+					return attemptErr
+				}
+				if callErr != nil {
+					logrus.Errorf("Failed to %s payment %s: %s", models.PaymentActionPartialRefund, payment.ID, callErr)
+					return dbHelper.DeferPaymentSettlement(tx, payment.ID, time.Now().Add(settlementBackoff(settlement.Failures+1)))
+				}
+				done = true
+				return dbHelper.SettlePendingRefund(tx, payment.ID, payment.PendingRefund)
+			}
+			if settlement.Action == models.PaymentActionPartialRefund {
+				return nil
+			}
+			// partial refunds of adjusted orders were already given back
+			amount := payment.Amount - payment.RefundedAmount
+			to := models.PaymentCaptured
+			var callErr error
+			switch {
+			case settlement.Action == models.PaymentActionCapture:
+				callErr = provider.Capture(*payment.ProviderRef, amount)
+			case payment.Status == models.PaymentAuthorized:
+				to = models.PaymentVoided
+				callErr = provider.Refund(*payment.ProviderRef, amount)
+			default:
+				to = models.PaymentRefunded
+				callErr = provider.Refund(*payment.ProviderRef, amount)
+			}
+			if attemptErr := dbHelper.CreatePaymentAttempt(tx, payment.ID, settlement.Action, callErr); attemptErr != nil {
+				return attemptErr
+			}
+			if callErr != nil {
+				logrus.Errorf("Failed to %s payment %s: %s", settlement.Action, payment.ID, callErr)
+				return dbHelper.DeferPaymentSettlement(tx, payment.ID, time.Now().Add(settlementBackoff(settlement.Failures+1)))
+			}
+			updated, updateErr := dbHelper.UpdatePaymentStatus(tx, payment.ID, []models.PaymentStatus{payment.Status}, to, "")
+			if updateErr != nil {
+				return updateErr
+			}
+			if !updated {
+				return fmt.Errorf("payment %s moved while settling", payment.ID)
+			}
+			done = true
+			return nil
+		})
+		if txErr != nil {
+			return txErr
+		}
+		if done {
+			settled++
+		}
+	}
+	if settled > 0 {
+		logrus.Infof("settled %d payments", settled)
+	}
+	return nil
+}
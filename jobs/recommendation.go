@@ -0,0 +1,66 @@
+package jobs
+
+import (
+	"rms/database"
+	"rms/database/dbHelper"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// recommendationInterval is how often a run is tried, a run only starts once the last one is
+	// recommendationMaxAge old so restarts and several instances don't add runs
+	recommendationInterval = 15 * time.Minute
+	recommendationMaxAge   = time.Hour
+	recommendationBatch    = 200
+	recommendationLimit    = 20
+	// recommendationRadiusKm is how far from a customer's default address a restaurant counts as nearby
+	recommendationRadiusKm = 10
+	// pairs and best sellers are worked out from the delivered orders of the window, pairs seen fewer than
+	// recommendationMinPairOrders times are noise
+	recommendationWindow        = 90 * 24 * time.Hour
+	recommendationMinPairOrders = 2
+)
+
+// RefreshRecommendations works the dish and restaurant picks of every customer out again into the recommendation
+// tables, so reading them back is a plain lookup. Customers go in batches of their own transaction so a run doesn't
+// hold locks on the tables for long
+func RefreshRecommendations() error {
+	claimed, claimErr := dbHelper.ClaimRecommendationRefresh(database.RMS, recommendationMaxAge)
+	if claimErr != nil || !claimed {
+		return claimErr
+	}
+	since := time.Now().Add(-recommendationWindow)
+	if pairsErr := database.Tx(func(tx *sqlx.Tx) error {
+		return dbHelper.RebuildDishPairs(tx, since, recommendationMinPairOrders)
+	}); pairsErr != nil {
+		return pairsErr
+	}
+
+	customers := 0
+	for after := ""; ; {
+		userIDs, usersErr := dbHelper.GetCustomerIDsAfter(database.RMS, after, recommendationBatch)
+		if usersErr != nil {
+			return usersErr
+		}
+		if len(userIDs) == 0 {
+			break
+		}
+		txErr := database.Tx(func(tx *sqlx.Tx) error {
+			if dishesErr := dbHelper.RefreshDishRecommendations(tx, userIDs, since, recommendationRadiusKm, recommendationLimit); dishesErr != nil {
+				return dishesErr
+			}
+			return dbHelper.RefreshRestaurantRecommendations(tx, userIDs, since, recommendationRadiusKm, recommendationLimit)
+		})
+		if txErr != nil {
+			logrus.Errorf("Failed to refresh recommendations of %d customers after %q: %v", len(userIDs), after, txErr)
+		} else {
+			customers += len(userIDs)
+		}
+		after = userIDs[len(userIDs)-1]
+	}
+	logrus.Infof("refreshed recommendations of %d customers", customers)
+	return nil
+}
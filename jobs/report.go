@@ -0,0 +1,41 @@
+package jobs
+
+import (
+	"rms/database"
+	"rms/database/dbHelper"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+const (
+	reportRefreshInterval = time.Minute
+	// reportRefreshOverlap looks back over changes that were still being committed when the last run looked
+	reportRefreshOverlap = 5 * time.Minute
+	reportRefreshBatch   = 500
+)
+
+// RefreshReports folds the orders changed since the last run into the report summaries, only the 15 minute slots
+// those orders fall in are worked out again
+func RefreshReports() error {
+	return database.Tx(func(tx *sqlx.Tx) error {
+		refreshedUntil, now, lockErr := dbHelper.LockReportRefresh(tx)
+		if lockErr != nil {
+			return lockErr
+		}
+		buckets, bucketsErr := dbHelper.GetStaleReportBuckets(tx, refreshedUntil.Add(-reportRefreshOverlap))
+		if bucketsErr != nil {
+			return bucketsErr
+		}
+		for start := 0; start < len(buckets); start += reportRefreshBatch {
+			end := start + reportRefreshBatch
+			if end > len(buckets) {
+				end = len(buckets)
+			}
+			if refreshErr := dbHelper.RefreshReportBuckets(tx, buckets[start:end]); refreshErr != nil {
+				return refreshErr
+			}
+		}
+		return dbHelper.SetReportRefreshedUntil(tx, now)
+	})
+}
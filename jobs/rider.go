@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"errors"
+	"rms/database"
+	"rms/database/dbHelper"
+	"rms/orderstatus"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const riderAssignmentInterval = 30 * time.Second
+
+// AssignRiders retries the ready delivery orders that found no free rider when they became ready
+func AssignRiders() error {
+	orders, err := dbHelper.GetUnassignedReadyOrders()
+	if err != nil {
+		return err
+	}
+	assigned := 0
+	for _, order := range orders {
+		var riderID string
+		txErr := database.Tx(func(tx *sqlx.Tx) error {
+			var assignErr error
+			riderID, assignErr = dbHelper.AssignNearestRider(tx, order.ID)
+			if assignErr != nil || riderID == "" {
+				return assignErr
+			}
+			// the order keeps its status, the rider changes its pickup time and what its webhooks know of it
+			return orderstatus.Moved(tx, &order, order.Status)
+		})
+		if errors.Is(txErr, dbHelper.ErrNoRiderFree) {
+			// the remaining orders would not find a rider either
+			break
+		}
+		if txErr != nil {
+			return txErr
+		}
+		if riderID == "" {
+			// the order got a rider or left the ready status meanwhile
+			continue
+		}
+		orderstatus.PublishRiderAssigned(order.ID, order.UserID, riderID)
+		assigned++
+	}
+	if assigned > 0 {
+		logrus.Infof("assigned riders to %d orders", assigned)
+	}
+	return nil
+}
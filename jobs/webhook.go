@@ -0,0 +1,138 @@
+package jobs
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"rms/database"
+	"rms/database/dbHelper"
+	"rms/models"
+	"rms/utils"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	webhookInterval    = 10 * time.Second
+	webhookBatchSize   = 50
+	webhookTimeout     = 10 * time.Second
+	webhookLease       = 2 * webhookTimeout
+	webhookMaxAttempts = 8
+	webhookBaseBackoff = 30 * time.Second
+)
+
+var errWebhookAddressNotPublic = errors.New("webhook address is not public")
+
+// webhookClient only connects to public addresses. The check runs on the address being dialled, after DNS, so a
+// host that resolved to a public address when the webhook was added can't be pointed at the internal network later
+var webhookClient = &http.Client{
+	Timeout: webhookTimeout,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: webhookTimeout,
+			Control: func(network, address string, _ syscall.RawConn) error {
+				host, _, err := net.SplitHostPort(address)
+				if err != nil {
+					return err
+				}
+				if !utils.IsPublicIP(net.ParseIP(host)) {
+					return errWebhookAddressNotPublic
+				}
+				return nil
+			},
+		}).DialContext,
+		TLSHandshakeTimeout: webhookTimeout,
+		MaxIdleConnsPerHost: 2,
+	},
+}
+
+// signWebhook is the HMAC-SHA256 of "timestamp.body" keyed with the webhook secret, receivers recompute it to
+// check the sender and reject old timestamps to stop replays
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff doubles the wait after every failed attempt
+func webhookBackoff(attempts int64) time.Duration {
+	return webhookBaseBackoff * time.Duration(int64(1)<<uint(attempts-1))
+}
+
+// sendWebhook posts one delivery and returns the response status code when the endpoint answered
+func sendWebhook(delivery *models.WebhookDelivery) (*int64, error) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req, err := http.NewRequest(http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-RMS-Event", delivery.EventType)
+	req.Header.Set("X-RMS-Delivery", delivery.ID)
+	req.Header.Set("X-RMS-Timestamp", timestamp)
+	req.Header.Set("X-RMS-Signature", signWebhook(delivery.Secret, timestamp, delivery.Payload))
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	statusCode := int64(resp.StatusCode)
+	// the response body is not kept, the endpoint may not be what the restaurant says it is
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return &statusCode, fmt.Errorf("endpoint responded %d", resp.StatusCode)
+	}
+	return &statusCode, nil
+}
+
+// DeliverWebhooks sends up to a batch of due outbox rows, failures are retried with exponential backoff until they
+// go dead. Rows are claimed one at a time right before sending so the lease only has to outlast a single request
+func DeliverWebhooks() error {
+	for sent := 0; sent < webhookBatchSize; sent++ {
+		deliveries, err := dbHelper.ClaimDueWebhookDeliveries(1, webhookLease)
+		if err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+		delivery := &deliveries[0]
+		start := time.Now()
+		statusCode, sendErr := sendWebhook(delivery)
+		var attemptErr *string
+		if sendErr != nil {
+			message := sendErr.Error()
+			attemptErr = &message
+		}
+		duration := time.Since(start)
+		txErr := database.Tx(func(tx *sqlx.Tx) error {
+			if logErr := dbHelper.CreateWebhookAttempt(tx, delivery.ID, statusCode, attemptErr, duration); logErr != nil {
+				return logErr
+			}
+			if sendErr == nil {
+				return dbHelper.MarkWebhookDelivered(tx, delivery.ID)
+			}
+			attempts := delivery.Attempts + 1
+			var nextAttemptAt *time.Time
+			if attempts < webhookMaxAttempts {
+				next := time.Now().Add(webhookBackoff(attempts))
+				nextAttemptAt = &next
+			}
+			return dbHelper.MarkWebhookFailed(tx, delivery.ID, *attemptErr, nextAttemptAt)
+		})
+		if txErr != nil {
+			logrus.Errorf("Failed to record webhook delivery %s: %v", delivery.ID, txErr)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,233 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	// GIF uploads are decoded through image.Decode
+	_ "image/gif"
+)
+
+const (
+	// maxPixels keeps a small file that unpacks into a huge image from taking the memory of the server
+	maxPixels   = 40000000
+	jpegQuality = 85
+)
+
+var (
+	ErrUnsupportedImage = errors.New("only JPEG, PNG and GIF images are supported")
+	ErrImageTooLarge    = errors.New("image has too many pixels")
+)
+
+// Variant is a size an upload is stored in, the image is scaled down to fit MaxSide and never up
+type Variant struct {
+	Name    string
+	MaxSide int
+}
+
+var (
+	VariantOriginal  = Variant{Name: "original", MaxSide: 2048}
+	VariantMedium    = Variant{Name: "medium", MaxSide: 800}
+	VariantThumbnail = Variant{Name: "thumbnail", MaxSide: 200}
+	Variants         = []Variant{VariantOriginal, VariantMedium, VariantThumbnail}
+)
+
+// Rendition is an encoded variant of an upload
+type Rendition struct {
+	Variant     Variant
+	ContentType string
+	Extension   string
+	Width       int
+	Height      int
+	Data        []byte
+}
+
+// Process checks an upload is an image by its content and renders every variant. The images are decoded and encoded
+// again so EXIF and any other metadata is left behind, the EXIF orientation of a photo is applied first. Images with
+// transparency become PNG, the others JPEG
+func Process(data []byte) ([]Rendition, error) {
+	contentType := http.DetectContentType(data)
+	if contentType != "image/jpeg" && contentType != "image/png" && contentType != "image/gif" {
+		return nil, ErrUnsupportedImage
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	if config.Width <= 0 || config.Height <= 0 || config.Width*config.Height > maxPixels {
+		return nil, ErrImageTooLarge
+	}
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, ErrUnsupportedImage
+	}
+	source := toRGBA(decoded)
+	if contentType == "image/jpeg" {
+		source = orient(source, jpegOrientation(data))
+	}
+	opaque := source.Opaque()
+
+	renditions := make([]Rendition, 0, len(Variants))
+	for _, variant := range Variants {
+		scaled := fit(source, variant.MaxSide)
+		rendition := Rendition{
+			Variant: variant,
+			Width:   scaled.Bounds().Dx(),
+			Height:  scaled.Bounds().Dy(),
+		}
+		var encoded bytes.Buffer
+		if opaque {
+			rendition.ContentType, rendition.Extension = "image/jpeg", ".jpg"
+			err = jpeg.Encode(&encoded, scaled, &jpeg.Options{Quality: jpegQuality})
+		} else {
+			rendition.ContentType, rendition.Extension = "image/png", ".png"
+			err = png.Encode(&encoded, scaled)
+		}
+		if err != nil {
+			return nil, err
+		}
+		rendition.Data = encoded.Bytes()
+		renditions = append(renditions, rendition)
+	}
+	return renditions, nil
+}
+
+func toRGBA(source image.Image) *image.RGBA {
+	bounds := source.Bounds()
+	converted := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(converted, converted.Bounds(), source, bounds.Min, draw.Src)
+	return converted
+}
+
+// fit scales the image down to fit a square of the side by averaging the pixels each new pixel covers
+func fit(source *image.RGBA, side int) *image.RGBA {
+	width, height := source.Bounds().Dx(), source.Bounds().Dy()
+	if width <= side && height <= side {
+		return source
+	}
+	newWidth, newHeight := side, side
+	if width > height {
+		newHeight = maxInt(1, height*side/width)
+	} else {
+		newWidth = maxInt(1, width*side/height)
+	}
+	scaled := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
+	for y := 0; y < newHeight; y++ {
+		top, bottom := y*height/newHeight, maxInt((y+1)*height/newHeight, y*height/newHeight+1)
+		for x := 0; x < newWidth; x++ {
+			left, right := x*width/newWidth, maxInt((x+1)*width/newWidth, x*width/newWidth+1)
+			var sum [4]int
+			for sourceY := top; sourceY < bottom; sourceY++ {
+				offset := source.PixOffset(left, sourceY)
+				for sourceX := left; sourceX < right; sourceX++ {
+					for channel := 0; channel < 4; channel++ {
+						sum[channel] += int(source.Pix[offset+channel])
+					}
+					offset += 4
+				}
+			}
+			count := (bottom - top) * (right - left)
+			offset := scaled.PixOffset(x, y)
+			for channel := 0; channel < 4; channel++ {
+				scaled.Pix[offset+channel] = uint8((sum[channel] + count/2) / count)
+			}
+		}
+	}
+	return scaled
+}
+
+// orient turns the stored pixels the way the EXIF orientation says the photo is meant to be seen
+func orient(source *image.RGBA, orientation int) *image.RGBA {
+	if orientation < 2 || orientation > 8 {
+		return source
+	}
+	width, height := source.Bounds().Dx(), source.Bounds().Dy()
+	newWidth, newHeight := width, height
+	if orientation >= 5 {
+		newWidth, newHeight = height, width
+	}
+	oriented := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
+	for y := 0; y < newHeight; y++ {
+		for x := 0; x < newWidth; x++ {
+			var sourceX, sourceY int
+			switch orientation {
+			case 2:
+				sourceX, sourceY = width-1-x, y
+			case 3:
+				sourceX, sourceY = width-1-x, height-1-y
+			case 4:
+				sourceX, sourceY = x, height-1-y
+			case 5:
+				sourceX, sourceY = y, x
+			case 6:
+				sourceX, sourceY = y, height-1-x
+			case 7:
+				sourceX, sourceY = width-1-y, height-1-x
+			case 8:
+				sourceX, sourceY = width-1-y, x
+			}
+			to, from := oriented.PixOffset(x, y), source.PixOffset(sourceX, sourceY)
+			copy(oriented.Pix[to:to+4], source.Pix[from:from+4])
+		}
+	}
+	return oriented
+}
+
+// jpegOrientation reads the orientation tag of the EXIF block of a JPEG, 1 when there is none
+func jpegOrientation(data []byte) int {
+	const orientationTag = 0x0112
+	for offset := 2; offset+4 <= len(data) && data[offset] == 0xff; {
+		marker := data[offset+1]
+		if marker == 0xd8 || marker == 0x01 || (marker >= 0xd0 && marker <= 0xd7) {
+			offset += 2
+			continue
+		}
+		if marker == 0xda || marker == 0xd9 {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[offset+2:]))
+		if length < 2 || offset+2+length > len(data) {
+			break
+		}
+		segment := data[offset+4 : offset+2+length]
+		offset += 2 + length
+		if marker != 0xe1 || len(segment) < 14 || string(segment[:6]) != "Exif\x00\x00" {
+			continue
+		}
+		tiff := segment[6:]
+		var order binary.ByteOrder
+		switch string(tiff[:2]) {
+		case "II":
+			order = binary.LittleEndian
+		case "MM":
+			order = binary.BigEndian
+		default:
+			return 1
+		}
+		directory := int(order.Uint32(tiff[4:]))
+		if directory+2 > len(tiff) {
+			return 1
+		}
+		entries := int(order.Uint16(tiff[directory:]))
+		for entry := directory + 2; entry+12 <= len(tiff) && entries > 0; entry, entries = entry+12, entries-1 {
+			if order.Uint16(tiff[entry:]) == orientationTag {
+				return int(order.Uint16(tiff[entry+8:]))
+			}
+		}
+		return 1
+	}
+	return 1
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
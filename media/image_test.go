@@ -0,0 +1,122 @@
+package media
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+func encodeJPEG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	picture := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			picture.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, picture, nil); err != nil {
+		t.Fatal(err)
+	}
+	return encoded.Bytes()
+}
+
+// withOrientation puts an EXIF block holding the orientation right after the start of the JPEG
+func withOrientation(data []byte, orientation uint16) []byte {
+	tiff := []byte{'M', 'M', 0, 42, 0, 0, 0, 8, 0, 1, 0x01, 0x12, 0, 3, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 0}
+	binary.BigEndian.PutUint16(tiff[18:], orientation)
+	segment := append([]byte("Exif\x00\x00"), tiff...)
+	header := []byte{0xff, 0xe1, 0, 0}
+	binary.BigEndian.PutUint16(header[2:], uint16(len(segment)+2))
+	result := append([]byte{}, data[:2]...)
+	result = append(result, header...)
+	result = append(result, segment...)
+	return append(result, data[2:]...)
+}
+
+func TestProcessSizes(t *testing.T) {
+	renditions, err := Process(encodeJPEG(t, 3000, 1500))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][2]int{"original": {2048, 1024}, "medium": {800, 400}, "thumbnail": {200, 100}}
+	if len(renditions) != len(want) {
+		t.Fatalf("got %d renditions", len(renditions))
+	}
+	for _, rendition := range renditions {
+		size := want[rendition.Variant.Name]
+		if rendition.Width != size[0] || rendition.Height != size[1] || rendition.ContentType != "image/jpeg" {
+			t.Errorf("%s: %dx%d %s", rendition.Variant.Name, rendition.Width, rendition.Height, rendition.ContentType)
+		}
+		decoded, err := jpeg.DecodeConfig(bytes.NewReader(rendition.Data))
+		if err != nil || decoded.Width != size[0] || decoded.Height != size[1] {
+			t.Errorf("%s decodes as %+v: %v", rendition.Variant.Name, decoded, err)
+		}
+	}
+}
+
+func TestProcessSmallImageIsNotScaledUp(t *testing.T) {
+	renditions, err := Process(encodeJPEG(t, 120, 90))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rendition := range renditions {
+		if rendition.Width != 120 || rendition.Height != 90 {
+			t.Errorf("%s: %dx%d", rendition.Variant.Name, rendition.Width, rendition.Height)
+		}
+	}
+}
+
+func TestProcessStripsExifAndAppliesOrientation(t *testing.T) {
+	upload := withOrientation(encodeJPEG(t, 40, 20), 6)
+	if jpegOrientation(upload) != 6 {
+		t.Fatal("test upload has no orientation")
+	}
+	renditions, err := Process(upload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rendition := range renditions {
+		if bytes.Contains(rendition.Data, []byte("Exif")) {
+			t.Errorf("%s still has EXIF", rendition.Variant.Name)
+		}
+		if rendition.Width != 20 || rendition.Height != 40 {
+			t.Errorf("%s: %dx%d, want the photo turned upright", rendition.Variant.Name, rendition.Width, rendition.Height)
+		}
+	}
+}
+
+func TestProcessKeepsTransparencyAsPNG(t *testing.T) {
+	picture := image.NewNRGBA(image.Rect(0, 0, 300, 300))
+	picture.Set(10, 10, color.NRGBA{R: 255, A: 128})
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, picture); err != nil {
+		t.Fatal(err)
+	}
+	renditions, err := Process(encoded.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, rendition := range renditions {
+		if rendition.ContentType != "image/png" || rendition.Extension != ".png" {
+			t.Errorf("%s: %s", rendition.Variant.Name, rendition.ContentType)
+		}
+	}
+}
+
+func TestProcessRejectsOtherContent(t *testing.T) {
+	for name, data := range map[string][]byte{
+		"text":      []byte("hello, not an image"),
+		"html":      []byte("<html><body><img src=x></body></html>"),
+		"truncated": encodeJPEG(t, 50, 50)[:40],
+	} {
+		if _, err := Process(data); !errors.Is(err, ErrUnsupportedImage) {
+			t.Errorf("%s: %v", name, err)
+		}
+	}
+}
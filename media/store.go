@@ -0,0 +1,75 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+)
+
+const (
+	StoreLocal = "local"
+	StoreS3    = "s3"
+
+	defaultLocalDir     = "uploads"
+	defaultLocalBaseURL = "/media"
+)
+
+var (
+	ErrUnknownStore = errors.New("unknown media store")
+	ErrInvalidKey   = errors.New("invalid media key")
+)
+
+// BlobStore keeps the uploaded files under slash separated keys and tells where they are served from
+type BlobStore interface {
+	Put(ctx context.Context, key, contentType string, body []byte) error
+	// Delete removes the file, a key with no file is not an error
+	Delete(ctx context.Context, key string) error
+	URL(key string) string
+}
+
+// Store returns the store set by MEDIA_STORE, files go to the local MEDIA_LOCAL_DIR when it is unset
+func Store() (BlobStore, error) {
+	switch os.Getenv("MEDIA_STORE") {
+	case "", StoreLocal:
+		return NewLocalStore(localDir(), baseURL(defaultLocalBaseURL)), nil
+	case StoreS3:
+		return NewS3Store(S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			BaseURL:   baseURL(""),
+		})
+	}
+	return nil, ErrUnknownStore
+}
+
+func localDir() string {
+	if dir := os.Getenv("MEDIA_LOCAL_DIR"); dir != "" {
+		return dir
+	}
+	return defaultLocalDir
+}
+
+// baseURL is where clients fetch the files from, a CDN in front of the store for instance
+func baseURL(fallback string) string {
+	if url := os.Getenv("MEDIA_BASE_URL"); url != "" {
+		return strings.TrimSuffix(url, "/")
+	}
+	return fallback
+}
+
+// validKey refuses keys that could leave the store's root or name a directory
+func validKey(key string) bool {
+	if key == "" || strings.HasPrefix(key, "/") || strings.HasSuffix(key, "/") || strings.Contains(key, "\\") {
+		return false
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return false
+		}
+	}
+	return true
+}
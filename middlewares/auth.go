@@ -17,18 +17,39 @@ const (
 	userContext ContextKeys = "__userContext"
 )
 
+// requestToken reads the bearer token, the access_token query param is only looked at when allowQuery is set
+func requestToken(r *http.Request, allowQuery bool) string {
+	if parts := strings.Split(r.Header.Get("authorization"), " "); len(parts) == 2 {
+		return parts[1]
+	}
+	if allowQuery {
+		return r.URL.Query().Get("access_token")
+	}
+	return ""
+}
+
 func AuthMiddleware(next http.Handler) http.Handler {
+	return authenticate(next, false)
+}
+
+// StreamAuthMiddleware also takes the token from the access_token query param, it is only for the event streams
+// since EventSource can't set headers and tokens should stay out of every other URL
+func StreamAuthMiddleware(next http.Handler) http.Handler {
+	return authenticate(next, true)
+}
+
+func authenticate(next http.Handler, allowQuery bool) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		token := strings.Split(r.Header.Get("authorization"), " ")[1]
+		token := requestToken(r, allowQuery)
 		jwtErr := utils.ParseJwtToken(token)
 		if jwtErr != nil {
-			logrus.WithError(jwtErr).Errorf("Failed to get user with token: %s", token)
+			logrus.WithError(jwtErr).Errorf("Failed to get user with token.")
 			utils.RespondError(w, http.StatusUnauthorized, jwtErr, "Invalid Token")
 			return
 		}
 		user, err := dbHelper.GetUserBySession(token)
 		if err != nil || user == nil {
-			logrus.WithError(err).Errorf("Failed to get user with token: %s", token)
+			logrus.WithError(err).Errorf("Failed to get user with token.")
 			utils.RespondError(w, http.StatusUnauthorized, err, "Failed to get user with token.")
 			return
 		}
@@ -49,7 +70,7 @@ func ShouldHaveRole(role models.Role) func(http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			user := UserContext(r)
 			if user == nil {
-				logrus.Errorf("Failed to get user: %s", user)
+				logrus.Errorf("Failed to get user: %v", user)
 				w.WriteHeader(http.StatusForbidden)
 				return
 			}
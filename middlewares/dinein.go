@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"rms/database"
+	"rms/database/dbHelper"
+	"rms/models"
+	"rms/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	dineInContext ContextKeys = "__dineInContext"
+)
+
+// DineInMiddleware authenticates guests with the token of an open table session, no account is needed
+func DineInMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token := requestToken(r, false)
+		session, err := dbHelper.GetOpenDineInSessionByToken(database.RMS, token, false)
+		if err != nil || session == nil {
+			logrus.WithError(err).Errorf("Failed to get dine in session with token")
+			utils.RespondError(w, http.StatusUnauthorized, err, "Table session is closed or invalid.")
+			return
+		}
+		ctx := context.WithValue(r.Context(), dineInContext, session)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func DineInContext(r *http.Request) *models.DineInSession {
+	if session, ok := r.Context().Value(dineInContext).(*models.DineInSession); ok && session != nil {
+		return session
+	}
+	return nil
+}
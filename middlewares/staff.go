@@ -0,0 +1,38 @@
+package middlewares
+
+import (
+	"net/http"
+	"rms/database/dbHelper"
+	"rms/models"
+	"rms/utils"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/sirupsen/logrus"
+)
+
+// ShouldBeRestaurantStaff lets through the users that work in the role at the restaurant in the URL
+func ShouldBeRestaurantStaff(role models.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := UserContext(r)
+			restaurantID := chi.URLParam(r, "restaurantId")
+			if user == nil || user.CurrentRole != role {
+				logrus.Errorf("Failed to invalid UserRole, accepted: %s", role)
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			isStaff, err := dbHelper.IsRestaurantStaff(restaurantID, user.ID, role)
+			if err != nil {
+				logrus.Errorf("Failed to check restaurant staff: %s", err)
+				utils.RespondError(w, http.StatusInternalServerError, err, "Failed to check restaurant staff")
+				return
+			}
+			if !isStaff {
+				logrus.Errorf("User %s is not %s staff of restaurant %s", user.ID, role, restaurantID)
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
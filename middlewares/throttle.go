@@ -0,0 +1,57 @@
+package middlewares
+
+import (
+	"errors"
+	"net/http"
+	"rms/utils"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+type ipWindow struct {
+	startedAt time.Time
+	hits      int
+}
+
+// ThrottleByIP allows at most limit requests per IP in every window and rejects the rest with 429. Windows that ran
+// out are swept once per window rather than on every request
+func ThrottleByIP(limit int, window time.Duration) func(http.Handler) http.Handler {
+	var mu sync.Mutex
+	windows := make(map[string]*ipWindow)
+	go func() {
+		ticker := time.NewTicker(window)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			mu.Lock()
+			for key, ipWin := range windows {
+				if now.Sub(ipWin.startedAt) > window {
+					delete(windows, key)
+				}
+			}
+			mu.Unlock()
+		}
+	}()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ip := utils.ClientIP(r)
+			now := time.Now()
+			mu.Lock()
+			ipWin, ok := windows[ip]
+			if !ok || now.Sub(ipWin.startedAt) > window {
+				ipWin = &ipWindow{startedAt: now}
+				windows[ip] = ipWin
+			}
+			ipWin.hits++
+			hits := ipWin.hits
+			mu.Unlock()
+			if hits > limit {
+				logrus.Errorf("Too many requests from IP: %s", ip)
+				utils.RespondError(w, http.StatusTooManyRequests, errors.New("rate limit exceeded"), "Too many attempts, please try again later.")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+type OrderAdjustmentKind string
+
+const (
+	AdjustmentItem   OrderAdjustmentKind = "item"
+	AdjustmentCoupon OrderAdjustmentKind = "coupon"
+	AdjustmentPoints OrderAdjustmentKind = "points"
+	AdjustmentRefund OrderAdjustmentKind = "refund"
+	// AdjustmentTax, AdjustmentFee and AdjustmentRounding record how repricing what is left moved those amounts
+	AdjustmentTax      OrderAdjustmentKind = "tax"
+	AdjustmentFee      OrderAdjustmentKind = "fee"
+	AdjustmentRounding OrderAdjustmentKind = "rounding"
+)
+
+// OrderAdjustment is one ledger line of a change to an accepted order, Amount is what the line added to the order
+// total except for refund lines where it is the money given back
+type OrderAdjustment struct {
+	ID           string              `json:"id" db:"id"`
+	AdjustmentID string              `json:"adjustmentId" db:"adjustment_id"`
+	OrderID      string              `json:"orderId" db:"order_id"`
+	Kind         OrderAdjustmentKind `json:"kind" db:"kind"`
+	OrderItemID  *string             `json:"orderItemId" db:"order_item_id"`
+	Quantity     int64               `json:"quantity" db:"quantity"`
+	Amount       int64               `json:"amount" db:"amount"`
+	Reason       string              `json:"reason" db:"reason"`
+	CreatedBy    string              `json:"createdBy" db:"created_by"`
+	CreatedAt    time.Time           `json:"createdAt" db:"created_at"`
+}
+
+// AdjustOrderItem takes Quantity of the dish off the order
+type AdjustOrderItem struct {
+	DishID   string `json:"dishId"`
+	Quantity int64  `json:"quantity"`
+}
+
+type AdjustOrderBody struct {
+	Items  []AdjustOrderItem `json:"items"`
+	Reason string            `json:"reason"`
+}
+
+type AdjustOrder struct {
+	Message string `json:"message"`
+	Order   Order  `json:"order"`
+	Refund  int64  `json:"refund"`
+}
+
+type GetOrderAdjustments struct {
+	Message     string            `json:"message"`
+	Adjustments []OrderAdjustment `json:"adjustments"`
+}
@@ -0,0 +1,81 @@
+package models
+
+import "time"
+
+type DiscountType string
+
+const (
+	DiscountPercentage DiscountType = "percentage"
+	DiscountFlat       DiscountType = "flat"
+)
+
+func (dt DiscountType) IsValid() bool {
+	return dt == DiscountPercentage || dt == DiscountFlat
+}
+
+type Coupon struct {
+	ID             string       `json:"id" db:"id"`
+	Code           string       `json:"code" db:"code"`
+	RestaurantID   *string      `json:"restaurantId" db:"restaurant_id"`
+	DiscountType   DiscountType `json:"discountType" db:"discount_type"`
+	Value          int64        `json:"value" db:"value"`
+	MinOrderValue  int64        `json:"minOrderValue" db:"min_order_value"`
+	MaxDiscount    *int64       `json:"maxDiscount" db:"max_discount"`
+	StartsAt       time.Time    `json:"startsAt" db:"starts_at"`
+	EndsAt         *time.Time   `json:"endsAt" db:"ends_at"`
+	UsageLimit     *int64       `json:"usageLimit" db:"usage_limit"`
+	PerUserLimit   *int64       `json:"perUserLimit" db:"per_user_limit"`
+	UsedCount      int64        `json:"usedCount" db:"used_count"`
+	FirstOrderOnly bool         `json:"firstOrderOnly" db:"first_order_only"`
+	CreatedBy      string       `json:"createdBy" db:"created_by"`
+	CreatedAt      time.Time    `json:"createdAt" db:"created_at"`
+}
+
+// Discount returns how much the coupon takes off the given amount, capped by max discount and the amount itself
+func (c *Coupon) Discount(amount int64) int64 {
+	discount := c.Value
+	if c.DiscountType == DiscountPercentage {
+		discount = amount * c.Value / 100
+	}
+	if c.MaxDiscount != nil && discount > *c.MaxDiscount {
+		discount = *c.MaxDiscount
+	}
+	if discount > amount {
+		discount = amount
+	}
+	return discount
+}
+
+type AddCouponBody struct {
+	Code           string       `json:"code"`
+	DiscountType   DiscountType `json:"discountType"`
+	Value          int64        `json:"value"`
+	MinOrderValue  int64        `json:"minOrderValue"`
+	MaxDiscount    *int64       `json:"maxDiscount"`
+	StartsAt       *time.Time   `json:"startsAt"`
+	EndsAt         *time.Time   `json:"endsAt"`
+	UsageLimit     *int64       `json:"usageLimit"`
+	PerUserLimit   *int64       `json:"perUserLimit"`
+	FirstOrderOnly bool         `json:"firstOrderOnly"`
+}
+
+type ApplyCouponBody struct {
+	RestaurantID string     `json:"restaurantId"`
+	Items        []CartItem `json:"items"`
+	CouponCode   string     `json:"couponCode"`
+	// ScheduledFor prices the cart against the menus served at that time instead of now
+	ScheduledFor *time.Time `json:"scheduledFor"`
+}
+
+type ApplyCoupon struct {
+	Message   string         `json:"message"`
+	Breakdown PriceBreakdown `json:"breakdown"`
+}
+
+type GetCoupons struct {
+	Message    string   `json:"message"`
+	Coupons    []Coupon `json:"coupons"`
+	TotalCount int64    `json:"totalCount"`
+	PageNumber int64    `json:"pageNumber"`
+	PageSize   int64    `json:"pageSize"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+const (
+	FeaturePureVeg  = "pure-veg"
+	FeatureTakeaway = "takeaway"
+	FeatureDineIn   = "dine-in"
+	FeatureDelivery = "delivery"
+)
+
+// RestaurantFeatures is the vocabulary of restaurant features
+var RestaurantFeatures = []string{FeaturePureVeg, FeatureTakeaway, FeatureDineIn, FeatureDelivery}
+
+const (
+	MinPriceRange = 1
+	MaxPriceRange = 4
+)
+
+func IsRestaurantFeature(feature string) bool {
+	return inVocabulary(RestaurantFeatures, feature)
+}
+
+type Cuisine struct {
+	Slug      string    `json:"slug" db:"slug"`
+	Name      string    `json:"name" db:"name"`
+	CreatedAt time.Time `json:"createdAt" db:"created_at"`
+}
+
+type CuisineBody struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+type GetCuisines struct {
+	Message  string    `json:"message"`
+	Cuisines []Cuisine `json:"cuisines"`
+}
+
+// RestaurantAttributesBody replaces the discovery attributes of a restaurant, CostForTwo is in the currency of the
+// restaurant and a nil PriceRange or CostForTwo clears it
+type RestaurantAttributesBody struct {
+	Cuisines   []string `json:"cuisines"`
+	PriceRange *int64   `json:"priceRange"`
+	CostForTwo *Money   `json:"costForTwo"`
+	Features   []string `json:"features"`
+}
+
+// Facet is how many restaurants of a listing have a value, counted with every filter but the facet's own so the
+// other values stay on offer
+type Facet struct {
+	Value string `json:"value" db:"value"`
+	Name  string `json:"name" db:"name"`
+	Count int64  `json:"count" db:"count"`
+}
+
+type RestaurantFacets struct {
+	Cuisines    []Facet `json:"cuisines"`
+	Features    []Facet `json:"features"`
+	PriceRanges []Facet `json:"priceRanges"`
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+const (
+	TagVeg        = "veg"
+	TagEgg        = "egg"
+	TagNonVeg     = "non-veg"
+	TagVegan      = "vegan"
+	TagGlutenFree = "gluten-free"
+	TagDairyFree  = "dairy-free"
+	TagJain       = "jain"
+	TagHalal      = "halal"
+)
+
+// DietaryTags is the vocabulary of dish tags, a dish is at most one of veg, egg and non-veg
+var DietaryTags = []string{TagVeg, TagEgg, TagNonVeg, TagVegan, TagGlutenFree, TagDairyFree, TagJain, TagHalal}
+
+// Allergens is the vocabulary of allergen warnings, none of them is also a tag so a filter can take either
+var Allergens = []string{"peanuts", "tree-nuts", "milk", "eggs", "gluten", "soy", "fish", "shellfish", "molluscs",
+	"sesame", "mustard", "celery", "lupin", "sulphites"}
+
+// SpiceLevels names the spice levels of a dish from MinSpiceLevel up
+var SpiceLevels = []string{"not spicy", "mild", "medium", "hot", "extra hot"}
+
+const (
+	MinSpiceLevel = 0
+	MaxSpiceLevel = 4
+)
+
+func IsDietaryTag(tag string) bool {
+	return inVocabulary(DietaryTags, tag)
+}
+
+func IsAllergen(allergen string) bool {
+	return inVocabulary(Allergens, allergen)
+}
+
+func inVocabulary(vocabulary []string, value string) bool {
+	for _, known := range vocabulary {
+		if known == value {
+			return true
+		}
+	}
+	return false
+}
+
+// DishNutrition is what a serving of the dish holds, any of it may be left out
+type DishNutrition struct {
+	Calories     *int64   `json:"calories"`
+	ProteinGrams *float64 `json:"proteinGrams"`
+	CarbsGrams   *float64 `json:"carbsGrams"`
+	FatGrams     *float64 `json:"fatGrams"`
+}
+
+func (n DishNutrition) Value() (driver.Value, error) {
+	return json.Marshal(n)
+}
+
+func (n *DishNutrition) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case []byte:
+		return json.Unmarshal(value, n)
+	case string:
+		return json.Unmarshal([]byte(value), n)
+	}
+	return errors.New("unsupported nutrition value")
+}
+
+type SpiceLevel struct {
+	Level int64  `json:"level"`
+	Name  string `json:"name"`
+}
+
+type GetDishVocabulary struct {
+	Message     string       `json:"message"`
+	Tags        []string     `json:"tags"`
+	Allergens   []string     `json:"allergens"`
+	SpiceLevels []SpiceLevel `json:"spiceLevels"`
+}
@@ -0,0 +1,90 @@
+package models
+
+import "time"
+
+type RestaurantArea struct {
+	ID           string    `json:"id" db:"id"`
+	RestaurantID string    `json:"restaurantId" db:"restaurant_id"`
+	Name         string    `json:"name" db:"name"`
+	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+}
+
+type RestaurantTable struct {
+	ID           string    `json:"id" db:"id"`
+	RestaurantID string    `json:"restaurantId" db:"restaurant_id"`
+	AreaID       *string   `json:"areaId" db:"area_id"`
+	Name         string    `json:"name" db:"name"`
+	Seats        int64     `json:"seats" db:"seats"`
+	QRNonce      string    `json:"-" db:"qr_nonce"`
+	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+}
+
+type DineInSession struct {
+	ID           string     `json:"id" db:"id"`
+	RestaurantID string     `json:"restaurantId" db:"restaurant_id"`
+	TableID      string     `json:"tableId" db:"table_id"`
+	TableName    string     `json:"tableName" db:"table_name"`
+	Token        string     `json:"-" db:"token"`
+	OpenedAt     time.Time  `json:"openedAt" db:"opened_at"`
+	ClosedAt     *time.Time `json:"closedAt" db:"closed_at"`
+}
+
+// DineInBill accumulates the orders of a table session
+type DineInBill struct {
+	Session  DineInSession `json:"session"`
+	Orders   []Order       `json:"orders"`
+	SubTotal int64         `json:"subTotal"`
+	Discount int64         `json:"discount"`
+	Tax      int64         `json:"tax"`
+	Total    int64         `json:"total"`
+}
+
+type AddAreaBody struct {
+	Name string `json:"name"`
+}
+
+type AddTableBody struct {
+	AreaID *string `json:"areaId"`
+	Name   string  `json:"name"`
+	Seats  int64   `json:"seats"`
+}
+
+type OpenDineInBody struct {
+	QRToken string `json:"qrToken"`
+}
+
+type DineInOrderBody struct {
+	Items []CartItem `json:"items"`
+}
+
+type GetAreas struct {
+	Message string           `json:"message"`
+	Areas   []RestaurantArea `json:"areas"`
+}
+
+type GetTables struct {
+	Message string            `json:"message"`
+	Tables  []RestaurantTable `json:"tables"`
+}
+
+type TableQR struct {
+	Message string `json:"message"`
+	Token   string `json:"token"`
+	URL     string `json:"url"`
+}
+
+type OpenDineIn struct {
+	Message      string        `json:"message"`
+	SessionToken string        `json:"sessionToken"`
+	Session      DineInSession `json:"session"`
+}
+
+type GetDineInBill struct {
+	Message string     `json:"message"`
+	Bill    DineInBill `json:"bill"`
+}
+
+type GetDineInBills struct {
+	Message string       `json:"message"`
+	Bills   []DineInBill `json:"bills"`
+}
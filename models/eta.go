@@ -0,0 +1,34 @@
+package models
+
+import "time"
+
+// DeliveryETA splits the expected wait into the kitchen and the road
+type DeliveryETA struct {
+	PrepMinutes   int64     `json:"prepMinutes"`
+	TravelMinutes int64     `json:"travelMinutes"`
+	TotalMinutes  int64     `json:"totalMinutes"`
+	EstimatedAt   time.Time `json:"estimatedAt"`
+}
+
+// KitchenLoad is how fast a restaurant prepares orders and how many are waiting in its kitchen
+type KitchenLoad struct {
+	RestaurantID   string  `db:"restaurant_id"`
+	AvgPrepSeconds float64 `db:"avg_prep_seconds"`
+	QueueLength    int64   `db:"queue_length"`
+}
+
+// OrderETAInput is what the ETA of an order is computed from, positions are missing when they are not known
+type OrderETAInput struct {
+	Status          OrderStatus `db:"status"`
+	OrderType       OrderType   `db:"order_type"`
+	ScheduledFor    *time.Time  `db:"scheduled_for"`
+	RestaurantLat   float64     `db:"restaurant_lat"`
+	RestaurantLng   float64     `db:"restaurant_lng"`
+	AddressLat      *float64    `db:"address_lat"`
+	AddressLng      *float64    `db:"address_lng"`
+	RiderLat        *float64    `db:"rider_lat"`
+	RiderLng        *float64    `db:"rider_lng"`
+	TicketCreatedAt *time.Time  `db:"ticket_created_at"`
+	AvgPrepSeconds  float64     `db:"avg_prep_seconds"`
+	QueueAhead      int64       `db:"queue_ahead"`
+}
@@ -0,0 +1,7 @@
+package models
+
+type GetFavourites struct {
+	Message     string       `json:"message"`
+	Restaurants []Restaurant `json:"restaurants"`
+	Dishes      []Dishes     `json:"dishes"`
+}
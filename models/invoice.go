@@ -0,0 +1,69 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+type InvoiceFormat string
+
+const (
+	InvoiceJSON InvoiceFormat = "json"
+	InvoiceText InvoiceFormat = "text"
+	InvoiceHTML InvoiceFormat = "html"
+	InvoicePDF  InvoiceFormat = "pdf"
+)
+
+func (f InvoiceFormat) IsValid() bool {
+	return f == InvoiceJSON || f == InvoiceText || f == InvoiceHTML || f == InvoicePDF
+}
+
+// Invoice is the numbered bill of a completed order, Sequence counts up without gaps per restaurant and fiscal year
+type Invoice struct {
+	ID           string         `json:"id" db:"id"`
+	OrderID      string         `json:"orderId" db:"order_id"`
+	RestaurantID string         `json:"restaurantId" db:"restaurant_id"`
+	FiscalYear   int            `json:"fiscalYear" db:"fiscal_year"`
+	Sequence     int64          `json:"sequence" db:"sequence"`
+	Number       string         `json:"number" db:"number"`
+	Details      InvoiceDetails `json:"details" db:"details"`
+	IssuedAt     time.Time      `json:"issuedAt" db:"issued_at"`
+}
+
+// InvoiceDetails is what the invoice says, taken when it is issued so later changes to the restaurant or the
+// customer do not rewrite it
+type InvoiceDetails struct {
+	Restaurant Restaurant       `json:"restaurant"`
+	Customer   *InvoiceCustomer `json:"customer"`
+	OrderType  OrderType        `json:"orderType"`
+	OrderedAt  time.Time        `json:"orderedAt"`
+	Breakdown  PriceBreakdown   `json:"breakdown"`
+}
+
+// InvoiceCustomer is who the invoice is billed to, dine-in guests have none
+type InvoiceCustomer struct {
+	Name    string       `json:"name" db:"name"`
+	Email   string       `json:"email" db:"email"`
+	Address *UserAddress `json:"address" db:"-"`
+}
+
+func (d InvoiceDetails) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
+
+func (d *InvoiceDetails) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case []byte:
+		return json.Unmarshal(value, d)
+	case string:
+		return json.Unmarshal([]byte(value), d)
+	}
+	return errors.New("unsupported invoice details value")
+}
+
+type GetInvoice struct {
+	Message string  `json:"message"`
+	Invoice Invoice `json:"invoice"`
+}
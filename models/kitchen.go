@@ -0,0 +1,63 @@
+package models
+
+import "time"
+
+type KitchenTicket struct {
+	ID           string              `json:"id" db:"id"`
+	OrderID      string              `json:"orderId" db:"order_id"`
+	RestaurantID string              `json:"restaurantId" db:"restaurant_id"`
+	BumpedAt     *time.Time          `json:"bumpedAt" db:"bumped_at"`
+	CreatedAt    time.Time           `json:"createdAt" db:"created_at"`
+	Items        []KitchenTicketItem `json:"items" db:"-"`
+}
+
+type KitchenTicketItem struct {
+	ID          string     `json:"id" db:"id"`
+	TicketID    string     `json:"ticketId" db:"ticket_id"`
+	DishID      string     `json:"dishId" db:"dish_id"`
+	Name        string     `json:"name" db:"name"`
+	Quantity    int64      `json:"quantity" db:"quantity"`
+	Station     string     `json:"station" db:"station"`
+	StartedAt   time.Time  `json:"startedAt" db:"started_at"`
+	BumpedAt    *time.Time `json:"bumpedAt" db:"bumped_at"`
+	RecallCount int64      `json:"recallCount" db:"recall_count"`
+	// ElapsedSeconds is the prep timer, it stops when the item is bumped
+	ElapsedSeconds int64 `json:"elapsedSeconds" db:"elapsed_seconds"`
+}
+
+// KitchenStation is the view of one station, the open items of every ticket it has to prepare
+type KitchenStation struct {
+	Station string              `json:"station"`
+	Items   []KitchenTicketItem `json:"items"`
+}
+
+type DishPrepTime struct {
+	DishID            string  `json:"dishId" db:"dish_id"`
+	Name              string  `json:"name" db:"name"`
+	Station           string  `json:"station" db:"station"`
+	PreparedCount     int64   `json:"preparedCount" db:"prepared_count"`
+	AvgPrepSeconds    float64 `json:"avgPrepSeconds" db:"avg_prep_seconds"`
+	MedianPrepSeconds float64 `json:"medianPrepSeconds" db:"median_prep_seconds"`
+}
+
+type AddStaffBody struct {
+	Name     string `json:"name"`
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type GetKitchenTickets struct {
+	Message  string           `json:"message"`
+	Tickets  []KitchenTicket  `json:"tickets"`
+	Stations []KitchenStation `json:"stations"`
+}
+
+type GetDishPrepTimes struct {
+	Message string         `json:"message"`
+	Dishes  []DishPrepTime `json:"dishes"`
+}
+
+type GetStaff struct {
+	Message string `json:"message"`
+	Staff   []User `json:"staff"`
+}
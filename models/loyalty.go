@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+type LoyaltyEntryKind string
+
+const (
+	LoyaltyEarn   LoyaltyEntryKind = "earn"
+	LoyaltyRedeem LoyaltyEntryKind = "redeem"
+	LoyaltyRefund LoyaltyEntryKind = "refund"
+	LoyaltyExpire LoyaltyEntryKind = "expire"
+	LoyaltyAdjust LoyaltyEntryKind = "adjust"
+)
+
+type LoyaltyEntry struct {
+	ID        string           `json:"id" db:"id"`
+	TxnID     string           `json:"txnId" db:"txn_id"`
+	Kind      LoyaltyEntryKind `json:"kind" db:"kind"`
+	Points    int64            `json:"points" db:"points"`
+	OrderID   *string          `json:"orderId" db:"order_id"`
+	Reason    string           `json:"reason" db:"reason"`
+	ExpiresAt *time.Time       `json:"expiresAt" db:"expires_at"`
+	CreatedAt time.Time        `json:"createdAt" db:"created_at"`
+}
+
+type AdjustLoyaltyBody struct {
+	Points int64  `json:"points"`
+	Reason string `json:"reason"`
+}
+
+type GetLoyalty struct {
+	Message    string         `json:"message"`
+	Balance    int64          `json:"balance"`
+	History    []LoyaltyEntry `json:"history"`
+	TotalCount int64          `json:"totalCount"`
+	PageNumber int64          `json:"pageNumber"`
+	PageSize   int64          `json:"pageSize"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+)
+
+// RestaurantImage names the image slots of a restaurant, each is a column of restaurants
+type RestaurantImage string
+
+const (
+	RestaurantLogo   RestaurantImage = "logo"
+	RestaurantBanner RestaurantImage = "banner"
+)
+
+func (i RestaurantImage) IsValid() bool {
+	return i == RestaurantLogo || i == RestaurantBanner
+}
+
+// Image is an uploaded image in the sizes it is served in, Keys are where its files are stored
+type Image struct {
+	Original  string   `json:"original"`
+	Medium    string   `json:"medium"`
+	Thumbnail string   `json:"thumbnail"`
+	Keys      []string `json:"-"`
+}
+
+// storedImage is an Image as it is saved, with its keys
+type storedImage struct {
+	Original  string   `json:"original"`
+	Medium    string   `json:"medium"`
+	Thumbnail string   `json:"thumbnail"`
+	Keys      []string `json:"keys"`
+}
+
+func (i Image) Value() (driver.Value, error) {
+	return json.Marshal(storedImage(i))
+}
+
+func (i *Image) Scan(src interface{}) error {
+	var stored storedImage
+	var err error
+	switch value := src.(type) {
+	case []byte:
+		err = json.Unmarshal(value, &stored)
+	case string:
+		err = json.Unmarshal([]byte(value), &stored)
+	default:
+		return errors.New("unsupported image value")
+	}
+	*i = Image(stored)
+	return err
+}
+
+type GetImage struct {
+	Message string `json:"message"`
+	Image   *Image `json:"image"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+// MenuHours is a serving window of a menu, StartsAt and EndsAt are HH:MM in the restaurant's timezone and EndsAt may
+// be 24:00 for a window running to midnight
+type MenuHours struct {
+	DayOfWeek int    `json:"dayOfWeek" db:"day_of_week"`
+	StartsAt  string `json:"startsAt" db:"starts_at"`
+	EndsAt    string `json:"endsAt" db:"ends_at"`
+}
+
+type Menu struct {
+	ID           string      `json:"id" db:"id"`
+	RestaurantID string      `json:"restaurantId" db:"restaurant_id"`
+	Name         string      `json:"name" db:"name"`
+	Hours        []MenuHours `json:"hours" db:"-"`
+	DishIDs      []string    `json:"dishIds" db:"-"`
+	CreatedAt    time.Time   `json:"createdAt" db:"created_at"`
+}
+
+// MenuBody creates or replaces a menu, a dish may be on several menus and is served during any of their windows
+type MenuBody struct {
+	Name    string      `json:"name"`
+	Hours   []MenuHours `json:"hours"`
+	DishIDs []string    `json:"dishIds"`
+}
+
+type GetMenus struct {
+	Message string `json:"message"`
+	Menus   []Menu `json:"menus"`
+}
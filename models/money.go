@@ -0,0 +1,283 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the currency of restaurants opened without one
+const DefaultCurrency = "INR"
+
+var (
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+	ErrInvalidCurrency  = errors.New("invalid currency")
+	ErrMoneyOverflow    = errors.New("money overflow")
+	ErrInvalidMoney     = errors.New("invalid money")
+)
+
+// currencyExponents is how many minor units digits each supported currency has
+var currencyExponents = map[string]int{
+	"AED": 2,
+	"AUD": 2,
+	"BHD": 3,
+	"CAD": 2,
+	"EUR": 2,
+	"GBP": 2,
+	"INR": 2,
+	"JPY": 0,
+	"KWD": 3,
+	"OMR": 3,
+	"SGD": 2,
+	"USD": 2,
+}
+
+// IsValidCurrency tells whether the ISO 4217 code is supported
+func IsValidCurrency(currency string) bool {
+	_, ok := currencyExponents[currency]
+	return ok
+}
+
+// CurrencyExponent returns the number of minor unit digits of a supported currency
+func CurrencyExponent(currency string) (int, error) {
+	exponent, ok := currencyExponents[currency]
+	if !ok {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidCurrency, currency)
+	}
+	return exponent, nil
+}
+
+type RoundingMode int
+
+const (
+	// RoundHalfUp rounds halves away from zero, it is the default
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven rounds halves to the even neighbour
+	RoundHalfEven
+	// RoundDown truncates towards zero
+	RoundDown
+	// RoundUp rounds away from zero
+	RoundUp
+)
+
+// Money is an amount in the minor units of its currency, 12.50 INR is Money{Amount: 1250, Currency: "INR"}. The
+// currency may be left empty by clients, it then means the currency of the restaurant the amount belongs to.
+type Money struct {
+	Amount   int64  `json:"amount"`
+	Currency string `json:"currency"`
+}
+
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: currency}
+}
+
+// ParseMoney reads a decimal amount in major units like "12.50", more decimals than the currency has are an error
+func ParseMoney(value, currency string) (Money, error) {
+	exponent, err := CurrencyExponent(currency)
+	if err != nil {
+		return Money{}, err
+	}
+	value = strings.TrimSpace(value)
+	negative := strings.HasPrefix(value, "-")
+	digits := strings.TrimPrefix(value, "-")
+	whole, fraction := digits, ""
+	if dot := strings.IndexByte(digits, '.'); dot >= 0 {
+		whole, fraction = digits[:dot], digits[dot+1:]
+	}
+	if whole == "" || len(fraction) > exponent || strings.ContainsAny(whole+fraction, "+-") {
+		return Money{}, fmt.Errorf("%w: %q in %s", ErrInvalidMoney, value, currency)
+	}
+	fraction += strings.Repeat("0", exponent-len(fraction))
+	amount, err := strconv.ParseInt(whole+fraction, 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("%w: %q in %s", ErrInvalidMoney, value, currency)
+	}
+	if negative {
+		amount = -amount
+	}
+	return Money{Amount: amount, Currency: currency}, nil
+}
+
+// In fills in the currency of an amount sent without one, an amount in another currency is an error
+func (m Money) In(currency string) (Money, error) {
+	if !IsValidCurrency(currency) {
+		return Money{}, fmt.Errorf("%w: %q", ErrInvalidCurrency, currency)
+	}
+	if m.Currency == "" {
+		m.Currency = currency
+	}
+	if m.Currency != currency {
+		return Money{}, fmt.Errorf("%w: %s amount for a %s restaurant", ErrCurrencyMismatch, m.Currency, currency)
+	}
+	return m, nil
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+func (m Money) sameCurrency(other Money) error {
+	if m.Currency != other.Currency {
+		return fmt.Errorf("%w: %s and %s", ErrCurrencyMismatch, m.Currency, other.Currency)
+	}
+	return nil
+}
+
+func (m Money) Add(other Money) (Money, error) {
+	if err := m.sameCurrency(other); err != nil {
+		return Money{}, err
+	}
+	sum := m.Amount + other.Amount
+	if (other.Amount > 0 && sum < m.Amount) || (other.Amount < 0 && sum > m.Amount) {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{Amount: sum, Currency: m.Currency}, nil
+}
+
+func (m Money) Sub(other Money) (Money, error) {
+	if other.Amount == math.MinInt64 {
+		return Money{}, ErrMoneyOverflow
+	}
+	return m.Add(Money{Amount: -other.Amount, Currency: other.Currency})
+}
+
+// Mul multiplies the amount by a whole number, like a price by a quantity
+func (m Money) Mul(factor int64) (Money, error) {
+	if m.Amount == 0 || factor == 0 {
+		return Money{Currency: m.Currency}, nil
+	}
+	product := m.Amount * factor
+	if product/factor != m.Amount || (m.Amount == -1 && factor == math.MinInt64) || (factor == -1 && m.Amount == math.MinInt64) {
+		return Money{}, ErrMoneyOverflow
+	}
+	return Money{Amount: product, Currency: m.Currency}, nil
+}
+
+// Scale multiplies the amount by numerator/denominator and rounds the result to a minor unit, a 5% share is
+// Scale(5, 100, mode)
+func (m Money) Scale(numerator, denominator int64, mode RoundingMode) (Money, error) {
+	if denominator == 0 {
+		return Money{}, fmt.Errorf("%w: division by zero", ErrInvalidMoney)
+	}
+	if denominator < 0 {
+		if numerator == math.MinInt64 || denominator == math.MinInt64 {
+			return Money{}, ErrMoneyOverflow
+		}
+		numerator, denominator = -numerator, -denominator
+	}
+	product, err := m.Mul(numerator)
+	if err != nil {
+		return Money{}, err
+	}
+	return Money{Amount: divide(product.Amount, denominator, mode), Currency: m.Currency}, nil
+}
+
+// divide divides by a positive denominator rounding with mode
+func divide(numerator, denominator int64, mode RoundingMode) int64 {
+	quotient, remainder := numerator/denominator, numerator%denominator
+	if remainder == 0 {
+		return quotient
+	}
+	sign := int64(1)
+	if numerator < 0 {
+		sign, remainder = -1, -remainder
+	}
+	var awayFromZero bool
+	switch mode {
+	case RoundDown:
+		awayFromZero = false
+	case RoundUp:
+		awayFromZero = true
+	case RoundHalfEven:
+		twice := remainder * 2
+		awayFromZero = twice > denominator || (twice == denominator && quotient%2 != 0)
+	default:
+		awayFromZero = remainder*2 >= denominator
+	}
+	if awayFromZero {
+		quotient += sign
+	}
+	return quotient
+}
+
+// Round rounds the amount to a multiple of unit minor units, like 100 to charge whole rupees
+func (m Money) Round(unit int64, mode RoundingMode) (Money, error) {
+	if unit <= 1 {
+		return m, nil
+	}
+	units, err := m.Scale(1, unit, mode)
+	if err != nil {
+		return Money{}, err
+	}
+	return units.Mul(unit)
+}
+
+// Major formats the amount in major units with all the decimals of the currency, 1250 INR is "12.50"
+func (m Money) Major() string {
+	exponent, err := CurrencyExponent(m.Currency)
+	if err != nil || exponent == 0 {
+		return strconv.FormatInt(m.Amount, 10)
+	}
+	digits := strconv.FormatInt(m.Amount, 10)
+	sign := ""
+	if strings.HasPrefix(digits, "-") {
+		sign, digits = "-", digits[1:]
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+func (m Money) String() string {
+	return m.Major() + " " + m.Currency
+}
+
+// MarshalJSON writes the amount with its currency and the formatted major units for display
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   int64  `json:"amount"`
+		Currency string `json:"currency"`
+		Display  string `json:"display"`
+	}{m.Amount, m.Currency, m.Major()})
+}
+
+// UnmarshalJSON reads {"amount": 1250, "currency": "INR"} and, for clients that predate the type, a bare number of
+// minor units without a currency
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+	if len(data) > 0 && data[0] != '{' {
+		amount, err := strconv.ParseInt(string(data), 10, 64)
+		if err != nil {
+			return fmt.Errorf("%w: %s is not a whole number of minor units", ErrInvalidMoney, data)
+		}
+		*m = Money{Amount: amount}
+		return nil
+	}
+	var money struct {
+		Amount   *int64 `json:"amount"`
+		Currency string `json:"currency"`
+	}
+	if err := json.Unmarshal(data, &money); err != nil {
+		return err
+	}
+	if money.Amount == nil {
+		return fmt.Errorf("%w: amount is required", ErrInvalidMoney)
+	}
+	if money.Currency != "" && !IsValidCurrency(money.Currency) {
+		return fmt.Errorf("%w: %q", ErrInvalidCurrency, money.Currency)
+	}
+	*m = Money{Amount: *money.Amount, Currency: money.Currency}
+	return nil
+}
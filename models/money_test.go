@@ -0,0 +1,206 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestMoneyArithmetic(t *testing.T) {
+	inr := func(amount int64) Money { return NewMoney(amount, "INR") }
+	tests := []struct {
+		name    string
+		do      func() (Money, error)
+		want    Money
+		wantErr error
+	}{
+		{name: "add", do: func() (Money, error) { return inr(1250).Add(inr(50)) }, want: inr(1300)},
+		{name: "add negative", do: func() (Money, error) { return inr(100).Add(inr(-250)) }, want: inr(-150)},
+		{name: "add other currency", do: func() (Money, error) { return inr(100).Add(NewMoney(100, "USD")) }, wantErr: ErrCurrencyMismatch},
+		{name: "add overflow", do: func() (Money, error) { return inr(math.MaxInt64).Add(inr(1)) }, wantErr: ErrMoneyOverflow},
+		{name: "add negative overflow", do: func() (Money, error) { return inr(math.MinInt64).Add(inr(-1)) }, wantErr: ErrMoneyOverflow},
+		{name: "sub", do: func() (Money, error) { return inr(100).Sub(inr(250)) }, want: inr(-150)},
+		{name: "sub overflow", do: func() (Money, error) { return inr(0).Sub(inr(math.MinInt64)) }, wantErr: ErrMoneyOverflow},
+		{name: "mul", do: func() (Money, error) { return inr(-250).Mul(3) }, want: inr(-750)},
+		{name: "mul by zero", do: func() (Money, error) { return inr(math.MaxInt64).Mul(0) }, want: inr(0)},
+		{name: "mul overflow", do: func() (Money, error) { return inr(math.MaxInt64 / 2).Mul(3) }, wantErr: ErrMoneyOverflow},
+		{name: "mul min by minus one", do: func() (Money, error) { return inr(math.MinInt64).Mul(-1) }, wantErr: ErrMoneyOverflow},
+		{name: "scale by zero", do: func() (Money, error) { return inr(100).Scale(1, 0, RoundHalfUp) }, wantErr: ErrInvalidMoney},
+		{name: "scale overflow", do: func() (Money, error) { return inr(math.MaxInt64/100).Scale(500, 10000, RoundHalfUp) }, wantErr: ErrMoneyOverflow},
+		{name: "scale negative denominator", do: func() (Money, error) { return inr(100).Scale(1, -4, RoundHalfUp) }, want: inr(-25)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.do()
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyScale(t *testing.T) {
+	tests := []struct {
+		name                   string
+		amount                 int64
+		numerator, denominator int64
+		mode                   RoundingMode
+		want                   int64
+	}{
+		{name: "exact", amount: 1000, numerator: 5, denominator: 100, mode: RoundHalfUp, want: 50},
+		{name: "half up rounds a half away from zero", amount: 25, numerator: 1, denominator: 10, mode: RoundHalfUp, want: 3},
+		{name: "half up rounds below a half down", amount: 24, numerator: 1, denominator: 10, mode: RoundHalfUp, want: 2},
+		{name: "half up on a negative half", amount: -25, numerator: 1, denominator: 10, mode: RoundHalfUp, want: -3},
+		{name: "half even rounds a half to even down", amount: 25, numerator: 1, denominator: 10, mode: RoundHalfEven, want: 2},
+		{name: "half even rounds a half to even up", amount: 35, numerator: 1, denominator: 10, mode: RoundHalfEven, want: 4},
+		{name: "half even above a half", amount: 26, numerator: 1, denominator: 10, mode: RoundHalfEven, want: 3},
+		{name: "half even on a negative half", amount: -25, numerator: 1, denominator: 10, mode: RoundHalfEven, want: -2},
+		{name: "down truncates", amount: 29, numerator: 1, denominator: 10, mode: RoundDown, want: 2},
+		{name: "down truncates a negative towards zero", amount: -29, numerator: 1, denominator: 10, mode: RoundDown, want: -2},
+		{name: "up", amount: 21, numerator: 1, denominator: 10, mode: RoundUp, want: 3},
+		{name: "up on a negative goes away from zero", amount: -21, numerator: 1, denominator: 10, mode: RoundUp, want: -3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewMoney(tt.amount, "INR").Scale(tt.numerator, tt.denominator, tt.mode)
+			if err != nil || got.Amount != tt.want {
+				t.Errorf("Scale(%d, %d) of %d = %d, %v, want %d", tt.numerator, tt.denominator, tt.amount, got.Amount, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyRound(t *testing.T) {
+	tests := []struct {
+		amount, unit int64
+		mode         RoundingMode
+		want         int64
+	}{
+		{amount: 1049, unit: 100, mode: RoundHalfUp, want: 1000},
+		{amount: 1050, unit: 100, mode: RoundHalfUp, want: 1100},
+		{amount: 1150, unit: 100, mode: RoundHalfEven, want: 1200},
+		{amount: 1250, unit: 100, mode: RoundHalfEven, want: 1200},
+		{amount: -1050, unit: 100, mode: RoundHalfUp, want: -1100},
+		{amount: 1049, unit: 0, mode: RoundHalfUp, want: 1049},
+		{amount: 1049, unit: 1, mode: RoundHalfUp, want: 1049},
+		{amount: 0, unit: 100, mode: RoundHalfUp, want: 0},
+		{amount: 12, unit: 5, mode: RoundHalfUp, want: 10},
+		{amount: 13, unit: 5, mode: RoundHalfUp, want: 15},
+	}
+	for _, tt := range tests {
+		got, err := NewMoney(tt.amount, "INR").Round(tt.unit, tt.mode)
+		if err != nil || got.Amount != tt.want {
+			t.Errorf("Round(%d) of %d = %d, %v, want %d", tt.unit, tt.amount, got.Amount, err, tt.want)
+		}
+	}
+}
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		value    string
+		currency string
+		want     int64
+		wantErr  error
+	}{
+		{value: "12.50", currency: "INR", want: 1250},
+		{value: "12.5", currency: "INR", want: 1250},
+		{value: "12", currency: "INR", want: 1200},
+		{value: " 0.05 ", currency: "INR", want: 5},
+		{value: "-3.25", currency: "INR", want: -325},
+		{value: "1500", currency: "JPY", want: 1500},
+		{value: "1.234", currency: "KWD", want: 1234},
+		{value: "12.505", currency: "INR", wantErr: ErrInvalidMoney},
+		{value: "1.5", currency: "JPY", wantErr: ErrInvalidMoney},
+		{value: ".50", currency: "INR", wantErr: ErrInvalidMoney},
+		{value: "--1", currency: "INR", wantErr: ErrInvalidMoney},
+		{value: "1.-5", currency: "INR", wantErr: ErrInvalidMoney},
+		{value: "abc", currency: "INR", wantErr: ErrInvalidMoney},
+		{value: "99999999999999999999", currency: "INR", wantErr: ErrInvalidMoney},
+		{value: "12.50", currency: "XYZ", wantErr: ErrInvalidCurrency},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.value, tt.currency)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("ParseMoney(%q, %s) error = %v, want %v", tt.value, tt.currency, err, tt.wantErr)
+			continue
+		}
+		if err == nil && got != NewMoney(tt.want, tt.currency) {
+			t.Errorf("ParseMoney(%q, %s) = %+v, want %d", tt.value, tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyMajor(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{money: NewMoney(1250, "INR"), want: "12.50"},
+		{money: NewMoney(5, "INR"), want: "0.05"},
+		{money: NewMoney(-5, "INR"), want: "-0.05"},
+		{money: NewMoney(-1250, "INR"), want: "-12.50"},
+		{money: NewMoney(1500, "JPY"), want: "1500"},
+		{money: NewMoney(1234, "KWD"), want: "1.234"},
+		{money: NewMoney(1250, ""), want: "1250"},
+	}
+	for _, tt := range tests {
+		if got := tt.money.Major(); got != tt.want {
+			t.Errorf("%+v.Major() = %q, want %q", tt.money, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    Money
+		wantErr error
+	}{
+		{name: "object", data: `{"amount": 1250, "currency": "INR"}`, want: NewMoney(1250, "INR")},
+		{name: "object without currency", data: `{"amount": -325}`, want: NewMoney(-325, "")},
+		{name: "display is ignored", data: `{"amount": 1250, "currency": "INR", "display": "99.00"}`, want: NewMoney(1250, "INR")},
+		{name: "legacy bare number", data: `1250`, want: NewMoney(1250, "")},
+		{name: "legacy negative number", data: ` -40 `, want: NewMoney(-40, "")},
+		{name: "legacy decimal", data: `12.50`, wantErr: ErrInvalidMoney},
+		{name: "missing amount", data: `{"currency": "INR"}`, wantErr: ErrInvalidMoney},
+		{name: "unknown currency", data: `{"amount": 1, "currency": "XYZ"}`, wantErr: ErrInvalidCurrency},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Money
+			err := json.Unmarshal([]byte(tt.data), &got)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Unmarshal(%s) error = %v, want %v", tt.data, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got != tt.want {
+				t.Fatalf("Unmarshal(%s) = %+v, want %+v", tt.data, got, tt.want)
+			}
+			encoded, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("Marshal(%+v) error = %v", got, err)
+			}
+			var again Money
+			if err := json.Unmarshal(encoded, &again); err != nil || again != got {
+				t.Errorf("round trip of %s = %+v, %v, want %+v", encoded, again, err, got)
+			}
+		})
+	}
+}
+
+func TestMoneyMarshalJSON(t *testing.T) {
+	encoded, err := json.Marshal(NewMoney(-1250, "INR"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"amount":-1250,"currency":"INR","display":"-12.50"}`; string(encoded) != want {
+		t.Errorf("Marshal = %s, want %s", encoded, want)
+	}
+}
@@ -0,0 +1,172 @@
+package models
+
+import "time"
+
+type OrderStatus string
+
+const (
+	OrderScheduled      OrderStatus = "scheduled"
+	OrderPlaced         OrderStatus = "placed"
+	OrderAccepted       OrderStatus = "accepted"
+	OrderPreparing      OrderStatus = "preparing"
+	OrderReady          OrderStatus = "ready"
+	OrderOutForDelivery OrderStatus = "out-for-delivery"
+	OrderDelivered      OrderStatus = "delivered"
+	OrderCancelled      OrderStatus = "cancelled"
+)
+
+// orderTransitions lists the statuses an order may move to from its current status
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderScheduled:      {OrderPlaced, OrderCancelled},
+	OrderPlaced:         {OrderAccepted, OrderCancelled},
+	OrderAccepted:       {OrderPreparing, OrderCancelled},
+	OrderPreparing:      {OrderReady},
+	OrderReady:          {OrderOutForDelivery},
+	OrderOutForDelivery: {OrderDelivered},
+}
+
+func (os OrderStatus) IsValid() bool {
+	return os == OrderScheduled || os == OrderPlaced || os == OrderAccepted || os == OrderPreparing || os == OrderReady ||
+		os == OrderOutForDelivery || os == OrderDelivered || os == OrderCancelled
+}
+
+func (os OrderStatus) CanMoveTo(next OrderStatus) bool {
+	for _, status := range orderTransitions[os] {
+		if status == next {
+			return true
+		}
+	}
+	return false
+}
+
+type OrderType string
+
+const (
+	OrderDelivery OrderType = "delivery"
+	OrderDineIn   OrderType = "dine-in"
+)
+
+// CanMoveTo is the status transition check of the order, dine in orders are served straight from ready
+func (o *Order) CanMoveTo(next OrderStatus) bool {
+	if o.OrderType == OrderDineIn && o.Status == OrderReady {
+		return next == OrderDelivered
+	}
+	return o.Status.CanMoveTo(next)
+}
+
+type Order struct {
+	ID                  string      `json:"id" db:"id"`
+	UserID              string      `json:"userId" db:"user_id"`
+	RestaurantID        string      `json:"restaurantId" db:"restaurant_id"`
+	AddressID           string      `json:"addressId" db:"address_id"`
+	Status              OrderStatus `json:"status" db:"status"`
+	OrderType           OrderType   `json:"orderType" db:"order_type"`
+	DineInSessionID     *string     `json:"dineInSessionId" db:"dine_in_session_id"`
+	RiderID             *string     `json:"riderId" db:"rider_id"`
+	EstimatedDeliveryAt *time.Time  `json:"estimatedDeliveryAt" db:"estimated_delivery_at"`
+	SubTotal            int64       `json:"subTotal" db:"sub_total"`
+	Discount            int64       `json:"discount" db:"discount"`
+	CouponID            *string     `json:"couponId" db:"coupon_id"`
+	CouponDiscount      int64       `json:"couponDiscount" db:"coupon_discount"`
+	PointsRedeemed      int64       `json:"pointsRedeemed" db:"points_redeemed"`
+	PackagingCharge     int64       `json:"packagingCharge" db:"packaging_charge"`
+	DeliveryFee         int64       `json:"deliveryFee" db:"delivery_fee"`
+	Tax                 int64       `json:"tax" db:"tax"`
+	TaxIncluded         int64       `json:"taxIncluded" db:"tax_included"`
+	Rounding            int64       `json:"rounding" db:"rounding"`
+	// Breakdown is the itemised price the order was placed with, orders from before taxes have none
+	Breakdown    *PriceBreakdown `json:"breakdown,omitempty" db:"price_breakdown"`
+	Total        int64           `json:"total" db:"total"`
+	ScheduledFor *time.Time      `json:"scheduledFor" db:"scheduled_for"`
+	ReleaseAt    *time.Time      `json:"releaseAt" db:"release_at"`
+	DeliveredAt  *time.Time      `json:"deliveredAt" db:"delivered_at"`
+	CreatedAt    time.Time       `json:"createdAt" db:"created_at"`
+	UpdatedAt    time.Time       `json:"updatedAt" db:"updated_at"`
+	Items        []OrderItem     `json:"items" db:"-"`
+}
+
+type OrderItem struct {
+	ID       string `json:"id" db:"id"`
+	OrderID  string `json:"-" db:"order_id"`
+	DishID   string `json:"dishId" db:"dish_id"`
+	Name     string `json:"name" db:"name"`
+	Quantity int64  `json:"quantity" db:"quantity"`
+	Price    int64  `json:"price" db:"price"`
+	Discount int64  `json:"discount" db:"discount"`
+	Total    int64  `json:"total" db:"total"`
+}
+
+type CartItem struct {
+	DishID   string `json:"dishId"`
+	Quantity int64  `json:"quantity"`
+}
+
+type PlaceOrderBody struct {
+	RestaurantID string     `json:"restaurantId"`
+	AddressID    string     `json:"addressId"`
+	Items        []CartItem `json:"items"`
+	CouponCode   string     `json:"couponCode"`
+	RedeemPoints int64      `json:"redeemPoints"`
+	ScheduledFor *time.Time `json:"scheduledFor"`
+	// Payment defaults to cash on delivery, IdempotencyKey makes a retried checkout return the order it created first
+	Payment        PaymentBody `json:"payment"`
+	IdempotencyKey string      `json:"idempotencyKey"`
+}
+
+type UpdateOrderStatusBody struct {
+	Status OrderStatus `json:"status"`
+}
+
+type PlaceOrder struct {
+	Message string   `json:"message"`
+	Order   Order    `json:"order"`
+	Payment *Payment `json:"payment,omitempty"`
+}
+
+type GetOrder struct {
+	Message string `json:"message"`
+	Order   Order  `json:"order"`
+}
+
+type GetOrders struct {
+	Message    string  `json:"message"`
+	Orders     []Order `json:"orders"`
+	TotalCount int64   `json:"totalCount"`
+	PageNumber int64   `json:"pageNumber"`
+	PageSize   int64   `json:"pageSize"`
+}
+
+type ReorderChangeReason string
+
+const (
+	ReorderDishRemoved      ReorderChangeReason = "removed"
+	ReorderDishUnavailable  ReorderChangeReason = "unavailable"
+	ReorderDishNotServed    ReorderChangeReason = "not_served"
+	ReorderDishSoldOut      ReorderChangeReason = "sold_out"
+	ReorderQuantityReduced  ReorderChangeReason = "quantity_reduced"
+	ReorderDishPriceChanged ReorderChangeReason = "price_changed"
+)
+
+// ReorderChange is an item of the past order that is left out of the new cart or goes in it differently. Quantity is
+// what was ordered and NewQuantity what the cart takes, prices are per unit in minor units of the restaurant currency
+type ReorderChange struct {
+	DishID      string              `json:"dishId"`
+	Name        string              `json:"name"`
+	Reason      ReorderChangeReason `json:"reason"`
+	Quantity    int64               `json:"quantity"`
+	NewQuantity int64               `json:"newQuantity"`
+	OldPrice    int64               `json:"oldPrice"`
+	NewPrice    int64               `json:"newPrice"`
+	OldDiscount int64               `json:"oldDiscount"`
+	NewDiscount int64               `json:"newDiscount"`
+}
+
+// Reorder is a cart rebuilt from a past order and priced at current prices, Breakdown is nil when none of the items
+// can be ordered now
+type Reorder struct {
+	Message      string          `json:"message"`
+	RestaurantID string          `json:"restaurantId"`
+	Items        []CartItem      `json:"items"`
+	Breakdown    *PriceBreakdown `json:"breakdown"`
+	Changes      []ReorderChange `json:"changes"`
+}
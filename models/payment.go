@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+type PaymentStatus string
+
+const (
+	PaymentPending    PaymentStatus = "pending"
+	PaymentAuthorized PaymentStatus = "authorized"
+	PaymentCaptured   PaymentStatus = "captured"
+	PaymentFailed     PaymentStatus = "failed"
+	PaymentVoided     PaymentStatus = "voided"
+	PaymentRefunded   PaymentStatus = "refunded"
+)
+
+type PaymentAction string
+
+const (
+	PaymentActionCreate  PaymentAction = "create"
+	PaymentActionWebhook PaymentAction = "webhook"
+	PaymentActionCapture PaymentAction = "capture"
+	PaymentActionRefund  PaymentAction = "refund"
+	// PaymentActionPartialRefund gives back what adjustments took off an order that goes on
+	PaymentActionPartialRefund PaymentAction = "partial_refund"
+)
+
+type Payment struct {
+	ID             string  `json:"id" db:"id"`
+	OrderID        string  `json:"orderId" db:"order_id"`
+	UserID         string  `json:"-" db:"user_id"`
+	Provider       string  `json:"provider" db:"provider"`
+	Method         string  `json:"method" db:"method"`
+	ProviderRef    *string `json:"providerRef" db:"provider_ref"`
+	Amount         int64   `json:"amount" db:"amount"`
+	RefundedAmount int64   `json:"refundedAmount" db:"refunded_amount"`
+	// PendingRefund is owed to the customer and not yet given back through the provider
+	PendingRefund int64         `json:"pendingRefund" db:"pending_refund"`
+	Status        PaymentStatus `json:"status" db:"status"`
+	FailureReason string        `json:"failureReason" db:"failure_reason"`
+	ClientSecret  string        `json:"clientSecret,omitempty" db:"-"`
+	CreatedAt     time.Time     `json:"createdAt" db:"created_at"`
+	UpdatedAt     time.Time     `json:"updatedAt" db:"updated_at"`
+}
+
+// PaymentSettlement is a payment the provider still has to capture or give back, Action tells which
+type PaymentSettlement struct {
+	Payment
+	Action PaymentAction `db:"action"`
+	// Failures counts the failed provider calls since the payment last settled, the next try waits longer for each
+	Failures int64 `db:"settlement_failures"`
+}
+
+type PaymentBody struct {
+	Provider string `json:"provider"`
+	Method   string `json:"method"`
+}
+
+type GetPayment struct {
+	Message string  `json:"message"`
+	Payment Payment `json:"payment"`
+}
@@ -0,0 +1,127 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// tax categories of the charges, dishes name their own categories freely
+const (
+	TaxCategoryDefault   = ""
+	TaxCategoryDelivery  = "delivery"
+	TaxCategoryPackaging = "packaging"
+)
+
+// TaxRate is one tax of a restaurant, e.g. CGST 2.5%. An inclusive rate is already part of the prices it applies to
+type TaxRate struct {
+	ID           string    `json:"id" db:"id"`
+	RestaurantID string    `json:"restaurantId" db:"restaurant_id"`
+	Category     string    `json:"category" db:"category"`
+	Name         string    `json:"name" db:"name"`
+	RateBps      int64     `json:"rateBps" db:"rate_bps"`
+	Inclusive    bool      `json:"inclusive" db:"inclusive"`
+	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+}
+
+// RestaurantPricing holds the charges and taxes a restaurant adds to its dish prices
+type RestaurantPricing struct {
+	RestaurantID string `json:"restaurantId" db:"id"`
+	// Currency is what every amount of the restaurant is in, in minor units
+	Currency    string `json:"currency" db:"currency"`
+	DeliveryFee int64  `json:"deliveryFee" db:"delivery_fee"`
+	// FreeDeliveryAbove waives the delivery fee from this amount after discounts, 0 never waives it
+	FreeDeliveryAbove int64 `json:"freeDeliveryAbove" db:"free_delivery_above"`
+	// RoundingUnit is what the grand total is rounded to, 1 keeps it as it is
+	RoundingUnit int64     `json:"roundingUnit" db:"rounding_unit"`
+	TaxRates     []TaxRate `json:"taxRates" db:"-"`
+}
+
+// TaxLine is the amount of one tax, Taxable is what it was charged on
+type TaxLine struct {
+	Name      string `json:"name"`
+	RateBps   int64  `json:"rateBps"`
+	Inclusive bool   `json:"inclusive"`
+	Taxable   int64  `json:"taxable"`
+	Amount    int64  `json:"amount"`
+}
+
+// PriceLine is an order item with its share of the order discounts and its taxes. Total of the item stays the price
+// after the dish discount, Taxable is that total less the coupon share and any tax included in it
+type PriceLine struct {
+	OrderItem
+	TaxCategory    string    `json:"taxCategory"`
+	Packaging      int64     `json:"packaging"`
+	CouponDiscount int64     `json:"couponDiscount"`
+	Taxable        int64     `json:"taxable"`
+	Tax            int64     `json:"tax"`
+	Taxes          []TaxLine `json:"taxes"`
+}
+
+// PriceBreakdown is the itemised price of a cart or an order. Tax includes TaxIncluded, which is already part of the
+// dish and charge amounts, GrandTotal is what the order costs and Total what is left to pay after points
+type PriceBreakdown struct {
+	Currency       string      `json:"currency"`
+	Items          []PriceLine `json:"items"`
+	SubTotal       int64       `json:"subTotal"`
+	DishDiscount   int64       `json:"dishDiscount"`
+	CouponCode     string      `json:"couponCode,omitempty"`
+	CouponDiscount int64       `json:"couponDiscount"`
+	Packaging      int64       `json:"packaging"`
+	DeliveryFee    int64       `json:"deliveryFee"`
+	Taxes          []TaxLine   `json:"taxes"`
+	Tax            int64       `json:"tax"`
+	TaxIncluded    int64       `json:"taxIncluded"`
+	Rounding       int64       `json:"rounding"`
+	GrandTotal     int64       `json:"grandTotal"`
+	PointsRedeemed int64       `json:"pointsRedeemed"`
+	Total          int64       `json:"total"`
+}
+
+// Value stores the breakdown of an order as JSON
+func (pb PriceBreakdown) Value() (driver.Value, error) {
+	return json.Marshal(pb)
+}
+
+func (pb *PriceBreakdown) Scan(src interface{}) error {
+	switch value := src.(type) {
+	case []byte:
+		return json.Unmarshal(value, pb)
+	case string:
+		return json.Unmarshal([]byte(value), pb)
+	}
+	return errors.New("unsupported price breakdown value")
+}
+
+type PriceCartBody struct {
+	RestaurantID string     `json:"restaurantId"`
+	Items        []CartItem `json:"items"`
+	CouponCode   string     `json:"couponCode"`
+	RedeemPoints int64      `json:"redeemPoints"`
+	// ScheduledFor prices the cart against the menus served at that time instead of now
+	ScheduledFor *time.Time `json:"scheduledFor"`
+}
+
+type PriceCart struct {
+	Message   string         `json:"message"`
+	Breakdown PriceBreakdown `json:"breakdown"`
+}
+
+type UpdateRestaurantPricingBody struct {
+	DeliveryFee       int64 `json:"deliveryFee"`
+	FreeDeliveryAbove int64 `json:"freeDeliveryAbove"`
+	RoundingUnit      int64 `json:"roundingUnit"`
+}
+
+type AddTaxRateBody struct {
+	Category  string `json:"category"`
+	Name      string `json:"name"`
+	RateBps   int64  `json:"rateBps"`
+	Inclusive bool   `json:"inclusive"`
+}
+
+type GetRestaurantPricing struct {
+	Message string            `json:"message"`
+	Pricing RestaurantPricing `json:"pricing"`
+}
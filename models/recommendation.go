@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// RecommendationReason tells which signal picked a recommendation, a pick can have several
+type RecommendationReason string
+
+const (
+	// RecommendedBoughtTogether dishes are often ordered along with the dishes the customer ordered, favourited or
+	// rated well
+	RecommendedBoughtTogether RecommendationReason = "bought_together"
+	// RecommendedPopularNearby picks sell well near the customer's default address
+	RecommendedPopularNearby RecommendationReason = "popular_nearby"
+	// RecommendedCuisineAffinity picks are of the cuisines the customer orders, favourites and rates well
+	RecommendedCuisineAffinity RecommendationReason = "cuisine_affinity"
+)
+
+type RecommendedDish struct {
+	Dishes
+	Score   float64        `json:"score" db:"score"`
+	Reasons pq.StringArray `json:"reasons" db:"reasons"`
+}
+
+type RecommendedRestaurant struct {
+	Restaurant
+	Score   float64        `json:"score" db:"score"`
+	Reasons pq.StringArray `json:"reasons" db:"reasons"`
+}
+
+// GetRecommendations holds the picks of the last recommendation run, ComputedAt is nil when the customer has none yet
+type GetRecommendations struct {
+	Message     string                  `json:"message"`
+	Dishes      []RecommendedDish       `json:"dishes"`
+	Restaurants []RecommendedRestaurant `json:"restaurants"`
+	ComputedAt  *time.Time              `json:"computedAt"`
+}
@@ -0,0 +1,109 @@
+package models
+
+import "time"
+
+type ReportGranularity string
+
+const (
+	ReportDay   ReportGranularity = "day"
+	ReportWeek  ReportGranularity = "week"
+	ReportMonth ReportGranularity = "month"
+)
+
+func (g ReportGranularity) IsValid() bool {
+	return g == ReportDay || g == ReportWeek || g == ReportMonth
+}
+
+// ReportBucket is a restaurant and 15 minute slot whose report rows have to be worked out again
+type ReportBucket struct {
+	RestaurantID string    `db:"restaurant_id"`
+	BucketStart  time.Time `db:"bucket_start"`
+}
+
+// ReportRange is the period a report covers, From and To are inclusive dates in TimeZone. Reports are served from
+// summaries that hold the orders changed up to RefreshedUntil
+type ReportRange struct {
+	From           string    `json:"from"`
+	To             string    `json:"to"`
+	TimeZone       string    `json:"timeZone"`
+	RefreshedUntil time.Time `json:"refreshedUntil"`
+}
+
+// RevenuePoint is one day, week or month of a revenue report, Period is its first local date
+type RevenuePoint struct {
+	Period    string `json:"period" db:"period"`
+	Currency  string `json:"currency" db:"currency"`
+	Orders    int64  `json:"orders" db:"orders"`
+	Delivered int64  `json:"delivered" db:"delivered"`
+	Revenue   int64  `json:"revenue" db:"revenue"`
+}
+
+type TopDish struct {
+	DishID       string `json:"dishId" db:"dish_id"`
+	Name         string `json:"name" db:"name"`
+	RestaurantID string `json:"restaurantId" db:"restaurant_id"`
+	Currency     string `json:"currency" db:"currency"`
+	Quantity     int64  `json:"quantity" db:"quantity"`
+	Revenue      int64  `json:"revenue" db:"revenue"`
+}
+
+// CurrencyRevenue is the revenue of the delivered orders in one currency, AverageOrderValue is rounded down
+type CurrencyRevenue struct {
+	Currency          string `json:"currency" db:"currency"`
+	Delivered         int64  `json:"delivered" db:"delivered"`
+	Revenue           int64  `json:"revenue" db:"revenue"`
+	AverageOrderValue int64  `json:"averageOrderValue" db:"-"`
+}
+
+type OrderSummary struct {
+	Orders           int64             `json:"orders" db:"orders"`
+	Delivered        int64             `json:"delivered" db:"delivered"`
+	Cancelled        int64             `json:"cancelled" db:"cancelled"`
+	CancellationRate float64           `json:"cancellationRate" db:"-"`
+	Revenue          []CurrencyRevenue `json:"revenue" db:"-"`
+}
+
+// PeakHour is how many orders came in during one local hour of the day over the whole range
+type PeakHour struct {
+	Hour   int   `json:"hour" db:"hour"`
+	Orders int64 `json:"orders" db:"orders"`
+}
+
+// CustomerReport splits the customers that ordered in the range into those ordering for the first time and those
+// that ordered before
+type CustomerReport struct {
+	Customers int64 `json:"customers" db:"customers"`
+	New       int64 `json:"new" db:"new"`
+	Returning int64 `json:"returning" db:"returning"`
+}
+
+type GetRevenueReport struct {
+	Message     string            `json:"message"`
+	Range       ReportRange       `json:"range"`
+	Granularity ReportGranularity `json:"granularity"`
+	Revenue     []RevenuePoint    `json:"revenue"`
+}
+
+type GetTopDishesReport struct {
+	Message string      `json:"message"`
+	Range   ReportRange `json:"range"`
+	Dishes  []TopDish   `json:"dishes"`
+}
+
+type GetOrderSummaryReport struct {
+	Message string       `json:"message"`
+	Range   ReportRange  `json:"range"`
+	Summary OrderSummary `json:"summary"`
+}
+
+type GetPeakHoursReport struct {
+	Message   string      `json:"message"`
+	Range     ReportRange `json:"range"`
+	PeakHours []PeakHour  `json:"peakHours"`
+}
+
+type GetCustomerReport struct {
+	Message   string         `json:"message"`
+	Range     ReportRange    `json:"range"`
+	Customers CustomerReport `json:"customers"`
+}
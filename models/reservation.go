@@ -0,0 +1,84 @@
+package models
+
+import "time"
+
+type ReservationStatus string
+
+const (
+	ReservationBooked    ReservationStatus = "booked"
+	ReservationCancelled ReservationStatus = "cancelled"
+	ReservationNoShow    ReservationStatus = "no-show"
+)
+
+type Reservation struct {
+	ID           string            `json:"id" db:"id"`
+	RestaurantID string            `json:"restaurantId" db:"restaurant_id"`
+	UserID       string            `json:"userId" db:"user_id"`
+	UserName     string            `json:"userName" db:"user_name"`
+	TableID      string            `json:"tableId" db:"table_id"`
+	TableName    string            `json:"tableName" db:"table_name"`
+	PartySize    int64             `json:"partySize" db:"party_size"`
+	StartsAt     time.Time         `json:"startsAt" db:"starts_at"`
+	EndsAt       time.Time         `json:"endsAt" db:"ends_at"`
+	Status       ReservationStatus `json:"status" db:"status"`
+	Note         string            `json:"note" db:"note"`
+	CreatedAt    time.Time         `json:"createdAt" db:"created_at"`
+	UpdatedAt    time.Time         `json:"updatedAt" db:"updated_at"`
+}
+
+type ReservationBlock struct {
+	ID           string    `json:"id" db:"id"`
+	RestaurantID string    `json:"restaurantId" db:"restaurant_id"`
+	TableID      *string   `json:"tableId" db:"table_id"`
+	StartsAt     time.Time `json:"startsAt" db:"starts_at"`
+	EndsAt       time.Time `json:"endsAt" db:"ends_at"`
+	Reason       string    `json:"reason" db:"reason"`
+	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+}
+
+// ReservationSlot is a start time at which a party still finds a free table
+type ReservationSlot struct {
+	StartsAt   time.Time `json:"startsAt"`
+	EndsAt     time.Time `json:"endsAt"`
+	FreeTables int64     `json:"freeTables"`
+}
+
+type ReservationBody struct {
+	RestaurantID    string    `json:"restaurantId"`
+	PartySize       int64     `json:"partySize"`
+	StartsAt        time.Time `json:"startsAt"`
+	DurationMinutes int64     `json:"durationMinutes"`
+	Note            string    `json:"note"`
+}
+
+type AddReservationBlockBody struct {
+	TableID  *string   `json:"tableId"`
+	StartsAt time.Time `json:"startsAt"`
+	EndsAt   time.Time `json:"endsAt"`
+	Reason   string    `json:"reason"`
+}
+
+type GetReservationSlots struct {
+	Message string            `json:"message"`
+	Slots   []ReservationSlot `json:"slots"`
+}
+
+type GetReservation struct {
+	Message     string      `json:"message"`
+	Reservation Reservation `json:"reservation"`
+}
+
+type GetReservations struct {
+	Message      string        `json:"message"`
+	Reservations []Reservation `json:"reservations"`
+	TotalCount   int64         `json:"totalCount"`
+	PageNumber   int64         `json:"pageNumber"`
+	PageSize     int64         `json:"pageSize"`
+}
+
+// GetReservationDay is the sub-admin view of one local day of bookings
+type GetReservationDay struct {
+	Message      string             `json:"message"`
+	Reservations []Reservation      `json:"reservations"`
+	Blocks       []ReservationBlock `json:"blocks"`
+}
@@ -1,33 +1,55 @@
 package models
 
-import "time"
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
 
 // Restaurant
 
 type Restaurant struct {
-	ID        string    `json:"id" db:"id"`
-	Name      string    `json:"name" db:"name"`
-	Email     string    `json:"email" db:"email"`
-	Address   string    `json:"address" db:"address"`
-	State     string    `json:"state" db:"state"`
-	City      string    `json:"city" db:"city"`
-	PinCode   string    `json:"pinCode" db:"pin_code"`
-	Lat       float64   `json:"lat" db:"lat"`
-	Lng       float64   `json:"lng" db:"lng"`
-	CreatedAt time.Time `json:"createdAt" db:"created_at"`
-	CreatedBy string    `json:"createdBy" db:"created_by"`
+	ID          string  `json:"id" db:"id"`
+	Name        string  `json:"name" db:"name"`
+	Email       string  `json:"email" db:"email"`
+	Address     string  `json:"address" db:"address"`
+	State       string  `json:"state" db:"state"`
+	City        string  `json:"city" db:"city"`
+	PinCode     string  `json:"pinCode" db:"pin_code"`
+	Lat         float64 `json:"lat" db:"lat"`
+	Lng         float64 `json:"lng" db:"lng"`
+	AvgRating   float64 `json:"avgRating" db:"avg_rating"`
+	RatingCount int64   `json:"ratingCount" db:"rating_count"`
+	Currency    string  `json:"currency" db:"currency"`
+	Logo        *Image  `json:"logo" db:"logo"`
+	Banner      *Image  `json:"banner" db:"banner"`
+	// Cuisines are slugs of the cuisines taxonomy and Features come from RestaurantFeatures, CostForTwo is in minor
+	// units of Currency
+	Cuisines   pq.StringArray `json:"cuisines" db:"cuisines"`
+	PriceRange *int64         `json:"priceRange" db:"price_range"`
+	CostForTwo *int64         `json:"costForTwo" db:"cost_for_two"`
+	Features   pq.StringArray `json:"features" db:"features"`
+	CreatedAt  time.Time      `json:"createdAt" db:"created_at"`
+	CreatedBy  string         `json:"createdBy" db:"created_by"`
+	// Distance and ETA are only set when the listing is asked for a delivery address
+	Distance *float64     `json:"distance,omitempty" db:"-"`
+	ETA      *DeliveryETA `json:"eta,omitempty" db:"-"`
+	// Favourite is set on customer listings when the customer favourited the restaurant
+	Favourite bool `json:"favourite" db:"-"`
 }
 
 type OpenRestaurantBody struct {
-	Name      string  `json:"name" db:"name"`
-	Email     string  `json:"email" db:"email"`
-	Address   string  `json:"address" db:"address"`
-	State     string  `json:"state" db:"state"`
-	City      string  `json:"city" db:"city"`
-	PinCode   string  `json:"pinCode" db:"pin_code"`
-	Lat       float64 `json:"lat" db:"lat"`
-	Lng       float64 `json:"lng" db:"lng"`
-	CreatedBy string  `json:"createdBy" db:"created_by"`
+	Name    string  `json:"name" db:"name"`
+	Email   string  `json:"email" db:"email"`
+	Address string  `json:"address" db:"address"`
+	State   string  `json:"state" db:"state"`
+	City    string  `json:"city" db:"city"`
+	PinCode string  `json:"pinCode" db:"pin_code"`
+	Lat     float64 `json:"lat" db:"lat"`
+	Lng     float64 `json:"lng" db:"lng"`
+	// Currency is the ISO 4217 code every amount of the restaurant is in, it defaults to DefaultCurrency
+	Currency  string `json:"currency" db:"currency"`
+	CreatedBy string `json:"createdBy" db:"created_by"`
 }
 
 type GetRestaurants struct {
@@ -36,12 +58,15 @@ type GetRestaurants struct {
 	TotalCount  int64        `json:"totalCount"`
 	PageNumber  int64        `json:"pageNumber"`
 	PageSize    int64        `json:"pageSize"`
+	// Facets count the restaurants of the listing by cuisine, feature and price range
+	Facets *RestaurantFacets `json:"facets,omitempty"`
 }
 
 type RestaurantDistance struct {
-	Message      string  `json:"message"`
-	Distance     float64 `json:"restaurantsDistance"`
-	DistanceUnit string  `json:"distanceUnit"`
+	Message      string      `json:"message"`
+	Distance     float64     `json:"restaurantsDistance"`
+	DistanceUnit string      `json:"distanceUnit"`
+	ETA          DeliveryETA `json:"eta"`
 }
 
 // Dishes
@@ -62,37 +87,89 @@ func (ds DishSortedBy) IsValid() bool {
 }
 
 type DishFilters struct {
-	PageNumber  int64
-	PageSize    int64
-	Name        string
-	Email       string
-	MinQuantity int64
-	MaxPrice    int64
-	MinPrice    int64
+	PageNumber int64
+	PageSize   int64
+	Name       string
+	Email      string
+	// MinQuantity lists only dishes with more stock than it, without it sold out dishes are listed too
+	MinQuantity *int64
+	// Currency is the one the client asked the prices in, if any. Prices given without it and the defaults carry none
+	Currency    string
+	MaxPrice    Money
+	MinPrice    Money
 	MaxDiscount int64
 	MinDiscount int64
 	CreatedBy   string
 	SortBy      DishSortedBy
+	// ServedAt keeps only the dishes the menus serve at that time, nil lists them all
+	ServedAt *time.Time
+	// IncludeTags keeps the dishes with every one of the tags or allergens, ExcludeTags drops the dishes with any.
+	// MaxSpiceLevel drops the dishes hotter than it and those of unknown spice level
+	IncludeTags   []string
+	ExcludeTags   []string
+	MaxSpiceLevel *int64
 }
 
 type Dishes struct {
-	ID          string    `json:"id" db:"id"`
-	Name        string    `json:"name" db:"name"`
-	Description string    `json:"description" db:"description"`
-	Quantity    int64     `json:"quantity" db:"quantity"`
-	Price       int64     `json:"price" db:"price"`
-	Discount    int64     `json:"discount" db:"discount"`
-	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
-	CreatedBy   string    `json:"createdBy" db:"created_by"`
+	ID string `json:"id" db:"id"`
+	// RestaurantID is only set on listings that span restaurants
+	RestaurantID string `json:"restaurantId,omitempty" db:"restaurant_id"`
+	Name         string `json:"name" db:"name"`
+	Description  string `json:"description" db:"description"`
+	Quantity     int64  `json:"quantity" db:"quantity"`
+	// Price and PackagingCharge are in minor units of Currency, the currency of the restaurant
+	Price    int64  `json:"price" db:"price"`
+	Currency string `json:"currency" db:"currency"`
+	Discount int64  `json:"discount" db:"discount"`
+	Station  string `json:"station" db:"station"`
+	// Available is false while the dish is switched off, until UnavailableUntil when that is set. SoldOut is set while
+	// the quantity is zero, either way the dish is listed but cannot be ordered
+	Available        bool       `json:"available" db:"available"`
+	UnavailableUntil *time.Time `json:"unavailableUntil" db:"unavailable_until"`
+	SoldOut          bool       `json:"soldOut" db:"sold_out"`
+	// Served is false outside the windows of the menus the dish is on, the listing's time or now
+	Served bool   `json:"served" db:"served"`
+	Photo  *Image `json:"photo" db:"photo"`
+	// Tags and Allergens come from DietaryTags and Allergens, SpiceLevel is nil when it is not known
+	Tags       pq.StringArray `json:"tags" db:"tags"`
+	Allergens  pq.StringArray `json:"allergens" db:"allergens"`
+	SpiceLevel *int64         `json:"spiceLevel" db:"spice_level"`
+	Nutrition  *DishNutrition `json:"nutrition" db:"nutrition"`
+	// TaxCategory picks the tax rates of the dish, PackagingCharge is added per unit on delivery orders
+	TaxCategory     string    `json:"taxCategory" db:"tax_category"`
+	PackagingCharge int64     `json:"packagingCharge" db:"packaging_charge"`
+	AvgRating       float64   `json:"avgRating" db:"avg_rating"`
+	RatingCount     int64     `json:"ratingCount" db:"rating_count"`
+	CreatedAt       time.Time `json:"createdAt" db:"created_at"`
+	CreatedBy       string    `json:"createdBy" db:"created_by"`
+	// Favourite is set on customer listings when the customer favourited the dish
+	Favourite bool `json:"favourite" db:"-"`
 }
 
 type AddDishesBody struct {
 	Name        string `json:"name" db:"name"`
 	Description string `json:"description" db:"description"`
 	Quantity    int64  `json:"quantity" db:"quantity"`
-	Price       int64  `json:"price" db:"price"`
-	Discount    int64  `json:"discount" db:"discount"`
-	CreatedBy   string `json:"createdBy" db:"created_by"`
+	// Price takes a Money or, from older clients, a bare number of minor units in the currency of the restaurant
+	Price    Money  `json:"price" db:"price"`
+	Discount int64  `json:"discount" db:"discount"`
+	Station  string `json:"station" db:"station"`
+	// TaxCategory and PackagingCharge keep the current values of the dish when they are left out of an update
+	TaxCategory     *string `json:"taxCategory" db:"tax_category"`
+	PackagingCharge *Money  `json:"packagingCharge" db:"packaging_charge"`
+	// Tags, Allergens, SpiceLevel and Nutrition keep the current values of the dish when they are left out of an
+	// update, an empty list clears them
+	Tags       []string       `json:"tags"`
+	Allergens  []string       `json:"allergens"`
+	SpiceLevel *int64         `json:"spiceLevel"`
+	Nutrition  *DishNutrition `json:"nutrition"`
+	CreatedBy  string         `json:"createdBy" db:"created_by"`
+}
+
+// DishAvailabilityBody switches a dish on or off, BackAt switches an unavailable dish back on by itself
+type DishAvailabilityBody struct {
+	Available bool       `json:"available"`
+	BackAt    *time.Time `json:"backAt"`
 }
 
 type GetDishes struct {
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+type Review struct {
+	ID           string       `json:"id" db:"id"`
+	OrderID      string       `json:"orderId" db:"order_id"`
+	UserID       string       `json:"userId" db:"user_id"`
+	UserName     string       `json:"userName" db:"user_name"`
+	RestaurantID string       `json:"restaurantId" db:"restaurant_id"`
+	Rating       int64        `json:"rating" db:"rating"`
+	Comment      string       `json:"comment" db:"comment"`
+	Reply        *string      `json:"reply" db:"reply"`
+	RepliedAt    *time.Time   `json:"repliedAt" db:"replied_at"`
+	FlagReason   *string      `json:"flagReason,omitempty" db:"flag_reason"`
+	FlaggedAt    *time.Time   `json:"flaggedAt,omitempty" db:"flagged_at"`
+	CreatedAt    time.Time    `json:"createdAt" db:"created_at"`
+	Dishes       []DishReview `json:"dishes" db:"-"`
+}
+
+type DishReview struct {
+	ID       string `json:"id" db:"id"`
+	ReviewID string `json:"-" db:"review_id"`
+	DishID   string `json:"dishId" db:"dish_id"`
+	DishName string `json:"dishName" db:"dish_name"`
+	Rating   int64  `json:"rating" db:"rating"`
+	Comment  string `json:"comment" db:"comment"`
+}
+
+type DishReviewBody struct {
+	DishID  string `json:"dishId"`
+	Rating  int64  `json:"rating"`
+	Comment string `json:"comment"`
+}
+
+type AddReviewBody struct {
+	Rating  int64            `json:"rating"`
+	Comment string           `json:"comment"`
+	Dishes  []DishReviewBody `json:"dishes"`
+}
+
+type ReplyReviewBody struct {
+	Reply string `json:"reply"`
+}
+
+type FlagReviewBody struct {
+	Reason string `json:"reason"`
+}
+
+type ModerateReviewBody struct {
+	Hide bool `json:"hide"`
+}
+
+type GetReviews struct {
+	Message    string   `json:"message"`
+	Reviews    []Review `json:"reviews"`
+	TotalCount int64    `json:"totalCount"`
+	PageNumber int64    `json:"pageNumber"`
+	PageSize   int64    `json:"pageSize"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+type Rider struct {
+	ID          string     `json:"id" db:"id"`
+	Name        string     `json:"name" db:"name"`
+	Email       string     `json:"email" db:"email"`
+	IsAvailable bool       `json:"isAvailable" db:"is_available"`
+	Lat         *float64   `json:"lat" db:"lat"`
+	Lng         *float64   `json:"lng" db:"lng"`
+	LocatedAt   *time.Time `json:"locatedAt" db:"located_at"`
+	ActiveOrder *string    `json:"activeOrder" db:"active_order"`
+	Distance    *float64   `json:"distance,omitempty" db:"-"`
+}
+
+type RiderAvailabilityBody struct {
+	Available bool `json:"available"`
+}
+
+type RiderLocationBody struct {
+	Lat float64 `json:"lat"`
+	Lng float64 `json:"lng"`
+}
+
+type AssignRiderBody struct {
+	RiderID string `json:"riderId"`
+}
+
+// RiderPosition is where the customer sees the rider of their order
+type RiderPosition struct {
+	RiderID   string     `json:"riderId" db:"id"`
+	Name      string     `json:"name" db:"name"`
+	Lat       *float64   `json:"lat" db:"lat"`
+	Lng       *float64   `json:"lng" db:"lng"`
+	LocatedAt *time.Time `json:"locatedAt" db:"located_at"`
+}
+
+type GetRiders struct {
+	Message string  `json:"message"`
+	Riders  []Rider `json:"riders"`
+}
+
+type OrderTracking struct {
+	Message string         `json:"message"`
+	OrderID string         `json:"orderId"`
+	Status  OrderStatus    `json:"status"`
+	Rider   *RiderPosition `json:"rider"`
+}
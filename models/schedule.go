@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+type RestaurantSchedule struct {
+	Timezone           string `json:"timezone" db:"timezone"`
+	SlotMinutes        int64  `json:"slotMinutes" db:"slot_minutes"`
+	SlotCapacity       int64  `json:"slotCapacity" db:"slot_capacity"`
+	LeadMinutes        int64  `json:"leadMinutes" db:"lead_minutes"`
+	ReservationMinutes int64  `json:"reservationMinutes" db:"reservation_minutes"`
+}
+
+type RestaurantHours struct {
+	DayOfWeek int    `json:"dayOfWeek" db:"day_of_week"`
+	OpensAt   string `json:"opensAt" db:"opens_at"`
+	ClosesAt  string `json:"closesAt" db:"closes_at"`
+}
+
+type UpdateScheduleBody struct {
+	RestaurantSchedule
+	Hours []RestaurantHours `json:"hours"`
+}
+
+type DeliverySlot struct {
+	StartsAt  time.Time `json:"startsAt"`
+	EndsAt    time.Time `json:"endsAt"`
+	Available int64     `json:"available"`
+}
+
+type GetDeliverySlots struct {
+	Message string         `json:"message"`
+	Slots   []DeliverySlot `json:"slots"`
+}
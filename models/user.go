@@ -10,10 +10,12 @@ const (
 	RoleAdmin    Role = "admin"
 	RoleSubAdmin Role = "sub-admin"
 	RoleUser     Role = "user"
+	RoleKitchen  Role = "kitchen-staff"
+	RoleRider    Role = "rider"
 )
 
 func (r Role) IsValid() bool {
-	return r == RoleAdmin || r == RoleSubAdmin || r == RoleUser
+	return r == RoleAdmin || r == RoleSubAdmin || r == RoleUser || r == RoleKitchen || r == RoleRider
 }
 
 type SortedBy string
@@ -23,10 +25,11 @@ const (
 	Name      SortedBy = "name"
 	Email     SortedBy = "email"
 	CreatedBy SortedBy = "created_by"
+	Rating    SortedBy = "avg_rating"
 )
 
 func (s SortedBy) IsValid() bool {
-	return s == ID || s == Name || s == Email || s == CreatedBy
+	return s == ID || s == Name || s == Email || s == CreatedBy || s == Rating
 }
 
 // User
@@ -66,7 +69,14 @@ type Filters struct {
 	Name       string
 	Email      string
 	CreatedBy  string
+	MinRating  float64
 	SortBy     SortedBy
+	// Cuisines keeps the restaurants with any of the cuisines, Features those with every feature and PriceRanges
+	// those in any of the ranges. MaxCostForTwo is in minor units of each restaurant's currency
+	Cuisines      []string
+	Features      []string
+	PriceRanges   []int64
+	MaxCostForTwo *int64
 }
 
 type RegisterUserBody struct {
@@ -75,6 +85,14 @@ type RegisterUserBody struct {
 	Password string `json:"password" db:"password"`
 }
 
+type VerifyEmailBody struct {
+	Token string `json:"token"`
+}
+
+type ResendVerificationBody struct {
+	Email string `json:"email"`
+}
+
 type GetUsers struct {
 	Message    string `json:"message"`
 	Users      []User `json:"users"`
@@ -0,0 +1,104 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jmoiron/sqlx/types"
+	"github.com/lib/pq"
+)
+
+const (
+	WebhookOrderPlaced        = "order.placed"
+	WebhookOrderStatusChanged = "order.status_changed"
+	WebhookDishUpdated        = "dish.updated"
+	WebhookRestaurantClosed   = "restaurant.closed"
+	WebhookOrderAdjusted      = "order.adjusted"
+)
+
+// WebhookEvents lists the events a webhook can subscribe to
+var WebhookEvents = []string{WebhookOrderPlaced, WebhookOrderStatusChanged, WebhookDishUpdated, WebhookRestaurantClosed,
+	WebhookOrderAdjusted}
+
+// WebhookDish is the payload of dish.updated, Removed is set once the dish left the menu
+type WebhookDish struct {
+	Dishes
+	Removed bool `json:"removed" db:"removed"`
+}
+
+type WebhookDeliveryStatus string
+
+const (
+	WebhookPending   WebhookDeliveryStatus = "pending"
+	WebhookDelivered WebhookDeliveryStatus = "delivered"
+	WebhookDead      WebhookDeliveryStatus = "dead"
+)
+
+func (ws WebhookDeliveryStatus) IsValid() bool {
+	return ws == WebhookPending || ws == WebhookDelivered || ws == WebhookDead
+}
+
+type Webhook struct {
+	ID           string         `json:"id" db:"id"`
+	RestaurantID string         `json:"restaurantId" db:"restaurant_id"`
+	URL          string         `json:"url" db:"url"`
+	Secret       string         `json:"-" db:"secret"`
+	Events       pq.StringArray `json:"events" db:"events"`
+	CreatedBy    string         `json:"createdBy" db:"created_by"`
+	CreatedAt    time.Time      `json:"createdAt" db:"created_at"`
+}
+
+type WebhookDelivery struct {
+	ID            string                   `json:"id" db:"id"`
+	WebhookID     string                   `json:"webhookId" db:"webhook_id"`
+	EventType     string                   `json:"eventType" db:"event_type"`
+	Payload       types.JSONText           `json:"payload" db:"payload"`
+	Status        WebhookDeliveryStatus    `json:"status" db:"status"`
+	Attempts      int64                    `json:"attempts" db:"attempts"`
+	NextAttemptAt time.Time                `json:"nextAttemptAt" db:"next_attempt_at"`
+	LastError     *string                  `json:"lastError" db:"last_error"`
+	DeliveredAt   *time.Time               `json:"deliveredAt" db:"delivered_at"`
+	CreatedAt     time.Time                `json:"createdAt" db:"created_at"`
+	URL           string                   `json:"-" db:"url"`
+	Secret        string                   `json:"-" db:"secret"`
+	AttemptLog    []WebhookDeliveryAttempt `json:"attemptLog" db:"-"`
+}
+
+type WebhookDeliveryAttempt struct {
+	ID         string    `json:"id" db:"id"`
+	DeliveryID string    `json:"-" db:"delivery_id"`
+	StatusCode *int64    `json:"statusCode" db:"status_code"`
+	Error      *string   `json:"error" db:"error"`
+	DurationMS int64     `json:"durationMs" db:"duration_ms"`
+	CreatedAt  time.Time `json:"createdAt" db:"created_at"`
+}
+
+// WebhookPayload is the body posted to webhook endpoints
+type WebhookPayload struct {
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+}
+
+type AddWebhookBody struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+}
+
+type AddWebhook struct {
+	Message string  `json:"message"`
+	Webhook Webhook `json:"webhook"`
+	Secret  string  `json:"secret"`
+}
+
+type GetWebhooks struct {
+	Message  string    `json:"message"`
+	Webhooks []Webhook `json:"webhooks"`
+}
+
+type GetWebhookDeliveries struct {
+	Message    string            `json:"message"`
+	Deliveries []WebhookDelivery `json:"deliveries"`
+	TotalCount int64             `json:"totalCount"`
+	PageNumber int64             `json:"pageNumber"`
+	PageSize   int64             `json:"pageSize"`
+}
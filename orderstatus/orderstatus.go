@@ -0,0 +1,59 @@
+// Package orderstatus runs what follows a status change of an order, for the handlers and the background jobs alike
+package orderstatus
+
+import (
+	"rms/database/dbHelper"
+	"rms/eta"
+	"rms/events"
+	"rms/models"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Moved estimates the order again for its new status and tells the restaurant's webhooks, call it in the
+// transaction that moved the order. An order reaching the restaurant queue is sent in full
+func Moved(db sqlx.Ext, order *models.Order, status models.OrderStatus) error {
+	if err := eta.RefreshOrder(db, order.ID); err != nil {
+		return err
+	}
+	if status == models.OrderPlaced {
+		return EnqueuePlaced(db, order.ID)
+	}
+	return dbHelper.EnqueueWebhookEvent(db, order.RestaurantID, models.WebhookOrderStatusChanged, events.OrderData{
+		OrderID:      order.ID,
+		RestaurantID: order.RestaurantID,
+		Status:       string(status),
+	})
+}
+
+// EnqueuePlaced sends the full order to the restaurant's webhooks once it reaches the restaurant queue
+func EnqueuePlaced(db sqlx.Ext, orderID string) error {
+	order, err := dbHelper.GetOrderByID(db, orderID)
+	if err != nil {
+		return err
+	}
+	return dbHelper.EnqueueWebhookEvent(db, order.RestaurantID, models.WebhookOrderPlaced, order)
+}
+
+// Publish tells everyone watching the order about a committed status change, the restaurant hears of an order
+// reaching its queue as a new one
+func Publish(order *models.Order, status models.OrderStatus) {
+	data := events.OrderData{OrderID: order.ID, RestaurantID: order.RestaurantID, Status: string(status)}
+	if order.UserID != "" {
+		events.Publish(events.UserTopic(order.UserID), events.OrderStatusChanged, data)
+	}
+	restaurantEvent := events.OrderStatusChanged
+	if status == models.OrderPlaced {
+		restaurantEvent = events.OrderPlaced
+	}
+	events.Publish(events.RestaurantTopic(order.RestaurantID), restaurantEvent, data)
+}
+
+// PublishRiderAssigned tells the rider about their new delivery and the customer about their rider
+func PublishRiderAssigned(orderID, userID, riderID string) {
+	data := events.RiderData{OrderID: orderID, RiderID: riderID}
+	events.Publish(events.UserTopic(riderID), events.RiderAssigned, data)
+	if userID != "" {
+		events.Publish(events.UserTopic(userID), events.RiderAssigned, data)
+	}
+}
@@ -0,0 +1,26 @@
+package payments
+
+import "net/http"
+
+// cashOnDelivery authorizes right away, the rider collects the cash and capture records it on delivery
+type cashOnDelivery struct{}
+
+func (cashOnDelivery) CreateIntent(request IntentRequest) (*Intent, error) {
+	return &Intent{
+		ProviderRef: "cod_" + request.PaymentID,
+		Status:      IntentAuthorized,
+	}, nil
+}
+
+func (cashOnDelivery) Capture(string, int64) error {
+	return nil
+}
+
+// Refund has nothing to give back before delivery, after it the cash is returned by hand
+func (cashOnDelivery) Refund(string, int64) error {
+	return nil
+}
+
+func (cashOnDelivery) VerifyWebhook(http.Header, []byte) (*WebhookEvent, error) {
+	return nil, ErrNoWebhooks
+}
@@ -0,0 +1,152 @@
+package payments
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// methods of the mock gateway pick the outcome of an intent, like the test cards of a real gateway
+const (
+	MockSucceed = "mock_succeed"
+	MockFail    = "mock_fail"
+	MockDelayed = "mock_delayed"
+	// MockDecline fails when the intent is created, the other outcomes arrive through a webhook
+	MockDecline = "mock_decline"
+)
+
+const (
+	mockSignatureHeader = "X-Mock-Signature"
+	mockWebhookDelay    = time.Second
+	mockDelayedDelay    = 30 * time.Second
+	mockWebhookTimeout  = 10 * time.Second
+)
+
+// mockGateway simulates an online gateway offline. Intents stay pending and the outcome is posted back as a signed
+// webhook to MOCK_PAYMENT_WEBHOOK_URL, captures and refunds always succeed
+type mockGateway struct {
+	once       sync.Once
+	secret     []byte
+	webhookURL string
+	client     *http.Client
+}
+
+func newMockGateway() *mockGateway {
+	return &mockGateway{client: &http.Client{Timeout: mockWebhookTimeout}}
+}
+
+// configure reads the env on first use since providers register before the .env file is loaded, without a secret
+// the webhooks are signed with a random one that only this process knows
+func (m *mockGateway) configure() {
+	m.once.Do(func() {
+		m.secret = []byte(os.Getenv("MOCK_PAYMENT_SECRET"))
+		if len(m.secret) == 0 {
+			m.secret = make([]byte, 32)
+			if _, err := rand.Read(m.secret); err != nil {
+				logrus.Errorf("Failed to generate mock payment secret: %s", err)
+			}
+		}
+		m.webhookURL = os.Getenv("MOCK_PAYMENT_WEBHOOK_URL")
+		if m.webhookURL == "" {
+			m.webhookURL = "http://localhost:8080/v1/payments/webhook/" + ProviderMock
+		}
+	})
+}
+
+func (m *mockGateway) sign(body []byte) string {
+	m.configure()
+	mac := hmac.New(sha256.New, m.secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (m *mockGateway) CreateIntent(request IntentRequest) (*Intent, error) {
+	ref := make([]byte, 12)
+	if _, err := rand.Read(ref); err != nil {
+		return nil, err
+	}
+	intent := &Intent{
+		ProviderRef:  "mock_" + hex.EncodeToString(ref),
+		ClientSecret: "mock_secret_" + request.PaymentID,
+		Status:       IntentPending,
+	}
+	event := WebhookEvent{PaymentID: request.PaymentID, ProviderRef: intent.ProviderRef, Status: IntentAuthorized}
+	delay := mockWebhookDelay
+	switch request.Method {
+	case "", MockSucceed:
+	case MockDelayed:
+		delay = mockDelayedDelay
+	case MockFail:
+		event.Status = IntentFailed
+		event.Reason = "card declined by the mock gateway"
+	case MockDecline:
+		return nil, fmt.Errorf("mock gateway declined method %s", request.Method)
+	default:
+		return nil, fmt.Errorf("unknown mock payment method %s", request.Method)
+	}
+	time.AfterFunc(delay, func() {
+		m.sendWebhook(event)
+	})
+	return intent, nil
+}
+
+func (m *mockGateway) sendWebhook(event WebhookEvent) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		logrus.Errorf("Failed to encode mock payment webhook: %s", err)
+		return
+	}
+	m.configure()
+	req, err := http.NewRequest(http.MethodPost, m.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		logrus.Errorf("Failed to build mock payment webhook: %s", err)
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(mockSignatureHeader, m.sign(body))
+	resp, err := m.client.Do(req)
+	if err != nil {
+		logrus.Errorf("Failed to send mock payment webhook: %s", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		logrus.Errorf("Mock payment webhook for %s answered %d", event.PaymentID, resp.StatusCode)
+	}
+}
+
+func (m *mockGateway) Capture(providerRef string, _ int64) error {
+	if !strings.HasPrefix(providerRef, "mock_") {
+		return fmt.Errorf("unknown mock intent %s", providerRef)
+	}
+	return nil
+}
+
+func (m *mockGateway) Refund(providerRef string, _ int64) error {
+	if !strings.HasPrefix(providerRef, "mock_") {
+		return fmt.Errorf("unknown mock intent %s", providerRef)
+	}
+	return nil
+}
+
+func (m *mockGateway) VerifyWebhook(header http.Header, body []byte) (*WebhookEvent, error) {
+	if !hmac.Equal([]byte(header.Get(mockSignatureHeader)), []byte(m.sign(body))) {
+		return nil, ErrInvalidSignature
+	}
+	var event WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
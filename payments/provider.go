@@ -0,0 +1,99 @@
+package payments
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+const (
+	ProviderCOD  = "cod"
+	ProviderMock = "mock"
+)
+
+var (
+	ErrUnknownProvider  = errors.New("unknown payment provider")
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+	ErrNoWebhooks       = errors.New("provider sends no webhooks")
+)
+
+// IntentStatus is where a payment stands at the provider
+type IntentStatus string
+
+const (
+	// IntentPending waits for the customer or the provider, a webhook tells how it ended
+	IntentPending IntentStatus = "pending"
+	// IntentAuthorized holds the amount until it is captured
+	IntentAuthorized IntentStatus = "authorized"
+	IntentFailed     IntentStatus = "failed"
+)
+
+// IntentRequest is what the provider needs to start collecting a payment. PaymentID travels back in webhooks as
+// metadata so a webhook can't race the recording of the provider reference
+type IntentRequest struct {
+	PaymentID string
+	Amount    int64
+	Method    string
+}
+
+type Intent struct {
+	ProviderRef  string
+	ClientSecret string
+	Status       IntentStatus
+}
+
+// WebhookEvent is a verified notification about the outcome of an intent
+type WebhookEvent struct {
+	PaymentID   string       `json:"paymentId"`
+	ProviderRef string       `json:"providerRef"`
+	Status      IntentStatus `json:"status"`
+	Reason      string       `json:"reason"`
+}
+
+// Provider collects, captures and gives back the money of orders
+type Provider interface {
+	CreateIntent(request IntentRequest) (*Intent, error)
+	Capture(providerRef string, amount int64) error
+	Refund(providerRef string, amount int64) error
+	VerifyWebhook(header http.Header, body []byte) (*WebhookEvent, error)
+}
+
+var (
+	mu        sync.RWMutex
+	providers = make(map[string]Provider)
+)
+
+// Register makes a provider available under the name, registering a name again replaces its provider
+func Register(name string, provider Provider) {
+	mu.Lock()
+	providers[name] = provider
+	mu.Unlock()
+}
+
+func Get(name string) (Provider, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	provider, ok := providers[name]
+	if !ok {
+		return nil, ErrUnknownProvider
+	}
+	return provider, nil
+}
+
+// Names lists the registered providers
+func Names() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func init() {
+	Register(ProviderCOD, cashOnDelivery{})
+	Register(ProviderMock, newMockGateway())
+}
@@ -0,0 +1,229 @@
+package pricing
+
+import "rms/models"
+
+// bpsScale is what a rate in basis points is divided by, 500 bps is 5%
+const bpsScale = 10000
+
+// Discounter works out a coupon discount on an amount, models.Coupon is one
+type Discounter interface {
+	Discount(amount int64) int64
+}
+
+// Input is everything a price depends on. Items need dish, name, quantity, price and dish discount filled in, their
+// Packaging is per unit
+type Input struct {
+	Items      []models.PriceLine
+	Coupon     Discounter
+	CouponCode string
+	Points     int64
+	// Delivery adds the delivery fee and packaging, dine-in orders have neither
+	Delivery bool
+	Pricing  models.RestaurantPricing
+}
+
+// Compute prices the input. Dish discounts come first and the coupon is taken off what is left, shared over the lines
+// by their amount. Every line is taxed with the rates of its category or, if it has none, the rates without a category.
+// Packaging and delivery are only taxed by rates of their own category. The grand total is rounded last and points
+// are taken off it, Compute does not check that the points fit. An amount too large for models.Money is an error.
+func Compute(input Input) (models.PriceBreakdown, error) {
+	breakdown := models.PriceBreakdown{
+		Currency:       input.Pricing.Currency,
+		Items:          make([]models.PriceLine, len(input.Items)),
+		CouponCode:     input.CouponCode,
+		Taxes:          make([]models.TaxLine, 0),
+		PointsRedeemed: input.Points,
+	}
+	taxes := newTaxTotals()
+	var itemsTotal, packaging int64
+	for index, item := range input.Items {
+		line := item
+		lineSubTotal, err := models.NewMoney(line.Price, input.Pricing.Currency).Mul(line.Quantity)
+		if err != nil {
+			return models.PriceBreakdown{}, err
+		}
+		lineDiscount, err := lineSubTotal.Scale(line.Discount, 100, models.RoundDown)
+		if err != nil {
+			return models.PriceBreakdown{}, err
+		}
+		line.Total = lineSubTotal.Amount - lineDiscount.Amount
+		breakdown.SubTotal += lineSubTotal.Amount
+		breakdown.DishDiscount += lineDiscount.Amount
+		itemsTotal += line.Total
+		if input.Delivery {
+			packaging += item.Packaging * line.Quantity
+			line.Packaging = item.Packaging * line.Quantity
+		} else {
+			line.Packaging = 0
+		}
+		breakdown.Items[index] = line
+	}
+
+	if input.Coupon != nil && itemsTotal > 0 {
+		breakdown.CouponDiscount = input.Coupon.Discount(itemsTotal)
+	}
+	shares, err := share(breakdown.CouponDiscount, breakdown.Items)
+	if err != nil {
+		return models.PriceBreakdown{}, err
+	}
+	charged := itemsTotal - breakdown.CouponDiscount
+	for index := range breakdown.Items {
+		line := &breakdown.Items[index]
+		line.CouponDiscount = shares[index]
+		line.Taxable, line.Taxes, err = tax(line.Total-line.CouponDiscount, ratesFor(input.Pricing.TaxRates, line.TaxCategory, true))
+		if err != nil {
+			return models.PriceBreakdown{}, err
+		}
+		line.Tax = sumTaxes(line.Taxes)
+		taxes.add(line.Taxes)
+	}
+
+	if input.Delivery {
+		breakdown.Packaging = packaging
+		breakdown.DeliveryFee = input.Pricing.DeliveryFee
+		if input.Pricing.FreeDeliveryAbove > 0 && charged >= input.Pricing.FreeDeliveryAbove {
+			breakdown.DeliveryFee = 0
+		}
+		_, packagingTaxes, err := tax(breakdown.Packaging, ratesFor(input.Pricing.TaxRates, models.TaxCategoryPackaging, false))
+		if err != nil {
+			return models.PriceBreakdown{}, err
+		}
+		_, deliveryTaxes, err := tax(breakdown.DeliveryFee, ratesFor(input.Pricing.TaxRates, models.TaxCategoryDelivery, false))
+		if err != nil {
+			return models.PriceBreakdown{}, err
+		}
+		taxes.add(packagingTaxes)
+		taxes.add(deliveryTaxes)
+	}
+
+	breakdown.Taxes = taxes.lines
+	for _, line := range breakdown.Taxes {
+		breakdown.Tax += line.Amount
+		if line.Inclusive {
+			breakdown.TaxIncluded += line.Amount
+		}
+	}
+	total := charged + breakdown.Packaging + breakdown.DeliveryFee + breakdown.Tax - breakdown.TaxIncluded
+	grandTotal, err := models.NewMoney(total, input.Pricing.Currency).Round(input.Pricing.RoundingUnit, models.RoundHalfUp)
+	if err != nil {
+		return models.PriceBreakdown{}, err
+	}
+	breakdown.GrandTotal = grandTotal.Amount
+	breakdown.Rounding = breakdown.GrandTotal - total
+	breakdown.Total = breakdown.GrandTotal - breakdown.PointsRedeemed
+	return breakdown, nil
+}
+
+// share splits amount over the lines in proportion to their totals without losing a unit, every line gets at most
+// its own total
+func share(amount int64, lines []models.PriceLine) ([]int64, error) {
+	shares := make([]int64, len(lines))
+	var total int64
+	for _, line := range lines {
+		total += line.Total
+	}
+	if amount == 0 || total == 0 {
+		return shares, nil
+	}
+	var running, given int64
+	for index, line := range lines {
+		running += line.Total
+		upTo, err := scale(amount, running, total, models.RoundDown)
+		if err != nil {
+			return nil, err
+		}
+		shares[index] = upTo - given
+		given = upTo
+	}
+	return shares, nil
+}
+
+// ratesFor picks the rates of a category, fallback lets categories without rates of their own use the default ones
+func ratesFor(rates []models.TaxRate, category string, fallback bool) []models.TaxRate {
+	picked := make([]models.TaxRate, 0)
+	for _, rate := range rates {
+		if rate.Category == category {
+			picked = append(picked, rate)
+		}
+	}
+	if len(picked) == 0 && fallback && category != models.TaxCategoryDefault {
+		return ratesFor(rates, models.TaxCategoryDefault, false)
+	}
+	return picked
+}
+
+// tax charges the rates on amount. Inclusive rates are taken out of amount first, what remains is the taxable value
+// that exclusive rates are charged on. The inclusive taxes always add up to exactly what was taken out.
+func tax(amount int64, rates []models.TaxRate) (int64, []models.TaxLine, error) {
+	lines := make([]models.TaxLine, 0, len(rates))
+	if amount <= 0 || len(rates) == 0 {
+		return amount, lines, nil
+	}
+	var inclusiveBps int64
+	last := -1
+	for index, rate := range rates {
+		if rate.Inclusive {
+			inclusiveBps += rate.RateBps
+			last = index
+		}
+	}
+	taxable, err := scale(amount, bpsScale, bpsScale+inclusiveBps, models.RoundHalfUp)
+	if err != nil {
+		return 0, nil, err
+	}
+	included := amount - taxable
+	for index, rate := range rates {
+		line := models.TaxLine{Name: rate.Name, RateBps: rate.RateBps, Inclusive: rate.Inclusive, Taxable: taxable}
+		if rate.Inclusive && index == last {
+			line.Amount = included
+		} else {
+			line.Amount, err = scale(taxable, rate.RateBps, bpsScale, models.RoundHalfUp)
+			if err != nil {
+				return 0, nil, err
+			}
+			if rate.Inclusive {
+				included -= line.Amount
+			}
+		}
+		lines = append(lines, line)
+	}
+	return taxable, lines, nil
+}
+
+func sumTaxes(lines []models.TaxLine) int64 {
+	var sum int64
+	for _, line := range lines {
+		sum += line.Amount
+	}
+	return sum
+}
+
+// taxTotals sums tax lines per tax, keeping the order the taxes first appeared in
+type taxTotals struct {
+	index map[models.TaxLine]int
+	lines []models.TaxLine
+}
+
+func newTaxTotals() *taxTotals {
+	return &taxTotals{index: make(map[models.TaxLine]int), lines: make([]models.TaxLine, 0)}
+}
+
+func (t *taxTotals) add(lines []models.TaxLine) {
+	for _, line := range lines {
+		key := models.TaxLine{Name: line.Name, RateBps: line.RateBps, Inclusive: line.Inclusive}
+		position, ok := t.index[key]
+		if !ok {
+			position = len(t.lines)
+			t.index[key] = position
+			t.lines = append(t.lines, key)
+		}
+		t.lines[position].Taxable += line.Taxable
+		t.lines[position].Amount += line.Amount
+	}
+}
+
+// scale takes numerator/denominator of an amount in minor units, the currency plays no part in it
+func scale(amount, numerator, denominator int64, mode models.RoundingMode) (int64, error) {
+	scaled, err := models.Money{Amount: amount}.Scale(numerator, denominator, mode)
+	return scaled.Amount, err
+}
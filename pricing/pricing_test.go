@@ -0,0 +1,402 @@
+package pricing
+
+import (
+	"errors"
+	"math"
+	"reflect"
+	"rms/models"
+	"testing"
+)
+
+func item(dishID string, price, quantity, discount int64) models.PriceLine {
+	return models.PriceLine{OrderItem: models.OrderItem{DishID: dishID, Name: dishID, Price: price, Quantity: quantity, Discount: discount}}
+}
+
+func categoryItem(dishID, category string, price, quantity int64) models.PriceLine {
+	line := item(dishID, price, quantity, 0)
+	line.TaxCategory = category
+	return line
+}
+
+func packedItem(dishID string, price, quantity, packaging int64) models.PriceLine {
+	line := item(dishID, price, quantity, 0)
+	line.Packaging = packaging
+	return line
+}
+
+func rate(category, name string, bps int64, inclusive bool) models.TaxRate {
+	return models.TaxRate{Category: category, Name: name, RateBps: bps, Inclusive: inclusive}
+}
+
+func percentOff(value int64) *models.Coupon {
+	return &models.Coupon{DiscountType: models.DiscountPercentage, Value: value}
+}
+
+func flatOff(value int64) *models.Coupon {
+	return &models.Coupon{DiscountType: models.DiscountFlat, Value: value}
+}
+
+// totals is the part of a breakdown the cases check, the invariants are checked for every case on top
+type totals struct {
+	SubTotal       int64
+	DishDiscount   int64
+	CouponDiscount int64
+	Packaging      int64
+	DeliveryFee    int64
+	Tax            int64
+	TaxIncluded    int64
+	Rounding       int64
+	GrandTotal     int64
+	Total          int64
+}
+
+func TestCompute(t *testing.T) {
+	gst := []models.TaxRate{rate("", "CGST", 250, false), rate("", "SGST", 250, false)}
+	tests := []struct {
+		name      string
+		input     Input
+		want      totals
+		wantTaxes []models.TaxLine
+		wantLines []int64
+	}{
+		{
+			name:      "no taxes and no charges",
+			input:     Input{Items: []models.PriceLine{item("a", 200, 2, 0)}},
+			want:      totals{SubTotal: 400, GrandTotal: 400, Total: 400},
+			wantTaxes: []models.TaxLine{},
+		},
+		{
+			name:      "dish discount",
+			input:     Input{Items: []models.PriceLine{item("a", 250, 3, 10)}},
+			want:      totals{SubTotal: 750, DishDiscount: 75, GrandTotal: 675, Total: 675},
+			wantTaxes: []models.TaxLine{},
+		},
+		{
+			name:  "exclusive gst split in central and state tax",
+			input: Input{Items: []models.PriceLine{item("a", 1000, 1, 0)}, Pricing: models.RestaurantPricing{TaxRates: gst}},
+			want:  totals{SubTotal: 1000, Tax: 50, GrandTotal: 1050, Total: 1050},
+			wantTaxes: []models.TaxLine{
+				{Name: "CGST", RateBps: 250, Taxable: 1000, Amount: 25},
+				{Name: "SGST", RateBps: 250, Taxable: 1000, Amount: 25},
+			},
+		},
+		{
+			name: "inclusive gst is taken out of the price",
+			input: Input{Items: []models.PriceLine{item("a", 1050, 1, 0)}, Pricing: models.RestaurantPricing{TaxRates: []models.TaxRate{
+				rate("", "CGST", 250, true), rate("", "SGST", 250, true),
+			}}},
+			want: totals{SubTotal: 1050, Tax: 50, TaxIncluded: 50, GrandTotal: 1050, Total: 1050},
+			wantTaxes: []models.TaxLine{
+				{Name: "CGST", RateBps: 250, Inclusive: true, Taxable: 1000, Amount: 25},
+				{Name: "SGST", RateBps: 250, Inclusive: true, Taxable: 1000, Amount: 25},
+			},
+		},
+		{
+			name: "inclusive tax on an uneven price adds up to the price",
+			input: Input{Items: []models.PriceLine{item("a", 999, 1, 0)}, Pricing: models.RestaurantPricing{TaxRates: []models.TaxRate{
+				rate("", "GST", 500, true),
+			}}},
+			want:      totals{SubTotal: 999, Tax: 48, TaxIncluded: 48, GrandTotal: 999, Total: 999},
+			wantTaxes: []models.TaxLine{{Name: "GST", RateBps: 500, Inclusive: true, Taxable: 951, Amount: 48}},
+		},
+		{
+			name: "exclusive cess on top of an inclusive gst",
+			input: Input{Items: []models.PriceLine{item("a", 1050, 1, 0)}, Pricing: models.RestaurantPricing{TaxRates: []models.TaxRate{
+				rate("", "GST", 500, true), rate("", "Cess", 100, false),
+			}}},
+			want: totals{SubTotal: 1050, Tax: 60, TaxIncluded: 50, GrandTotal: 1060, Total: 1060},
+			wantTaxes: []models.TaxLine{
+				{Name: "GST", RateBps: 500, Inclusive: true, Taxable: 1000, Amount: 50},
+				{Name: "Cess", RateBps: 100, Taxable: 1000, Amount: 10},
+			},
+		},
+		{
+			name: "categories use their own slab and fall back to the default one",
+			input: Input{
+				Items: []models.PriceLine{
+					categoryItem("food", "", 1000, 1),
+					categoryItem("beer", "alcohol", 500, 1),
+					categoryItem("soda", "beverage", 200, 1),
+				},
+				Pricing: models.RestaurantPricing{TaxRates: []models.TaxRate{rate("", "GST", 500, false), rate("alcohol", "VAT", 2000, false)}},
+			},
+			want: totals{SubTotal: 1700, Tax: 160, GrandTotal: 1860, Total: 1860},
+			wantTaxes: []models.TaxLine{
+				{Name: "GST", RateBps: 500, Taxable: 1200, Amount: 60},
+				{Name: "VAT", RateBps: 2000, Taxable: 500, Amount: 100},
+			},
+			wantLines: []int64{50, 100, 10},
+		},
+		{
+			name: "percentage coupon is shared over the lines before tax",
+			input: Input{
+				Items:   []models.PriceLine{item("a", 300, 1, 0), item("b", 700, 1, 0)},
+				Coupon:  percentOff(10),
+				Pricing: models.RestaurantPricing{TaxRates: []models.TaxRate{rate("", "GST", 500, false)}},
+			},
+			want:      totals{SubTotal: 1000, CouponDiscount: 100, Tax: 46, GrandTotal: 946, Total: 946},
+			wantTaxes: []models.TaxLine{{Name: "GST", RateBps: 500, Taxable: 900, Amount: 46}},
+			wantLines: []int64{14, 32},
+		},
+		{
+			name:      "flat coupon larger than the cart is capped",
+			input:     Input{Items: []models.PriceLine{item("a", 300, 1, 0)}, Coupon: flatOff(500)},
+			want:      totals{SubTotal: 300, CouponDiscount: 300},
+			wantTaxes: []models.TaxLine{},
+		},
+		{
+			name:      "coupon applies after the dish discount",
+			input:     Input{Items: []models.PriceLine{item("a", 1000, 1, 20)}, Coupon: percentOff(50)},
+			want:      totals{SubTotal: 1000, DishDiscount: 200, CouponDiscount: 400, GrandTotal: 400, Total: 400},
+			wantTaxes: []models.TaxLine{},
+		},
+		{
+			name: "delivery and packaging are only taxed by their own rates",
+			input: Input{
+				Items:    []models.PriceLine{packedItem("a", 500, 2, 10)},
+				Delivery: true,
+				Pricing: models.RestaurantPricing{DeliveryFee: 40, TaxRates: []models.TaxRate{
+					rate("", "GST", 500, false), rate(models.TaxCategoryDelivery, "GST", 1800, false),
+				}},
+			},
+			want: totals{SubTotal: 1000, Packaging: 20, DeliveryFee: 40, Tax: 57, GrandTotal: 1117, Total: 1117},
+			wantTaxes: []models.TaxLine{
+				{Name: "GST", RateBps: 500, Taxable: 1000, Amount: 50},
+				{Name: "GST", RateBps: 1800, Taxable: 40, Amount: 7},
+			},
+		},
+		{
+			name: "packaging taxed with its own rates",
+			input: Input{
+				Items:    []models.PriceLine{packedItem("a", 100, 1, 20)},
+				Delivery: true,
+				Pricing:  models.RestaurantPricing{TaxRates: []models.TaxRate{rate(models.TaxCategoryPackaging, "GST", 1800, false)}},
+			},
+			want:      totals{SubTotal: 100, Packaging: 20, Tax: 4, GrandTotal: 124, Total: 124},
+			wantTaxes: []models.TaxLine{{Name: "GST", RateBps: 1800, Taxable: 20, Amount: 4}},
+		},
+		{
+			name: "delivery is free above the threshold",
+			input: Input{
+				Items:    []models.PriceLine{item("a", 600, 1, 0)},
+				Delivery: true,
+				Pricing:  models.RestaurantPricing{DeliveryFee: 40, FreeDeliveryAbove: 500},
+			},
+			want:      totals{SubTotal: 600, GrandTotal: 600, Total: 600},
+			wantTaxes: []models.TaxLine{},
+		},
+		{
+			name: "free delivery threshold counts the coupon",
+			input: Input{
+				Items:    []models.PriceLine{item("a", 600, 1, 0)},
+				Coupon:   flatOff(150),
+				Delivery: true,
+				Pricing:  models.RestaurantPricing{DeliveryFee: 40, FreeDeliveryAbove: 500},
+			},
+			want:      totals{SubTotal: 600, CouponDiscount: 150, DeliveryFee: 40, GrandTotal: 490, Total: 490},
+			wantTaxes: []models.TaxLine{},
+		},
+		{
+			name: "dine-in has no delivery fee or packaging",
+			input: Input{
+				Items:   []models.PriceLine{packedItem("a", 500, 1, 10)},
+				Pricing: models.RestaurantPricing{DeliveryFee: 40},
+			},
+			want:      totals{SubTotal: 500, GrandTotal: 500, Total: 500},
+			wantTaxes: []models.TaxLine{},
+		},
+		{
+			name:      "rounding down",
+			input:     Input{Items: []models.PriceLine{item("a", 1049, 1, 0)}, Pricing: models.RestaurantPricing{RoundingUnit: 100}},
+			want:      totals{SubTotal: 1049, Rounding: -49, GrandTotal: 1000, Total: 1000},
+			wantTaxes: []models.TaxLine{},
+		},
+		{
+			name:      "rounding half up",
+			input:     Input{Items: []models.PriceLine{item("a", 1050, 1, 0)}, Pricing: models.RestaurantPricing{RoundingUnit: 100}},
+			want:      totals{SubTotal: 1050, Rounding: 50, GrandTotal: 1100, Total: 1100},
+			wantTaxes: []models.TaxLine{},
+		},
+		{
+			name:      "rounding unit of one keeps the total",
+			input:     Input{Items: []models.PriceLine{item("a", 1049, 1, 0)}, Pricing: models.RestaurantPricing{RoundingUnit: 1}},
+			want:      totals{SubTotal: 1049, GrandTotal: 1049, Total: 1049},
+			wantTaxes: []models.TaxLine{},
+		},
+		{
+			name: "rounding applies to the taxed total",
+			input: Input{
+				Items:   []models.PriceLine{item("a", 1000, 1, 0)},
+				Pricing: models.RestaurantPricing{RoundingUnit: 100, TaxRates: []models.TaxRate{rate("", "GST", 1800, false)}},
+			},
+			want:      totals{SubTotal: 1000, Tax: 180, Rounding: 20, GrandTotal: 1200, Total: 1200},
+			wantTaxes: []models.TaxLine{{Name: "GST", RateBps: 1800, Taxable: 1000, Amount: 180}},
+		},
+		{
+			name:      "points are taken off the grand total",
+			input:     Input{Items: []models.PriceLine{item("a", 500, 1, 0)}, Points: 200},
+			want:      totals{SubTotal: 500, GrandTotal: 500, Total: 300},
+			wantTaxes: []models.TaxLine{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Compute(tt.input)
+			if err != nil {
+				t.Fatalf("Compute() error = %v", err)
+			}
+			gotTotals := totals{
+				SubTotal:       got.SubTotal,
+				DishDiscount:   got.DishDiscount,
+				CouponDiscount: got.CouponDiscount,
+				Packaging:      got.Packaging,
+				DeliveryFee:    got.DeliveryFee,
+				Tax:            got.Tax,
+				TaxIncluded:    got.TaxIncluded,
+				Rounding:       got.Rounding,
+				GrandTotal:     got.GrandTotal,
+				Total:          got.Total,
+			}
+			if gotTotals != tt.want {
+				t.Errorf("totals = %+v, want %+v", gotTotals, tt.want)
+			}
+			if !reflect.DeepEqual(got.Taxes, tt.wantTaxes) {
+				t.Errorf("taxes = %+v, want %+v", got.Taxes, tt.wantTaxes)
+			}
+			if tt.wantLines != nil {
+				lineTaxes := make([]int64, 0, len(got.Items))
+				for _, line := range got.Items {
+					lineTaxes = append(lineTaxes, line.Tax)
+				}
+				if !reflect.DeepEqual(lineTaxes, tt.wantLines) {
+					t.Errorf("line taxes = %v, want %v", lineTaxes, tt.wantLines)
+				}
+			}
+			checkInvariants(t, got)
+		})
+	}
+}
+
+func TestComputeOverflow(t *testing.T) {
+	tests := []struct {
+		name  string
+		input Input
+	}{
+		{name: "line total", input: Input{Items: []models.PriceLine{item("a", math.MaxInt64/2, 3, 0)}}},
+		{name: "tax", input: Input{
+			Items:   []models.PriceLine{item("a", math.MaxInt64/1000, 1, 0)},
+			Pricing: models.RestaurantPricing{TaxRates: []models.TaxRate{rate("", "GST", 500, false)}},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Compute(tt.input); !errors.Is(err, models.ErrMoneyOverflow) {
+				t.Errorf("Compute() error = %v, want %v", err, models.ErrMoneyOverflow)
+			}
+		})
+	}
+}
+
+// checkInvariants makes sure the parts of a breakdown always add up
+func checkInvariants(t *testing.T, got models.PriceBreakdown) {
+	t.Helper()
+	var itemsTotal, couponShares, lineTaxes int64
+	for _, line := range got.Items {
+		itemsTotal += line.Total
+		couponShares += line.CouponDiscount
+		lineTaxes += line.Tax
+		if line.CouponDiscount > line.Total {
+			t.Errorf("line %s coupon share %d is more than its total %d", line.DishID, line.CouponDiscount, line.Total)
+		}
+	}
+	if itemsTotal != got.SubTotal-got.DishDiscount {
+		t.Errorf("items total %d, want sub total less dish discount %d", itemsTotal, got.SubTotal-got.DishDiscount)
+	}
+	if couponShares != got.CouponDiscount {
+		t.Errorf("coupon shares %d, want coupon discount %d", couponShares, got.CouponDiscount)
+	}
+	var taxes int64
+	for _, line := range got.Taxes {
+		taxes += line.Amount
+	}
+	if taxes != got.Tax {
+		t.Errorf("tax lines add up to %d, want %d", taxes, got.Tax)
+	}
+	if lineTaxes > got.Tax {
+		t.Errorf("line taxes %d are more than the tax %d", lineTaxes, got.Tax)
+	}
+	total := got.SubTotal - got.DishDiscount - got.CouponDiscount + got.Packaging + got.DeliveryFee + got.Tax - got.TaxIncluded + got.Rounding
+	if total != got.GrandTotal {
+		t.Errorf("parts add up to %d, want grand total %d", total, got.GrandTotal)
+	}
+	if got.GrandTotal-got.PointsRedeemed != got.Total {
+		t.Errorf("total %d, want grand total less points %d", got.Total, got.GrandTotal-got.PointsRedeemed)
+	}
+}
+
+func TestShare(t *testing.T) {
+	tests := []struct {
+		name   string
+		amount int64
+		totals []int64
+		want   []int64
+	}{
+		{name: "nothing to share", amount: 0, totals: []int64{100, 200}, want: []int64{0, 0}},
+		{name: "nothing to share on", amount: 10, totals: []int64{0, 0}, want: []int64{0, 0}},
+		{name: "even split", amount: 100, totals: []int64{500, 500}, want: []int64{50, 50}},
+		{name: "proportional split", amount: 100, totals: []int64{300, 700}, want: []int64{30, 70}},
+		{name: "remainder goes to later lines", amount: 2, totals: []int64{1, 1, 1}, want: []int64{0, 1, 1}},
+		{name: "whole amount", amount: 30, totals: []int64{10, 20}, want: []int64{10, 20}},
+		{name: "small line never gets more than its total", amount: 99, totals: []int64{98, 1}, want: []int64{98, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := make([]models.PriceLine, 0, len(tt.totals))
+			for _, total := range tt.totals {
+				lines = append(lines, models.PriceLine{OrderItem: models.OrderItem{Total: total}})
+			}
+			if got, err := share(tt.amount, lines); err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("share(%d, %v) = %v, %v, want %v", tt.amount, tt.totals, got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestTax(t *testing.T) {
+	tests := []struct {
+		name        string
+		amount      int64
+		rates       []models.TaxRate
+		wantTaxable int64
+		wantAmounts []int64
+	}{
+		{name: "no rates", amount: 100, wantTaxable: 100, wantAmounts: []int64{}},
+		{name: "nothing to tax", amount: 0, rates: []models.TaxRate{rate("", "GST", 500, false)}, wantTaxable: 0, wantAmounts: []int64{}},
+		{name: "exclusive rounds half up", amount: 10, rates: []models.TaxRate{rate("", "GST", 500, false)}, wantTaxable: 10, wantAmounts: []int64{1}},
+		{name: "exclusive rounds down", amount: 9, rates: []models.TaxRate{rate("", "GST", 500, false)}, wantTaxable: 9, wantAmounts: []int64{0}},
+		{name: "inclusive split keeps every unit", amount: 101, rates: []models.TaxRate{rate("", "CGST", 250, true), rate("", "SGST", 250, true)}, wantTaxable: 96, wantAmounts: []int64{2, 3}},
+		{name: "inclusive and exclusive", amount: 112, rates: []models.TaxRate{rate("", "GST", 1200, true), rate("", "Cess", 100, false)}, wantTaxable: 100, wantAmounts: []int64{12, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			taxable, lines, err := tax(tt.amount, tt.rates)
+			if err != nil {
+				t.Fatalf("tax(%d) error = %v", tt.amount, err)
+			}
+			amounts := make([]int64, 0, len(lines))
+			var included int64
+			for _, line := range lines {
+				amounts = append(amounts, line.Amount)
+				if line.Inclusive {
+					included += line.Amount
+				}
+			}
+			if taxable != tt.wantTaxable || !reflect.DeepEqual(amounts, tt.wantAmounts) {
+				t.Errorf("tax(%d) = %d %v, want %d %v", tt.amount, taxable, amounts, tt.wantTaxable, tt.wantAmounts)
+			}
+			if tt.amount > 0 && len(lines) > 0 && taxable+included != tt.amount {
+				t.Errorf("taxable %d and included tax %d don't add up to %d", taxable, included, tt.amount)
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"net/http"
 	"rms/handler"
+	"rms/media"
 	"rms/middlewares"
 	"rms/models"
 	"time"
@@ -20,26 +21,69 @@ const (
 	readTimeout       = 5 * time.Minute
 	readHeaderTimeout = 30 * time.Second
 	writeTimeout      = 5 * time.Minute
+	signupLimit       = 5
+	signupWindow      = time.Hour
+	verifyLimit       = 20
+	verifyWindow      = time.Hour
+	resendLimit       = 5
+	resendWindow      = time.Hour
+	dineInOpenLimit   = 30
+	dineInOpenWindow  = time.Hour
 )
 
 // SetupRoutes provides all the routes that can be used
 func SetupRoutes() *Server {
 	router := chi.NewRouter()
+	// uploaded images kept on the local disk, nothing is served here when they are in a bucket
+	router.Handle("/media/*", http.StripPrefix("/media", media.LocalHandler()))
 	router.Route("/v1", func(v1 chi.Router) {
 		v1.Use(middlewares.CommonMiddlewares()...)
 		v1.Route("/", func(public chi.Router) {
 			public.Post("/login", handler.LoginUser)
+			public.Route("/signup", func(signup chi.Router) {
+				signup.With(middlewares.ThrottleByIP(signupLimit, signupWindow)).Post("/", handler.SignupUser)
+				signup.With(middlewares.ThrottleByIP(verifyLimit, verifyWindow)).Post("/verify", handler.VerifyEmail)
+				signup.With(middlewares.ThrottleByIP(resendLimit, resendWindow)).Post("/resend", handler.ResendEmailVerification)
+			})
+			public.Post("/payments/webhook/{provider}", handler.PaymentWebhook)
+			public.Route("/dine-in", func(dineIn chi.Router) {
+				dineIn.With(middlewares.ThrottleByIP(dineInOpenLimit, dineInOpenWindow)).Post("/session", handler.OpenDineInSession)
+				dineIn.Route("/", func(guest chi.Router) {
+					guest.Use(middlewares.DineInMiddleware)
+					guest.Group(dineInRoutes)
+				})
+			})
+			// event streams are the only routes that take the token from the URL
+			public.Group(func(streams chi.Router) {
+				streams.Use(middlewares.StreamAuthMiddleware)
+				streams.Get("/events", handler.StreamEvents)
+				streams.With(middlewares.ShouldBeRestaurantStaff(models.RoleKitchen)).Get("/kitchen/{restaurantId}/tickets/live", handler.StreamKitchenTickets)
+			})
 			public.Route("/", func(authRouts chi.Router) {
 				authRouts.Use(middlewares.AuthMiddleware)
 				authRouts.Get("/", handler.GetInfo)
 				authRouts.Put("/", handler.UpdateSelfInfo)
 				authRouts.Delete("/logout", handler.Logout)
 				authRouts.Get("/restaurants", handler.GetRestaurants)
+				authRouts.Get("/cuisines", handler.GetCuisines)
+				authRouts.Get("/dish-vocabulary", handler.GetDishVocabulary)
 				authRouts.Get("/restaurant/{restaurantId}/dishes", handler.GetRestaurantsDishes)
+				authRouts.Get("/restaurant/{restaurantId}/reviews", handler.GetRestaurantReviews)
+				authRouts.Get("/restaurant/{restaurantId}/menus", handler.GetMenus)
+				authRouts.Get("/restaurant/{restaurantId}/slots", handler.GetRestaurantSlots)
+				authRouts.Get("/restaurant/{restaurantId}/reservation-slots", handler.GetReservationSlots)
 				authRouts.Route("/user", func(user chi.Router) {
 					user.Use(middlewares.ShouldHaveRole(models.RoleUser))
 					user.Group(userRoutes)
 				})
+				authRouts.Route("/rider", func(rider chi.Router) {
+					rider.Use(middlewares.ShouldHaveRole(models.RoleRider))
+					rider.Group(riderRoutes)
+				})
+				authRouts.Route("/kitchen/{restaurantId}", func(kitchen chi.Router) {
+					kitchen.Use(middlewares.ShouldBeRestaurantStaff(models.RoleKitchen))
+					kitchen.Group(kitchenRoutes)
+				})
 				authRouts.Route("/sub-admin", func(subAdmin chi.Router) {
 					subAdmin.Use(middlewares.ShouldHaveRole(models.RoleSubAdmin))
 					subAdmin.Group(subAdminRoutes)
@@ -11,6 +11,21 @@ func adminRoutes(r chi.Router) {
 		admin.Post("/subAdmin", handler.RegisterSubAdmin)
 		admin.Get("/subAdmins", handler.GetSubAdmins)
 		admin.Delete("/subAdmin/{subAdminId}", handler.RemoveSubAdmin)
+		admin.Get("/reviews/flagged", handler.GetFlaggedReviews)
+		admin.Put("/review/{reviewId}/moderate", handler.ModerateReview)
+		admin.Post("/coupon", handler.AddPlatformCoupon)
+		admin.Get("/coupons", handler.GetPlatformCoupons)
+		admin.Delete("/coupon/{couponId}", handler.RemovePlatformCoupon)
+		admin.Post("/user/{userId}/loyalty", handler.AdjustUserLoyalty)
+		admin.Post("/rider", handler.RegisterRider)
+		admin.Get("/riders", handler.GetRiders)
+		admin.Delete("/rider/{riderId}", handler.RemoveRider)
+		admin.Post("/cuisine", handler.AddCuisine)
+		admin.Get("/report/revenue", handler.GetRevenueReport)
+		admin.Get("/report/top-dishes", handler.GetTopDishesReport)
+		admin.Get("/report/summary", handler.GetOrderSummaryReport)
+		admin.Get("/report/peak-hours", handler.GetPeakHoursReport)
+		admin.Get("/report/customers", handler.GetCustomerReport)
 	})
 }
 
@@ -22,9 +37,92 @@ func subAdminRoutes(r chi.Router) {
 		subAdmin.Post("/restaurant", handler.OpenRestaurant)
 		subAdmin.Put("/restaurant/{restaurantId}", handler.UpdateRestaurant)
 		subAdmin.Delete("/restaurant/{restaurantId}", handler.CloseRestaurant)
+		subAdmin.Put("/restaurant/{restaurantId}/logo", handler.UploadRestaurantLogo)
+		subAdmin.Delete("/restaurant/{restaurantId}/logo", handler.RemoveRestaurantLogo)
+		subAdmin.Put("/restaurant/{restaurantId}/banner", handler.UploadRestaurantBanner)
+		subAdmin.Delete("/restaurant/{restaurantId}/banner", handler.RemoveRestaurantBanner)
+		subAdmin.Put("/restaurant/{restaurantId}/attributes", handler.UpdateRestaurantAttributes)
 		subAdmin.Post("/restaurant/{restaurantId}/dish", handler.AddRestaurantDish)
 		subAdmin.Put("/restaurant/{restaurantId}/dish/{dishId}", handler.UpdateDish)
+		subAdmin.Put("/restaurant/{restaurantId}/dish/{dishId}/availability", handler.UpdateDishAvailability)
+		subAdmin.Put("/restaurant/{restaurantId}/dish/{dishId}/photo", handler.UploadDishPhoto)
+		subAdmin.Delete("/restaurant/{restaurantId}/dish/{dishId}/photo", handler.RemoveDishPhoto)
 		subAdmin.Delete("/restaurant/{restaurantId}/dish/{dishId}", handler.RemoveDish)
+		subAdmin.Put("/restaurant/{restaurantId}/schedule", handler.UpdateRestaurantSchedule)
+		subAdmin.Post("/restaurant/{restaurantId}/menu", handler.AddMenu)
+		subAdmin.Put("/restaurant/{restaurantId}/menu/{menuId}", handler.UpdateMenu)
+		subAdmin.Delete("/restaurant/{restaurantId}/menu/{menuId}", handler.RemoveMenu)
+		subAdmin.Get("/restaurant/{restaurantId}/pricing", handler.GetRestaurantPricing)
+		subAdmin.Put("/restaurant/{restaurantId}/pricing", handler.UpdateRestaurantPricing)
+		subAdmin.Post("/restaurant/{restaurantId}/tax-rate", handler.AddTaxRate)
+		subAdmin.Delete("/restaurant/{restaurantId}/tax-rate/{taxRateId}", handler.RemoveTaxRate)
+		subAdmin.Get("/restaurant/{restaurantId}/orders", handler.GetRestaurantOrders)
+		subAdmin.Get("/restaurant/{restaurantId}/report/revenue", handler.GetRevenueReport)
+		subAdmin.Get("/restaurant/{restaurantId}/report/top-dishes", handler.GetTopDishesReport)
+		subAdmin.Get("/restaurant/{restaurantId}/report/summary", handler.GetOrderSummaryReport)
+		subAdmin.Get("/restaurant/{restaurantId}/report/peak-hours", handler.GetPeakHoursReport)
+		subAdmin.Get("/restaurant/{restaurantId}/report/customers", handler.GetCustomerReport)
+		subAdmin.Put("/restaurant/{restaurantId}/order/{orderId}/status", handler.UpdateOrderStatus)
+		subAdmin.Put("/restaurant/{restaurantId}/order/{orderId}/items", handler.AdjustOrderItems)
+		subAdmin.Get("/restaurant/{restaurantId}/order/{orderId}/adjustments", handler.GetOrderAdjustments)
+		subAdmin.Get("/restaurant/{restaurantId}/order/{orderId}/invoice", handler.GetOrderInvoice)
+		subAdmin.Get("/restaurant/{restaurantId}/riders", handler.GetNearbyRiders)
+		subAdmin.Put("/restaurant/{restaurantId}/order/{orderId}/rider", handler.AssignOrderRider)
+		subAdmin.Post("/restaurant/{restaurantId}/coupon", handler.AddRestaurantCoupon)
+		subAdmin.Get("/restaurant/{restaurantId}/coupons", handler.GetRestaurantCoupons)
+		subAdmin.Delete("/restaurant/{restaurantId}/coupon/{couponId}", handler.RemoveRestaurantCoupon)
+		subAdmin.Post("/restaurant/{restaurantId}/webhook", handler.AddWebhook)
+		subAdmin.Get("/restaurant/{restaurantId}/webhooks", handler.GetWebhooks)
+		subAdmin.Delete("/restaurant/{restaurantId}/webhook/{webhookId}", handler.RemoveWebhook)
+		subAdmin.Get("/restaurant/{restaurantId}/webhook/{webhookId}/deliveries", handler.GetWebhookDeliveries)
+		subAdmin.Post("/restaurant/{restaurantId}/webhook/{webhookId}/delivery/{deliveryId}/redeliver", handler.RedeliverWebhook)
+		subAdmin.Post("/restaurant/{restaurantId}/kitchen-staff", handler.AddKitchenStaff)
+		subAdmin.Get("/restaurant/{restaurantId}/kitchen-staff", handler.GetKitchenStaff)
+		subAdmin.Delete("/restaurant/{restaurantId}/kitchen-staff/{userId}", handler.RemoveKitchenStaff)
+		subAdmin.Post("/restaurant/{restaurantId}/area", handler.AddRestaurantArea)
+		subAdmin.Get("/restaurant/{restaurantId}/areas", handler.GetRestaurantAreas)
+		subAdmin.Delete("/restaurant/{restaurantId}/area/{areaId}", handler.RemoveRestaurantArea)
+		subAdmin.Post("/restaurant/{restaurantId}/table", handler.AddRestaurantTable)
+		subAdmin.Get("/restaurant/{restaurantId}/tables", handler.GetRestaurantTables)
+		subAdmin.Delete("/restaurant/{restaurantId}/table/{tableId}", handler.RemoveRestaurantTable)
+		subAdmin.Post("/restaurant/{restaurantId}/table/{tableId}/qr", handler.GenerateTableQR)
+		subAdmin.Get("/restaurant/{restaurantId}/dine-in/sessions", handler.GetOpenDineInBills)
+		subAdmin.Post("/restaurant/{restaurantId}/dine-in/session/{sessionId}/close", handler.CloseDineInSession)
+		subAdmin.Get("/restaurant/{restaurantId}/reservations", handler.GetRestaurantReservationDay)
+		subAdmin.Put("/restaurant/{restaurantId}/reservation/{reservationId}/no-show", handler.MarkReservationNoShow)
+		subAdmin.Post("/restaurant/{restaurantId}/reservation-block", handler.AddReservationBlock)
+		subAdmin.Delete("/restaurant/{restaurantId}/reservation-block/{blockId}", handler.RemoveReservationBlock)
+		subAdmin.Post("/review/{reviewId}/reply", handler.ReplyToReview)
+		subAdmin.Post("/review/{reviewId}/flag", handler.FlagReview)
+	})
+}
+
+func kitchenRoutes(r chi.Router) {
+	r.Group(func(kitchen chi.Router) {
+		kitchen.Get("/tickets", handler.GetKitchenTickets)
+		kitchen.Post("/ticket/{ticketId}/bump", handler.BumpKitchenTicket)
+		kitchen.Post("/ticket/{ticketId}/recall", handler.RecallKitchenTicket)
+		kitchen.Post("/item/{itemId}/bump", handler.BumpKitchenItem)
+		kitchen.Post("/item/{itemId}/recall", handler.RecallKitchenItem)
+		kitchen.Get("/metrics/prep-times", handler.GetDishPrepTimes)
+	})
+}
+
+func dineInRoutes(r chi.Router) {
+	r.Group(func(dineIn chi.Router) {
+		dineIn.Get("/dishes", handler.GetDineInDishes)
+		dineIn.Post("/order", handler.PlaceDineInOrder)
+		dineIn.Get("/bill", handler.GetDineInBill)
+	})
+}
+
+func riderRoutes(r chi.Router) {
+	r.Group(func(rider chi.Router) {
+		rider.Put("/availability", handler.UpdateMyAvailability)
+		rider.Post("/location", handler.UpdateMyLocation)
+		rider.Get("/orders", handler.GetMyDeliveries)
+		rider.Put("/order/{orderId}/pick-up", handler.PickUpOrder)
+		rider.Put("/order/{orderId}/deliver", handler.DeliverOrder)
 	})
 }
 
@@ -33,5 +131,28 @@ func userRoutes(r chi.Router) {
 		user.Post("/address", handler.AddAddress)
 		user.Put("/address/{addressId}", handler.UpdateAddress)
 		user.Get("/restaurantDistance", handler.GetRestaurantDistance)
+		user.Post("/cart/apply-coupon", handler.ApplyCoupon)
+		user.Post("/cart/price", handler.PriceCart)
+		user.Post("/order", handler.PlaceOrder)
+		user.Get("/orders", handler.GetMyOrders)
+		user.Get("/order/{orderId}", handler.GetMyOrder)
+		user.Put("/order/{orderId}/cancel", handler.CancelMyOrder)
+		user.Get("/order/{orderId}/tracking", handler.TrackMyOrder)
+		user.Get("/order/{orderId}/payment", handler.GetMyOrderPayment)
+		user.Get("/order/{orderId}/adjustments", handler.GetMyOrderAdjustments)
+		user.Get("/order/{orderId}/invoice", handler.GetMyOrderInvoice)
+		user.Post("/order/{orderId}/review", handler.AddOrderReview)
+		user.Post("/order/{orderId}/reorder", handler.ReorderMyOrder)
+		user.Get("/favourites", handler.GetMyFavourites)
+		user.Get("/recommendations", handler.GetMyRecommendations)
+		user.Post("/favourite/restaurant/{restaurantId}", handler.AddFavouriteRestaurant)
+		user.Delete("/favourite/restaurant/{restaurantId}", handler.RemoveFavouriteRestaurant)
+		user.Post("/favourite/dish/{dishId}", handler.AddFavouriteDish)
+		user.Delete("/favourite/dish/{dishId}", handler.RemoveFavouriteDish)
+		user.Get("/loyalty", handler.GetMyLoyalty)
+		user.Post("/reservation", handler.CreateReservation)
+		user.Get("/reservations", handler.GetMyReservations)
+		user.Put("/reservation/{reservationId}", handler.UpdateMyReservation)
+		user.Put("/reservation/{reservationId}/cancel", handler.CancelMyReservation)
 	})
 }
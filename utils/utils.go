@@ -3,12 +3,15 @@ package utils
 import (
 	"crypto/rand"
 	"database/sql"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"math"
 	"math/big"
+	"net"
 	"net/http"
+	"net/smtp"
 	"os"
 	"regexp"
 	"rms/models"
@@ -112,6 +115,16 @@ func newClientError(err error, statusCode int, messageToUser string, additionalI
 	}
 }
 
+// RespondFile sends body as a download named filename
+func RespondFile(w http.ResponseWriter, contentType, filename string, body []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.WriteHeader(http.StatusOK)
+	if _, err := w.Write(body); err != nil {
+		logrus.Errorf("Failed to respond file with error: %+v", err)
+	}
+}
+
 // RespondError sends an error message to the API caller and logs the error
 func RespondError(w http.ResponseWriter, statusCode int, err error, messageToUser string, additionalInfoForDevs ...string) {
 	logrus.Errorf("status: %d, message: %s, err: %+v ", statusCode, messageToUser, err)
@@ -152,6 +165,144 @@ func ParseJwtToken(token string) error {
 	return jwt.ErrTokenInvalidClaims
 }
 
+// TableQRToken signs the table and its current nonce, rotating the nonce invalidates the printed QR codes
+func TableQRToken(tableID, nonce string) (string, error) {
+	secretKey := []byte(os.Getenv("SESSION_KEY"))
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"typ":     "table",
+		"tableId": tableID,
+		"nonce":   nonce,
+	})
+	return token.SignedString(secretKey)
+}
+
+// ParseTableQRToken checks the signature of a table QR token and returns the table and nonce it carries
+func ParseTableQRToken(token string) (string, string, error) {
+	secretKey := []byte(os.Getenv("SESSION_KEY"))
+	claims := jwt.MapClaims{}
+	_, jwtErr := jwt.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		return secretKey, nil
+	}, jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}))
+	if jwtErr != nil {
+		return "", "", jwtErr
+	}
+	tableID, _ := claims["tableId"].(string)
+	nonce, _ := claims["nonce"].(string)
+	if claims["typ"] != "table" || tableID == "" || nonce == "" {
+		return "", "", jwt.ErrTokenInvalidClaims
+	}
+	return tableID, nonce, nil
+}
+
+// GenerateToken returns a random hex encoded token of the given byte length
+func GenerateToken(length int) (string, error) {
+	token := make([]byte, length)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
+
+// SendEmail sends a plain text mail through the SMTP server configured in env, it only logs the mail when no server is set
+func SendEmail(to, subject, body string) error {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		logrus.Infof("SMTP_HOST not set, mail to %s: %s\n%s", to, subject, body)
+		return nil
+	}
+	from := os.Getenv("SMTP_FROM")
+	auth := smtp.PlainAuth("", os.Getenv("SMTP_USER"), os.Getenv("SMTP_PASSWORD"), host)
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s\r\n", from, to, subject, body)
+	return smtp.SendMail(net.JoinHostPort(host, os.Getenv("SMTP_PORT")), auth, from, []string{to}, []byte(msg))
+}
+
+// ClientIP returns the caller IP. X-Forwarded-For is only believed when the request comes from a proxy listed in
+// TRUSTED_PROXIES (comma separated IPs or CIDRs), then the last entry no trusted proxy added is the caller
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	trusted := trustedProxies()
+	if len(trusted) == 0 || !isTrustedProxy(trusted, host) {
+		return host
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		host = hop
+		if !isTrustedProxy(trusted, hop) {
+			break
+		}
+	}
+	return host
+}
+
+func trustedProxies() []*net.IPNet {
+	trusted := make([]*net.IPNet, 0)
+	for _, entry := range strings.Split(os.Getenv("TRUSTED_PROXIES"), ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			logrus.Errorf("Invalid TRUSTED_PROXIES entry: %s", entry)
+			continue
+		}
+		trusted = append(trusted, network)
+	}
+	return trusted
+}
+
+func isTrustedProxy(trusted []*net.IPNet, host string) bool {
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, network := range trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// nonPublicNetworks are the private, shared and reserved ranges net.IP has no check for
+var nonPublicNetworks = func() []*net.IPNet {
+	networks := make([]*net.IPNet, 0)
+	for _, cidr := range []string{"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7"} {
+		_, network, _ := net.ParseCIDR(cidr)
+		networks = append(networks, network)
+	}
+	return networks
+}()
+
+// IsPublicIP tells whether the address is reachable on the internet, loopback, private, link-local, multicast and
+// unspecified addresses are not
+func IsPublicIP(ip net.IP) bool {
+	if ip == nil || ip.IsLoopback() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range nonPublicNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
 // IsEmailValid checks if the email provided is valid by regex.
 func IsEmailValid(e string) bool {
 	emailRegex := regexp.MustCompile("^[a-zA-Z0-9.!#$%&'*+/=?^_`{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)*$")
@@ -197,6 +348,15 @@ func CalculateDistance(lat1, lng1, lat2, lng2 float64) (float64, string) {
 	return distance, "Kilo Meter"
 }
 
+// DistanceKm is the haversine distance between two points in kilometers whatever unit CalculateDistance picks
+func DistanceKm(lat1, lng1, lat2, lng2 float64) float64 {
+	distance, unit := CalculateDistance(lat1, lng1, lat2, lng2)
+	if unit == "Meter" {
+		return distance / 1000
+	}
+	return distance
+}
+
 // TrimAll removes a given rune form given string
 func TrimAll(str string, remove rune) string {
 	return strings.Map(func(r rune) rune {
@@ -339,6 +499,22 @@ func GetFilters(r *http.Request) models.Filters {
 	Filters.Email = Email
 	CreatedBy := r.URL.Query().Get("createdBy")
 	Filters.CreatedBy = CreatedBy
+	MinRating, MinRatingErr := strconv.ParseFloat(r.URL.Query().Get("minRating"), 64)
+	if MinRatingErr == nil && MinRating > 0 {
+		Filters.MinRating = MinRating
+	}
+	Filters.Cuisines = splitList(r.URL.Query().Get("cuisines"))
+	Filters.Features = splitList(r.URL.Query().Get("features"))
+	Filters.PriceRanges = make([]int64, 0)
+	for _, value := range splitList(r.URL.Query().Get("priceRange")) {
+		if PriceRange, PriceRangeErr := strconv.ParseInt(value, 10, 64); PriceRangeErr == nil {
+			Filters.PriceRanges = append(Filters.PriceRanges, PriceRange)
+		}
+	}
+	MaxCostForTwo, MaxCostForTwoErr := strconv.ParseInt(r.URL.Query().Get("maxCostForTwo"), 10, 64)
+	if MaxCostForTwoErr == nil {
+		Filters.MaxCostForTwo = &MaxCostForTwo
+	}
 	SortBy := r.URL.Query().Get("SortBy")
 	//TODO remove case id From switch case because it is already added in default case **NO NEED**
 	switch SortBy {
@@ -350,12 +526,35 @@ func GetFilters(r *http.Request) models.Filters {
 		Filters.SortBy = models.Email
 	case "Created By":
 		Filters.SortBy = models.CreatedBy
+	case "Rating":
+		Filters.SortBy = models.Rating
 	default:
 		Filters.SortBy = models.ID
 	}
 	return Filters
 }
 
+// parsePriceFilter reads a price filter as whole minor units, like older clients send it, or as a decimal amount in
+// major units when the currency is given
+func parsePriceFilter(value, currency string) (models.Money, error) {
+	if currency == "" {
+		amount, err := strconv.ParseInt(value, 10, 64)
+		return models.NewMoney(amount, ""), err
+	}
+	return models.ParseMoney(value, currency)
+}
+
+// splitList reads a comma separated query value, it is empty rather than nil when the value is
+func splitList(value string) []string {
+	list := make([]string, 0)
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(strings.ToLower(item)); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
 func GetDishFilters(r *http.Request) models.DishFilters {
 	var Filters models.DishFilters
 	PageNumber, PageNumberErr := strconv.ParseInt(r.URL.Query().Get("pageNumber"), 10, 64)
@@ -372,21 +571,20 @@ func GetDishFilters(r *http.Request) models.DishFilters {
 	}
 	MinQuantity, MinQuantityErr := strconv.ParseInt(r.URL.Query().Get("minQuantity"), 10, 64)
 	if MinQuantityErr == nil && MinQuantity != 0 {
-		Filters.MinQuantity = MinQuantity
-	} else {
-		Filters.MinQuantity = 1
+		Filters.MinQuantity = &MinQuantity
 	}
-	MaxPrice, MaxPriceErr := strconv.ParseInt(r.URL.Query().Get("maxPrice"), 10, 64)
+	Filters.Currency = strings.ToUpper(r.URL.Query().Get("currency"))
+	MaxPrice, MaxPriceErr := parsePriceFilter(r.URL.Query().Get("maxPrice"), Filters.Currency)
 	if MaxPriceErr == nil && PageSize != 0 {
 		Filters.MaxPrice = MaxPrice
 	} else {
-		Filters.MaxPrice = math.MaxInt64
+		Filters.MaxPrice = models.NewMoney(math.MaxInt64, "")
 	}
-	MinPrice, MinPriceErr := strconv.ParseInt(r.URL.Query().Get("minPrice"), 10, 64)
+	MinPrice, MinPriceErr := parsePriceFilter(r.URL.Query().Get("minPrice"), Filters.Currency)
 	if MinPriceErr == nil && PageSize != 0 {
 		Filters.MinPrice = MinPrice
 	} else {
-		Filters.MinPrice = 1
+		Filters.MinPrice = models.NewMoney(1, "")
 	}
 	MaxDiscount, MaxDiscountErr := strconv.ParseInt(r.URL.Query().Get("maxDiscount"), 10, 64)
 	if MaxDiscountErr == nil && PageSize != 0 {
@@ -405,6 +603,12 @@ func GetDishFilters(r *http.Request) models.DishFilters {
 	Filters.Name = Name
 	CreatedBy := r.URL.Query().Get("createdBy")
 	Filters.CreatedBy = CreatedBy
+	Filters.IncludeTags = splitList(r.URL.Query().Get("tags"))
+	Filters.ExcludeTags = splitList(r.URL.Query().Get("excludeTags"))
+	MaxSpiceLevel, MaxSpiceLevelErr := strconv.ParseInt(r.URL.Query().Get("maxSpiceLevel"), 10, 64)
+	if MaxSpiceLevelErr == nil {
+		Filters.MaxSpiceLevel = &MaxSpiceLevel
+	}
 	SortBy := r.URL.Query().Get("SortBy")
 	switch SortBy {
 	case "Id":